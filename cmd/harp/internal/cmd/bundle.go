@@ -40,6 +40,16 @@ var bundleCmd = func() *cobra.Command {
 	cmd.AddCommand(bundleFilterCmd())
 	cmd.AddCommand(bundleLintCmd())
 	cmd.AddCommand(bundlePrefixerCmd())
+	cmd.AddCommand(bundleHistoryCmd())
+	cmd.AddCommand(bundleRollbackCmd())
+	cmd.AddCommand(bundleMergeCmd())
+	cmd.AddCommand(bundleApplyCmd())
+	cmd.AddCommand(bundleProofCmd())
+	cmd.AddCommand(bundleVerifyProofCmd())
+	cmd.AddCommand(bundleExpiryCmd())
+	cmd.AddCommand(bundleAuditCmd())
+	cmd.AddCommand(bundleRekeyCmd())
+	cmd.AddCommand(bundleIndexCmd())
 
 	return cmd
 }
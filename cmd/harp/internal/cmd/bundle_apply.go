@@ -0,0 +1,107 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleApplyParams struct {
+	inputPath      string
+	oplogPath      string
+	jsonPatchPath  string
+	outputPath     string
+	strict         bool
+	withoutHistory bool
+}
+
+var bundleApplyCmd = func() *cobra.Command {
+	params := &bundleApplyParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Replay an OpLog produced by 'harp bundle diff' on an existing Bundle.
+
+	RFC6902 JSON Patch documents targeting the bundle map view
+	('/<package>/<secret key>') are also supported, 'test' operations are used
+	as previous value assertions.
+
+	In strict mode, 'remove' and 'replace' operations fail if they target a
+	missing secret, if they don't record a previous value, or if the secret
+	current value differs from the previous value recorded by
+	'harp bundle diff --with-previous'. JSON Patch secret operations must be
+	preceded by a 'test' operation.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Compute a reviewable change set
+	harp bundle diff --old base.bundle --new rotated.bundle --with-previous --out changes.json
+
+	# Apply the change set on the base bundle
+	harp bundle apply --in base.bundle --oplog changes.json --strict --out patched.bundle
+
+	# Apply a RFC6902 JSON Patch on the base bundle
+	harp bundle apply --in base.bundle --jsonpatch changes.json --out patched.bundle`)
+
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   "Apply an OpLog or a JSON Patch to the given bundle",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-apply", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.ApplyTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				Strict:          params.strict,
+				WithoutHistory:  params.withoutHistory,
+			}
+			if params.oplogPath != "" {
+				t.OpLogReader = cmdutil.FileReader(params.oplogPath)
+			}
+			if params.jsonPatchPath != "" {
+				t.JSONPatchReader = cmdutil.FileReader(params.jsonPatchPath)
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or a filename)")
+	cmd.Flags().StringVar(&params.oplogPath, "oplog", "", "JSON OpLog path ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.jsonPatchPath, "jsonpatch", "", "RFC6902 JSON Patch path ('-' for stdin or filename)")
+	cmd.MarkFlagsMutuallyExclusive("oplog", "jsonpatch")
+	cmd.Flags().BoolVar(&params.strict, "strict", false, "Fail if a remove or replace operation targets a missing or modified secret, or has no previous value")
+	cmd.Flags().BoolVar(&params.withoutHistory, "without-history", false, "Don't record modified secrets in package version history")
+
+	return cmd
+}
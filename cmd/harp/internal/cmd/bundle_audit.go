@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/bundle/audit"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleAuditParams struct {
+	inputPath          string
+	outputPath         string
+	format             string
+	fingerprintKey     string
+	fingerprintKeyPath string
+	minEntropy         float64
+}
+
+var bundleAuditCmd = func() *cobra.Command {
+	params := &bundleAuditParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Audit bundle secret values hygiene.
+
+	String secret values are checked against well-known weak passwords and the
+	diceware word list, and their entropy is estimated. Values reused by several
+	packages are detected by comparing keyed fingerprints, reuse across
+	environments is reported as an error. Findings of values which are not
+	credential-like (password, secret, token, key, ...) are reported with a
+	lowered severity. Secret values are never exposed in the report.
+
+	Provide a fingerprint key to get fingerprints comparable across audit runs.
+	The fingerprint key is read from the '--fingerprint-key-file' file or the
+	HARP_AUDIT_FINGERPRINT_KEY environment variable, so that it doesn't leak in
+	the process list or the shell history.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Audit a bundle
+	harp bundle audit --in secrets.bundle
+
+	# Export findings as SARIF for code scanning tools
+	harp bundle audit --in secrets.bundle --format sarif --out audit.sarif
+
+	# Use stable fingerprints and a stricter entropy requirement
+	harp bundle audit --in secrets.bundle --fingerprint-key-file audit.key --min-entropy 80
+
+	# Use stable fingerprints with the key from the environment
+	HARP_AUDIT_FINGERPRINT_KEY="$(cat audit.key)" harp bundle audit --in secrets.bundle`)
+
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Short:   "Audit secret values for weak, low-entropy and reused values",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-audit", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.AuditTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				Format:          params.format,
+				MinEntropy:      params.minEntropy,
+			}
+			key, err := secretFlagValue(params.fingerprintKey, params.fingerprintKeyPath, "HARP_AUDIT_FINGERPRINT_KEY")
+			if err != nil {
+				log.For(ctx).Fatal("unable to read fingerprint key", zap.Error(err))
+			}
+			if len(key) > 0 {
+				t.FingerprintKey = key
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Report output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.format, "format", bundle.AuditFormatJSON, "Report format (json, sarif)")
+	cmd.Flags().StringVar(&params.fingerprintKey, "fingerprint-key", "", "Secret key used to compute value fingerprints, random by default (prefer '--fingerprint-key-file' or HARP_AUDIT_FINGERPRINT_KEY)")
+	cmd.Flags().StringVar(&params.fingerprintKeyPath, "fingerprint-key-file", "", "File containing the secret key used to compute value fingerprints ('-' for stdin or filename)")
+	cmd.Flags().Float64Var(&params.minEntropy, "min-entropy", audit.DefaultMinEntropy, "Minimal estimated entropy in bits of secret values")
+
+	return cmd
+}
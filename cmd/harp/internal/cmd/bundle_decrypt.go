@@ -34,6 +34,8 @@ type bundleDecryptParams struct {
 	outputPath         string
 	keys               []string
 	skipNotDecryptable bool
+	requireContext     bool
+	workerCount        int64
 }
 
 var bundleDecryptCmd = func() *cobra.Command {
@@ -55,6 +57,12 @@ var bundleDecryptCmd = func() *cobra.Command {
 	by the encryption transformer factory.
 
 	This act as in-transit/in-use encryption.
+
+	Package values encrypted with a transformer authenticating additional data
+	are bound to their package path and secret key, and marked with an
+	annotation. The annotation is not authenticated, removing it downgrades the
+	package to the unbound decryption. Use --require-context to refuse packages
+	without binding.
 	`)
 
 	examples := cmdutil.Examples(`
@@ -70,6 +78,12 @@ var bundleDecryptCmd = func() *cobra.Command {
 
 	# Decrypt a bundle from STDIN and produce output to a file
 	harp bundle decrypt --key <transformer key> --out decrypted.bundle
+
+	# Decrypt a bundle using 16 concurrent package workers
+	harp bundle decrypt --key <transformer key> --worker-count 16
+
+	# Decrypt a bundle and refuse packages not bound to their path
+	harp bundle decrypt --key <transformer key> --require-context
 	`)
 
 	cmd := &cobra.Command{
@@ -104,6 +118,8 @@ var bundleDecryptCmd = func() *cobra.Command {
 				OutputWriter:       cmdutil.FileWriter(params.outputPath),
 				Transformers:       transformers,
 				SkipNotDecryptable: params.skipNotDecryptable,
+				RequireContext:     params.requireContext,
+				WorkerCount:        params.workerCount,
 			}
 
 			// Run the task
@@ -118,6 +134,8 @@ var bundleDecryptCmd = func() *cobra.Command {
 	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or filename)")
 	cmd.Flags().StringSliceVar(&params.keys, "key", []string{""}, "Secret value decryption key. Repeat to add multiple keys to try.")
 	cmd.Flags().BoolVarP(&params.skipNotDecryptable, "skip-not-decryptable", "s", false, "Skip not decryptable secrets without raising an error.")
+	cmd.Flags().BoolVar(&params.requireContext, "require-context", false, "Refuse encrypted packages not bound to their package path and secret keys.")
+	cmd.Flags().Int64Var(&params.workerCount, "worker-count", 4, "Active package decryption worker count")
 
 	return cmd
 }
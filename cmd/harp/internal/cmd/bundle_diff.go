@@ -18,6 +18,11 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
@@ -28,10 +33,17 @@ import (
 
 // -----------------------------------------------------------------------------.
 type bundleDiffParams struct {
-	sourcePath      string
-	destinationPath string
-	generatePatch   bool
-	outputPath      string
+	sourcePath       string
+	destinationPath  string
+	generatePatch    bool
+	withPrevious     bool
+	format           string
+	redact           bool
+	redactionKeyPath string
+	redactionKeyRaw  string
+	semantic         bool
+	outputPath       string
+	indexKeys        []string
 }
 
 var bundleDiffCmd = func() *cobra.Command {
@@ -41,6 +53,18 @@ var bundleDiffCmd = func() *cobra.Command {
 	Compute Bundle object differences.
 
 	Useful to debug a BundlePatch application and watch for a Bundle alteration.
+
+	The redacted mode replaces secret values by keyed fingerprints so that the
+	diff can be shared in code reviews or CI logs. Reviewers can see that a value
+	changed, or that multiple secrets share the same value, without seeing it.
+	The redaction key is read from the '--redact-key-file' file or the
+	HARP_DIFF_REDACT_KEY environment variable, so that it doesn't leak in the
+	process list or the shell history.
+
+	The semantic mode explains replaced secret values by comparing JSON / YAML
+	documents field by field, and PEM certificates by subject, issuer, serial,
+	subject alternative names and validity period. Previous field values are
+	only displayed with '--with-previous'.
 	`)
 
 	examples := cmdutil.Examples(`
@@ -48,7 +72,19 @@ var bundleDiffCmd = func() *cobra.Command {
 	harp bundle diff --old - --new rotated.bundle
 
 	# Generate a BundlePatch from differences
-	harp bundle diff --old - --new rotated.bundle --patch --out rotation.yaml`)
+	harp bundle diff --old - --new rotated.bundle --patch --out rotation.yaml
+
+	# Generate a RFC6902 JSON Patch from differences
+	harp bundle diff --old - --new rotated.bundle --format jsonpatch --out rotation.json
+
+	# Display differences with values replaced by keyed fingerprints
+	harp bundle diff --old - --new rotated.bundle --redact --redact-key-file diff.key
+
+	# Display redacted differences with the key from the environment
+	HARP_DIFF_REDACT_KEY="$(cat diff.key)" harp bundle diff --old - --new rotated.bundle --redact
+
+	# Explain structured secret value changes
+	harp bundle diff --old - --new rotated.bundle --semantic`)
 
 	cmd := &cobra.Command{
 		Use:     "diff",
@@ -60,12 +96,32 @@ var bundleDiffCmd = func() *cobra.Command {
 			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-diff", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
 			defer cancel()
 
+			// Prepare indexed bundle transformers
+			transformers, err := indexTransformers(params.indexKeys)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
 			// Prepare task
 			t := &bundle.DiffTask{
 				SourceReader:      cmdutil.FileReader(params.sourcePath),
 				DestinationReader: cmdutil.FileReader(params.destinationPath),
 				OutputWriter:      cmdutil.FileWriter(params.outputPath),
 				GeneratePatch:     params.generatePatch,
+				WithPrevious:      params.withPrevious,
+				Format:            params.format,
+				Semantic:          params.semantic,
+				Transformers:      transformers,
+			}
+			if params.redact {
+				key, errKey := secretFlagValue(params.redactionKeyRaw, params.redactionKeyPath, "HARP_DIFF_REDACT_KEY")
+				if errKey != nil {
+					log.For(ctx).Fatal("unable to read redaction key", zap.Error(errKey))
+				}
+				if len(key) == 0 {
+					log.For(ctx).Fatal("a redaction key must be specified with '--redact-key-file' or HARP_DIFF_REDACT_KEY to compute fingerprints")
+				}
+				t.RedactionKey = key
 			}
 
 			// Run the task
@@ -81,7 +137,42 @@ var bundleDiffCmd = func() *cobra.Command {
 	cmd.Flags().StringVar(&params.destinationPath, "new", "", "Container path ('-' for stdin or filename)")
 	log.CheckErr("unable to mark 'new' flag as required.", cmd.MarkFlagRequired("new"))
 	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Output ('-' for stdout or filename)")
-	cmd.Flags().BoolVar(&params.generatePatch, "patch", false, "Output as a bundle patch")
+	cmd.Flags().BoolVar(&params.generatePatch, "patch", false, "Output as a bundle patch (alias for '--format patch')")
+	cmd.Flags().StringVar(&params.format, "format", "oplog", "Output format (oplog, patch, jsonpatch)")
+	cmd.Flags().BoolVar(&params.redact, "redact", false, "Replace secret values by HMAC fingerprints")
+	cmd.Flags().StringVar(&params.redactionKeyPath, "redact-key-file", "", "File containing the secret key used to compute value fingerprints ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.redactionKeyRaw, "redact-key", "", "Secret key used to compute value fingerprints (prefer '--redact-key-file' or HARP_DIFF_REDACT_KEY)")
+	cmd.Flags().BoolVar(&params.semantic, "semantic", false, "Describe structural changes of JSON/YAML and PEM certificate values")
+	cmd.Flags().BoolVar(&params.withPrevious, "with-previous", false, "Record previous values of replaced and removed secrets in the OpLog")
+	cmd.Flags().StringSliceVar(&params.indexKeys, "index-key", []string{}, "Indexed bundle decryption key. Repeat to add multiple keys to try.")
 
 	return cmd
 }
+
+// secretFlagValue returns the secret given as a command line value, read from
+// the given file, or from the given environment variable, in this order.
+// Trailing line breaks are removed from the file content.
+func secretFlagValue(value, path, envName string) ([]byte, error) {
+	switch {
+	case value != "":
+		return []byte(value), nil
+	case path != "":
+		// Open the secret file
+		r, err := cmdutil.Reader(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open secret file %q: %w", path, err)
+		}
+
+		// Read the secret
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read secret file %q: %w", path, err)
+		}
+
+		return bytes.TrimRight(raw, "\r\n"), nil
+	default:
+	}
+
+	// No error
+	return []byte(os.Getenv(envName)), nil
+}
@@ -28,12 +28,15 @@ import (
 
 // -----------------------------------------------------------------------------.
 type bundleDumpParams struct {
-	inputPath      string
-	dataOnly       bool
-	metadataOnly   bool
-	pathOnly       bool
-	jmesPathFilter string
-	skipTemplate   bool
+	inputPath       string
+	dataOnly        bool
+	metadataOnly    bool
+	pathOnly        bool
+	jmesPathFilter  string
+	skipTemplate    bool
+	inventory       bool
+	inventoryFormat string
+	indexKeys       []string
 }
 
 var bundleDumpCmd = func() *cobra.Command {
@@ -67,7 +70,10 @@ var bundleDumpCmd = func() *cobra.Command {
 	harp bundle dump --query <jmesfilter query>
 
 	# Dump a bundle content excluding the template used to generate
-	harp bundle dump --skip-template`)
+	harp bundle dump --skip-template
+
+	# Generate an inventory report without secret values
+	harp bundle dump --inventory --inventory-format csv`)
 
 	cmd := &cobra.Command{
 		Use:     "dump",
@@ -79,6 +85,12 @@ var bundleDumpCmd = func() *cobra.Command {
 			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-dump", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
 			defer cancel()
 
+			// Prepare indexed bundle transformers
+			transformers, err := indexTransformers(params.indexKeys)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
 			// Prepare task
 			t := &bundle.DumpTask{
 				ContainerReader: cmdutil.FileReader(params.inputPath),
@@ -88,6 +100,9 @@ var bundleDumpCmd = func() *cobra.Command {
 				PathOnly:        params.pathOnly,
 				JMESPathFilter:  params.jmesPathFilter,
 				IgnoreTemplate:  params.skipTemplate,
+				Inventory:       params.inventory,
+				InventoryFormat: params.inventoryFormat,
+				Transformers:    transformers,
 			}
 
 			// Run the task
@@ -105,6 +120,9 @@ var bundleDumpCmd = func() *cobra.Command {
 	cmd.Flags().BoolVar(&params.pathOnly, "path-only", false, "Display path only")
 	cmd.Flags().StringVar(&params.jmesPathFilter, "query", "", "Specify a JMESPath query to format output")
 	cmd.Flags().BoolVar(&params.skipTemplate, "skip-template", false, "Drop template from dump")
+	cmd.Flags().BoolVar(&params.inventory, "inventory", false, "Display an inventory report without secret values")
+	cmd.Flags().StringVar(&params.inventoryFormat, "inventory-format", bundle.InventoryFormatMarkdown, "Inventory report format (csv, markdown, cyclonedx)")
+	cmd.Flags().StringSliceVar(&params.indexKeys, "index-key", []string{}, "Indexed bundle decryption key. Repeat to add multiple keys to try.")
 
 	return cmd
 }
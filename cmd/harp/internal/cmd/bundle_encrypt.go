@@ -37,6 +37,8 @@ type bundleEncryptParams struct {
 	key            string
 	keyAliases     []string
 	skipUnresolved bool
+	workerCount    int64
+	withoutHistory bool
 }
 
 var bundleEncryptCmd = func() *cobra.Command {
@@ -58,7 +60,11 @@ var bundleEncryptCmd = func() *cobra.Command {
 	Annotations:
 
 	* harp.elastic.co/v1/package#encryptionKeyAlias=<alias> - Set this
-	  annotation on packages to reference a key alias.`)
+	  annotation on packages to reference a key alias.
+	* harp.elastic.co/v1/package#encryptionMode=key - Encrypt each secret
+	  value in place instead of the whole package, secret keys remain readable.
+	* harp.elastic.co/v1/package#encryptedKeys=<key>,<key> - Restrict the
+	  in place encryption to the given secret keys (all keys by default).`)
 
 	examples := cmdutil.Examples(`
 	# Encrypt a whole bundle from STDIN and produce output to STDOUT
@@ -83,6 +89,8 @@ var bundleEncryptCmd = func() *cobra.Command {
 			t := &bundle.EncryptTask{
 				ContainerReader: cmdutil.FileReader(params.inputPath),
 				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				WorkerCount:     params.workerCount,
+				RecordHistory:   !params.withoutHistory,
 			}
 			switch {
 			case params.key != "":
@@ -136,6 +144,8 @@ var bundleEncryptCmd = func() *cobra.Command {
 	cmd.Flags().StringVar(&params.key, "key", "", "Secret value encryption key for full bundle encryption")
 	cmd.Flags().StringSliceVar(&params.keyAliases, "key-alias", []string{}, "Secret value encryption key for partial bundle encryption ('alias:key')")
 	cmd.Flags().BoolVarP(&params.skipUnresolved, "skip-unresolved-key-alias", "s", false, "Skip unresolved key alias during partial bundle encryption")
+	cmd.Flags().Int64Var(&params.workerCount, "worker-count", 4, "Active package encryption worker count")
+	cmd.Flags().BoolVar(&params.withoutHistory, "without-history", false, "Don't archive modified plaintext package secrets in package version history")
 
 	return cmd
 }
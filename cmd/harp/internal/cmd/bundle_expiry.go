@@ -0,0 +1,105 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	pkgbundle "github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleExpiryParams struct {
+	inputPath  string
+	outputPath string
+	within     string
+	threshold  string
+}
+
+var bundleExpiryCmd = func() *cobra.Command {
+	params := &bundleExpiryParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Report overdue and soon to expire secrets.
+
+	Package expiration dates are read from the 'harp.elastic.co/v1/package#expiresAt'
+	annotation, or computed from 'harp.elastic.co/v1/package#lastRotated' and
+	'harp.elastic.co/v1/package#rotationInterval' annotations. PEM encoded
+	certificate values are reported using their NotAfter date.
+
+	The command exits with a non-zero status when a secret expires before the
+	given threshold, so that it can be used in CI pipelines.
+	`)
+
+	examples := cmdutil.Examples(`
+	# List secrets expiring in the next 30 days, fail on overdue secrets
+	harp bundle expiry --in secrets.bundle
+
+	# Fail when a secret expires in the next 2 weeks
+	harp bundle expiry --in secrets.bundle --within 60d --threshold 2w
+
+	# Report only
+	harp bundle expiry --in secrets.bundle --threshold -1s`)
+
+	cmd := &cobra.Command{
+		Use:     "expiry",
+		Short:   "Report overdue and expiring secrets",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-expiry", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Parse durations
+			within, err := pkgbundle.ParseDuration(params.within)
+			if err != nil {
+				log.For(ctx).Fatal("unable to parse reporting window", zap.Error(err))
+			}
+			threshold, err := pkgbundle.ParseDuration(params.threshold)
+			if err != nil {
+				log.For(ctx).Fatal("unable to parse threshold", zap.Error(err))
+			}
+
+			// Prepare task
+			t := &bundle.ExpiryTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				Within:          within,
+				Threshold:       threshold,
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Report output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.within, "within", "30d", "Report secrets expiring in the given duration (supports d and w units)")
+	cmd.Flags().StringVar(&params.threshold, "threshold", "0s", "Fail when a secret expires in the given duration (negative value disables the check)")
+
+	return cmd
+}
@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleHistoryParams struct {
+	inputPath   string
+	outputPath  string
+	packageName string
+}
+
+var bundleHistoryCmd = func() *cobra.Command {
+	params := &bundleHistoryParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Display the secret version history of bundle packages.
+
+	Each package reports its active secret version and the archived versions
+	recorded during bundle rotations (patch, template regeneration).
+	`)
+
+	examples := cmdutil.Examples(`
+	# Display all package histories
+	harp bundle history --in secrets.bundle
+
+	# Display a package history
+	harp bundle history --in secrets.bundle --path app/production/customer1/ece/v1.0.0/adminconsole/database/usage_credentials`)
+
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Display secret version history",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-history", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.HistoryTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				PackageName:     params.packageName,
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.packageName, "path", "", "Restrict to the given package path")
+
+	return cmd
+}
@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleIndexParams struct {
+	inputPath  string
+	outputPath string
+	key        string
+}
+
+var bundleIndexCmd = func() *cobra.Command {
+	params := &bundleIndexParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Convert a bundle container to the random-access indexed layout.
+
+	The indexed layout stores an encrypted package index followed by
+	independently encrypted package segments, so that a single package can be
+	decrypted without decoding the whole bundle. Each segment is bound to the
+	container and to its package name, so the key must use a transformer
+	authenticating additional data (AEAD, secretbox, jwe, paseto); age, branca
+	and fernet keys are rejected. Package and bundle merkle tree roots are
+	checked when segments are decrypted.
+
+	The produced container can be sealed as any other container, and read with
+	'harp bundle read --key <transformer key>'.`)
+
+	examples := cmdutil.Examples(`
+	# Convert a bundle to the indexed layout
+	harp bundle index --in customer.bundle --key <transformer key> --out customer.indexed.bundle
+
+	# Read a secret from an indexed bundle
+	harp bundle read --in customer.indexed.bundle --key <transformer key> --path app/production/database`)
+
+	cmd := &cobra.Command{
+		Use:     "index",
+		Short:   "Convert a bundle to a random-access indexed container",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-index", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Create transformer according to used encryption key
+			transformer, err := encryption.FromKey(params.key)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
+			// Prepare task
+			t := &bundle.IndexTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				Transformer:     transformer,
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.key, "key", "", "Package index and segment encryption key")
+	log.CheckErr("unable to mark 'key' flag as required.", cmd.MarkFlagRequired("key"))
+
+	return cmd
+}
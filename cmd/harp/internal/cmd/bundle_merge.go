@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleMergeParams struct {
+	basePath     string
+	outputPath   string
+	conflictPath string
+	strategy     string
+	overrides    []string
+}
+
+var bundleMergeCmd = func() *cobra.Command {
+	params := &bundleMergeParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Merge multiple Bundles into a single one.
+
+	Bundles are merged in the given order. When a package, secret, label or
+	annotation exists in multiple bundles with different values, the conflict
+	is resolved using the selected strategy:
+
+	* ours   - keep the value of the bundle merged first;
+	* theirs - keep the value of the bundle merged last;
+	* fail   - abort the merge;
+	* newest - keep the value of the package with the highest secret version.
+
+	The default strategy can be overridden for packages matching a glob pattern
+	or for a specific secret key using '<package>[#<key>]=<strategy>' values.
+
+	When a common ancestor is given with '--base', a three-way merge of the
+	local (first) and remote (second) bundles is computed. Only items changed
+	differently by both sides, or deleted by one side and changed by the other,
+	are reported as conflicts; 'ours' selects the local side and 'theirs' the
+	remote one. The merged bundle is only produced when '--out' is specified.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Merge bundles and fail on conflict
+	harp bundle merge team1.bundle team2.bundle --out merged.bundle
+
+	# Merge bundles, keep the last value and write the conflict report
+	harp bundle merge team1.bundle team2.bundle --strategy theirs --conflicts conflicts.json --out merged.bundle
+
+	# Merge bundles using overrides
+	harp bundle merge team1.bundle team2.bundle --strategy ours --override 'app/production/*=newest' --override 'app/production/database#password=theirs'
+
+	# Compute three-way merge conflicts
+	harp bundle merge --base base.bundle local.bundle remote.bundle --conflicts -
+
+	# Reconcile parallel modifications from a common ancestor
+	harp bundle merge --base base.bundle local.bundle remote.bundle --strategy theirs --out merged.bundle`)
+
+	cmd := &cobra.Command{
+		Use:     "merge <container> <container>...",
+		Short:   "Merge multiple bundles",
+		Long:    longDesc,
+		Example: examples,
+		Args:    cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-merge", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Three-way merge
+			if params.basePath != "" {
+				runThreeWayMerge(ctx, params, args)
+				return
+			}
+
+			// Prepare readers
+			readers := make([]tasks.ReaderProvider, 0, len(args))
+			for _, path := range args {
+				readers = append(readers, cmdutil.FileReader(path))
+			}
+
+			// Prepare task
+			t := &bundle.MergeTask{
+				ContainerReaders: readers,
+				OutputWriter:     cmdutil.FileWriter(params.outputPath),
+				Strategy:         params.strategy,
+				Overrides:        params.overrides,
+			}
+			if params.conflictPath != "" {
+				t.ConflictWriter = cmdutil.FileWriter(params.conflictPath)
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.basePath, "base", "", "Common ancestor container path to compute a three-way merge")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.conflictPath, "conflicts", "", "Conflict report output as JSON ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.strategy, "strategy", "fail", "Default conflict resolution strategy (ours, theirs, fail, newest)")
+	cmd.Flags().StringArrayVar(&params.overrides, "override", []string{}, "Conflict resolution strategy override ('<package>[#<key>]=<strategy>')")
+
+	return cmd
+}
+
+func runThreeWayMerge(ctx context.Context, params *bundleMergeParams, args []string) {
+	if len(args) != 2 {
+		log.For(ctx).Fatal("three-way merge requires exactly 2 containers (local and remote)")
+	}
+
+	// Prepare task
+	t := &bundle.ThreeWayMergeTask{
+		BaseReader:   cmdutil.FileReader(params.basePath),
+		LocalReader:  cmdutil.FileReader(args[0]),
+		RemoteReader: cmdutil.FileReader(args[1]),
+		Strategy:     params.strategy,
+		Overrides:    params.overrides,
+	}
+	if params.outputPath != "" {
+		t.OutputWriter = cmdutil.FileWriter(params.outputPath)
+	}
+	if params.conflictPath != "" {
+		t.ReportWriter = cmdutil.FileWriter(params.conflictPath)
+	}
+
+	// Run the task
+	if err := t.Run(ctx); err != nil {
+		log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+	}
+}
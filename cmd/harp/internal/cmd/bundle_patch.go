@@ -41,6 +41,7 @@ type bundlePatchParams struct {
 	stopAtRuleID      string
 	ignoreRuleIDs     []string
 	ignoreRuleIndexes []int
+	withoutHistory    bool
 }
 
 var bundlePatchCmd = func() *cobra.Command {
@@ -73,6 +74,9 @@ var bundlePatchCmd = func() *cobra.Command {
 				patch.WithIgnoreRuleIDs(params.ignoreRuleIDs...),
 				patch.WithIgnoreRuleIndexes(params.ignoreRuleIndexes...),
 			}
+			if params.withoutHistory {
+				opts = append(opts, patch.WithoutVersionHistory())
+			}
 
 			// Prepare task
 			t := &bundle.PatchTask{
@@ -103,6 +107,7 @@ var bundlePatchCmd = func() *cobra.Command {
 	cmd.Flags().IntVar(&params.stopAtRuleIndex, "stop-at-rule-index", -1, "Stop patch evaluation before the given rule index (0 for first rule)")
 	cmd.Flags().StringArrayVar(&params.ignoreRuleIDs, "ignore-rule-id", []string{}, "List of Rule identifier to ignore during evaluation")
 	cmd.Flags().IntSliceVar(&params.ignoreRuleIndexes, "ignore-rule-index", []int{}, "List of Rule index to ignore during evaluation")
+	cmd.Flags().BoolVar(&params.withoutHistory, "without-history", false, "Don't record modified secrets in package version history")
 
 	return cmd
 }
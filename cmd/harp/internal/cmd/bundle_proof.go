@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleProofParams struct {
+	inputPath   string
+	outputPath  string
+	packageName string
+	key         string
+}
+
+var bundleProofCmd = func() *cobra.Command {
+	params := &bundleProofParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Generate a merkle inclusion proof of a secret value.
+
+	The proof allows a third party to check that a single secret value is part
+	of a bundle identified by its published merkle tree root, without having
+	access to the other bundle secrets. Use 'harp bundle verify-proof' to check
+	the generated proof.
+
+	The proof contains an unsalted hash of the secret value, a low entropy value
+	can be recovered from it by brute force. Share proofs with the same care as
+	the secret itself.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Generate the inclusion proof of a secret
+	harp bundle proof --in secrets.bundle --path app/production/database/credentials --key password
+
+	# Publish the bundle merkle tree root
+	harp bundle dump --in secrets.bundle --query merkleTreeRoot`)
+
+	cmd := &cobra.Command{
+		Use:     "proof",
+		Short:   "Generate a secret inclusion proof",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-proof", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.ProofTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				PackageName:     params.packageName,
+				Key:             params.key,
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Proof output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.packageName, "path", "", "Package path of the secret")
+	log.CheckErr("unable to mark 'path' flag as required.", cmd.MarkFlagRequired("path"))
+	cmd.Flags().StringVar(&params.key, "key", "", "Secret key to prove")
+	log.CheckErr("unable to mark 'key' flag as required.", cmd.MarkFlagRequired("key"))
+
+	return cmd
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
 	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
 )
 
@@ -33,6 +35,7 @@ var bundleReadCmd = func() *cobra.Command {
 		inputPath   string
 		packageName string
 		secretKey   string
+		keys        []string
 	)
 
 	cmd := &cobra.Command{
@@ -43,12 +46,19 @@ var bundleReadCmd = func() *cobra.Command {
 			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-read", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
 			defer cancel()
 
+			// Prepare indexed bundle transformers
+			transformers, err := indexTransformers(keys)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
 			// Prepare task
 			t := &bundle.ReadTask{
 				ContainerReader: cmdutil.FileReader(inputPath),
 				OutputWriter:    cmdutil.StdoutWriter(),
 				PackageName:     packageName,
 				SecretKey:       secretKey,
+				Transformers:    transformers,
 			}
 
 			// Run the task
@@ -63,6 +73,22 @@ var bundleReadCmd = func() *cobra.Command {
 	cmd.Flags().StringVar(&packageName, "path", "", "Secret path")
 	log.CheckErr("unable to mark 'path' flag as required.", cmd.MarkFlagRequired("path"))
 	cmd.Flags().StringVar(&secretKey, "field", "", "Secret field")
+	cmd.Flags().StringSliceVar(&keys, "key", []string{}, "Indexed bundle decryption key. Repeat to add multiple keys to try.")
 
 	return cmd
 }
+
+// indexTransformers returns the transformers used to decrypt indexed bundle
+// containers.
+func indexTransformers(keys []string) ([]value.Transformer, error) {
+	transformers := []value.Transformer{}
+	for _, keyRaw := range keys {
+		transformer, err := encryption.FromKey(keyRaw)
+		if err != nil {
+			return nil, err
+		}
+		transformers = append(transformers, transformer)
+	}
+
+	return transformers, nil
+}
@@ -0,0 +1,153 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleRekeyParams struct {
+	inputPath      string
+	outputPath     string
+	oldKeys        []string
+	newKey         string
+	oldKeyAliases  []string
+	newKeyAliases  []string
+	skipUnresolved bool
+}
+
+var bundleRekeyCmd = func() *cobra.Command {
+	params := &bundleRekeyParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Rotate the encryption key of an encrypted bundle.
+
+	Each encrypted package is decrypted with the old key and encrypted again
+	with the new key in memory, so that plaintext values are never written to
+	disk. Clear-text packages remain untouched.
+
+	When the old and the new keys are the same Vault transit key
+	(vault:<path>:<data encryption>), the data encryption keys are rewrapped
+	with the latest transit key version without decrypting the package values.
+
+	The list of rotated packages is displayed as JSON.`)
+
+	examples := cmdutil.Examples(`
+	# Rotate the key of a whole encrypted bundle
+	harp bundle rekey --in encrypted.bundle --out rotated.bundle --old-key <transformer key> --new-key <transformer key>
+
+	# Rotate the keys of an annotation based encrypted bundle
+	harp bundle rekey --in encrypted.bundle --out rotated.bundle --old-key-alias <alias>:<transformer key> --new-key-alias <alias>:<transformer key>
+
+	# Rewrap the bundle data encryption keys after a Vault transit key rotation
+	harp bundle rekey --in encrypted.bundle --out rotated.bundle --old-key vault:transit/harp:aesgcm --new-key vault:transit/harp:aesgcm`)
+
+	cmd := &cobra.Command{
+		Use:     "rekey",
+		Short:   "Rotate secret values encryption key",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-rekey", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Share transformer instances between identical keys to enable
+			// key rewrapping
+			transformers := map[string]value.Transformer{}
+			fromKey := func(key string) value.Transformer {
+				if t, ok := transformers[key]; ok {
+					return t
+				}
+
+				// Create transformer according to used encryption key
+				t, err := encryption.FromKey(key)
+				if err != nil {
+					log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+				}
+				transformers[key] = t
+
+				return t
+			}
+			fromAliases := func(aliases []string) map[string]value.Transformer {
+				res := map[string]value.Transformer{}
+				for _, alias := range aliases {
+					// Split alias
+					parts := strings.SplitN(alias, ":", 2)
+					if len(parts) != 2 {
+						log.For(ctx).Fatal("invalid alias, it must be formatted alias:key.", zap.String("alias", alias))
+					}
+
+					// Assign to map
+					res[parts[0]] = fromKey(parts[1])
+				}
+				return res
+			}
+
+			// Prepare task
+			t := &bundle.RekeyTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				ReportWriter:    cmdutil.StdoutWriter(),
+				SkipUnresolved:  params.skipUnresolved,
+			}
+			switch {
+			case params.newKey != "":
+				if len(params.oldKeys) == 0 {
+					log.For(ctx).Fatal("--old-key must be provided with --new-key")
+				}
+				for _, k := range params.oldKeys {
+					t.OldTransformers = append(t.OldTransformers, fromKey(k))
+				}
+				t.NewTransformer = fromKey(params.newKey)
+			case len(params.newKeyAliases) > 0:
+				t.OldTransformerMap = fromAliases(params.oldKeyAliases)
+				t.NewTransformerMap = fromAliases(params.newKeyAliases)
+			default:
+				log.For(ctx).Fatal("--new-key or --new-key-alias must be provided")
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or filename)")
+	log.CheckErr("unable to mark 'out' flag as required.", cmd.MarkFlagRequired("out"))
+	cmd.Flags().StringSliceVar(&params.oldKeys, "old-key", []string{}, "Secret value decryption key for full bundle rotation (multiple keys can be provided)")
+	cmd.Flags().StringVar(&params.newKey, "new-key", "", "Secret value encryption key for full bundle rotation")
+	cmd.Flags().StringSliceVar(&params.oldKeyAliases, "old-key-alias", []string{}, "Secret value decryption key for partial bundle rotation ('alias:key')")
+	cmd.Flags().StringSliceVar(&params.newKeyAliases, "new-key-alias", []string{}, "Secret value encryption key for partial bundle rotation ('alias:key')")
+	cmd.Flags().BoolVarP(&params.skipUnresolved, "skip-unresolved-key-alias", "s", false, "Skip unresolved key alias during partial bundle rotation")
+
+	return cmd
+}
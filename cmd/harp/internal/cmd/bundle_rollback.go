@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleRollbackParams struct {
+	inputPath   string
+	outputPath  string
+	packageName string
+	version     uint32
+}
+
+var bundleRollbackCmd = func() *cobra.Command {
+	params := &bundleRollbackParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Restore an archived secret version of a package.
+
+	The restored version becomes a new active version, the replaced one is
+	archived in the package history so that the rollback is also auditable.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Restore the version 2 of a package
+	harp bundle rollback --in secrets.bundle --out restored.bundle --package app/production/database/credentials --version 2`)
+
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Restore a previous secret version",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-rollback", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.RollbackTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				PackageName:     params.packageName,
+				Version:         params.version,
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Container output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.packageName, "package", "", "Package path to restore")
+	log.CheckErr("unable to mark 'package' flag as required.", cmd.MarkFlagRequired("package"))
+	cmd.Flags().Uint32Var(&params.version, "version", 0, "Secret version to restore")
+	log.CheckErr("unable to mark 'version' flag as required.", cmd.MarkFlagRequired("version"))
+
+	return cmd
+}
@@ -0,0 +1,102 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/bundle"
+)
+
+// -----------------------------------------------------------------------------.
+type bundleVerifyProofParams struct {
+	proofPath  string
+	valuePath  string
+	inputPath  string
+	outputPath string
+	root       string
+}
+
+var bundleVerifyProofCmd = func() *cobra.Command {
+	params := &bundleVerifyProofParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Verify a secret inclusion proof against a published bundle merkle tree root.
+
+	The root can be given as hexadecimal or base64 encoded value. When a value
+	file is provided, its content is also checked against the proven secret
+	value.
+
+	When a bundle is provided, it must match the published root. If the bundle
+	is corrupted, the proof is used to check whether the proven secret is one of
+	the diverging secrets.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Verify an inclusion proof
+	harp bundle verify-proof --proof password.proof.json --root <published-root>
+
+	# Verify an inclusion proof and the secret value
+	harp bundle verify-proof --proof password.proof.json --root <published-root> --value-file password.txt
+
+	# Check if a corrupted bundle still holds the proven secret value
+	harp bundle verify-proof --proof password.proof.json --root <published-root> --in secrets.bundle`)
+
+	cmd := &cobra.Command{
+		Use:     "verify-proof",
+		Short:   "Verify a secret inclusion proof",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-bundle-verify-proof", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &bundle.VerifyProofTask{
+				ProofReader:  cmdutil.FileReader(params.proofPath),
+				OutputWriter: cmdutil.FileWriter(params.outputPath),
+				Root:         params.root,
+			}
+			if params.valuePath != "" {
+				t.ValueReader = cmdutil.FileReader(params.valuePath)
+			}
+			if params.inputPath != "" {
+				t.ContainerReader = cmdutil.FileReader(params.inputPath)
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.proofPath, "proof", "-", "Proof input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.root, "root", "", "Published bundle merkle tree root (hex or base64)")
+	log.CheckErr("unable to mark 'root' flag as required.", cmd.MarkFlagRequired("root"))
+	cmd.Flags().StringVar(&params.valuePath, "value-file", "", "Secret value file to check against the proof")
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Bundle to check against the proof (filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Verification result output ('-' for stdout or filename)")
+
+	return cmd
+}
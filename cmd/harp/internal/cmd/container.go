@@ -31,7 +31,10 @@ var containerCmd = func() *cobra.Command {
 	}
 
 	// Bundle commands
+	cmd.AddCommand(containerExportShareCmd())
 	cmd.AddCommand(containerIdentityCmd())
+	cmd.AddCommand(containerInspectCmd())
+	cmd.AddCommand(containerRecipientsCmd())
 	cmd.AddCommand(containerRecoveryCmd())
 	cmd.AddCommand(containerSealCmd())
 	cmd.AddCommand(containerUnsealCmd())
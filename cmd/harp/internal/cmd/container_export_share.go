@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/container"
+)
+
+// -----------------------------------------------------------------------------
+
+type containerExportShareParams struct {
+	inputPath       string
+	outputPath      string
+	containerKeyRaw string
+	preSharedKeyRaw string
+}
+
+var containerExportShareCmd = func() *cobra.Command {
+	params := containerExportShareParams{}
+
+	cmd := &cobra.Command{
+		Use:   "export-share",
+		Short: "Export a recipient share of a threshold sealed container",
+		Long: `Export the payload key share of a recipient of a threshold sealed container.
+
+The exported share is bound to the container and can be sent to the recipient
+in charge of unsealing, who combines it with other shares using the
+'--share-file' flag of the 'unseal' command. The share doesn't reveal the
+recipient private key, but it must be handled as a secret until the container
+is unsealed.`,
+		Example: `  # Export a share
+  harp container export-share --in secrets.sealed --key v2.ck.xxx --out alice.share`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-export-share", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare passphrase
+			containerKey := memguard.NewBufferFromBytes([]byte(params.containerKeyRaw))
+			if params.containerKeyRaw == "" {
+				var err error
+				// Read passphrase from stdin
+				containerKey, err = cmdutil.ReadSecret("Enter container key", false)
+				if err != nil {
+					log.For(ctx).Fatal("unable to read passphrase", zap.Error(err))
+				}
+			}
+			defer containerKey.Destroy()
+
+			// Prepare task
+			t := &container.ExportShareTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				ContainerKey:    containerKey,
+			}
+			if params.preSharedKeyRaw != "" {
+				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Sealed container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Share output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.containerKeyRaw, "key", "", "Container key")
+	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Use a pre-shared-key to recover the share")
+
+	return cmd
+}
@@ -40,6 +40,7 @@ type containerIdentityParams struct {
 	vaultTransitPath string
 	vaultTransitKey  string
 	version          uint
+	sealVersion      uint
 }
 
 var containerIdentityCmd = func() *cobra.Command {
@@ -75,12 +76,28 @@ var containerIdentityCmd = func() *cobra.Command {
 				return
 			}
 
+			// Select identity version from the seal strategy version
+			version := container.IdentityVersion(params.version + 1)
+			switch params.sealVersion {
+			case 0:
+				// Use identity version
+			case 1:
+				version = container.ModernIdentity
+			case 2, 4, 5:
+				version = container.NISTIdentity
+			case 3:
+				version = container.PostQuantumIdentity
+			default:
+				log.For(ctx).Fatal("unsupported seal version", zap.Uint("version", params.sealVersion))
+				return
+			}
+
 			// Prepare task
 			t := &container.IdentityTask{
 				OutputWriter: cmdutil.FileWriter(params.outputPath),
 				Description:  params.description,
 				Transformer:  transformer,
-				Version:      container.IdentityVersion(params.version + 1),
+				Version:      version,
 			}
 
 			// Run the task
@@ -104,6 +121,7 @@ var containerIdentityCmd = func() *cobra.Command {
 	cmd.Flags().StringVar(&params.vaultTransitKey, "vault-transit-key", "", "Use Vault transit encryption to protect identity private key")
 	cmd.Flags().StringVar(&params.description, "description", "", "Identity description")
 	log.CheckErr("unable to mark 'description' flag as required.", cmd.MarkFlagRequired("description"))
-	cmd.Flags().UintVar(&params.version, "version", identityVersion-1, "Select identity version (0:legacy, 1:modern, 2:nist)")
+	cmd.Flags().UintVar(&params.version, "version", identityVersion-1, "Select identity version (0:legacy, 1:modern, 2:nist, 3:post-quantum)")
+	cmd.Flags().UintVar(&params.sealVersion, "seal-version", 0, "Select identity version matching the sealing strategy version, overrides version (1:modern, 2:fips-compliant, 3:post-quantum hybrid, 4:fips-compliant streaming, 5:fips-compliant threshold)")
 	return cmd
 }
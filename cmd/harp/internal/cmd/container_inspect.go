@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption/jwe"
+	"github.com/zntrio/harp/v2/pkg/tasks/container"
+	"github.com/zntrio/harp/v2/pkg/vault"
+)
+
+// -----------------------------------------------------------------------------
+
+type containerInspectParams struct {
+	inputPath         string
+	containerKeysRaw  []string
+	identityFilePaths []string
+	passPhrase        string
+	vaultTransitPath  string
+	vaultTransitKey   string
+	preSharedKeyRaw   string
+}
+
+var containerInspectCmd = func() *cobra.Command {
+	params := containerInspectParams{}
+
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Display container metadata as JSON",
+		Long: `Display the container headers as JSON without unsealing it.
+
+The report contains the seal version, the content type and encoding, and the
+recipient identifiers of a sealed container.
+
+Recipient identifiers are derived from the recipient private keys, so a public
+key can't be matched against them. Use container keys, identity recovery keys
+or identity files with their passphrase to check which of them are recipients.
+The pre-shared key usage is only reported when one of the checked keys is a
+recipient.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-inspect", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &container.InspectTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.StdoutWriter(),
+			}
+			for _, k := range params.containerKeysRaw {
+				t.ContainerKeys = append(t.ContainerKeys, memguard.NewBufferFromBytes([]byte(k)))
+			}
+			defer func() {
+				for _, k := range t.ContainerKeys {
+					k.Destroy()
+				}
+			}()
+			for _, f := range params.identityFilePaths {
+				t.IdentityReaders = append(t.IdentityReaders, cmdutil.FileReader(f))
+			}
+			if params.preSharedKeyRaw != "" {
+				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
+			}
+
+			// Prepare identity transformer
+			if len(t.IdentityReaders) > 0 {
+				var errTransformer error
+				switch {
+				case params.passPhrase != "":
+					t.Transformer, errTransformer = jwe.Transformer(jwe.PBES2_HS512_A256KW, params.passPhrase)
+				case params.vaultTransitKey != "" && params.vaultTransitPath != "":
+					t.Transformer, errTransformer = vault.Transformer(params.vaultTransitPath, params.vaultTransitKey, vault.Chacha20Poly1305)
+				default:
+					var passPhrase *memguard.LockedBuffer
+					// Read passphrase from stdin
+					passPhrase, errTransformer = cmdutil.ReadSecret("Enter identity passphrase", false)
+					if errTransformer == nil {
+						defer passPhrase.Destroy()
+						t.Transformer, errTransformer = jwe.Transformer(jwe.PBES2_HS512_A256KW, passPhrase.String())
+					}
+				}
+				if errTransformer != nil {
+					log.For(ctx).Fatal("unable to initialize value transformer", zap.Error(errTransformer))
+				}
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Container input ('-' for stdin or filename)")
+	cmd.Flags().StringArrayVar(&params.containerKeysRaw, "key", []string{}, "Container key or identity recovery key to check")
+	cmd.Flags().StringArrayVar(&params.identityFilePaths, "identity-file", []string{}, "Identity file to check")
+	cmd.Flags().StringVar(&params.passPhrase, "passphrase", "", "Identity private key passphrase")
+	cmd.Flags().StringVar(&params.vaultTransitPath, "vault-transit-path", "transit", "Vault transit backend mount path")
+	cmd.Flags().StringVar(&params.vaultTransitKey, "vault-transit-key", "", "Use Vault transit encryption to decrypt identity private keys")
+	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Pre-shared key used to seal the container")
+
+	return cmd
+}
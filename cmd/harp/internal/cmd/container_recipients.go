@@ -0,0 +1,47 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// -----------------------------------------------------------------------------
+
+var containerRecipientsCmd = func() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recipients",
+		Short: "Manage sealed container recipients",
+		Long: `Manage the recipients of a sealed container without unsealing it.
+
+The payload key is recovered with an existing container key or identity
+recovery key and wrapped for the added recipients. The container signature
+is recomputed for the updated headers.
+
+Removed recipients which kept a copy of the payload key are still able to
+decrypt the container payload, seal the container again to rotate the
+payload key.`,
+	}
+
+	// Add sub commands
+	cmd.AddCommand(containerRecipientsListCmd())
+	cmd.AddCommand(containerRecipientsAddCmd())
+	cmd.AddCommand(containerRecipientsRemoveCmd())
+
+	return cmd
+}
@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/container/identity"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/container"
+)
+
+// -----------------------------------------------------------------------------
+
+type containerRecipientsAddParams struct {
+	identities        []string
+	identityFilePaths []string
+	inputPath         string
+	outputPath        string
+	containerKeyRaw   string
+	preSharedKeyRaw   string
+}
+
+var containerRecipientsAddCmd = func() *cobra.Command {
+	params := containerRecipientsAddParams{}
+
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add recipients to a sealed container",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-recipients-add", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Load identity from files
+			for _, f := range params.identityFilePaths {
+				if f == "" {
+					// Ignore empty
+					continue
+				}
+
+				// Open for reading
+				r, err := cmdutil.Reader(f)
+				if err != nil {
+					log.For(ctx).Fatal("unable to read identity file", zap.Error(err), zap.String("identity", f))
+				}
+
+				// Decode identity
+				id, err := identity.FromReader(r)
+				if err != nil {
+					log.For(ctx).Fatal("unable to decode identity from file", zap.Error(err), zap.String("identity", f))
+				}
+
+				// Append to identity list
+				params.identities = append(params.identities, id.Public)
+			}
+
+			// Prepare container key
+			containerKey := memguard.NewBufferFromBytes([]byte(params.containerKeyRaw))
+			if params.containerKeyRaw == "" {
+				var err error
+				// Read container key from stdin
+				containerKey, err = cmdutil.ReadSecret("Enter container key", false)
+				if err != nil {
+					log.For(ctx).Fatal("unable to read container key", zap.Error(err))
+				}
+			}
+			defer containerKey.Destroy()
+
+			// Prepare task
+			t := &container.RecipientsAddTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				ContainerKey:    containerKey,
+				PeerPublicKeys:  params.identities,
+			}
+			if params.preSharedKeyRaw != "" {
+				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Sealed container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Sealed container output ('-' for stdout or filename)")
+	log.CheckErr("unable to mark 'out' flag as required.", cmd.MarkFlagRequired("out"))
+	cmd.Flags().StringVar(&params.containerKeyRaw, "key", "", "Container key or identity recovery key of an existing recipient")
+	cmd.Flags().StringArrayVar(&params.identities, "identity", []string{}, "Identity or sealing public key allowed to unseal")
+	cmd.Flags().StringArrayVar(&params.identityFilePaths, "identity-file", []string{}, "Files with identity allowed to unseal")
+	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Pre-shared key used to seal the container")
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/container"
+)
+
+// -----------------------------------------------------------------------------
+
+type containerRecipientsRemoveParams struct {
+	identifiers     []string
+	inputPath       string
+	outputPath      string
+	containerKeyRaw string
+	preSharedKeyRaw string
+}
+
+var containerRecipientsRemoveCmd = func() *cobra.Command {
+	params := containerRecipientsRemoveParams{}
+
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove recipients from a sealed container",
+		Long: `Remove recipients from a sealed container.
+
+Recipients are designated by their identifier as displayed by the
+'harp container recipients list' command.
+
+Removing a recipient does not revoke its access. The payload key is not
+rotated, a removed recipient can recover it from a previous copy of the
+container and still decrypt the updated container. Seal the content again
+to revoke access.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-recipients-remove", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare container key
+			containerKey := memguard.NewBufferFromBytes([]byte(params.containerKeyRaw))
+			if params.containerKeyRaw == "" {
+				var err error
+				// Read container key from stdin
+				containerKey, err = cmdutil.ReadSecret("Enter container key", false)
+				if err != nil {
+					log.For(ctx).Fatal("unable to read container key", zap.Error(err))
+				}
+			}
+			defer containerKey.Destroy()
+
+			// Prepare task
+			t := &container.RecipientsRemoveTask{
+				ContainerReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter:    cmdutil.FileWriter(params.outputPath),
+				ContainerKey:    containerKey,
+				Identifiers:     params.identifiers,
+			}
+			if params.preSharedKeyRaw != "" {
+				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Sealed container input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "", "Sealed container output ('-' for stdout or filename)")
+	log.CheckErr("unable to mark 'out' flag as required.", cmd.MarkFlagRequired("out"))
+	cmd.Flags().StringVar(&params.containerKeyRaw, "key", "", "Container key or identity recovery key of an existing recipient")
+	cmd.Flags().StringArrayVar(&params.identifiers, "id", []string{}, "Identifier of the recipient to remove")
+	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Pre-shared key used to seal the container")
+
+	return cmd
+}
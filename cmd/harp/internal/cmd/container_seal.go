@@ -43,7 +43,10 @@ type containerSealParams struct {
 	noContainerIdentity bool
 	jsonOutput          bool
 	sealVersion         uint
+	threshold           uint
 	preSharedKeyRaw     string
+	skipTypeCheck       bool
+	indexKeys           []string
 }
 
 var containerSealCmd = func() *cobra.Command {
@@ -52,6 +55,14 @@ var containerSealCmd = func() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "seal",
 		Short: "Seal a secret container",
+		Long: `Seal a secret container.
+
+Bundle containers are checked against their declared secret types before
+sealing, unless '--skip-type-check' is set.
+
+The streaming seal ('--seal-version 4') processes the container by chunks in
+constant memory. It doesn't load the bundle, so its secret types are never
+validated, and it only accepts v2 identities.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Initialize logger and context
 			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-seal", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
@@ -100,6 +111,17 @@ var containerSealCmd = func() *cobra.Command {
 				sealingPublicKeys.AddIfNotContains(sealingPublicKey)
 			}
 
+			// Threshold sealing uses its own sealing strategy by default
+			if params.threshold > 0 && !cmd.Flags().Changed("seal-version") {
+				params.sealVersion = 5
+			}
+
+			// Prepare indexed bundle transformers for type validation
+			transformers, err := indexTransformers(params.indexKeys)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
 			// Prepare task
 			t := &container.SealTask{
 				ContainerReader:       cmdutil.FileReader(params.inputPath),
@@ -108,6 +130,9 @@ var containerSealCmd = func() *cobra.Command {
 				JSONOutput:            params.jsonOutput,
 				PeerPublicKeys:        sealingPublicKeys,
 				SealVersion:           params.sealVersion,
+				Threshold:             params.threshold,
+				SkipTypeValidation:    params.skipTypeCheck,
+				IndexTransformers:     transformers,
 			}
 			if params.preSharedKeyRaw != "" {
 				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
@@ -135,8 +160,11 @@ var containerSealCmd = func() *cobra.Command {
 	cmd.Flags().BoolVar(&params.noContainerIdentity, "no-container-identity", false, "Disable container identity")
 	cmd.Flags().StringVar(&params.masterKey, "dckd-master-key", "", "Master key used for deterministic container key derivation")
 	cmd.Flags().StringVar(&params.target, "dckd-target", "", "Target parameter for deterministic container key derivation")
-	cmd.Flags().UintVar(&params.sealVersion, "seal-version", sealVersion, "Select the sealing strategy version (1:modern, 2:fips-compliant)")
+	cmd.Flags().UintVar(&params.sealVersion, "seal-version", sealVersion, "Select the sealing strategy version (1:modern, 2:fips-compliant, 3:post-quantum hybrid, 4:fips-compliant streaming without type validation and v2 keys only, 5:fips-compliant threshold)")
+	cmd.Flags().UintVar(&params.threshold, "threshold", 0, "Minimum count of recipients required to unseal the container (disables container identity)")
 	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Use a pre-shared-key to seal the container to act as a second factor")
+	cmd.Flags().BoolVar(&params.skipTypeCheck, "skip-type-check", false, "Skip bundle secret values validation against their declared type (always skipped with streaming seal)")
+	cmd.Flags().StringSliceVar(&params.indexKeys, "index-key", []string{}, "Indexed bundle decryption key used for type validation. Repeat to add multiple keys to try.")
 
 	return cmd
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
 	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks"
 	"github.com/zntrio/harp/v2/pkg/tasks/container"
 )
 
@@ -31,8 +32,10 @@ import (
 type containerUnsealParams struct {
 	inputPath       string
 	outputPath      string
-	containerKeyRaw string
+	containerKeys   []string
+	shareFiles      []string
 	preSharedKeyRaw string
+	sealVersion     uint
 }
 
 var containerUnsealCmd = func() *cobra.Command {
@@ -41,28 +44,62 @@ var containerUnsealCmd = func() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unseal",
 		Short: "Unseal a secret container",
+		Long: `Unseal a secret container.
+
+Threshold sealed containers require as many recipient shares as the threshold
+defined during sealing. Shares can be recovered by providing several container
+keys, or by collecting the shares exported by other recipients with the
+'export-share' command.
+
+Streaming sealed containers ('--seal-version 4') are unsealed by chunks, each
+chunk is written to the output once authenticated. A truncated or tampered
+container is only detected when its chunk is read, the output of a failed
+unseal holds a partial plaintext and must be discarded.`,
+		Example: `  # Unseal a container
+  harp container unseal --in secrets.sealed --key v2.ck.xxx
+
+  # Unseal a threshold sealed container with 2 recipient keys
+  harp container unseal --in secrets.sealed --key v2.ck.xxx --key v2.ck.yyy
+
+  # Unseal a threshold sealed container with exported shares
+  harp container unseal --in secrets.sealed --key v2.ck.xxx --share-file alice.share`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Initialize logger and context
 			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-container-unseal", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
 			defer cancel()
 
-			// Prepare passphrase
-			containerKey := memguard.NewBufferFromBytes([]byte(params.containerKeyRaw))
-			if params.containerKeyRaw == "" {
-				var err error
+			// Prepare container keys
+			containerKeys := []*memguard.LockedBuffer{}
+			for _, k := range params.containerKeys {
+				containerKeys = append(containerKeys, memguard.NewBufferFromBytes([]byte(k)))
+			}
+			if len(containerKeys) == 0 && len(params.shareFiles) == 0 {
 				// Read passphrase from stdin
-				containerKey, err = cmdutil.ReadSecret("Enter container key", false)
+				containerKey, err := cmdutil.ReadSecret("Enter container key", false)
 				if err != nil {
 					log.For(ctx).Fatal("unable to read passphrase", zap.Error(err))
 				}
+				containerKeys = append(containerKeys, containerKey)
+			}
+			defer func() {
+				for _, k := range containerKeys {
+					k.Destroy()
+				}
+			}()
+
+			// Prepare share readers
+			shareReaders := []tasks.ReaderProvider{}
+			for _, f := range params.shareFiles {
+				shareReaders = append(shareReaders, cmdutil.FileReader(f))
 			}
-			defer containerKey.Destroy()
 
 			// Prepare task
 			t := &container.UnsealTask{
 				ContainerReader: cmdutil.FileReader(params.inputPath),
 				OutputWriter:    cmdutil.StdoutWriter(),
-				ContainerKey:    containerKey,
+				ContainerKeys:   containerKeys,
+				ShareReaders:    shareReaders,
+				SealVersion:     params.sealVersion,
 			}
 			if params.preSharedKeyRaw != "" {
 				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
@@ -78,9 +115,10 @@ var containerUnsealCmd = func() *cobra.Command {
 	// Parameters
 	cmd.Flags().StringVar(&params.inputPath, "in", "", "Sealed container input ('-' for stdin or filename)")
 	cmd.Flags().StringVar(&params.outputPath, "out", "", "Unsealed container output ('-' for stdout or filename)")
-	cmd.Flags().StringVar(&params.containerKeyRaw, "key", "", "Container key")
-	log.CheckErr("unable to mark 'key' flag as required.", cmd.MarkFlagRequired("key"))
+	cmd.Flags().StringArrayVar(&params.containerKeys, "key", []string{}, "Container key (repeatable for threshold sealed containers)")
+	cmd.Flags().StringArrayVar(&params.shareFiles, "share-file", []string{}, "Exported recipient share file (repeatable)")
 	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Use a pre-shared-key to unseal the container")
+	cmd.Flags().UintVar(&params.sealVersion, "seal-version", 0, "Only accept containers sealed with the given sealing strategy version (0:any, 1:modern, 2:fips-compliant, 3:post-quantum hybrid, 4:fips-compliant streaming, 5:fips-compliant threshold)")
 
 	return cmd
 }
@@ -32,6 +32,7 @@ var csoCmd = func() *cobra.Command {
 	// Sub-commands
 	cmd.AddCommand(csoValidateCmd())
 	cmd.AddCommand(csoParseCmd())
+	cmd.AddCommand(csoServerCmd())
 
 	return cmd
 }
@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"crypto/tls"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+	"github.com/zntrio/harp/v2/pkg/tasks/server"
+)
+
+// -----------------------------------------------------------------------------.
+type csoServerParams struct {
+	network     string
+	address     string
+	tlsCertPath string
+	tlsKeyPath  string
+	tlsCAPath   string
+}
+
+var csoServerCmd = func() *cobra.Command {
+	params := &csoServerParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Expose the CSO ValidatorService using gRPC and a JSON/HTTP gateway.
+
+	Both protocols are served on the same listener. The JSON/HTTP gateway
+	exposes the validation method using 'GET /v1/cso/validate?path=...' or
+	'POST /v1/cso/validate' with a JSON body.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Start the CSO validator server on port 8080
+	harp cso server --listen :8080
+
+	# Start the CSO validator server with TLS
+	harp cso server --tls-cert server.pem --tls-key server-key.pem
+
+	# Validate a path using the JSON/HTTP gateway
+	curl "http://localhost:8080/v1/cso/validate?path=infra/aws/security/eu-central-1/ec2/ssh/default/ec2_default_user"`)
+
+	cmd := &cobra.Command{
+		Use:     "server",
+		Short:   "Serve CSO path validation over gRPC and JSON/HTTP",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-cso-server", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &server.CSOTask{
+				Network:         params.network,
+				Address:         params.address,
+				Debug:           conf.Debug.Enabled,
+				Instrumentation: conf.Instrumentation,
+			}
+			if params.tlsCertPath != "" || params.tlsKeyPath != "" {
+				t.TLS = &tlsconfig.Options{
+					CertFile: params.tlsCertPath,
+					KeyFile:  params.tlsKeyPath,
+				}
+				if params.tlsCAPath != "" {
+					t.TLS.CAFile = params.tlsCAPath
+					t.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+					t.TLS.ExclusiveRootPools = true
+				}
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.network, "network", "tcp", "Network class used for listen (tcp, tcp4, tcp6, unix)")
+	cmd.Flags().StringVar(&params.address, "listen", ":8080", "Listen address")
+	cmd.Flags().StringVar(&params.tlsCertPath, "tls-cert", "", "Server certificate path")
+	cmd.Flags().StringVar(&params.tlsKeyPath, "tls-key", "", "Server private key path")
+	cmd.Flags().StringVar(&params.tlsCAPath, "tls-ca", "", "Client certificate authority path (enables client authentication)")
+
+	return cmd
+}
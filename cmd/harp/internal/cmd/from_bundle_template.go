@@ -36,6 +36,7 @@ import (
 var fromTemplateCmd = func() *cobra.Command {
 	var (
 		inputPath    string
+		previousPath string
 		outputPath   string
 		rootPath     string
 		valueFiles   []string
@@ -89,6 +90,9 @@ var fromTemplateCmd = func() *cobra.Command {
 					engine.WithFiles(files),
 				),
 			}
+			if previousPath != "" {
+				t.PreviousReader = cmdutil.FileReader(previousPath)
+			}
 
 			// Run the task
 			if err := t.Run(ctx); err != nil {
@@ -100,6 +104,7 @@ var fromTemplateCmd = func() *cobra.Command {
 	// Parameters
 	cmd.Flags().StringVar(&inputPath, "in", "-", "Template input path ('-' for stdin or filename)")
 	cmd.Flags().StringVar(&outputPath, "out", "", "Container output ('-' for stdout or a filename)")
+	cmd.Flags().StringVar(&previousPath, "previous", "", "Previously generated container path to carry secret version history from")
 	cmd.Flags().StringVar(&rootPath, "root", "", "Defines file loader root base path")
 	cmd.Flags().StringArrayVarP(&valueFiles, "values", "f", []string{}, "Specifies value files to load")
 	cmd.Flags().StringArrayVar(&values, "set", []string{}, "Specifies value (k=v)")
@@ -38,6 +38,8 @@ var keygenCmd = func() *cobra.Command {
 	cmd.AddCommand(keygenMasterKeyCmd())
 	cmd.AddCommand(keygenKeypairCmd())
 	cmd.AddCommand(keygenPreSharedKeyCmd())
+	cmd.AddCommand(keygenSplitCmd())
+	cmd.AddCommand(keygenCombineCmd())
 
 	if !fips.Enabled() {
 		cmd.AddCommand(keygenSecretBoxCmd())
@@ -0,0 +1,73 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/keygen"
+)
+
+// -----------------------------------------------------------------------------
+
+type keygenCombineParams struct {
+	inputPaths []string
+	outputPath string
+}
+
+var keygenCombineCmd = func() *cobra.Command {
+	params := &keygenCombineParams{}
+
+	cmd := &cobra.Command{
+		Use:   "combine",
+		Short: "Reconstruct a secret from Shamir shares",
+		Long: `Reconstruct a secret split with the 'split' command.
+
+Share files can be given in any order, blank lines and lines starting with '#'
+are ignored.`,
+		Example: `  # Combine shares
+  harp keygen combine --in share-1-of-5.txt --in share-4-of-5.txt --in share-5-of-5.txt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-keygen-combine", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &keygen.CombineTask{
+				OutputWriter: cmdutil.FileWriter(params.outputPath),
+			}
+			for _, f := range params.inputPaths {
+				t.ShareReaders = append(t.ShareReaders, cmdutil.FileReader(f))
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringArrayVar(&params.inputPaths, "in", []string{"-"}, "Share input ('-' for stdin or filename, repeatable)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Secret output ('-' for stdout or filename)")
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/tasks/keygen"
+)
+
+// -----------------------------------------------------------------------------
+
+type keygenSplitParams struct {
+	inputPath  string
+	outputPath string
+	outputDir  string
+	parts      uint
+	threshold  uint
+}
+
+var keygenSplitCmd = func() *cobra.Command {
+	params := &keygenSplitParams{}
+
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split a secret in Shamir shares",
+		Long: `Split a secret, such as a container master key or an identity recovery key,
+in Shamir shares. Any threshold of them can reconstruct the secret with the
+'combine' command.
+
+Each share is encoded as Bech32 strings holding the share metadata (share set,
+index, threshold and parts count) protected by a checksum. A checksum of the
+secret is split with it to detect invalid combinations.
+
+The input is split as-is, including trailing new lines. Identity files can be
+split, but recovering the identity container key with 'harp container recover'
+produces much shorter shares.`,
+		Example: `  # Split a new master key in 5 shares, 3 of them are required to reconstruct it
+  harp keygen master-key | harp keygen split --parts 5 --threshold 3
+
+  # Split an identity recovery key, one file per share
+  harp container recover --identity security.json --passphrase "..." | harp keygen split --parts 3 --threshold 2 --out-dir shares`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-keygen-split", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare task
+			t := &keygen.SplitTask{
+				SecretReader: cmdutil.FileReader(params.inputPath),
+				OutputWriter: cmdutil.FileWriter(params.outputPath),
+				Parts:        params.parts,
+				Threshold:    params.threshold,
+			}
+			if params.outputDir != "" {
+				for i := uint(1); i <= params.parts; i++ {
+					t.ShareWriters = append(t.ShareWriters, cmdutil.FileWriter(filepath.Join(params.outputDir, fmt.Sprintf("share-%d-of-%d.txt", i, params.parts))))
+				}
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "-", "Secret input ('-' for stdin or filename)")
+	cmd.Flags().StringVar(&params.outputPath, "out", "-", "Shares output ('-' for stdout or filename)")
+	cmd.Flags().StringVar(&params.outputDir, "out-dir", "", "Write each share to its own file in the given directory")
+	cmd.Flags().UintVar(&params.parts, "parts", 0, "Number of shares to generate")
+	log.CheckErr("unable to mark 'parts' flag as required.", cmd.MarkFlagRequired("parts"))
+	cmd.Flags().UintVar(&params.threshold, "threshold", 0, "Number of shares required to reconstruct the secret")
+	log.CheckErr("unable to mark 'threshold' flag as required.", cmd.MarkFlagRequired("threshold"))
+
+	return cmd
+}
@@ -75,6 +75,7 @@ var mainCmd = func() *cobra.Command {
 	cmd.AddCommand(transformCmd())
 	cmd.AddCommand(shareCmd())
 	cmd.AddCommand(lintCmd())
+	cmd.AddCommand(serverCmd())
 
 	// Return command
 	return cmd
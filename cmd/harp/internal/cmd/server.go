@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+	"github.com/zntrio/harp/v2/pkg/tasks/server"
+)
+
+// -----------------------------------------------------------------------------.
+type serverParams struct {
+	inputPath       string
+	containerKeyRaw string
+	preSharedKeyRaw string
+	namespace       string
+	network         string
+	address         string
+	tlsCertPath     string
+	tlsKeyPath      string
+	tlsCAPath       string
+	insecure        bool
+	indexKeys       []string
+}
+
+var serverCmd = func() *cobra.Command {
+	params := &serverParams{}
+
+	longDesc := cmdutil.LongDesc(`
+	Serve secrets from a container using the BundleService gRPC API.
+
+	The container is unsealed at startup and kept in memory, it is reloaded
+	each time the container file is modified. Clients must be authenticated
+	using mutual TLS unless the insecure mode is explicitly enabled.
+	`)
+
+	examples := cmdutil.Examples(`
+	# Serve a sealed container over mTLS
+	harp server --in secrets.sealed --key <container-key> --tls-cert server.pem --tls-key server-key.pem --tls-ca clients-ca.pem
+
+	# Serve an unsealed container on a local unix socket
+	harp server --in secrets.bundle --network unix --listen /var/run/harp.sock --insecure`)
+
+	cmd := &cobra.Command{
+		Use:     "server",
+		Short:   "Serve container secrets over gRPC",
+		Long:    longDesc,
+		Example: examples,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Initialize logger and context
+			ctx, cancel := cmdutil.Context(cmd.Context(), "harp-server", conf.Debug.Enabled, conf.Instrumentation.Logs.Level)
+			defer cancel()
+
+			// Prepare indexed bundle transformers
+			transformers, err := indexTransformers(params.indexKeys)
+			if err != nil {
+				log.For(ctx).Fatal("unable to initialize transformer", zap.Error(err))
+			}
+
+			// Prepare task
+			t := &server.BundleTask{
+				ContainerPath:     params.inputPath,
+				Namespace:         params.namespace,
+				Network:           params.network,
+				Address:           params.address,
+				IndexTransformers: transformers,
+				Debug:             conf.Debug.Enabled,
+				Instrumentation:   conf.Instrumentation,
+			}
+			if params.containerKeyRaw != "" {
+				t.ContainerKey = memguard.NewBufferFromBytes([]byte(params.containerKeyRaw))
+				defer t.ContainerKey.Destroy()
+			}
+			if params.preSharedKeyRaw != "" {
+				t.PreSharedKey = memguard.NewBufferFromBytes([]byte(params.preSharedKeyRaw))
+			}
+			if !params.insecure {
+				t.TLS = &tlsconfig.Options{
+					CertFile: params.tlsCertPath,
+					KeyFile:  params.tlsKeyPath,
+					CAFile:   params.tlsCAPath,
+				}
+			}
+
+			// Run the task
+			if err := t.Run(ctx); err != nil {
+				log.For(ctx).Fatal("unable to execute task", zap.Error(err))
+			}
+		},
+	}
+
+	// Parameters
+	cmd.Flags().StringVar(&params.inputPath, "in", "", "Container path")
+	log.CheckErr("unable to mark 'in' flag as required.", cmd.MarkFlagRequired("in"))
+	cmd.Flags().StringVar(&params.containerKeyRaw, "key", "", "Container key used to unseal the container")
+	cmd.Flags().StringVar(&params.preSharedKeyRaw, "pre-shared-key", "", "Use a pre-shared-key to unseal the container")
+	cmd.Flags().StringVar(&params.namespace, "namespace", "default", "Namespace name used to expose the container secrets")
+	cmd.Flags().StringVar(&params.network, "network", "tcp", "Network class used for listen (tcp, tcp4, tcp6, unix)")
+	cmd.Flags().StringVar(&params.address, "listen", ":5555", "Listen address")
+	cmd.Flags().StringVar(&params.tlsCertPath, "tls-cert", "", "Server certificate path")
+	cmd.Flags().StringVar(&params.tlsKeyPath, "tls-key", "", "Server private key path")
+	cmd.Flags().StringVar(&params.tlsCAPath, "tls-ca", "", "Client certificate authority path")
+	cmd.Flags().BoolVar(&params.insecure, "insecure", false, "Disable mutual TLS client authentication")
+	cmd.Flags().StringSliceVar(&params.indexKeys, "index-key", []string{}, "Indexed bundle decryption key. Repeat to add multiple keys to try.")
+
+	return cmd
+}
@@ -45,12 +45,28 @@ type Visitor interface {
 // -----------------------------------------------------------------------------
 
 var (
-	bundleAnnotationsKey        = "harp.elastic.co/v1/bundle#annotations"
-	bundleLabelsKey             = "harp.elastic.co/v1/bundle#labels"
-	packageAnnotations          = "harp.elastic.co/v1/package#annotations"
-	packageLabels               = "harp.elastic.co/v1/package#labels"
-	packageEncryptionAnnotation = "harp.elastic.co/v1/package#encryptionKeyAlias"
-	packageEncryptedValueType   = "harp.elastic.co/v1/package#encryptedValue"
+	bundleAnnotationsKey          = "harp.elastic.co/v1/bundle#annotations"
+	bundleLabelsKey               = "harp.elastic.co/v1/bundle#labels"
+	bundleMerkleIndexKey          = "harp.elastic.co/v1/bundle#merkleIndex"
+	packageAnnotations            = "harp.elastic.co/v1/package#annotations"
+	packageLabels                 = "harp.elastic.co/v1/package#labels"
+	packageEncryptionAnnotation   = "harp.elastic.co/v1/package#encryptionKeyAlias"
+	packageEncryptionMode         = "harp.elastic.co/v1/package#encryptionMode"
+	packageEncryptedKeys          = "harp.elastic.co/v1/package#encryptedKeys"
+	packageEncryptedValueType     = "harp.elastic.co/v1/package#encryptedValue"
+	secretChainRollbackAnnotation = "harp.elastic.co/v1/secretchain#rollbackOf"
+	secretChainEncryptionContext  = "harp.elastic.co/v1/secretchain#encryptionContext"
+)
+
+const (
+	// encryptionContextV2 binds ciphertexts to the package name, the secret
+	// chain version and the secret key.
+	encryptionContextV2 = "v2"
+	// lockedPayloadV2 prefixes the locked secret chain payload storing the
+	// secret types with the secret values. The prefix is encrypted with the
+	// payload, legacy payloads are flat JSON objects that previous versions
+	// fail to decode when prefixed.
+	lockedPayloadV2 = "harp:lockedPayload:v2\n"
 )
 
 // AnnotationOwner defines annotations owner contract.
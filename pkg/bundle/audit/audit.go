@@ -0,0 +1,318 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	csov1 "github.com/zntrio/harp/v2/pkg/cso/v1"
+)
+
+const (
+	// RuleWeakPassword is raised for well-known weak passwords.
+	RuleWeakPassword = "weak-password"
+	// RuleDictionaryPassword is raised for passwords derived from a dictionary
+	// word.
+	RuleDictionaryPassword = "dictionary-password"
+	// RuleLowEntropy is raised for values with an estimated entropy below the
+	// expected minimum.
+	RuleLowEntropy = "low-entropy"
+	// RuleReusedValue is raised when the same value is used by several
+	// packages.
+	RuleReusedValue = "reused-value"
+)
+
+// Severity describes a finding severity.
+type Severity string
+
+const (
+	// SeverityError is used for findings which must be fixed.
+	SeverityError Severity = "error"
+	// SeverityWarning is used for findings which should be reviewed.
+	SeverityWarning Severity = "warning"
+	// SeverityNote is used for informational findings.
+	SeverityNote Severity = "note"
+)
+
+// DefaultMinEntropy defines the default minimal estimated entropy in bits.
+const DefaultMinEntropy = 48
+
+// Location describes a secret location in a bundle.
+type Location struct {
+	Package string `json:"package"`
+	Key     string `json:"key"`
+}
+
+// String returns the secret path.
+func (l Location) String() string {
+	return fmt.Sprintf("%s#%s", l.Package, l.Key)
+}
+
+// Finding describes a secret hygiene issue.
+type Finding struct {
+	RuleID      string     `json:"rule_id"`
+	Severity    Severity   `json:"severity"`
+	Message     string     `json:"message"`
+	Locations   []Location `json:"locations"`
+	Entropy     float64    `json:"entropy,omitempty"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+}
+
+// Report describes a bundle audit result.
+type Report struct {
+	SecretCount int        `json:"secret_count"`
+	Findings    []*Finding `json:"findings"`
+}
+
+// -----------------------------------------------------------------------------
+
+type options struct {
+	fingerprintKey []byte
+	minEntropy     float64
+}
+
+// Option defines audit option function.
+type Option func(*options)
+
+// WithFingerprintKey sets the key used to compute value fingerprints. When
+// not set a random key is generated so that fingerprints can't be compared
+// across audit runs.
+func WithFingerprintKey(key []byte) Option {
+	return func(o *options) {
+		o.fingerprintKey = key
+	}
+}
+
+// WithMinEntropy sets the minimal estimated entropy in bits.
+func WithMinEntropy(bits float64) Option {
+	return func(o *options) {
+		o.minEntropy = bits
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// sensitiveKey matches secret keys holding credentials. It is only used to
+// decide the finding severity.
+var sensitiveKey = regexp.MustCompile(`(?i)(pass(word|wd|phrase)?|pwd|secret|token|key|credential|auth)`)
+
+// Run audits the active secret values of the given bundle.
+//
+// Entropy, weak, dictionary password and reuse checks are applied to all
+// string values. Findings of non credential-like keys (password, secret,
+// token, key, ...) are reported with a lowered severity. Locked packages are
+// ignored.
+//
+//nolint:gocognit,gocyclo // To refactor
+func Run(b *bundlev1.Bundle, opts ...Option) (*Report, error) {
+	// Check arguments
+	if b == nil {
+		return nil, errors.New("unable to process nil bundle")
+	}
+
+	// Default options
+	dopts := &options{
+		minEntropy: DefaultMinEntropy,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+	if dopts.fingerprintKey == nil {
+		dopts.fingerprintKey = make([]byte, 32)
+		if _, err := rand.Read(dopts.fingerprintKey); err != nil {
+			return nil, fmt.Errorf("unable to generate fingerprint key: %w", err)
+		}
+	}
+	if len(dopts.fingerprintKey) == 0 {
+		return nil, errors.New("unable to process with an empty fingerprint key")
+	}
+
+	res := &Report{
+		Findings: []*Finding{},
+	}
+	reuses := map[string][]Location{}
+	sensitives := map[string]bool{}
+
+	for _, p := range b.Packages {
+		if p == nil || p.Secrets == nil {
+			continue
+		}
+
+		for _, kv := range p.Secrets.Data {
+			if kv == nil {
+				continue
+			}
+			res.SecretCount++
+
+			// Only string values can be audited
+			var value interface{}
+			if err := secret.Unpack(kv.Value, &value); err != nil {
+				return nil, fmt.Errorf("unable to unpack %q - %q secret value: %w", p.Name, kv.Key, err)
+			}
+			raw, ok := value.(string)
+			if !ok || raw == "" {
+				continue
+			}
+
+			loc := Location{Package: p.Name, Key: kv.Key}
+			sensitive := sensitiveKey.MatchString(kv.Key)
+
+			// Index fingerprint
+			fp, err := compare.Fingerprint(dopts.fingerprintKey, raw)
+			if err != nil {
+				return nil, fmt.Errorf("unable to compute %q fingerprint: %w", loc, err)
+			}
+			reuses[fp] = append(reuses[fp], loc)
+			sensitives[fp] = sensitives[fp] || sensitive
+
+			// Skip strength checks of PEM encoded material
+			if strings.HasPrefix(strings.TrimSpace(raw), "-----BEGIN ") {
+				continue
+			}
+
+			// Strength checks
+			switch {
+			case isWeak(raw):
+				res.Findings = append(res.Findings, &Finding{
+					RuleID:    RuleWeakPassword,
+					Severity:  severity(SeverityError, sensitive),
+					Message:   "value is a well-known weak password",
+					Locations: []Location{loc},
+				})
+			case isDictionary(raw):
+				res.Findings = append(res.Findings, &Finding{
+					RuleID:    RuleDictionaryPassword,
+					Severity:  severity(SeverityError, sensitive),
+					Message:   "value is derived from a dictionary word",
+					Locations: []Location{loc},
+				})
+			default:
+				if bits := Entropy(raw); bits < dopts.minEntropy {
+					res.Findings = append(res.Findings, &Finding{
+						RuleID:    RuleLowEntropy,
+						Severity:  severity(SeverityWarning, sensitive),
+						Message:   fmt.Sprintf("estimated entropy of %.1f bits is below %.1f bits", bits, dopts.minEntropy),
+						Locations: []Location{loc},
+						Entropy:   bits,
+					})
+				}
+			}
+		}
+	}
+
+	// Reused values
+	for fp, locations := range reuses {
+		if len(distinctPackages(locations)) < 2 {
+			continue
+		}
+
+		f := &Finding{
+			RuleID:      RuleReusedValue,
+			Severity:    severity(SeverityWarning, sensitives[fp]),
+			Message:     fmt.Sprintf("same value is used by %d packages", len(distinctPackages(locations))),
+			Locations:   locations,
+			Fingerprint: fp,
+		}
+		if stages := distinctStages(locations); len(stages) > 1 {
+			f.Severity = severity(SeverityError, sensitives[fp])
+			f.Message = fmt.Sprintf("same value is used by %d packages across environments (%s)", len(distinctPackages(locations)), strings.Join(stages, ", "))
+		}
+		res.Findings = append(res.Findings, f)
+	}
+
+	// Sort findings
+	for _, f := range res.Findings {
+		sort.SliceStable(f.Locations, func(i, j int) bool {
+			return f.Locations[i].String() < f.Locations[j].String()
+		})
+	}
+	sort.SliceStable(res.Findings, func(i, j int) bool {
+		if res.Findings[i].RuleID != res.Findings[j].RuleID {
+			return res.Findings[i].RuleID < res.Findings[j].RuleID
+		}
+		return res.Findings[i].Locations[0].String() < res.Findings[j].Locations[0].String()
+	})
+
+	// No error
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// severity returns the given severity for credential-like keys, or the next
+// lower one for other keys.
+func severity(s Severity, sensitive bool) Severity {
+	if sensitive {
+		return s
+	}
+
+	switch s {
+	case SeverityError:
+		return SeverityWarning
+	default:
+		return SeverityNote
+	}
+}
+
+func distinctPackages(locations []Location) []string {
+	index := map[string]struct{}{}
+	for _, l := range locations {
+		index[l.Package] = struct{}{}
+	}
+
+	res := make([]string, 0, len(index))
+	for p := range index {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// distinctStages returns the CSO stages of the given locations.
+func distinctStages(locations []Location) []string {
+	index := map[string]struct{}{}
+	for _, l := range locations {
+		s, err := csov1.Pack(l.Package)
+		if err != nil {
+			continue
+		}
+		switch {
+		case s.GetPlatform() != nil:
+			index[csov1.ToStageName(s.GetPlatform().GetStage())] = struct{}{}
+		case s.GetApplication() != nil:
+			index[csov1.ToStageName(s.GetApplication().GetStage())] = struct{}{}
+		}
+	}
+
+	res := make([]string, 0, len(index))
+	for s := range index {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+
+	return res
+}
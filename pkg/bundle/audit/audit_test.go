@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func auditFixture() *bundlev1.Bundle {
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/customer1/ece/v1.0.0/adminconsole/database/credentials",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "user", Value: secret.MustPack("admin")},
+						{Key: "password", Value: secret.MustPack("X7d#kP2v!qL9zR4w@Tm8Yb3n")},
+					},
+				},
+			},
+			{
+				Name: "app/staging/customer1/ece/v1.0.0/adminconsole/database/credentials",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "user", Value: secret.MustPack("admin")},
+						{Key: "password", Value: secret.MustPack("X7d#kP2v!qL9zR4w@Tm8Yb3n")},
+					},
+				},
+			},
+			{
+				Name: "app/staging/customer1/ece/v1.0.0/adminconsole/cache/credentials",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Value: secret.MustPack("Password123!")},
+						{Key: "token", Value: secret.MustPack("aaaaaaaaaaaaaaaa")},
+						{Key: "api_key", Value: secret.MustPack("changeme")},
+						{Key: "passphrase", Value: secret.MustPack("abacus-zoom-ablaze-unfold-dispatch")},
+						{Key: "port", Value: secret.MustPack(6379)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	report, err := Run(auditFixture(), WithFingerprintKey([]byte("audit-key")))
+	require.NoError(t, err)
+	assert.Equal(t, 9, report.SecretCount)
+
+	findings := map[string]*Finding{}
+	for _, f := range report.Findings {
+		findings[f.RuleID+"@"+f.Locations[0].String()] = f
+	}
+	require.Len(t, findings, 7)
+
+	// Weak password
+	assert.Contains(t, findings, RuleWeakPassword+"@app/staging/customer1/ece/v1.0.0/adminconsole/cache/credentials#api_key")
+	// Dictionary password
+	assert.Contains(t, findings, RuleDictionaryPassword+"@app/staging/customer1/ece/v1.0.0/adminconsole/cache/credentials#password")
+	// Low entropy
+	assert.Contains(t, findings, RuleLowEntropy+"@app/staging/customer1/ece/v1.0.0/adminconsole/cache/credentials#token")
+
+	// Reused across environments
+	reused := findings[RuleReusedValue+"@app/production/customer1/ece/v1.0.0/adminconsole/database/credentials#password"]
+	require.NotNil(t, reused)
+	assert.Equal(t, SeverityError, reused.Severity)
+	assert.Len(t, reused.Locations, 2)
+	assert.Contains(t, reused.Message, "production, staging")
+
+	// Non credential-like keys are audited with a lowered severity
+	weak := findings[RuleWeakPassword+"@app/production/customer1/ece/v1.0.0/adminconsole/database/credentials#user"]
+	require.NotNil(t, weak)
+	assert.Equal(t, SeverityWarning, weak.Severity)
+	assert.Equal(t, SeverityError, findings[RuleWeakPassword+"@app/staging/customer1/ece/v1.0.0/adminconsole/cache/credentials#api_key"].Severity)
+	reused = findings[RuleReusedValue+"@app/production/customer1/ece/v1.0.0/adminconsole/database/credentials#user"]
+	require.NotNil(t, reused)
+	assert.Equal(t, SeverityWarning, reused.Severity)
+	assert.Len(t, reused.Locations, 2)
+
+	// Fingerprints are stable for a given key
+	again, err := Run(auditFixture(), WithFingerprintKey([]byte("audit-key")))
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+
+	// Values must never be exposed
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(report))
+	assert.NotContains(t, buf.String(), "X7d#kP2v")
+}
+
+func TestRun_Options(t *testing.T) {
+	_, err := Run(nil)
+	assert.Error(t, err)
+
+	_, err = Run(auditFixture(), WithFingerprintKey([]byte{}))
+	assert.Error(t, err)
+
+	// Stricter entropy
+	report, err := Run(auditFixture(), WithMinEntropy(200))
+	require.NoError(t, err)
+	count := 0
+	for _, f := range report.Findings {
+		if f.RuleID == RuleLowEntropy {
+			count++
+		}
+	}
+	assert.Equal(t, 4, count)
+
+	// Non credential-like low entropy values are informational
+	report, err = Run(&bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/customer1/ece/v1.0.0/adminconsole/database/config",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "host", Value: secret.MustPack("db.local")},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, RuleLowEntropy, report.Findings[0].RuleID)
+	assert.Equal(t, SeverityNote, report.Findings[0].Severity)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	report, err := Run(auditFixture())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, report))
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log["version"])
+	runs, _ := log["runs"].([]interface{})
+	require.Len(t, runs, 1)
+	results, _ := runs[0].(map[string]interface{})["results"].([]interface{})
+	assert.Len(t, results, len(report.Findings))
+
+	assert.Error(t, WriteSARIF(nil, report))
+	assert.Error(t, WriteSARIF(&buf, nil))
+}
@@ -0,0 +1,125 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/security/diceware"
+)
+
+var (
+	// passphraseSeparator matches passphrase word separators.
+	passphraseSeparator = regexp.MustCompile(`[\s\-_.,:;+]+`)
+	// trailingDecoration matches digits and symbols appended to a word.
+	trailingDecoration = regexp.MustCompile(`[0-9!@#$%^&*?.+=_\-]+$`)
+)
+
+// Entropy returns the estimated entropy in bits of the given value.
+//
+// Passphrases composed of diceware words are estimated using the word list
+// size. Other values are estimated as the lowest value of the character pool
+// entropy and the Shannon entropy of the value.
+func Entropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+
+	// Passphrase estimation
+	if words := passphraseWords(value); words > 0 {
+		return float64(words) * diceware.WordEntropy
+	}
+
+	// Character pool estimation
+	var lower, upper, digit, other bool
+	frequencies := map[rune]float64{}
+	length := 0.0
+	for _, r := range value {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+		frequencies[r]++
+		length++
+	}
+
+	pool := 0.0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	poolBits := length * math.Log2(pool)
+
+	// Shannon estimation
+	shannon := 0.0
+	for _, count := range frequencies {
+		p := count / length
+		shannon -= p * math.Log2(p)
+	}
+	shannonBits := shannon * length
+
+	return math.Min(poolBits, shannonBits)
+}
+
+// -----------------------------------------------------------------------------
+
+// passphraseWords returns the word count of a passphrase exclusively composed
+// of diceware words, 0 otherwise.
+func passphraseWords(value string) int {
+	words := passphraseSeparator.Split(strings.TrimSpace(value), -1)
+	count := 0
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		if !diceware.IsWord(w) {
+			return 0
+		}
+		count++
+	}
+
+	return count
+}
+
+// isDictionary returns true when the value is a single dictionary word with
+// optional trailing digits and symbols.
+func isDictionary(value string) bool {
+	base := trailingDecoration.ReplaceAllString(strings.ToLower(strings.TrimSpace(value)), "")
+	if base == "" {
+		return false
+	}
+
+	return diceware.IsWord(base) || isWeak(base)
+}
@@ -0,0 +1,43 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, Entropy(""))
+	assert.Equal(t, 0.0, Entropy("aaaaaaaa"))
+	assert.Less(t, Entropy("abcabcabc"), 20.0)
+	assert.Greater(t, Entropy("X7d#kP2v!qL9zR4w@Tm8Yb3n"), 100.0)
+
+	// Passphrases are estimated using the word list size
+	assert.InDelta(t, 4*12.92, Entropy("abacus-zoom-ablaze-unfold"), 0.1)
+	assert.Less(t, Entropy("abacus zoom"), Entropy("abacus-zoom-ablaze-unfold"))
+}
+
+func TestIsDictionary(t *testing.T) {
+	assert.True(t, isDictionary("abacus"))
+	assert.True(t, isDictionary("Abacus2023!"))
+	assert.True(t, isDictionary("Password123!"))
+	assert.False(t, isDictionary("X7d#kP2v!qL9zR4w"))
+	assert.False(t, isDictionary("123!"))
+}
@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleWeakPassword:       "Secret value is a well-known weak password",
+	RuleDictionaryPassword: "Secret value is derived from a dictionary word",
+	RuleLowEntropy:         "Secret value has a low estimated entropy",
+	RuleReusedValue:        "Secret value is shared by several packages",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the given report as a SARIF log. Secret locations are
+// described as logical locations using the package path and the secret key.
+func WriteSARIF(w io.Writer, r *Report) error {
+	// Check arguments
+	if types.IsNil(w) {
+		return errors.New("unable to process nil writer")
+	}
+	if r == nil {
+		return errors.New("unable to process nil report")
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "harp",
+				InformationURI: "https://github.com/zntrio/harp",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	// Declare rules
+	for _, id := range []string{RuleDictionaryPassword, RuleLowEntropy, RuleReusedValue, RuleWeakPassword} {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: ruleDescriptions[id]},
+		})
+	}
+
+	// Convert findings
+	for _, f := range r.Findings {
+		res := sarifResult{
+			RuleID:    f.RuleID,
+			Level:     string(f.Severity),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{},
+		}
+		for _, l := range f.Locations {
+			res.Locations = append(res.Locations, sarifLocation{
+				LogicalLocations: []sarifLogicalLocation{
+					{
+						Name:               l.Key,
+						FullyQualifiedName: l.String(),
+						Kind:               "member",
+					},
+				},
+			})
+		}
+		if f.Fingerprint != "" {
+			res.PartialFingerprints = map[string]string{
+				"valueFingerprint": f.Fingerprint,
+			}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	// Encode as JSON
+	if err := json.NewEncoder(w).Encode(&sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}); err != nil {
+		return fmt.Errorf("unable to encode SARIF report: %w", err)
+	}
+
+	// No error
+	return nil
+}
@@ -0,0 +1,40 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import "strings"
+
+// weakPasswords lists well-known weak passwords.
+var weakPasswords = map[string]struct{}{
+	"123456": {}, "1234567": {}, "12345678": {}, "123456789": {}, "1234567890": {},
+	"000000": {}, "111111": {}, "123123": {}, "654321": {}, "666666": {},
+	"password": {}, "passw0rd": {}, "p@ssw0rd": {}, "p@ssword": {}, "pass": {},
+	"qwerty": {}, "qwertyuiop": {}, "azerty": {}, "asdfgh": {}, "zxcvbnm": {},
+	"abc123": {}, "letmein": {}, "welcome": {}, "monkey": {}, "dragon": {},
+	"master": {}, "secret": {}, "changeme": {}, "changeit": {}, "default": {},
+	"admin": {}, "administrator": {}, "root": {}, "toor": {}, "guest": {},
+	"test": {}, "test123": {}, "iloveyou": {}, "sunshine": {}, "football": {},
+	"baseball": {}, "princess": {}, "trustno1": {}, "superman": {}, "starwars": {},
+	"postgres": {}, "mysql": {}, "oracle": {}, "redis": {}, "elastic": {},
+}
+
+// isWeak returns true when the value is a well-known weak password.
+func isWeak(value string) bool {
+	_, ok := weakPasswords[strings.ToLower(strings.TrimSpace(value))]
+	return ok
+}
@@ -29,6 +29,7 @@ import (
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
 	"github.com/zntrio/harp/v2/pkg/bundle/compare"
 	"github.com/zntrio/harp/v2/pkg/bundle/hcl"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
 )
@@ -54,21 +55,24 @@ func FromDump(r io.Reader) (*bundlev1.Bundle, error) {
 
 	// Convert secret values to current value packing method.
 	for _, p := range b.Packages {
-		for _, s := range p.Secrets.Data {
-			// Decode json encoded value
-			var data interface{}
-			if errJSON := json.Unmarshal(s.Value, &data); errJSON != nil {
-				return nil, fmt.Errorf("unable to decode %q - %q secret value as json: %w", p.Name, s.Key, errJSON)
-			}
+		chains := append([]*bundlev1.SecretChain{p.Secrets}, History(p)...)
+		for _, c := range chains {
+			for _, s := range c.GetData() {
+				// Decode json encoded value
+				var data interface{}
+				if errJSON := json.Unmarshal(s.Value, &data); errJSON != nil {
+					return nil, fmt.Errorf("unable to decode %q - %q secret value as json: %w", p.Name, s.Key, errJSON)
+				}
 
-			// Pack secret value
-			payload, err := secret.Pack(data)
-			if err != nil {
-				return nil, fmt.Errorf("unable to pack %q - %q secret value: %w", p.Name, s.Key, err)
-			}
+				// Pack secret value
+				payload, err := secret.Pack(data)
+				if err != nil {
+					return nil, fmt.Errorf("unable to pack %q - %q secret value: %w", p.Name, s.Key, err)
+				}
 
-			// Replace current json encoded secret value by packed one.
-			s.Value = payload
+				// Replace current json encoded secret value by packed one.
+				s.Value = payload
+			}
 		}
 	}
 
@@ -85,6 +89,10 @@ func FromOpLog(oplog compare.OpLog) (*bundlev1.Bundle, error) {
 
 	// Generate patch rules
 	for _, op := range oplog {
+		if op.Redacted {
+			return nil, fmt.Errorf("unable to build a bundle from a redacted oplog")
+		}
+
 		switch op.Type {
 		case "package":
 			// Ignore package operation
@@ -162,7 +170,7 @@ func FromMap(input map[string]KV) (*bundlev1.Bundle, error) {
 			// Add to secret package
 			p.Secrets.Data = append(p.Secrets.Data, &bundlev1.KV{
 				Key:   k,
-				Type:  fmt.Sprintf("%T", v),
+				Type:  kind.TypeOf(k, v),
 				Value: packed,
 			})
 		}
@@ -209,7 +217,7 @@ func FromHCL(input *hcl.Config) (*bundlev1.Bundle, error) {
 			// Add to secret package
 			p.Secrets.Data = append(p.Secrets.Data, &bundlev1.KV{
 				Key:   k,
-				Type:  fmt.Sprintf("%T", v),
+				Type:  kind.TypeOf(k, v),
 				Value: packed,
 			})
 		}
@@ -25,9 +25,6 @@ import (
 	"gitlab.com/NebulousLabs/merkletree"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
-	csov1 "github.com/zntrio/harp/v2/pkg/cso/v1"
-
-	"golang.org/x/crypto/blake2b"
 )
 
 // Annotate a bundle object.
@@ -47,61 +44,13 @@ func Tree(b *bundlev1.Bundle) (*merkletree.Tree, *Statistic, error) {
 		return nil, nil, fmt.Errorf("unable to process nil bundle")
 	}
 
-	// Calculate merkle tree root
-	h, err := blake2b.New512(nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to initialize hash function for merkle tree")
-	}
-
-	// Initialize merkle tree
-	tree := merkletree.New(h)
-	if err = tree.SetIndex(1); err != nil {
-		return nil, nil, fmt.Errorf("unable to initialize merkle tree")
-	}
+	// Compute tree leaves
+	leaves, stats := treeLeaves(b)
 
-	// Prepare statistics
-	stats := &Statistic{
-		SecretCount:                  0,
-		PackageCount:                 0,
-		CSOCompliantPackageNameCount: 0,
-	}
-
-	// Ensure packages order
-	sort.SliceStable(b.Packages, func(i, j int) bool {
-		return b.Packages[i].Name < b.Packages[j].Name
-	})
-
-	// All packages
-	for _, p := range b.Packages {
-		// Increment package count
-		stats.PackageCount++
-
-		// Check compliance with CSO
-		if errValidate := csov1.Validate(p.Name); errValidate == nil {
-			stats.CSOCompliantPackageNameCount++
-		}
-
-		// Prepare secret uri list
-		uris := []string{}
-
-		// Follow secret chain
-		if p.Secrets != nil {
-			for _, s := range p.Secrets.Data {
-				// Increment secret count
-				stats.SecretCount++
-
-				// Build merkle tree leaf
-				uris = append(uris, fmt.Sprintf("%s:%d:%s:%x", p.Name, p.Secrets.Version, s.Key, blake2b.Sum512(s.Value)))
-			}
-
-			// Sort them
-			sort.Strings(uris)
-
-			// Push sorted secret uri as proof
-			for _, u := range uris {
-				tree.Push([]byte(u))
-			}
-		}
+	// Build the tree
+	tree, err := newTree(leaves, 1)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Return the tree
@@ -68,7 +68,18 @@ func Load(r io.Reader) (*bundlev1.Bundle, error) {
 
 	// Check if root match
 	if !security.SecureCompare(bundle.MerkleTreeRoot, tree.Root()) {
-		return nil, fmt.Errorf("invalid merkle tree root, bundle is corrupted")
+		// Keep recomputed leaves to locate diverging secrets
+		leaves, _ := treeLeaves(bundle)
+		return nil, &CorruptionError{
+			root:   bundle.MerkleTreeRoot,
+			leaves: leaves,
+		}
+	}
+
+	// Remove legacy merkle index from loaded content
+	delete(bundle.Annotations, bundleMerkleIndexKey)
+	if len(bundle.Annotations) == 0 {
+		bundle.Annotations = nil
 	}
 
 	// No error
@@ -176,21 +187,24 @@ func AsProtoJSON(w io.Writer, b *bundlev1.Bundle) error {
 
 	// Decode packed values
 	for _, p := range cloned.Packages {
-		for _, s := range p.Secrets.Data {
-			// Unpack secret value
-			var data interface{}
-			if err := secret.Unpack(s.Value, &data); err != nil {
-				return fmt.Errorf("unable to unpack %q - %q secret value: %w", p.Name, s.Key, err)
+		chains := append([]*bundlev1.SecretChain{p.Secrets}, History(p)...)
+		for _, c := range chains {
+			for _, s := range c.GetData() {
+				// Unpack secret value
+				var data interface{}
+				if err := secret.Unpack(s.Value, &data); err != nil {
+					return fmt.Errorf("unable to unpack %q - %q secret value: %w", p.Name, s.Key, err)
+				}
+
+				// Re-encode as json
+				payload, err := json.Marshal(data)
+				if err != nil {
+					return fmt.Errorf("unable to encode %q - %q secret value as json: %w", p.Name, s.Key, err)
+				}
+
+				// Replace current packed secret value by json encoded one.
+				s.Value = payload
 			}
-
-			// Re-encode as json
-			payload, err := json.Marshal(data)
-			if err != nil {
-				return fmt.Errorf("unable to encode %q - %q secret value as json: %w", p.Name, s.Key, err)
-			}
-
-			// Replace current packed secret value by json encoded one.
-			s.Value = payload
 		}
 	}
 
@@ -34,6 +34,8 @@ const (
 	Remove string = "remove"
 	// Replace describes an operation to replace content of the target path object.
 	Replace string = "replace"
+	// Conflict describes that the target path object has divergent contents.
+	Conflict string = "conflict"
 )
 
 // OpLog represents operation log calculate from bundle differences.
@@ -41,10 +43,13 @@ type OpLog []DiffItem
 
 // DiffItem represents bundle comparison operations.
 type DiffItem struct {
-	Operation string `json:"op"`
-	Type      string `json:"type"`
-	Path      string `json:"path"`
-	Value     string `json:"value,omitempty"`
+	Operation string        `json:"op"`
+	Type      string        `json:"type"`
+	Path      string        `json:"path"`
+	Value     string        `json:"value,omitempty"`
+	Previous  *string       `json:"previous,omitempty"`
+	Redacted  bool          `json:"redacted,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
 }
 
 // -----------------------------------------------------------------------------
@@ -52,7 +57,7 @@ type DiffItem struct {
 // Diff calculates bundle differences.
 //
 //nolint:funlen,gocognit,gocyclo // To refactor
-func Diff(src, dst *bundlev1.Bundle) ([]DiffItem, error) {
+func Diff(src, dst *bundlev1.Bundle, opts ...DiffOption) ([]DiffItem, error) {
 	// Check arguments
 	if src == nil {
 		return nil, fmt.Errorf("unable to diff with a nil source")
@@ -61,6 +66,15 @@ func Diff(src, dst *bundlev1.Bundle) ([]DiffItem, error) {
 		return nil, fmt.Errorf("unable to diff with a nil destination")
 	}
 
+	// Prepare options
+	dopts := &options{}
+	for _, o := range opts {
+		o(dopts)
+	}
+	if dopts.redactionKey != nil && len(dopts.redactionKey) == 0 {
+		return nil, fmt.Errorf("unable to redact diff values with an empty key")
+	}
+
 	diffs := []DiffItem{}
 
 	// Index source packages
@@ -169,27 +183,64 @@ func Diff(src, dst *bundlev1.Bundle) ([]DiffItem, error) {
 					return nil, fmt.Errorf("unable to unpack %q - %q secret value: %w", dp.Name, ds.Key, err)
 				}
 
-				diffs = append(diffs, DiffItem{
+				item := DiffItem{
 					Operation: Replace,
 					Type:      "secret",
 					Path:      fmt.Sprintf("%s#%s", dp.Name, ds.Key),
 					Value:     data,
-				})
+				}
+				if dopts.withPrevious || dopts.semantic {
+					var previous string
+					if err := secret.Unpack(oldValue.Value, &previous); err != nil {
+						return nil, fmt.Errorf("unable to unpack %q - %q previous secret value: %w", sp.Name, oldValue.Key, err)
+					}
+					if dopts.withPrevious {
+						item.Previous = &previous
+					}
+					if dopts.semantic {
+						item.Changes = semanticChanges(previous, data)
+
+						// Previous field values are exposed on demand only
+						if !dopts.withPrevious {
+							for i := range item.Changes {
+								item.Changes[i].Previous = ""
+							}
+						}
+					}
+				}
+
+				diffs = append(diffs, item)
 			}
 		}
 
 		// Clean removed source secrets
-		for k := range srcSecretIndex {
+		for k, ss := range srcSecretIndex {
 			if _, ok := dstSecretIndex[k]; !ok {
-				diffs = append(diffs, DiffItem{
+				item := DiffItem{
 					Operation: Remove,
 					Type:      "secret",
 					Path:      fmt.Sprintf("%s#%s", dp.Name, k),
-				})
+				}
+				if dopts.withPrevious {
+					var previous string
+					if err := secret.Unpack(ss.Value, &previous); err != nil {
+						return nil, fmt.Errorf("unable to unpack %q - %q previous secret value: %w", sp.Name, k, err)
+					}
+					item.Previous = &previous
+				}
+
+				diffs = append(diffs, item)
 			}
 		}
 	}
 
+	// Redact values
+	if dopts.redactionKey != nil {
+		if err := redact(diffs, dopts.redactionKey); err != nil {
+			return nil, fmt.Errorf("unable to redact diff values: %w", err)
+		}
+	}
+
 	// Sort diff
 	sort.SliceStable(diffs, func(i, j int) bool {
 		var (
@@ -303,6 +303,47 @@ func TestDiff(t *testing.T) {
 	}
 }
 
+func TestDiff_WithPreviousValues(t *testing.T) {
+	src := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/test",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "k1", Value: MustPack("v1")},
+						{Key: "k2", Value: MustPack("v2")},
+					},
+				},
+			},
+		},
+	}
+	dst := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/test",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "k1", Value: MustPack("v1-rotated")},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Diff(src, dst, WithPreviousValues())
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := []DiffItem{
+		{Operation: Replace, Type: "secret", Path: "app/test#k1", Value: "v1-rotated", Previous: stringPtr("v1")},
+		{Operation: Remove, Type: "secret", Path: "app/test#k2", Previous: stringPtr("v2")},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Diff():\n-got/+want\ndiff %s", diff)
+	}
+}
+
 func TestDiff_Fuzz(t *testing.T) {
 	// Making sure the descrption never panics
 	for i := 0; i < 50; i++ {
@@ -321,3 +362,7 @@ func TestDiff_Fuzz(t *testing.T) {
 		Diff(&src, &dst)
 	}
 }
+
+func stringPtr(v string) *string {
+	return &v
+}
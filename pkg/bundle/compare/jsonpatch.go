@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// JSONPatchAdd describes a JSON Patch add operation.
+	JSONPatchAdd = "add"
+	// JSONPatchRemove describes a JSON Patch remove operation.
+	JSONPatchRemove = "remove"
+	// JSONPatchReplace describes a JSON Patch replace operation.
+	JSONPatchReplace = "replace"
+	// JSONPatchTest describes a JSON Patch test operation.
+	JSONPatchTest = "test"
+)
+
+// JSONPatch represents a RFC6902 JSON Patch document.
+type JSONPatch []JSONPatchOperation
+
+// JSONPatchOperation represents a RFC6902 JSON Patch operation.
+type JSONPatchOperation struct {
+	Operation string      `json:"op"`
+	Path      string      `json:"path"`
+	From      string      `json:"from,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+}
+
+// -----------------------------------------------------------------------------
+
+// ToJSONPatch converts the given oplog to a RFC6902 JSON Patch document.
+//
+// Generated paths target the `bundle.AsMap` view where packages are the root
+// object properties and secrets are the package object properties. Recorded
+// previous values are converted to `test` operations.
+func ToJSONPatch(oplog OpLog) (JSONPatch, error) {
+	res := JSONPatch{}
+
+	for i, op := range oplog {
+		if op.Redacted {
+			return nil, fmt.Errorf("unable to convert redacted operation %d", i)
+		}
+
+		var path string
+		switch op.Type {
+		case "package":
+			path = jsonPointer(op.Path)
+		case "secret":
+			pathParts := strings.SplitN(op.Path, "#", 2)
+			if len(pathParts) != 2 {
+				return nil, fmt.Errorf("invalid secret path %q for operation %d", op.Path, i)
+			}
+			path = jsonPointer(pathParts[0], pathParts[1])
+		default:
+			return nil, fmt.Errorf("unsupported oplog type %q for operation %d", op.Type, i)
+		}
+
+		switch op.Operation {
+		case Add:
+			var value interface{} = op.Value
+			if op.Type == "package" {
+				value = map[string]interface{}{}
+			}
+			res = append(res, JSONPatchOperation{Operation: JSONPatchAdd, Path: path, Value: value})
+		case Replace, Remove:
+			// Assert previous value
+			if op.Previous != nil {
+				res = append(res, JSONPatchOperation{Operation: JSONPatchTest, Path: path, Value: *op.Previous})
+			}
+			if op.Operation == Remove {
+				res = append(res, JSONPatchOperation{Operation: JSONPatchRemove, Path: path})
+			} else {
+				res = append(res, JSONPatchOperation{Operation: JSONPatchReplace, Path: path, Value: op.Value})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported oplog operation %q for operation %d", op.Operation, i)
+		}
+	}
+
+	// No error
+	return res, nil
+}
+
+// FromJSONPatch converts the given RFC6902 JSON Patch document targeting the
+// `bundle.AsMap` view to an oplog.
+//
+// `test` operations are converted as previous value of the following
+// operation targeting the same secret. `move` and `copy` operations are not
+// supported.
+//
+//nolint:gocognit,gocyclo // To refactor
+func FromJSONPatch(patch JSONPatch) (OpLog, error) {
+	res := OpLog{}
+	tests := map[string]*string{}
+
+	for i, op := range patch {
+		tokens, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for operation %d: %w", i, err)
+		}
+
+		switch len(tokens) {
+		case 1:
+			// Package operation
+			packageName := tokens[0]
+			switch op.Operation {
+			case JSONPatchRemove:
+				res = append(res, DiffItem{Operation: Remove, Type: "package", Path: packageName})
+			case JSONPatchAdd, JSONPatchReplace:
+				secrets, ok := op.Value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("package value of operation %d must be an object", i)
+				}
+				if op.Operation == JSONPatchReplace {
+					res = append(res, DiffItem{Operation: Remove, Type: "package", Path: packageName})
+				}
+				res = append(res, DiffItem{Operation: Add, Type: "package", Path: packageName})
+				for _, k := range sortedKeys(secrets) {
+					value, ok := secrets[k].(string)
+					if !ok {
+						return nil, fmt.Errorf("secret %q value of operation %d must be a string", k, i)
+					}
+					res = append(res, DiffItem{Operation: Add, Type: "secret", Path: fmt.Sprintf("%s#%s", packageName, k), Value: value})
+				}
+			default:
+				return nil, fmt.Errorf("unsupported package operation %q for operation %d", op.Operation, i)
+			}
+		case 2:
+			// Secret operation
+			secretPath := fmt.Sprintf("%s#%s", tokens[0], tokens[1])
+			switch op.Operation {
+			case JSONPatchTest:
+				value, ok := op.Value.(string)
+				if !ok {
+					return nil, fmt.Errorf("secret value of operation %d must be a string", i)
+				}
+				tests[secretPath] = &value
+				continue
+			case JSONPatchRemove:
+				res = append(res, DiffItem{Operation: Remove, Type: "secret", Path: secretPath, Previous: tests[secretPath]})
+			case JSONPatchAdd, JSONPatchReplace:
+				value, ok := op.Value.(string)
+				if !ok {
+					return nil, fmt.Errorf("secret value of operation %d must be a string", i)
+				}
+				item := DiffItem{Operation: Add, Type: "secret", Path: secretPath, Value: value}
+				if op.Operation == JSONPatchReplace {
+					item.Operation = Replace
+					item.Previous = tests[secretPath]
+				}
+				res = append(res, item)
+			default:
+				return nil, fmt.Errorf("unsupported secret operation %q for operation %d", op.Operation, i)
+			}
+			delete(tests, secretPath)
+		default:
+			return nil, fmt.Errorf("path %q of operation %d doesn't target a package or a secret", op.Path, i)
+		}
+	}
+
+	// Check unbound assertions
+	if len(tests) > 0 {
+		return nil, fmt.Errorf("%d test operation(s) are not followed by a replace or remove operation", len(tests))
+	}
+
+	// No error
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func jsonPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(pointerEscaper.Replace(t))
+	}
+	return sb.String()
+}
+
+func parseJSONPointer(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		if t == "" {
+			return nil, fmt.Errorf("JSON pointer %q has an empty reference token", path)
+		}
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+
+	return tokens, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
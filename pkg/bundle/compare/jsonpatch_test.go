@@ -0,0 +1,146 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToJSONPatch(t *testing.T) {
+	oplog := OpLog{
+		{Operation: Add, Type: "package", Path: "app/new"},
+		{Operation: Add, Type: "secret", Path: "app/new#k~1", Value: "v1"},
+		{Operation: Remove, Type: "package", Path: "app/old"},
+		{Operation: Replace, Type: "secret", Path: "app/test#k1", Value: "v2", Previous: stringPtr("v1")},
+		{Operation: Remove, Type: "secret", Path: "app/test#k2"},
+	}
+
+	got, err := ToJSONPatch(oplog)
+	if err != nil {
+		t.Fatalf("ToJSONPatch() error = %v", err)
+	}
+
+	out, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("unable to marshal JSON Patch: %v", err)
+	}
+	want := `[{"op":"add","path":"/app~1new","value":{}},{"op":"add","path":"/app~1new/k~01","value":"v1"},{"op":"remove","path":"/app~1old"},{"op":"test","path":"/app~1test/k1","value":"v1"},{"op":"replace","path":"/app~1test/k1","value":"v2"},{"op":"remove","path":"/app~1test/k2"}]`
+	if diff := cmp.Diff(string(out), want); diff != "" {
+		t.Errorf("ToJSONPatch():\n-got/+want\ndiff %s", diff)
+	}
+
+	// Roundtrip
+	var patch JSONPatch
+	if err := json.Unmarshal(out, &patch); err != nil {
+		t.Fatalf("unable to unmarshal JSON Patch: %v", err)
+	}
+	back, err := FromJSONPatch(patch)
+	if err != nil {
+		t.Fatalf("FromJSONPatch() error = %v", err)
+	}
+	if diff := cmp.Diff(back, oplog); diff != "" {
+		t.Errorf("FromJSONPatch():\n-got/+want\ndiff %s", diff)
+	}
+}
+
+func TestToJSONPatch_Errors(t *testing.T) {
+	if _, err := ToJSONPatch(OpLog{{Operation: Conflict, Type: "secret", Path: "app#k"}}); err == nil {
+		t.Error("expected an error for conflict operation")
+	}
+	if _, err := ToJSONPatch(OpLog{{Operation: Add, Type: "label", Path: "app"}}); err == nil {
+		t.Error("expected an error for unsupported type")
+	}
+	if _, err := ToJSONPatch(OpLog{{Operation: Add, Type: "secret", Path: "app"}}); err == nil {
+		t.Error("expected an error for invalid secret path")
+	}
+}
+
+func TestFromJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    OpLog
+		wantErr bool
+	}{
+		{
+			name:  "package replace",
+			patch: `[{"op":"replace","path":"/app","value":{"b":"2","a":"1"}}]`,
+			want: OpLog{
+				{Operation: Remove, Type: "package", Path: "app"},
+				{Operation: Add, Type: "package", Path: "app"},
+				{Operation: Add, Type: "secret", Path: "app#a", Value: "1"},
+				{Operation: Add, Type: "secret", Path: "app#b", Value: "2"},
+			},
+		},
+		{
+			name:    "invalid pointer",
+			patch:   `[{"op":"remove","path":"app"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "root target",
+			patch:   `[{"op":"remove","path":"/"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "too deep",
+			patch:   `[{"op":"remove","path":"/app/k/sub"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "move",
+			patch:   `[{"op":"move","from":"/app/a","path":"/app/b"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "non string secret",
+			patch:   `[{"op":"add","path":"/app/a","value":1}]`,
+			wantErr: true,
+		},
+		{
+			name:    "non object package",
+			patch:   `[{"op":"add","path":"/app","value":"a"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "standalone test",
+			patch:   `[{"op":"test","path":"/app/a","value":"1"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch JSONPatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("unable to unmarshal JSON Patch: %v", err)
+			}
+
+			got, err := FromJSONPatch(patch)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("%q. FromJSONPatch():\n-got/+want\ndiff %s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+type options struct {
+	withPrevious bool
+	redactionKey []byte
+	semantic     bool
+}
+
+// DiffOption defines diff function option.
+type DiffOption func(o *options)
+
+// -----------------------------------------------------------------------------
+
+// WithPreviousValues records the previous secret values of replaced and
+// removed secrets.
+//
+// They are used by strict oplog application to detect concurrent changes.
+func WithPreviousValues() DiffOption {
+	return func(o *options) {
+		o.withPrevious = true
+	}
+}
+
+// WithRedactedValues replaces all secret values by a keyed fingerprint
+// computed using HMAC-SHA256 and the given key.
+//
+// Identical values share the same fingerprint, the key must be kept secret to
+// prevent offline guessing of low entropy values.
+func WithRedactedValues(key []byte) DiffOption {
+	return func(o *options) {
+		o.redactionKey = key
+	}
+}
+
+// WithSemanticDiff describes the structural changes of replaced secret values.
+//
+// JSON and YAML documents are compared field by field, PEM certificates are
+// compared by subject, issuer, serial, subject alternative names and validity.
+// Previous field values are only reported with WithPreviousValues.
+func WithSemanticDiff() DiffOption {
+	return func(o *options) {
+		o.semantic = true
+	}
+}
@@ -47,6 +47,9 @@ func ToPatch(oplog []DiffItem) (*bundlev1.Patch, error) {
 
 	// Generate patch rules
 	for _, op := range oplog {
+		if op.Redacted {
+			return nil, fmt.Errorf("unable to generate a patch from a redacted oplog")
+		}
 		if op.Type == "package" {
 			if op.Operation == Remove {
 				res.Spec.Rules = append(res.Spec.Rules, &bundlev1.PatchRule{
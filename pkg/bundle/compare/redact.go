@@ -0,0 +1,89 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	// Ensure SHA256 availability.
+	_ "crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/value/hash"
+)
+
+const fingerprintAlgorithm = "sha256"
+
+// Fingerprint returns the keyed fingerprint of the given value as used by
+// redacted diffs.
+func Fingerprint(key []byte, value string) (string, error) {
+	h, err := hash.NewKeyedHasher(fingerprintAlgorithm, key)
+	if err != nil {
+		return "", fmt.Errorf("unable to initialize fingerprint hasher: %w", err)
+	}
+
+	// Compute fingerprint
+	if _, err := h.Write([]byte(value)); err != nil {
+		return "", fmt.Errorf("unable to compute fingerprint: %w", err)
+	}
+
+	// No error
+	return fmt.Sprintf("hmac-%s:%s", fingerprintAlgorithm, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// -----------------------------------------------------------------------------
+
+func redact(diffs []DiffItem, key []byte) error {
+	var err error
+	for i := range diffs {
+		item := &diffs[i]
+
+		if item.Value != "" || (item.Type == "secret" && item.Operation != Remove) {
+			fp, err := Fingerprint(key, item.Value)
+			if err != nil {
+				return err
+			}
+			item.Value = fp
+		}
+		if item.Previous != nil {
+			fp, err := Fingerprint(key, *item.Previous)
+			if err != nil {
+				return err
+			}
+			item.Previous = &fp
+		}
+
+		for j := range item.Changes {
+			change := &item.Changes[j]
+			if change.Value != "" {
+				if change.Value, err = Fingerprint(key, change.Value); err != nil {
+					return err
+				}
+			}
+			if change.Previous != "" {
+				if change.Previous, err = Fingerprint(key, change.Previous); err != nil {
+					return err
+				}
+			}
+		}
+
+		item.Redacted = true
+	}
+
+	// No error
+	return nil
+}
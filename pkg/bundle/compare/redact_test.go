@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+)
+
+func TestDiff_WithRedactedValues(t *testing.T) {
+	src := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/a",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "k1", Value: MustPack("v1")},
+						{Key: "k2", Value: MustPack("v2")},
+					},
+				},
+			},
+		},
+	}
+	dst := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/a",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "k1", Value: MustPack("shared")},
+					},
+				},
+			},
+			{
+				Name: "app/b",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "k1", Value: MustPack("shared")},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Diff(src, dst, WithPreviousValues(), WithRedactedValues([]byte("diff-key")))
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("Diff() returned %d items, expected 4", len(got))
+	}
+
+	index := map[string]DiffItem{}
+	for _, item := range got {
+		if !item.Redacted {
+			t.Errorf("item %q is not marked as redacted", item.Path)
+		}
+		for _, v := range []string{"v1", "v2", "shared"} {
+			if item.Value == v || (item.Previous != nil && *item.Previous == v) {
+				t.Errorf("item %q leaks a secret value", item.Path)
+			}
+		}
+		index[item.Path] = item
+	}
+
+	// Identical values share the same fingerprint
+	if index["app/a#k1"].Value != index["app/b#k1"].Value {
+		t.Error("identical values must have the same fingerprint")
+	}
+	if !strings.HasPrefix(index["app/a#k1"].Value, "hmac-sha256:") {
+		t.Errorf("unexpected fingerprint format %q", index["app/a#k1"].Value)
+	}
+
+	// Fingerprint depends on the key
+	other, err := Fingerprint([]byte("other-key"), "shared")
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if other == index["app/a#k1"].Value {
+		t.Error("fingerprint must depend on the key")
+	}
+
+	// Redacted oplog can't be converted
+	if _, err := ToPatch(got); err == nil {
+		t.Error("ToPatch() must reject redacted oplog")
+	}
+	if _, err := ToJSONPatch(got); err == nil {
+		t.Error("ToJSONPatch() must reject redacted oplog")
+	}
+}
+
+func TestFingerprint_EmptyKey(t *testing.T) {
+	if _, err := Fingerprint(nil, "value"); err == nil {
+		t.Error("Fingerprint() must reject empty key")
+	}
+}
@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FieldChange describes a modification of a structured secret value.
+type FieldChange struct {
+	Operation string `json:"op"`
+	Path      string `json:"path"`
+	Value     string `json:"value,omitempty"`
+	Previous  string `json:"previous,omitempty"`
+}
+
+// -----------------------------------------------------------------------------
+
+// semanticChanges returns the structural differences between the given secret
+// values. It returns nil if one of the values is not a JSON / YAML document or
+// a PEM certificate bundle.
+func semanticChanges(previous, current string) []FieldChange {
+	previousTree, ok := structuredValue(previous)
+	if !ok {
+		return nil
+	}
+	currentTree, ok := structuredValue(current)
+	if !ok {
+		return nil
+	}
+
+	changes := []FieldChange{}
+	deepDiff("", previousTree, currentTree, &changes)
+
+	return changes
+}
+
+func structuredValue(value string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, false
+	}
+
+	// PEM certificates
+	if strings.HasPrefix(trimmed, "-----BEGIN ") {
+		return certificateTree([]byte(trimmed))
+	}
+
+	// JSON documents
+	var out interface{}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &out); err == nil {
+			return out, true
+		}
+	}
+
+	// YAML documents (only objects and arrays, scalars are opaque values)
+	if err := yaml.Unmarshal([]byte(trimmed), &out); err == nil {
+		switch out.(type) {
+		case map[string]interface{}, []interface{}:
+			return out, true
+		}
+	}
+
+	return nil, false
+}
+
+func certificateTree(raw []byte) (interface{}, bool) {
+	certs := []interface{}{}
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, false
+		}
+
+		// Collect subject alternative names
+		sans := []interface{}{}
+		for _, n := range cert.DNSNames {
+			sans = append(sans, "DNS:"+n)
+		}
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, "IP:"+ip.String())
+		}
+		for _, e := range cert.EmailAddresses {
+			sans = append(sans, "email:"+e)
+		}
+		for _, u := range cert.URIs {
+			sans = append(sans, "URI:"+u.String())
+		}
+
+		certs = append(certs, map[string]interface{}{
+			"subject":    cert.Subject.String(),
+			"issuer":     cert.Issuer.String(),
+			"serial":     cert.SerialNumber.String(),
+			"san":        sans,
+			"not_before": cert.NotBefore.UTC().Format(time.RFC3339),
+			"not_after":  cert.NotAfter.UTC().Format(time.RFC3339),
+		})
+	}
+
+	switch len(certs) {
+	case 0:
+		return nil, false
+	case 1:
+		return certs[0], true
+	default:
+		return certs, true
+	}
+}
+
+//nolint:gocognit // Recursive comparison
+func deepDiff(path string, previous, current interface{}, changes *[]FieldChange) {
+	switch p := previous.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		// Collect all keys
+		keys := []string{}
+		for k := range p {
+			keys = append(keys, k)
+		}
+		for k := range c {
+			if _, ok := p[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			childPath := path + jsonPointer(k)
+			pv, pOk := p[k]
+			cv, cOk := c[k]
+			switch {
+			case !pOk:
+				*changes = append(*changes, FieldChange{Operation: Add, Path: childPath, Value: encodeLeaf(cv)})
+			case !cOk:
+				*changes = append(*changes, FieldChange{Operation: Remove, Path: childPath, Previous: encodeLeaf(pv)})
+			default:
+				deepDiff(childPath, pv, cv, changes)
+			}
+		}
+		return
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(p) || i < len(c); i++ {
+			childPath := fmt.Sprintf("%s/%d", path, i)
+			switch {
+			case i >= len(p):
+				*changes = append(*changes, FieldChange{Operation: Add, Path: childPath, Value: encodeLeaf(c[i])})
+			case i >= len(c):
+				*changes = append(*changes, FieldChange{Operation: Remove, Path: childPath, Previous: encodeLeaf(p[i])})
+			default:
+				deepDiff(childPath, p[i], c[i], changes)
+			}
+		}
+		return
+	}
+
+	// Compare leaves
+	if !reflect.DeepEqual(previous, current) {
+		if path == "" {
+			path = "/"
+		}
+		*changes = append(*changes, FieldChange{
+			Operation: Replace,
+			Path:      path,
+			Value:     encodeLeaf(current),
+			Previous:  encodeLeaf(previous),
+		})
+	}
+}
+
+func encodeLeaf(v interface{}) string {
+	// Keep strings as is
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compare
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+)
+
+func testCertificate(t *testing.T, cn string, dnsNames []string, notAfter time.Time) string {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestSemanticChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     []FieldChange
+	}{
+		{
+			name:     "opaque",
+			previous: "foo",
+			current:  "bar",
+			want:     nil,
+		},
+		{
+			name:     "json",
+			previous: `{"host":"db1","port":5432,"options":{"ssl":true},"replicas":["r1"]}`,
+			current:  `{"host":"db2","port":5432,"options":{"ssl":false,"timeout":10},"replicas":["r1","r2"]}`,
+			want: []FieldChange{
+				{Operation: Replace, Path: "/host", Value: "db2", Previous: "db1"},
+				{Operation: Replace, Path: "/options/ssl", Value: "false", Previous: "true"},
+				{Operation: Add, Path: "/options/timeout", Value: "10"},
+				{Operation: Add, Path: "/replicas/1", Value: "r2"},
+			},
+		},
+		{
+			name:     "yaml",
+			previous: "user: admin\npassword: foo\n",
+			current:  "user: admin\n",
+			want: []FieldChange{
+				{Operation: Remove, Path: "/password", Previous: "foo"},
+			},
+		},
+		{
+			name:     "json to opaque",
+			previous: `{"host":"db1"}`,
+			current:  "db1",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := semanticChanges(tt.previous, tt.current)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("%q. semanticChanges():\n-got/+want\ndiff %s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestSemanticChanges_Certificate(t *testing.T) {
+	previous := testCertificate(t, "api.example.com", []string{"api.example.com"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	current := testCertificate(t, "api.example.com", []string{"api.example.com", "www.example.com"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	got := semanticChanges(previous, current)
+	want := []FieldChange{
+		{Operation: Replace, Path: "/not_after", Value: "2025-01-01T00:00:00Z", Previous: "2024-01-01T00:00:00Z"},
+		{Operation: Add, Path: "/san/1", Value: "DNS:www.example.com"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("semanticChanges():\n-got/+want\ndiff %s", diff)
+	}
+}
+
+func TestDiff_WithSemanticDiff(t *testing.T) {
+	src := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/test",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "config", Value: MustPack(`{"host":"db1"}`)},
+					},
+				},
+			},
+		},
+	}
+	dst := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/test",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "config", Value: MustPack(`{"host":"db2"}`)},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Diff(src, dst, WithSemanticDiff())
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := []DiffItem{
+		{
+			Operation: Replace, Type: "secret", Path: "app/test#config", Value: `{"host":"db2"}`,
+			Changes: []FieldChange{
+				{Operation: Replace, Path: "/host", Value: "db2"},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Diff():\n-got/+want\ndiff %s", diff)
+	}
+
+	// Previous field values are exposed on demand
+	got, err = Diff(src, dst, WithSemanticDiff(), WithPreviousValues())
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want = []DiffItem{
+		{
+			Operation: Replace, Type: "secret", Path: "app/test#config", Value: `{"host":"db2"}`, Previous: stringPtr(`{"host":"db1"}`),
+			Changes: []FieldChange{
+				{Operation: Replace, Path: "/host", Value: "db2", Previous: "db1"},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Diff():\n-got/+want\ndiff %s", diff)
+	}
+}
@@ -20,13 +20,17 @@ package bundle
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
 	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
 	"github.com/zntrio/harp/v2/pkg/container"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
 )
 
 // Statistic hold bundle statistic information.
@@ -41,6 +45,10 @@ const (
 	gzipCompressionLevel = 9
 )
 
+// ErrIndexedContainer is raised when an indexed Bundle container is loaded
+// without its index key.
+var ErrIndexedContainer = errors.New("indexed Bundle containers must be opened with their index key")
+
 // FromContainerReader returns a Bundle extracted from a secret container.
 func FromContainerReader(r io.Reader) (*bundlev1.Bundle, error) {
 	// Check parameters
@@ -78,6 +86,11 @@ func ToContainerWriter(w io.Writer, b *bundlev1.Bundle) error {
 	return container.Dump(w, c)
 }
 
+// IsBundleContainer returns true if the given container holds a Bundle.
+func IsBundleContainer(c *containerv1.Container) bool {
+	return c.GetHeaders().GetContentType() == bundleContentType
+}
+
 // FromContainer unwraps a Bundle from a secret container.
 func FromContainer(c *containerv1.Container) (*bundlev1.Bundle, error) {
 	// Check parameters
@@ -89,6 +102,9 @@ func FromContainer(c *containerv1.Container) (*bundlev1.Bundle, error) {
 	if types.IsNil(c.Headers) {
 		return nil, fmt.Errorf("unable to process nil container headers")
 	}
+	if IsIndexedBundleContainer(c) {
+		return nil, ErrIndexedContainer
+	}
 	if c.Headers.ContentType != bundleContentType {
 		return nil, fmt.Errorf("invalid content type for Bundle loader")
 	}
@@ -106,6 +122,86 @@ func FromContainer(c *containerv1.Container) (*bundlev1.Bundle, error) {
 	return Load(zr)
 }
 
+// OpenContainerReader returns a Bundle extracted from a secret container. The
+// given transformers are used to decrypt indexed Bundle containers, they are
+// ignored for other containers.
+func OpenContainerReader(ctx context.Context, r io.Reader, transformers []value.Transformer) (*bundlev1.Bundle, error) {
+	// Check parameters
+	if types.IsNil(r) {
+		return nil, fmt.Errorf("unable to process nil reader")
+	}
+
+	// Load secret container
+	c, err := container.Load(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load Bundle: %w", err)
+	}
+
+	// Delegate to bundle loader
+	return OpenContainer(ctx, c, transformers)
+}
+
+// OpenContainer unwraps a Bundle from a secret container. Indexed Bundle
+// containers are fully decrypted with the given transformers.
+func OpenContainer(ctx context.Context, c *containerv1.Container, transformers []value.Transformer) (*bundlev1.Bundle, error) {
+	// Check parameters
+	if types.IsNil(c) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if !IsIndexedBundleContainer(c) {
+		return FromContainer(c)
+	}
+	if len(transformers) == 0 {
+		return nil, ErrIndexedContainer
+	}
+
+	// Decrypt all segments
+	idx, err := OpenIndex(ctx, c, transformers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open bundle index: %w", err)
+	}
+
+	return idx.Bundle(ctx)
+}
+
+// OpenPackage returns the package matching the given name from a secret
+// container. Only the requested package segment of an indexed Bundle container
+// is decrypted with the given transformers.
+func OpenPackage(ctx context.Context, c *containerv1.Container, transformers []value.Transformer, name string) (*bundlev1.Package, error) {
+	// Check parameters
+	if types.IsNil(c) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("unable to process blank package name")
+	}
+
+	// Decrypt the requested package segment
+	if IsIndexedBundleContainer(c) {
+		if len(transformers) == 0 {
+			return nil, ErrIndexedContainer
+		}
+		idx, err := OpenIndex(ctx, c, transformers)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open bundle index: %w", err)
+		}
+		return idx.Package(ctx, name)
+	}
+
+	// Load the complete bundle
+	b, err := FromContainer(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range b.Packages {
+		if p != nil && strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to lookup package %q", name)
+}
+
 // ToContainer wrpas a Bundle as a container object.
 func ToContainer(b *bundlev1.Bundle) (*containerv1.Container, error) {
 	if b == nil {
@@ -18,23 +18,85 @@
 package bundle
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/awnumar/memguard"
 	"github.com/golang/protobuf/ptypes/wrappers"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
 	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 )
 
+type lockOptions struct {
+	workerCount     int64
+	recordHistory   bool
+	requireBindings bool
+}
+
+// LockOption defines bundle encryption/decryption option.
+type LockOption func(*lockOptions)
+
+// WithMaxWorkerCount sets the maximum count of packages processed
+// concurrently. Packages are processed sequentially by default.
+func WithMaxWorkerCount(value int64) LockOption {
+	return func(o *lockOptions) {
+		o.workerCount = value
+	}
+}
+
+// WithVersionHistory archives the plaintext secret chain of encrypted packages
+// in the package version history before encryption. The chain is archived only
+// when its secrets differ from the latest archived version, so that encrypting
+// an unchanged bundle again doesn't create a new version.
+func WithVersionHistory(value bool) LockOption {
+	return func(o *lockOptions) {
+		o.recordHistory = value
+	}
+}
+
+// WithRequiredEncryptionContext refuses to decrypt secret chains which are not
+// bound to their package path and secret keys.
+//
+// The binding is declared by a secret chain annotation. Without this option,
+// removing the annotation downgrades the chain to the unbound decryption, so
+// ciphertexts can be swapped between packages or keys again.
+func WithRequiredEncryptionContext(value bool) LockOption {
+	return func(o *lockOptions) {
+		o.requireBindings = value
+	}
+}
+
+func defaultLockOptions(opts ...LockOption) *lockOptions {
+	dopts := &lockOptions{
+		workerCount: 1,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+	if dopts.workerCount < 1 {
+		dopts.workerCount = 1
+	}
+
+	return dopts
+}
+
+// -----------------------------------------------------------------------------
+
 // PartialLock apply conditional transformer according to applicable annotation
 // on the given package.
 // The annotation is referring to a key alias provided.
-func PartialLock(ctx context.Context, b *bundlev1.Bundle, transformerMap map[string]value.Transformer, skipUnresolved bool) error {
+func PartialLock(ctx context.Context, b *bundlev1.Bundle, transformerMap map[string]value.Transformer, skipUnresolved bool, opts ...LockOption) error {
 	// Check bundle
 	if b == nil {
 		return fmt.Errorf("unable to process nil bundle")
@@ -43,8 +105,9 @@ func PartialLock(ctx context.Context, b *bundlev1.Bundle, transformerMap map[str
 		return fmt.Errorf("unable to process nil transformer map")
 	}
 
-	// For each packages
-	for _, p := range b.Packages {
+	// Resolve transformers before any package modification
+	transformers := make([]value.Transformer, len(b.Packages))
+	for i, p := range b.Packages {
 		// Check annotation usage
 		keyAlias, hasKeyAlias := p.Annotations[packageEncryptionAnnotation]
 		if !hasKeyAlias {
@@ -65,37 +128,187 @@ func PartialLock(ctx context.Context, b *bundlev1.Bundle, transformerMap map[str
 			return fmt.Errorf("key alias %q refers to a nil transformer", keyAlias)
 		}
 
-		// Convert secret as a map
-		secrets := map[string]interface{}{}
-		for _, s := range p.Secrets.Data {
-			var out interface{}
-			if err := secret.Unpack(s.Value, &out); err != nil {
-				return fmt.Errorf("unable to load secret value, corrupted bundle: %w", err)
+		transformers[i] = transformer
+	}
+
+	// Lock package secrets
+	dopts := defaultLockOptions(opts...)
+	return processPackages(ctx, b.Packages, dopts.workerCount, func(ctx context.Context, i int, p *bundlev1.Package) error {
+		if transformers[i] == nil {
+			// Skip package processing
+			return nil
+		}
+		return lockPackage(ctx, p, transformers[i], dopts.recordHistory)
+	})
+}
+
+// Lock apply transformer function to all secret values and set as locked.
+func Lock(ctx context.Context, b *bundlev1.Bundle, transformer value.Transformer, opts ...LockOption) error {
+	// Check bundle
+	if b == nil {
+		return fmt.Errorf("unable to process nil bundle")
+	}
+	if types.IsNil(transformer) {
+		return fmt.Errorf("unable to process nil transformer")
+	}
+
+	// Lock package secrets
+	dopts := defaultLockOptions(opts...)
+	return processPackages(ctx, b.Packages, dopts.workerCount, func(ctx context.Context, _ int, p *bundlev1.Package) error {
+		return lockPackage(ctx, p, transformer, dopts.recordHistory)
+	})
+}
+
+// UnLock apply transformer function to all secret values and set as unlocked.
+func UnLock(ctx context.Context, b *bundlev1.Bundle, transformers []value.Transformer, skipNotDecryptable bool, opts ...LockOption) error {
+	// Check bundle
+	if b == nil {
+		return fmt.Errorf("unable to process nil bundle")
+	}
+	if len(transformers) == 0 {
+		return fmt.Errorf("unable to process empty transformer list")
+	}
+
+	// Unlock package secrets
+	dopts := defaultLockOptions(opts...)
+	return processPackages(ctx, b.Packages, dopts.workerCount, func(ctx context.Context, _ int, p *bundlev1.Package) error {
+		// Unlock active version
+		if err := unlock(ctx, p.Name, p.Secrets, transformers, dopts.requireBindings); err != nil {
+			if skipNotDecryptable {
+				// Skip not decrypted secrets.
+				return nil
 			}
+			return fmt.Errorf("unable to transform %q: %w", p.Name, err)
+		}
 
-			// Assign to secret map
-			secrets[s.Key] = out
+		// Unlock archived versions
+		for _, v := range History(p) {
+			if err := unlock(ctx, p.Name, v, transformers, dopts.requireBindings); err != nil {
+				if skipNotDecryptable {
+					// Skip not decrypted secrets.
+					continue
+				}
+				return fmt.Errorf("unable to transform %q version %d: %w", p.Name, v.Version, err)
+			}
 		}
 
-		// Export secrets as JSON
-		content, err := json.Marshal(secrets)
-		if err != nil {
-			return fmt.Errorf("unable to extract secret map as json")
+		// No error
+		return nil
+	})
+}
+
+// processPackages applies the given function to all packages using at most
+// workerCount concurrent workers. Packages are modified in place so that the
+// bundle package order is preserved, and the error of the first failing
+// package in bundle order is returned.
+func processPackages(ctx context.Context, packages []*bundlev1.Package, workerCount int64, fn func(context.Context, int, *bundlev1.Package) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	// Initialize a semaphore with workerCount tokens
+	sem := semaphore.NewWeighted(workerCount)
+	g, gctx := errgroup.WithContext(ctx)
+
+	// Keep errors by package index for deterministic reporting
+	errs := make([]error, len(packages))
+
+	for i, p := range packages {
+		// Assign local reference
+		i, p := i, p
+
+		// Acquire a token
+		if err := sem.Acquire(gctx, 1); err != nil {
+			// Stop processing
+			break
 		}
 
-		// Apply transformer
-		out, err := transformer.To(ctx, content)
+		g.Go(func() error {
+			defer sem.Release(1)
+
+			if err := gctx.Err(); err != nil {
+				//nolint:nilerr // Context has already an error
+				return nil
+			}
+
+			if err := fn(gctx, i, p); err != nil {
+				errs[i] = err
+				return err
+			}
+
+			// No error
+			return nil
+		})
+	}
+
+	// Wait for all workers
+	//nolint:errcheck // Errors are collected by package index
+	_ = g.Wait()
+
+	// Report the first package error
+	for _, err := range errs {
 		if err != nil {
-			return fmt.Errorf("unable to apply secret transformer: %w", err)
+			return err
 		}
+	}
 
-		// Cleanup
-		memguard.WipeBytes(content)
-		p.Secrets.Data = nil
+	// Check parent context
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("unable to process bundle packages: %w", err)
+	}
+
+	// No error
+	return nil
+}
 
-		// Assign locked secret
-		p.Secrets.Locked = &wrappers.BytesValue{
-			Value: out,
+// -----------------------------------------------------------------------------
+
+const (
+	// EncryptionModePackage encrypts the whole package secret chain.
+	EncryptionModePackage = "package"
+	// EncryptionModeKey encrypts secret values in place, keeping secret keys
+	// readable.
+	EncryptionModeKey = "key"
+)
+
+func lockPackage(ctx context.Context, p *bundlev1.Package, transformer value.Transformer, recordHistory bool) error {
+	lock := lockChain
+
+	// Check encryption mode
+	switch mode := p.Annotations[packageEncryptionMode]; mode {
+	case "", EncryptionModePackage:
+	case EncryptionModeKey:
+		keys := encryptedKeySelection(p)
+		lock = func(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformer value.Transformer) error {
+			return lockKeys(ctx, packageName, chain, transformer, keys)
+		}
+	default:
+		return fmt.Errorf("unsupported encryption mode %q for package %q", mode, p.Name)
+	}
+
+	// Archive the plaintext version, it is encrypted with the archived versions
+	if recordHistory && p.Secrets != nil && p.Secrets.Locked == nil && !hasEncryptedValues(p.Secrets) && !sameAsLatestVersion(p) {
+		if err := PushVersion(p, p.Secrets); err != nil {
+			return fmt.Errorf("unable to push %q secret version: %w", p.Name, err)
+		}
+	}
+
+	// Lock active version
+	if err := lock(ctx, p.Name, p.Secrets, transformer); err != nil {
+		return err
+	}
+
+	// Lock archived versions
+	for _, v := range History(p) {
+		// Skip already locked versions
+		if v.Locked != nil {
+			continue
+		}
+		if err := lock(ctx, p.Name, v, transformer); err != nil {
+			return err
 		}
 	}
 
@@ -103,49 +316,78 @@ func PartialLock(ctx context.Context, b *bundlev1.Bundle, transformerMap map[str
 	return nil
 }
 
-// Lock apply transformer function to all secret values and set as locked.
-func Lock(ctx context.Context, b *bundlev1.Bundle, transformer value.Transformer) error {
-	// Check bundle
-	if b == nil {
-		return fmt.Errorf("unable to process nil bundle")
+// sameAsLatestVersion returns true when the package secret chain holds the
+// same secrets as the latest archived version.
+func sameAsLatestVersion(p *bundlev1.Package) bool {
+	versions := History(p)
+	if len(versions) == 0 {
+		return false
 	}
-	if types.IsNil(transformer) {
-		return fmt.Errorf("unable to process nil transformer")
+
+	return SameSecrets(versions[len(versions)-1], p.Secrets)
+}
+
+// encryptedKeySelection returns the secret keys to encrypt in key mode, an
+// empty selection means all keys.
+func encryptedKeySelection(p *bundlev1.Package) map[string]struct{} {
+	res := map[string]struct{}{}
+	for _, k := range strings.Split(p.Annotations[packageEncryptedKeys], ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			res[k] = struct{}{}
+		}
 	}
 
-	// For each packages
-	for _, p := range b.Packages {
-		// Convert secret as a map
-		secrets := map[string]interface{}{}
-		for _, s := range p.Secrets.Data {
-			var out interface{}
-			if err := secret.Unpack(s.Value, &out); err != nil {
-				return fmt.Errorf("unable to load secret value, corrupted bundle: %w", err)
-			}
+	return res
+}
 
-			// Assign to secret map
-			secrets[s.Key] = out
+func lockKeys(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformer value.Transformer, keys map[string]struct{}) error {
+	// Skip locked chain
+	if chain == nil || chain.Locked != nil {
+		return nil
+	}
+
+	// Values encrypted without encryption context can't be bound anymore.
+	binds := encryption.BindsAdditionalData(transformer)
+	if binds && !hasEncryptionContext(chain) && hasEncryptedValues(chain) {
+		return fmt.Errorf("unable to encrypt %q values, the package contains values encrypted without encryption context", packageName)
+	}
+
+	for _, kv := range chain.Data {
+		if kv == nil {
+			continue
+		}
+		if _, ok := keys[kv.Key]; len(keys) > 0 && !ok {
+			continue
 		}
 
-		// Export secrets as JSON
-		content, err := json.Marshal(secrets)
-		if err != nil {
-			return fmt.Errorf("unable to extract secret map as json")
+		// Skip already encrypted values
+		if _, ok := encryptedValue(kv); ok {
+			continue
 		}
 
 		// Apply transformer
-		out, err := transformer.To(ctx, content)
+		tctx := ctx
+		if binds {
+			tctx = withEncryptionContext(ctx, packageName, chain.Version, kv.Key)
+		}
+		out, err := transformer.To(tctx, kv.Value)
+		if err != nil {
+			return fmt.Errorf("unable to apply secret transformer on %q: %w", kv.Key, err)
+		}
+
+		// Wrap as an encrypted value
+		packed, err := packEncryptedValue(out)
 		if err != nil {
-			return fmt.Errorf("unable to apply secret transformer: %w", err)
+			return fmt.Errorf("unable to pack encrypted value of %q: %w", kv.Key, err)
 		}
 
 		// Cleanup
-		memguard.WipeBytes(content)
-		p.Secrets.Data = nil
+		memguard.WipeBytes(kv.Value)
+		kv.Value = packed
 
-		// Assign locked secret
-		p.Secrets.Locked = &wrappers.BytesValue{
-			Value: out,
+		// Mark the chain only if the transformer authenticates the context
+		if binds {
+			setEncryptionContext(chain)
 		}
 	}
 
@@ -153,23 +395,25 @@ func Lock(ctx context.Context, b *bundlev1.Bundle, transformer value.Transformer
 	return nil
 }
 
-// UnLock apply transformer function to all secret values and set as unlocked.
-func UnLock(ctx context.Context, b *bundlev1.Bundle, transformers []value.Transformer, skipNotDecryptable bool) error {
-	// Check bundle
-	if b == nil {
-		return fmt.Errorf("unable to process nil bundle")
+func unlockKeys(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformers []value.Transformer, requireBindings bool) error {
+	// Skip locked chain
+	if chain == nil || chain.Locked != nil {
+		return nil
 	}
-	if len(transformers) == 0 {
-		return fmt.Errorf("unable to process empty transformer list")
+
+	// Check encryption context usage
+	bound := hasEncryptionContext(chain)
+	if requireBindings && !bound && hasEncryptedValues(chain) {
+		return fmt.Errorf("encrypted values of %q are not bound to their encryption context", packageName)
 	}
 
-	// For each packages
-	for _, p := range b.Packages {
-		// Skip not locked package
-		if p.Secrets.Locked == nil {
+	for _, kv := range chain.Data {
+		if kv == nil {
 			continue
 		}
-		if len(p.Secrets.Locked.Value) == 0 {
+
+		ciphertext, ok := encryptedValue(kv)
+		if !ok {
 			continue
 		}
 
@@ -178,57 +422,257 @@ func UnLock(ctx context.Context, b *bundlev1.Bundle, transformers []value.Transf
 			out          []byte
 			errTransform error
 		)
-	LOOP:
+		tctx := ctx
+		if bound {
+			tctx = withEncryptionContext(ctx, packageName, chain.Version, kv.Key)
+		}
 		for _, t := range transformers {
-			// Apply transformation
-			out, errTransform = t.From(ctx, p.Secrets.Locked.Value)
-			switch {
-			case errTransform != nil:
-				// Try next transformer
-				continue
-			default:
-				break LOOP
+			if out, errTransform = t.From(tctx, ciphertext); errTransform == nil {
+				break
 			}
 		}
 		if errTransform != nil {
-			if skipNotDecryptable {
-				// Skip not decrypted secrets.
-				continue
-			}
-			return fmt.Errorf("unable to transform %q: %w", p.Name, errTransform)
+			return fmt.Errorf("unable to decrypt %q: %w", kv.Key, errTransform)
 		}
 
-		// Unpack secrets
-		raw := map[string]interface{}{}
-		if err := json.Unmarshal(out, &raw); err != nil {
-			return fmt.Errorf("unable to unpack locked secret: %w", err)
+		// Restore the packed value
+		kv.Value = out
+	}
+
+	// No error
+	return nil
+}
+
+// packEncryptedValue wraps the given ciphertext as a secret value.
+func packEncryptedValue(ciphertext []byte) ([]byte, error) {
+	return secret.Pack(fmt.Sprintf("%s:%s", packageEncryptedValueType, base64.StdEncoding.EncodeToString(ciphertext)))
+}
+
+// encryptedValue returns the ciphertext of a secret value encrypted in key
+// mode.
+func encryptedValue(kv *bundlev1.KV) ([]byte, bool) {
+	var raw interface{}
+	if err := secret.Unpack(kv.Value, &raw); err != nil {
+		return nil, false
+	}
+
+	envelope, ok := raw.(string)
+	if !ok {
+		return nil, false
+	}
+	encoded := strings.TrimPrefix(envelope, packageEncryptedValueType+":")
+	if encoded == envelope {
+		return nil, false
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	return ciphertext, true
+}
+
+// lockedPayload describes the locked secret chain content.
+type lockedPayload struct {
+	Data  map[string]interface{} `json:"data"`
+	Types map[string]string      `json:"types"`
+}
+
+func lockChain(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformer value.Transformer) error {
+	// Convert secret as a map
+	payload := &lockedPayload{
+		Data:  map[string]interface{}{},
+		Types: map[string]string{},
+	}
+	for _, s := range chain.Data {
+		var out interface{}
+		if err := secret.Unpack(s.Value, &out); err != nil {
+			return fmt.Errorf("unable to load secret value, corrupted bundle: %w", err)
 		}
 
-		// Prepare secrets collection
-		secrets := []*bundlev1.KV{}
-		for key, value := range raw {
-			// Pack secret value
-			s, err := secret.Pack(value)
-			if err != nil {
-				return fmt.Errorf("unable to pack as secret bundle: %w", err)
-			}
+		// Assign to secret map
+		payload.Data[s.Key] = out
+		payload.Types[s.Key] = s.Type
+	}
+
+	// Export secrets as JSON
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to extract secret map as json")
+	}
+
+	// Prefix with the payload version
+	content := append([]byte(lockedPayloadV2), encoded...)
+	memguard.WipeBytes(encoded)
+
+	// Bind the encryption context only if the transformer authenticates it
+	binds := encryption.BindsAdditionalData(transformer)
+	if binds {
+		ctx = withEncryptionContext(ctx, packageName, chain.Version, "")
+	}
+
+	// Apply transformer
+	out, err := transformer.To(ctx, content)
+	if err != nil {
+		return fmt.Errorf("unable to apply secret transformer: %w", err)
+	}
+
+	// Cleanup
+	memguard.WipeBytes(content)
+	chain.Data = nil
+
+	// Assign locked secret
+	chain.Locked = &wrappers.BytesValue{
+		Value: out,
+	}
+	if binds {
+		setEncryptionContext(chain)
+	}
+
+	// No error
+	return nil
+}
+
+func unlock(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformers []value.Transformer, requireBindings bool) error {
+	if err := unlockChain(ctx, packageName, chain, transformers, requireBindings); err != nil {
+		return err
+	}
+	if err := unlockKeys(ctx, packageName, chain, transformers, requireBindings); err != nil {
+		return err
+	}
 
-			// Add to secret collection
-			secrets = append(secrets, &bundlev1.KV{
-				Key:   key,
-				Type:  fmt.Sprintf("%T", value),
-				Value: s,
-			})
+	// Remove the encryption context marker from plaintext chains
+	if chain != nil && chain.Locked == nil && !hasEncryptedValues(chain) {
+		delete(chain.Annotations, secretChainEncryptionContext)
+		if len(chain.Annotations) == 0 {
+			chain.Annotations = nil
 		}
+	}
 
-		// Cleanup
-		memguard.WipeBytes(p.Secrets.Locked.Value)
-		p.Secrets.Locked = nil
+	// No error
+	return nil
+}
 
-		// Assign unlocked secrets
-		p.Secrets.Data = secrets
+func unlockChain(ctx context.Context, packageName string, chain *bundlev1.SecretChain, transformers []value.Transformer, requireBindings bool) error {
+	// Skip not locked chain
+	if chain == nil || chain.Locked == nil {
+		return nil
+	}
+	if len(chain.Locked.Value) == 0 {
+		return nil
+	}
+
+	// Check encryption context usage
+	switch {
+	case hasEncryptionContext(chain):
+		ctx = withEncryptionContext(ctx, packageName, chain.Version, "")
+	case requireBindings:
+		return fmt.Errorf("locked secrets of %q are not bound to their encryption context", packageName)
+	default:
+	}
+
+	// Try all transformers
+	var (
+		out          []byte
+		errTransform error
+	)
+LOOP:
+	for _, t := range transformers {
+		// Apply transformation
+		out, errTransform = t.From(ctx, chain.Locked.Value)
+		switch {
+		case errTransform != nil:
+			// Try next transformer
+			continue
+		default:
+			break LOOP
+		}
+	}
+	if errTransform != nil {
+		return errTransform
+	}
+
+	// Unpack secrets
+	payload := &lockedPayload{}
+	if bytes.HasPrefix(out, []byte(lockedPayloadV2)) {
+		if err := json.Unmarshal(out[len(lockedPayloadV2):], payload); err != nil {
+			return fmt.Errorf("unable to unpack locked secret: %w", err)
+		}
+	} else {
+		// Legacy payload without secret types
+		if err := json.Unmarshal(out, &payload.Data); err != nil {
+			return fmt.Errorf("unable to unpack locked secret: %w", err)
+		}
 	}
 
+	// Prepare secrets collection
+	secrets := []*bundlev1.KV{}
+	for key, value := range payload.Data {
+		// Pack secret value
+		s, err := secret.Pack(value)
+		if err != nil {
+			return fmt.Errorf("unable to pack as secret bundle: %w", err)
+		}
+
+		// Restore the secret type, or detect it for legacy payloads
+		typ, ok := payload.Types[key]
+		if !ok {
+			typ = kind.TypeOf(key, value)
+		}
+
+		// Add to secret collection
+		secrets = append(secrets, &bundlev1.KV{
+			Key:   key,
+			Type:  typ,
+			Value: s,
+		})
+	}
+
+	// Cleanup
+	memguard.WipeBytes(chain.Locked.Value)
+	chain.Locked = nil
+
+	// Assign unlocked secrets
+	chain.Data = secrets
+
 	// No error
 	return nil
 }
+
+// -----------------------------------------------------------------------------
+
+// withEncryptionContext binds the ciphertext to the secret location and to the
+// secret chain version using the AEAD additional data, so that archived
+// versions can't be swapped.
+func withEncryptionContext(ctx context.Context, packageName string, version uint32, key string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	aad := fmt.Sprintf("%s:%s:%s@%d#%s", secretChainEncryptionContext, encryptionContextV2, packageName, version, key)
+	return encryption.WithAdditionalData(ctx, []byte(aad))
+}
+
+func hasEncryptionContext(chain *bundlev1.SecretChain) bool {
+	return chain.GetAnnotations()[secretChainEncryptionContext] == encryptionContextV2
+}
+
+func setEncryptionContext(chain *bundlev1.SecretChain) {
+	if chain.Annotations == nil {
+		chain.Annotations = map[string]string{}
+	}
+	chain.Annotations[secretChainEncryptionContext] = encryptionContextV2
+}
+
+func hasEncryptedValues(chain *bundlev1.SecretChain) bool {
+	for _, kv := range chain.GetData() {
+		if kv == nil {
+			continue
+		}
+		if _, ok := encryptedValue(kv); ok {
+			return true
+		}
+	}
+
+	return false
+}
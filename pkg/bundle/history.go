@@ -0,0 +1,272 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+)
+
+// PushVersion archives the given previous secret chain in the package version
+// history and links the current package secret chain to it.
+//
+// The current secret chain version is set to the successor of the previous one.
+func PushVersion(p *bundlev1.Package, previous *bundlev1.SecretChain) error {
+	// Check arguments
+	if p == nil {
+		return errors.New("unable to process nil package")
+	}
+	if previous == nil {
+		return errors.New("unable to process nil previous secret chain")
+	}
+	if p.Secrets == nil {
+		p.Secrets = &bundlev1.SecretChain{}
+	}
+
+	// Clone previous chain (we don't want to share references)
+	archived, ok := proto.Clone(previous).(*bundlev1.SecretChain)
+	if !ok {
+		return fmt.Errorf("the cloned secret chain does not have a correct type: %T", archived)
+	}
+
+	// Check version collision
+	nextVersion := archived.Version + 1
+	if _, exists := p.Versions[nextVersion]; exists {
+		return fmt.Errorf("unable to push version %d of %q, version already exists", nextVersion, p.Name)
+	}
+
+	// Link versions
+	archived.NextVersion = &wrappers.UInt32Value{Value: nextVersion}
+	p.Secrets.Version = nextVersion
+	p.Secrets.PreviousVersion = &wrappers.UInt32Value{Value: archived.Version}
+	p.Secrets.NextVersion = nil
+
+	// Archive previous version
+	if p.Versions == nil {
+		p.Versions = map[uint32]*bundlev1.SecretChain{}
+	}
+	p.Versions[archived.Version] = archived
+
+	// No error
+	return nil
+}
+
+// History returns the archived secret chains of the given package ordered by
+// version.
+func History(p *bundlev1.Package) []*bundlev1.SecretChain {
+	// Check arguments
+	if p == nil {
+		return nil
+	}
+
+	res := make([]*bundlev1.SecretChain, 0, len(p.Versions))
+	for _, v := range p.Versions {
+		if v == nil {
+			continue
+		}
+		res = append(res, v)
+	}
+
+	// Sort by version
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+
+	return res
+}
+
+// CarryHistory transfers the version history of previous bundle packages to
+// the matching packages of the given bundle.
+//
+// Packages with modified secrets are pushed as a new version of the previous
+// one, unchanged packages keep the previous version identifier.
+func CarryHistory(previous, b *bundlev1.Bundle) error {
+	// Check arguments
+	if previous == nil {
+		return errors.New("unable to process nil previous bundle")
+	}
+	if b == nil {
+		return errors.New("unable to process nil bundle")
+	}
+
+	// Index previous packages
+	previousIndex := map[string]*bundlev1.Package{}
+	for _, p := range previous.Packages {
+		if p == nil || p.Secrets == nil {
+			continue
+		}
+		previousIndex[p.Name] = p
+	}
+
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+
+		prev, ok := previousIndex[p.Name]
+		if !ok {
+			continue
+		}
+
+		// Copy previous history
+		for _, v := range History(prev) {
+			archived, ok := proto.Clone(v).(*bundlev1.SecretChain)
+			if !ok {
+				return fmt.Errorf("the cloned secret chain does not have a correct type: %T", archived)
+			}
+			if p.Versions == nil {
+				p.Versions = map[uint32]*bundlev1.SecretChain{}
+			}
+			p.Versions[archived.Version] = archived
+		}
+
+		// Keep previous version when secrets are unchanged
+		if p.Secrets != nil && SameSecrets(prev.Secrets, p.Secrets) {
+			p.Secrets.Version = prev.Secrets.Version
+			p.Secrets.PreviousVersion = prev.Secrets.PreviousVersion
+			if err := carryRotation(prev, p); err != nil {
+				return fmt.Errorf("unable to carry %q rotation date: %w", p.Name, err)
+			}
+			continue
+		}
+
+		// Push as a new version
+		if err := PushVersion(p, prev.Secrets); err != nil {
+			return fmt.Errorf("unable to push %q secret version: %w", p.Name, err)
+		}
+	}
+
+	// No error
+	return nil
+}
+
+// carryRotation keeps the previous rotation date of an unchanged package.
+func carryRotation(prev, p *bundlev1.Package) error {
+	raw, ok := prev.Annotations[LastRotatedAnnotation]
+	if !ok {
+		return nil
+	}
+
+	lastRotated, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return fmt.Errorf("invalid last rotation date for package %q: %w", prev.Name, err)
+	}
+
+	// Recompute expiration date with the current rotation policy
+	return MarkRotated(p, lastRotated)
+}
+
+// Rollback restores the given secret chain version of the package as a new
+// active version. The replaced active version is archived in the history.
+// Versions encrypted with their encryption context must be decrypted first.
+func Rollback(b *bundlev1.Bundle, packageName string, version uint32) error {
+	// Check arguments
+	if b == nil {
+		return errors.New("unable to process nil bundle")
+	}
+	if packageName == "" {
+		return errors.New("unable to process with blank package name")
+	}
+
+	// Lookup package
+	var found *bundlev1.Package
+	for _, p := range b.Packages {
+		if p != nil && strings.EqualFold(p.Name, packageName) {
+			found = p
+			break
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("unable to lookup package %q", packageName)
+	}
+	if found.Secrets == nil {
+		return fmt.Errorf("package %q has no active secret version", packageName)
+	}
+
+	// Lookup version
+	target, ok := found.Versions[version]
+	if !ok || target == nil {
+		return fmt.Errorf("unable to lookup version %d of package %q", version, packageName)
+	}
+
+	// Encrypted secrets are bound to their version
+	if hasEncryptionContext(target) && (target.Locked != nil || hasEncryptedValues(target)) {
+		return fmt.Errorf("unable to restore version %d of package %q, encrypted secrets are bound to their version, decrypt the bundle first", version, packageName)
+	}
+
+	// Prepare restored chain
+	restored, ok := proto.Clone(target).(*bundlev1.SecretChain)
+	if !ok {
+		return fmt.Errorf("the cloned secret chain does not have a correct type: %T", restored)
+	}
+	if restored.Annotations == nil {
+		restored.Annotations = map[string]string{}
+	}
+	restored.Annotations[secretChainRollbackAnnotation] = fmt.Sprintf("%d", version)
+
+	// Swap active version and archive the current one
+	current := found.Secrets
+	found.Secrets = restored
+	if err := PushVersion(found, current); err != nil {
+		return fmt.Errorf("unable to archive current version of %q: %w", packageName, err)
+	}
+
+	// No error
+	return nil
+}
+
+// SameSecrets returns true when both secret chains hold the same secret values.
+func SameSecrets(a, b *bundlev1.SecretChain) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !proto.Equal(a.Locked, b.Locked) {
+		return false
+	}
+	if len(a.Data) != len(b.Data) {
+		return false
+	}
+
+	// Index secrets
+	index := map[string]*bundlev1.KV{}
+	for _, kv := range a.Data {
+		if kv == nil {
+			continue
+		}
+		index[kv.Key] = kv
+	}
+	for _, kv := range b.Data {
+		if kv == nil {
+			continue
+		}
+		other, ok := index[kv.Key]
+		if !ok || !proto.Equal(kv, other) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,334 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	_ "github.com/zntrio/harp/v2/pkg/sdk/value/encryption/aead"
+)
+
+func TestPushVersion(t *testing.T) {
+	p := &bundlev1.Package{
+		Name: "app/production/database",
+		Secrets: &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("new")},
+			},
+		},
+	}
+	previous := &bundlev1.SecretChain{
+		Version: 3,
+		Data: []*bundlev1.KV{
+			{Key: "password", Type: "string", Value: secret.MustPack("old")},
+		},
+	}
+
+	require.NoError(t, PushVersion(p, previous))
+	assert.Equal(t, uint32(4), p.Secrets.Version)
+	assert.Equal(t, uint32(3), p.Secrets.PreviousVersion.GetValue())
+	require.Contains(t, p.Versions, uint32(3))
+	assert.Equal(t, uint32(4), p.Versions[3].NextVersion.GetValue())
+
+	// Version collision
+	p.Versions[5] = &bundlev1.SecretChain{Version: 5}
+	assert.Error(t, PushVersion(p, &bundlev1.SecretChain{Version: 4}))
+
+	// Invalid arguments
+	assert.Error(t, PushVersion(nil, previous))
+	assert.Error(t, PushVersion(p, nil))
+}
+
+func TestRollback(t *testing.T) {
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("v0")},
+					},
+				},
+			},
+		},
+	}
+	p := b.Packages[0]
+
+	// Rotate twice
+	for _, v := range []string{"v1", "v2"} {
+		previous := p.Secrets
+		p.Secrets = &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack(v)},
+			},
+		}
+		require.NoError(t, PushVersion(p, previous))
+	}
+	require.Len(t, History(p), 2)
+
+	// Restore the initial version
+	require.NoError(t, Rollback(b, "app/production/database", 0))
+	assert.Equal(t, uint32(3), p.Secrets.Version)
+	assert.Equal(t, "0", p.Secrets.Annotations[secretChainRollbackAnnotation])
+	assert.Len(t, History(p), 3)
+
+	secrets, err := Read(b, "app/production/database")
+	require.NoError(t, err)
+	assert.Equal(t, "v0", secrets["password"])
+
+	// History must survive a serialization roundtrip
+	var buf bytes.Buffer
+	require.NoError(t, Dump(&buf, b))
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+	assert.Len(t, History(loaded.Packages[0]), 3)
+
+	// Errors
+	assert.Error(t, Rollback(b, "app/production/database", 42))
+	assert.Error(t, Rollback(b, "app/unknown", 0))
+	assert.Error(t, Rollback(b, "", 0))
+	assert.Error(t, Rollback(nil, "app/production/database", 0))
+}
+
+func TestCarryHistory(t *testing.T) {
+	previous := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/rotated",
+				Secrets: &bundlev1.SecretChain{
+					Version: 1,
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("old")},
+					},
+				},
+				Versions: map[uint32]*bundlev1.SecretChain{
+					0: {Version: 0},
+				},
+			},
+			{
+				Name: "app/unchanged",
+				Secrets: &bundlev1.SecretChain{
+					Version: 2,
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("same")},
+					},
+				},
+			},
+		},
+	}
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/rotated",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("new")},
+					},
+				},
+			},
+			{
+				Name: "app/unchanged",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("same")},
+					},
+				},
+			},
+			{
+				Name:    "app/created",
+				Secrets: &bundlev1.SecretChain{},
+			},
+		},
+	}
+
+	require.NoError(t, CarryHistory(previous, b))
+	assert.Equal(t, uint32(2), b.Packages[0].Secrets.Version)
+	assert.Len(t, History(b.Packages[0]), 2)
+	assert.Equal(t, uint32(2), b.Packages[1].Secrets.Version)
+	assert.Empty(t, History(b.Packages[1]))
+	assert.Empty(t, History(b.Packages[2]))
+}
+
+func TestCarryHistory_Rotation(t *testing.T) {
+	previous := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/unchanged",
+				Annotations: map[string]string{
+					LastRotatedAnnotation: "2023-01-01T00:00:00Z",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("same")},
+					},
+				},
+			},
+		},
+	}
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/unchanged",
+				Annotations: map[string]string{
+					RotationIntervalAnnotation: "7d",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "key", Type: "string", Value: secret.MustPack("same")},
+					},
+				},
+			},
+		},
+	}
+
+	// Unchanged secrets keep their rotation date
+	require.NoError(t, CarryHistory(previous, b))
+	assert.Equal(t, "2023-01-01T00:00:00Z", b.Packages[0].Annotations[LastRotatedAnnotation])
+	assert.Equal(t, "2023-01-08T00:00:00Z", b.Packages[0].Annotations[ExpiresAtAnnotation])
+}
+
+func TestLock_History(t *testing.T) {
+	p := &bundlev1.Package{
+		Name: "app/production/database",
+		Secrets: &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("new")},
+			},
+		},
+	}
+	require.NoError(t, PushVersion(p, &bundlev1.SecretChain{
+		Data: []*bundlev1.KV{
+			{Key: "password", Type: "string", Value: secret.MustPack("old")},
+		},
+	}))
+	b := &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	// Archived versions must be locked too
+	require.NoError(t, Lock(context.Background(), b, transformer))
+	assert.NotNil(t, p.Secrets.Locked)
+	assert.NotNil(t, p.Versions[0].Locked)
+	assert.Nil(t, p.Versions[0].Data)
+
+	require.NoError(t, UnLock(context.Background(), b, []value.Transformer{transformer}, false))
+	assert.Nil(t, p.Versions[0].Locked)
+	assert.Len(t, p.Versions[0].Data, 1)
+}
+
+func TestLock_VersionHistory(t *testing.T) {
+	p := &bundlev1.Package{
+		Name: "app/production/database",
+		Secrets: &bundlev1.SecretChain{
+			Version: 1,
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("value")},
+			},
+		},
+	}
+	b := &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	// The plaintext chain is archived and encrypted
+	require.NoError(t, Lock(context.Background(), b, transformer, WithVersionHistory(true)))
+	assert.Equal(t, uint32(2), p.Secrets.Version)
+	assert.Equal(t, uint32(1), p.Secrets.PreviousVersion.GetValue())
+	require.Contains(t, p.Versions, uint32(1))
+	assert.NotNil(t, p.Versions[1].Locked)
+	assert.Equal(t, uint32(2), p.Versions[1].NextVersion.GetValue())
+
+	// Both versions are decrypted
+	require.NoError(t, UnLock(context.Background(), b, []value.Transformer{transformer}, false))
+	assert.Len(t, p.Secrets.Data, 1)
+	assert.Len(t, p.Versions[1].Data, 1)
+
+	// Unchanged secrets are not archived again
+	require.NoError(t, Lock(context.Background(), b, transformer, WithVersionHistory(true)))
+	assert.Equal(t, uint32(2), p.Secrets.Version)
+	assert.Len(t, p.Versions, 1)
+
+	// Modified secrets are archived
+	require.NoError(t, UnLock(context.Background(), b, []value.Transformer{transformer}, false))
+	p.Secrets.Data[0].Value = secret.MustPack("rotated")
+	require.NoError(t, Lock(context.Background(), b, transformer, WithVersionHistory(true)))
+	assert.Equal(t, uint32(3), p.Secrets.Version)
+	require.Contains(t, p.Versions, uint32(2))
+	assert.Len(t, p.Versions, 2)
+}
+
+func TestLock_VersionSwap(t *testing.T) {
+	newPackage := func(mode string) *bundlev1.Package {
+		p := &bundlev1.Package{
+			Name: "app/production/database",
+			Annotations: map[string]string{
+				packageEncryptionMode: mode,
+			},
+			Secrets: &bundlev1.SecretChain{
+				Data: []*bundlev1.KV{
+					{Key: "password", Type: "string", Value: secret.MustPack("new")},
+				},
+			},
+		}
+		require.NoError(t, PushVersion(p, &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("old")},
+			},
+		}))
+		return p
+	}
+
+	ctx := context.Background()
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	transformers := []value.Transformer{transformer}
+
+	t.Run("package mode", func(t *testing.T) {
+		p := newPackage(EncryptionModePackage)
+		b := &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+		require.NoError(t, Lock(ctx, b, transformer))
+
+		// Swap the active locked secrets with the archived ones
+		p.Secrets.Locked, p.Versions[0].Locked = p.Versions[0].Locked, p.Secrets.Locked
+		assert.Error(t, UnLock(ctx, b, transformers, false))
+
+		// Bound versions can't be restored while encrypted
+		p = newPackage(EncryptionModePackage)
+		b = &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+		require.NoError(t, Lock(ctx, b, transformer))
+		assert.Error(t, Rollback(b, p.Name, 0))
+	})
+
+	t.Run("key mode", func(t *testing.T) {
+		p := newPackage(EncryptionModeKey)
+		b := &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+		require.NoError(t, Lock(ctx, b, transformer))
+
+		// Swap the active encrypted value with the archived one
+		p.Secrets.Data[0].Value, p.Versions[0].Data[0].Value = p.Versions[0].Data[0].Value, p.Secrets.Data[0].Value
+		assert.Error(t, UnLock(ctx, b, transformers, false))
+	})
+}
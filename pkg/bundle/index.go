@@ -0,0 +1,445 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/ioutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+)
+
+// Indexed bundle layout
+//
+// The container payload is composed of an encrypted package index followed by
+// independently encrypted package segments:
+//
+//	magic (8) | index length (uint32 BE) | index | segment 0 | ... | segment n
+//
+// The index is a JSON document holding a random container identifier and the
+// offset/length of each segment relative to the end of the index. Segments are
+// gzip compressed protobuf encoded objects (bundle metadata without packages,
+// then one per package). Each ciphertext is bound with additional data to the
+// container identifier and to its segment name, so that segments can't be
+// swapped between containers or packages. Only transformers authenticating the
+// additional data are supported.
+//
+// The index holds the merkle tree root of each package, and the metadata
+// segment the bundle merkle tree root, both are checked on decryption.
+
+const (
+	indexedBundleContentType     = "application/vnd.harp.v1.IndexedBundle"
+	indexedBundleContentEncoding = "harp-indexed"
+	indexedBundleVersion         = 1
+	indexedBundleIDSize          = 16
+	indexAdditionalData          = "harp.elastic.co/v1/bundle#index"
+)
+
+var indexedBundleMagic = []byte("HARPIDX\x01")
+
+// ErrPackageNotIndexed is raised when the requested package is not part of the
+// bundle index.
+var ErrPackageNotIndexed = errors.New("package not found in bundle index")
+
+type indexSegment struct {
+	Name   string `json:"name,omitempty"`
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+	Root   []byte `json:"root,omitempty"`
+}
+
+type segmentIndex struct {
+	Version  uint32          `json:"version"`
+	ID       string          `json:"id"`
+	Metadata indexSegment    `json:"metadata"`
+	Packages []*indexSegment `json:"packages"`
+}
+
+// IsIndexedBundleContainer returns true if the given container holds an
+// indexed Bundle.
+func IsIndexedBundleContainer(c *containerv1.Container) bool {
+	return c.GetHeaders().GetContentType() == indexedBundleContentType
+}
+
+// ToIndexedContainer wraps a Bundle as a random-access container object. The
+// package index and all package segments are encrypted with the given
+// transformer, which must authenticate additional data.
+func ToIndexedContainer(ctx context.Context, b *bundlev1.Bundle, transformer value.Transformer) (*containerv1.Container, error) {
+	// Check parameters
+	if b == nil {
+		return nil, fmt.Errorf("unable to process nil bundle")
+	}
+	if types.IsNil(transformer) {
+		return nil, fmt.Errorf("unable to process nil transformer")
+	}
+	if !encryption.BindsAdditionalData(transformer) {
+		return nil, fmt.Errorf("unable to process with a transformer ignoring additional data, segments could be swapped")
+	}
+
+	// Generate container identifier
+	var id [indexedBundleIDSize]byte
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate container identifier: %w", err)
+	}
+
+	idx := &segmentIndex{
+		Version:  indexedBundleVersion,
+		ID:       base64.RawURLEncoding.EncodeToString(id[:]),
+		Packages: make([]*indexSegment, 0, len(b.Packages)),
+	}
+	segments := &bytes.Buffer{}
+
+	// Prepare bundle metadata
+	metadata, ok := proto.Clone(b).(*bundlev1.Bundle)
+	if !ok {
+		return nil, fmt.Errorf("the cloned bundle does not have the expected type: %T", metadata)
+	}
+	metadata.Packages = nil
+
+	// Compute merkle tree root without altering the package order
+	packages := []*bundlev1.Package{}
+	for _, p := range b.Packages {
+		if p != nil {
+			packages = append(packages, p)
+		}
+	}
+	root, err := packagesRoot(packages...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute bundle merkle tree root: %w", err)
+	}
+	metadata.MerkleTreeRoot = root
+
+	// Encrypt metadata segment
+	seg, err := appendSegment(ctx, segments, transformer, idx.ID, "", metadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt bundle metadata: %w", err)
+	}
+	idx.Metadata = *seg
+
+	// Encrypt package segments
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+
+		seg, err := appendSegment(ctx, segments, transformer, idx.ID, p.Name, p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encrypt package %q: %w", p.Name, err)
+		}
+		if seg.Root, err = packagesRoot(p); err != nil {
+			return nil, fmt.Errorf("unable to compute package %q merkle tree root: %w", p.Name, err)
+		}
+		idx.Packages = append(idx.Packages, seg)
+	}
+
+	// Encrypt index
+	rawIndex, err := json.Marshal(idx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode package index: %w", err)
+	}
+	encryptedIndex, err := transformer.To(withIndexContext(ctx, []byte(indexAdditionalData)), rawIndex)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt package index: %w", err)
+	}
+
+	// Assemble payload
+	payload := &bytes.Buffer{}
+	payload.Write(indexedBundleMagic)
+	if err := binary.Write(payload, binary.BigEndian, uint32(len(encryptedIndex))); err != nil {
+		return nil, fmt.Errorf("unable to write index length: %w", err)
+	}
+	payload.Write(encryptedIndex)
+	payload.Write(segments.Bytes())
+
+	// Return container
+	return &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: indexedBundleContentEncoding,
+			ContentType:     indexedBundleContentType,
+		},
+		Raw: payload.Bytes(),
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// Index provides random access to the packages of an indexed Bundle container.
+type Index struct {
+	index       *segmentIndex
+	segments    []byte
+	transformer value.Transformer
+}
+
+// OpenIndex decrypts the package index of the given indexed Bundle container.
+// Package segments are only decrypted on demand.
+func OpenIndex(ctx context.Context, c *containerv1.Container, transformers []value.Transformer) (*Index, error) {
+	// Check parameters
+	if types.IsNil(c) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if !IsIndexedBundleContainer(c) {
+		return nil, fmt.Errorf("invalid content type for indexed Bundle loader")
+	}
+	if c.Headers.ContentEncoding != indexedBundleContentEncoding {
+		return nil, fmt.Errorf("invalid content encoding for indexed Bundle loader")
+	}
+	if len(transformers) == 0 {
+		return nil, fmt.Errorf("unable to process empty transformer list")
+	}
+
+	// Decode payload header
+	headerSize := len(indexedBundleMagic) + 4
+	if len(c.Raw) < headerSize || !bytes.Equal(c.Raw[:len(indexedBundleMagic)], indexedBundleMagic) {
+		return nil, fmt.Errorf("invalid indexed Bundle payload")
+	}
+	indexLen := uint64(binary.BigEndian.Uint32(c.Raw[len(indexedBundleMagic):headerSize]))
+	if indexLen > uint64(len(c.Raw)-headerSize) {
+		return nil, fmt.Errorf("invalid indexed Bundle index length")
+	}
+	encryptedIndex := c.Raw[headerSize : uint64(headerSize)+indexLen]
+
+	// Try all transformers
+	var (
+		rawIndex    []byte
+		transformer value.Transformer
+		errIndex    error
+	)
+	for _, t := range transformers {
+		// Segments are only bound with authenticated additional data
+		if types.IsNil(t) || !encryption.BindsAdditionalData(t) {
+			continue
+		}
+		rawIndex, errIndex = t.From(withIndexContext(ctx, []byte(indexAdditionalData)), encryptedIndex)
+		if errIndex == nil {
+			transformer = t
+			break
+		}
+	}
+	if transformer == nil {
+		if errIndex == nil {
+			errIndex = errors.New("no transformer authenticating additional data")
+		}
+		return nil, fmt.Errorf("unable to decrypt package index: %w", errIndex)
+	}
+
+	// Decode index
+	idx := &segmentIndex{}
+	if err := json.Unmarshal(rawIndex, idx); err != nil {
+		return nil, fmt.Errorf("unable to decode package index: %w", err)
+	}
+	if idx.Version != indexedBundleVersion {
+		return nil, fmt.Errorf("unsupported package index version %d", idx.Version)
+	}
+
+	// No error
+	return &Index{
+		index:       idx,
+		segments:    c.Raw[uint64(headerSize)+indexLen:],
+		transformer: transformer,
+	}, nil
+}
+
+// Packages returns the indexed package names in bundle order.
+func (i *Index) Packages() []string {
+	names := make([]string, 0, len(i.index.Packages))
+	for _, seg := range i.index.Packages {
+		names = append(names, seg.Name)
+	}
+	return names
+}
+
+// Package decrypts and returns the package matching the given name.
+func (i *Index) Package(ctx context.Context, name string) (*bundlev1.Package, error) {
+	// Lookup segment
+	var found *indexSegment
+	for _, seg := range i.index.Packages {
+		if strings.EqualFold(seg.Name, name) {
+			found = seg
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unable to lookup package %q: %w", name, ErrPackageNotIndexed)
+	}
+
+	// Decrypt segment
+	return i.openPackage(ctx, found)
+}
+
+// Bundle decrypts all segments and returns the complete bundle.
+func (i *Index) Bundle(ctx context.Context) (*bundlev1.Bundle, error) {
+	// Decrypt metadata
+	b := &bundlev1.Bundle{}
+	if err := i.openSegment(ctx, &i.index.Metadata, b); err != nil {
+		return nil, fmt.Errorf("unable to decrypt bundle metadata: %w", err)
+	}
+
+	// Decrypt packages
+	for _, seg := range i.index.Packages {
+		p, err := i.openPackage(ctx, seg)
+		if err != nil {
+			return nil, err
+		}
+		b.Packages = append(b.Packages, p)
+	}
+
+	// Check bundle merkle tree root
+	root, err := packagesRoot(b.Packages...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute bundle merkle tree root: %w", err)
+	}
+	if !security.SecureCompare(b.MerkleTreeRoot, root) {
+		return nil, fmt.Errorf("invalid merkle tree root, indexed bundle is corrupted")
+	}
+
+	// No error
+	return b, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func withIndexContext(ctx context.Context, aad []byte) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return encryption.WithAdditionalData(ctx, aad)
+}
+
+// packagesRoot returns the merkle tree root of the given packages. The package
+// order of the caller is preserved.
+func packagesRoot(packages ...*bundlev1.Package) ([]byte, error) {
+	tree, _, err := Tree(&bundlev1.Bundle{
+		Packages: append([]*bundlev1.Package{}, packages...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.Root(), nil
+}
+
+func segmentAdditionalData(id, name string) []byte {
+	return []byte(fmt.Sprintf("%s:%s:%s", indexAdditionalData, id, name))
+}
+
+func appendSegment(ctx context.Context, w *bytes.Buffer, transformer value.Transformer, id, name string, m proto.Message) (*indexSegment, error) {
+	// Encode object
+	raw, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode segment: %w", err)
+	}
+
+	// Compress with gzip
+	compressed := &bytes.Buffer{}
+	zw, err := gzip.NewWriterLevel(compressed, gzipCompressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize compression writer: %w", err)
+	}
+	if _, err = zw.Write(raw); err != nil {
+		return nil, fmt.Errorf("unable to compress segment: %w", err)
+	}
+	if err = zw.Close(); err != nil {
+		return nil, fmt.Errorf("unable to close compression writer: %w", err)
+	}
+
+	// Encrypt segment
+	out, err := transformer.To(withIndexContext(ctx, segmentAdditionalData(id, name)), compressed.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt segment: %w", err)
+	}
+
+	seg := &indexSegment{
+		Name:   name,
+		Offset: uint64(w.Len()),
+		Length: uint64(len(out)),
+	}
+	w.Write(out)
+
+	// No error
+	return seg, nil
+}
+
+func (i *Index) openPackage(ctx context.Context, seg *indexSegment) (*bundlev1.Package, error) {
+	p := &bundlev1.Package{}
+	if err := i.openSegment(ctx, seg, p); err != nil {
+		return nil, fmt.Errorf("unable to decrypt package %q: %w", seg.Name, err)
+	}
+	if p.Name != seg.Name {
+		return nil, fmt.Errorf("package %q segment holds an unexpected package", seg.Name)
+	}
+
+	// Check package merkle tree root
+	root, err := packagesRoot(p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute package %q merkle tree root: %w", seg.Name, err)
+	}
+	if !security.SecureCompare(seg.Root, root) {
+		return nil, fmt.Errorf("invalid merkle tree root, package %q is corrupted", seg.Name)
+	}
+
+	// No error
+	return p, nil
+}
+
+func (i *Index) openSegment(ctx context.Context, seg *indexSegment, m proto.Message) error {
+	// Check segment bounds
+	if seg.Offset > uint64(len(i.segments)) || seg.Length > uint64(len(i.segments))-seg.Offset {
+		return fmt.Errorf("segment is out of payload bounds")
+	}
+
+	// Decrypt segment
+	compressed, err := i.transformer.From(withIndexContext(ctx, segmentAdditionalData(i.index.ID, seg.Name)), i.segments[seg.Offset:seg.Offset+seg.Length])
+	if err != nil {
+		return fmt.Errorf("unable to decrypt segment: %w", err)
+	}
+
+	// Decompress segment
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("unable to initialize compression reader: %w", err)
+	}
+	raw := &bytes.Buffer{}
+	if err := ioutil.Copy(maxBundleSize, raw, zr); err != nil {
+		return fmt.Errorf("unable to decompress segment: %w", err)
+	}
+
+	// Decode object
+	if err := proto.Unmarshal(raw.Bytes(), m); err != nil {
+		return fmt.Errorf("unable to decode segment: %w", err)
+	}
+
+	// No error
+	return nil
+}
@@ -0,0 +1,230 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+)
+
+func TestIndexedContainer(t *testing.T) {
+	b := &bundlev1.Bundle{
+		Labels: map[string]string{"env": "production"},
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("database")},
+					},
+				},
+			},
+			{
+				Name: "app/production/cache",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("cache")},
+					},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	otherTransformer := encryption.Must(encryption.FromKey("aes-gcm:Ad2PSymVvDlBHGf0dMTvbw=="))
+
+	c, err := ToIndexedContainer(ctx, b, transformer)
+	require.NoError(t, err)
+	assert.True(t, IsIndexedBundleContainer(c))
+	assert.False(t, IsBundleContainer(c))
+	assert.Equal(t, indexedBundleContentEncoding, c.Headers.ContentEncoding)
+	assert.NotContains(t, string(c.Raw), "app/production/database")
+
+	// Random access
+	idx, err := OpenIndex(ctx, c, []value.Transformer{otherTransformer, transformer})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/production/database", "app/production/cache"}, idx.Packages())
+
+	p, err := idx.Package(ctx, "app/production/cache")
+	require.NoError(t, err)
+	secrets, err := Read(&bundlev1.Bundle{Packages: []*bundlev1.Package{p}}, "app/production/cache")
+	require.NoError(t, err)
+	assert.Equal(t, "cache", secrets["password"])
+
+	_, err = idx.Package(ctx, "app/unknown")
+	assert.ErrorIs(t, err, ErrPackageNotIndexed)
+
+	// Full bundle
+	out, err := idx.Bundle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "production", out.Labels["env"])
+	require.Len(t, out.Packages, 2)
+	assert.Equal(t, "app/production/database", out.Packages[0].Name)
+
+	// SDK loaders
+	out, err = OpenContainer(ctx, c, []value.Transformer{transformer})
+	require.NoError(t, err)
+	assert.Len(t, out.Packages, 2)
+	_, err = FromContainer(c)
+	assert.ErrorIs(t, err, ErrIndexedContainer)
+	_, err = OpenContainer(ctx, c, nil)
+	assert.ErrorIs(t, err, ErrIndexedContainer)
+	p, err = OpenPackage(ctx, c, []value.Transformer{transformer}, "app/production/database")
+	require.NoError(t, err)
+	assert.Equal(t, "app/production/database", p.Name)
+	_, err = OpenPackage(ctx, c, nil, "app/production/database")
+	assert.ErrorIs(t, err, ErrIndexedContainer)
+
+	// Errors
+	_, err = OpenIndex(ctx, c, []value.Transformer{otherTransformer})
+	assert.Error(t, err)
+	_, err = OpenIndex(ctx, c, nil)
+	assert.Error(t, err)
+	_, err = OpenIndex(ctx, nil, []value.Transformer{transformer})
+	assert.Error(t, err)
+	bc, err := ToContainer(b)
+	require.NoError(t, err)
+	_, err = OpenIndex(ctx, bc, []value.Transformer{transformer})
+	assert.Error(t, err)
+
+	// Plain containers are supported by SDK loaders
+	out, err = OpenContainer(ctx, bc, nil)
+	require.NoError(t, err)
+	assert.Len(t, out.Packages, 2)
+	p, err = OpenPackage(ctx, bc, nil, "app/production/cache")
+	require.NoError(t, err)
+	assert.Equal(t, "app/production/cache", p.Name)
+	_, err = OpenPackage(ctx, bc, nil, "app/unknown")
+	assert.Error(t, err)
+	_, err = OpenPackage(ctx, bc, nil, "")
+	assert.Error(t, err)
+	_, err = ToIndexedContainer(ctx, nil, transformer)
+	assert.Error(t, err)
+	_, err = ToIndexedContainer(ctx, b, nil)
+	assert.Error(t, err)
+
+	// Unexpected content encoding
+	gc, ok := proto.Clone(c).(*containerv1.Container)
+	require.True(t, ok)
+	gc.Headers.ContentEncoding = "gzip"
+	_, err = OpenIndex(ctx, gc, []value.Transformer{transformer})
+	assert.Error(t, err)
+
+	// Transformers ignoring additional data are not supported
+	unbound := encryption.Must(encryption.FromKey("fernet:ZER8WwNyw5Dsd65bctxillSrRMX4ObaZsQjaNW1nBBI="))
+	_, err = ToIndexedContainer(ctx, b, unbound)
+	assert.Error(t, err)
+	_, err = OpenIndex(ctx, c, []value.Transformer{unbound})
+	assert.Error(t, err)
+}
+
+func TestIndexedContainer_MerkleTreeRoot(t *testing.T) {
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("database")},
+					},
+				},
+			},
+			{
+				Name: "app/production/cache",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("cache")},
+					},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	c, err := ToIndexedContainer(ctx, b, transformer)
+	require.NoError(t, err)
+
+	// Package order of the caller is preserved
+	assert.Equal(t, "app/production/database", b.Packages[0].Name)
+
+	// Bundle root matches the dumped bundle root
+	clone, ok := proto.Clone(b).(*bundlev1.Bundle)
+	require.True(t, ok)
+	expected, _, err := Tree(clone)
+	require.NoError(t, err)
+	idx, err := OpenIndex(ctx, c, []value.Transformer{transformer})
+	require.NoError(t, err)
+	out, err := idx.Bundle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Root(), out.MerkleTreeRoot)
+
+	// Diverging package root
+	idx.index.Packages[0].Root = idx.index.Packages[1].Root
+	_, err = idx.Package(ctx, "app/production/database")
+	assert.Error(t, err)
+	_, err = idx.Bundle(ctx)
+	assert.Error(t, err)
+
+	// Missing package
+	idx, err = OpenIndex(ctx, c, []value.Transformer{transformer})
+	require.NoError(t, err)
+	idx.index.Packages = idx.index.Packages[1:]
+	_, err = idx.Package(ctx, "app/production/cache")
+	assert.NoError(t, err)
+	_, err = idx.Bundle(ctx)
+	assert.Error(t, err)
+}
+
+func TestIndexedContainer_SegmentSwap(t *testing.T) {
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{Name: "app/production/a", Secrets: &bundlev1.SecretChain{}},
+			{Name: "app/production/b", Secrets: &bundlev1.SecretChain{}},
+		},
+	}
+	ctx := context.Background()
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	c, err := ToIndexedContainer(ctx, b, transformer)
+	require.NoError(t, err)
+	idx, err := OpenIndex(ctx, c, []value.Transformer{transformer})
+	require.NoError(t, err)
+
+	// Swap package segments
+	first, second := idx.index.Packages[0], idx.index.Packages[1]
+	require.Equal(t, first.Length, second.Length)
+	segA := append([]byte{}, idx.segments[first.Offset:first.Offset+first.Length]...)
+	copy(idx.segments[first.Offset:], idx.segments[second.Offset:second.Offset+second.Length])
+	copy(idx.segments[second.Offset:], segA)
+
+	_, err = idx.Package(ctx, "app/production/a")
+	assert.Error(t, err)
+	_, err = idx.Package(ctx, "app/production/b")
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	csov1 "github.com/zntrio/harp/v2/api/gen/go/cso/v1"
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	cso "github.com/zntrio/harp/v2/pkg/cso/v1"
+)
+
+// Inventory describes the bundle content without secret values.
+type Inventory struct {
+	Packages []*Package `json:"packages"`
+}
+
+// Package describes a bundle package.
+type Package struct {
+	Name         string            `json:"name"`
+	CSO          *CSO              `json:"cso,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Locked       bool              `json:"locked"`
+	Version      uint32            `json:"version"`
+	VersionCount int               `json:"version_count"`
+	Secrets      []*Secret         `json:"secrets"`
+}
+
+// CSO describes the CSO decomposition of a package name.
+type CSO struct {
+	Ring       string            `json:"ring"`
+	Components map[string]string `json:"components"`
+}
+
+// Secret describes a package secret without its value.
+type Secret struct {
+	Key  string `json:"key"`
+	Type string `json:"type,omitempty"`
+	Size int    `json:"size"`
+}
+
+// Build returns the inventory of the given bundle. Secret values are never
+// included, only their type and size are reported. Keys of locked packages
+// can't be listed.
+func Build(b *bundlev1.Bundle) (*Inventory, error) {
+	// Check arguments
+	if b == nil {
+		return nil, errors.New("unable to process nil bundle")
+	}
+
+	res := &Inventory{
+		Packages: []*Package{},
+	}
+
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+
+		item := &Package{
+			Name:         p.Name,
+			CSO:          decompose(p.Name),
+			Labels:       p.Labels,
+			Annotations:  p.Annotations,
+			VersionCount: len(bundle.History(p)),
+			Secrets:      []*Secret{},
+		}
+
+		if p.Secrets != nil {
+			item.Locked = p.Secrets.Locked != nil
+			item.Version = p.Secrets.Version
+			item.VersionCount++
+
+			for _, kv := range p.Secrets.Data {
+				if kv == nil {
+					continue
+				}
+
+				size, err := valueSize(kv)
+				if err != nil {
+					return nil, fmt.Errorf("unable to compute %q - %q secret size: %w", p.Name, kv.Key, err)
+				}
+
+				item.Secrets = append(item.Secrets, &Secret{
+					Key:  kv.Key,
+					Type: kv.Type,
+					Size: size,
+				})
+			}
+			sort.SliceStable(item.Secrets, func(i, j int) bool {
+				return item.Secrets[i].Key < item.Secrets[j].Key
+			})
+		}
+
+		res.Packages = append(res.Packages, item)
+	}
+
+	// Sort packages
+	sort.SliceStable(res.Packages, func(i, j int) bool {
+		return res.Packages[i].Name < res.Packages[j].Name
+	})
+
+	// No error
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// valueSize returns the size of the secret value. String values are measured
+// in bytes, other values using their packed size.
+func valueSize(kv *bundlev1.KV) (int, error) {
+	var value interface{}
+	if err := secret.Unpack(kv.Value, &value); err != nil {
+		return 0, err
+	}
+	if raw, ok := value.(string); ok {
+		return len(raw), nil
+	}
+
+	return len(kv.Value), nil
+}
+
+// decompose returns the CSO decomposition of the given package name, nil if
+// the name is not CSO compliant.
+func decompose(name string) *CSO {
+	s, err := cso.Pack(name)
+	if err != nil {
+		return nil
+	}
+
+	res := &CSO{
+		Ring:       cso.ToRingName(s.RingLevel),
+		Components: map[string]string{},
+	}
+
+	switch path := s.Path.(type) {
+	case *csov1.Secret_Meta:
+		res.Components["key"] = path.Meta.Key
+	case *csov1.Secret_Infrastructure:
+		res.Components["cloud_provider"] = path.Infrastructure.CloudProvider
+		res.Components["account_id"] = path.Infrastructure.AccountId
+		res.Components["region"] = path.Infrastructure.Region
+		res.Components["service_name"] = path.Infrastructure.ServiceName
+		res.Components["key"] = path.Infrastructure.Key
+	case *csov1.Secret_Platform:
+		res.Components["stage"] = cso.ToStageName(path.Platform.Stage)
+		res.Components["name"] = path.Platform.Name
+		res.Components["region"] = path.Platform.Region
+		res.Components["service_name"] = path.Platform.ServiceName
+		res.Components["key"] = path.Platform.Key
+	case *csov1.Secret_Product:
+		res.Components["name"] = path.Product.Name
+		res.Components["version"] = path.Product.Version
+		res.Components["component_name"] = path.Product.ComponentName
+		res.Components["key"] = path.Product.Key
+	case *csov1.Secret_Application:
+		res.Components["stage"] = cso.ToStageName(path.Application.Stage)
+		res.Components["platform_name"] = path.Application.PlatformName
+		res.Components["product_name"] = path.Application.ProductName
+		res.Components["product_version"] = path.Application.ProductVersion
+		res.Components["component_name"] = path.Application.ComponentName
+		res.Components["key"] = path.Application.Key
+	case *csov1.Secret_Artifact:
+		res.Components["type"] = path.Artifact.Type
+		res.Components["id"] = path.Artifact.Id
+		res.Components["key"] = path.Artifact.Key
+	}
+
+	return res
+}
@@ -0,0 +1,128 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func inventoryFixture(t *testing.T) *bundlev1.Bundle {
+	t.Helper()
+
+	p := &bundlev1.Package{
+		Name:        "app/production/customer1/ece/v1.0.0/adminconsole/database/credentials",
+		Labels:      map[string]string{"team": "db"},
+		Annotations: map[string]string{bundle.OwnerAnnotation: "team-db"},
+		Secrets: &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("super|secret")},
+				{Key: "port", Type: "int", Value: secret.MustPack(5432)},
+			},
+		},
+	}
+	require.NoError(t, bundle.PushVersion(p, &bundlev1.SecretChain{
+		Data: []*bundlev1.KV{
+			{Key: "password", Type: "string", Value: secret.MustPack("initial")},
+		},
+	}))
+
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			p,
+			{
+				Name: "not/a/cso/path",
+				Secrets: &bundlev1.SecretChain{
+					Locked: &wrappers.BytesValue{Value: []byte("locked")},
+				},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	inv, err := Build(inventoryFixture(t))
+	require.NoError(t, err)
+	require.Len(t, inv.Packages, 2)
+
+	// CSO compliant package
+	p := inv.Packages[0]
+	require.NotNil(t, p.CSO)
+	assert.Equal(t, "app", p.CSO.Ring)
+	assert.Equal(t, "production", p.CSO.Components["stage"])
+	assert.Equal(t, "database/credentials", p.CSO.Components["key"])
+	assert.Equal(t, uint32(1), p.Version)
+	assert.Equal(t, 2, p.VersionCount)
+	assert.Equal(t, []*Secret{
+		{Key: "password", Type: "string", Size: 12},
+		{Key: "port", Type: "int", Size: 9},
+	}, p.Secrets)
+
+	// Locked package
+	assert.Nil(t, inv.Packages[1].CSO)
+	assert.True(t, inv.Packages[1].Locked)
+	assert.Empty(t, inv.Packages[1].Secrets)
+
+	_, err = Build(nil)
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	inv, err := Build(inventoryFixture(t))
+	require.NoError(t, err)
+
+	// CSV
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, inv))
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, columns, records[0])
+	assert.NotContains(t, buf.String(), "super")
+
+	// Markdown
+	buf.Reset()
+	require.NoError(t, WriteMarkdown(&buf, inv))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 5)
+	assert.NotContains(t, buf.String(), "super")
+
+	// CycloneDX
+	buf.Reset()
+	require.NoError(t, WriteCycloneDX(&buf, inv))
+	var bom map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom["bomFormat"])
+	assert.Len(t, bom["components"], 2)
+	assert.NotContains(t, buf.String(), "super")
+
+	// Invalid arguments
+	assert.Error(t, WriteCSV(nil, inv))
+	assert.Error(t, WriteMarkdown(&buf, nil))
+	assert.Error(t, WriteCycloneDX(&buf, nil))
+}
@@ -0,0 +1,258 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+var columns = []string{"package", "ring", "stage", "key", "type", "size", "locked", "version", "version_count", "labels", "annotations"}
+
+// WriteCSV writes the inventory as CSV, one row per secret key.
+func WriteCSV(w io.Writer, inv *Inventory) error {
+	// Check arguments
+	if types.IsNil(w) {
+		return errors.New("unable to process nil writer")
+	}
+	if inv == nil {
+		return errors.New("unable to process nil inventory")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return fmt.Errorf("unable to write CSV header: %w", err)
+	}
+	for _, row := range rows(inv) {
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("unable to write CSV row: %w", err)
+		}
+	}
+
+	// Flush buffered rows
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("unable to write CSV: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// WriteMarkdown writes the inventory as a Markdown table, one row per secret
+// key.
+func WriteMarkdown(w io.Writer, inv *Inventory) error {
+	// Check arguments
+	if types.IsNil(w) {
+		return errors.New("unable to process nil writer")
+	}
+	if inv == nil {
+		return errors.New("unable to process nil inventory")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows(inv) {
+		for i := range row {
+			row[i] = markdownEscaper.Replace(row[i])
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("unable to write Markdown: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+type cdxBOM struct {
+	BOMFormat   string         `json:"bomFormat"`
+	SpecVersion string         `json:"specVersion"`
+	Version     int            `json:"version"`
+	Metadata    cdxMetadata    `json:"metadata"`
+	Components  []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string    `json:"timestamp"`
+	Tools     []cdxTool `json:"tools"`
+}
+
+type cdxTool struct {
+	Name string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WriteCycloneDX writes the inventory as a CycloneDX-style JSON document.
+// Packages and secrets are described as nested data components.
+func WriteCycloneDX(w io.Writer, inv *Inventory) error {
+	// Check arguments
+	if types.IsNil(w) {
+		return errors.New("unable to process nil writer")
+	}
+	if inv == nil {
+		return errors.New("unable to process nil inventory")
+	}
+
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     []cdxTool{{Name: "harp"}},
+		},
+		Components: []cdxComponent{},
+	}
+
+	for _, p := range inv.Packages {
+		c := cdxComponent{
+			Type:       "data",
+			BOMRef:     p.Name,
+			Name:       p.Name,
+			Version:    fmt.Sprintf("%d", p.Version),
+			Properties: []cdxProperty{},
+		}
+
+		// CSO decomposition
+		if p.CSO != nil {
+			c.Properties = append(c.Properties, cdxProperty{Name: "harp:cso:ring", Value: p.CSO.Ring})
+			for _, k := range sortedKeys(p.CSO.Components) {
+				c.Properties = append(c.Properties, cdxProperty{Name: "harp:cso:" + k, Value: p.CSO.Components[k]})
+			}
+		}
+
+		// Package metadata
+		c.Properties = append(c.Properties,
+			cdxProperty{Name: "harp:locked", Value: fmt.Sprintf("%t", p.Locked)},
+			cdxProperty{Name: "harp:version_count", Value: fmt.Sprintf("%d", p.VersionCount)},
+		)
+		for _, k := range sortedKeys(p.Labels) {
+			c.Properties = append(c.Properties, cdxProperty{Name: "harp:label:" + k, Value: p.Labels[k]})
+		}
+		for _, k := range sortedKeys(p.Annotations) {
+			c.Properties = append(c.Properties, cdxProperty{Name: "harp:annotation:" + k, Value: p.Annotations[k]})
+		}
+
+		// Secrets
+		for _, s := range p.Secrets {
+			c.Components = append(c.Components, cdxComponent{
+				Type:   "data",
+				BOMRef: fmt.Sprintf("%s#%s", p.Name, s.Key),
+				Name:   s.Key,
+				Properties: []cdxProperty{
+					{Name: "harp:secret:type", Value: s.Type},
+					{Name: "harp:secret:size", Value: fmt.Sprintf("%d", s.Size)},
+				},
+			})
+		}
+
+		bom.Components = append(bom.Components, c)
+	}
+
+	// Encode as JSON
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&bom); err != nil {
+		return fmt.Errorf("unable to encode CycloneDX document: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ")
+
+// rows flattens the inventory as table rows.
+func rows(inv *Inventory) [][]string {
+	res := [][]string{}
+	for _, p := range inv.Packages {
+		ring, stage := "", ""
+		if p.CSO != nil {
+			ring = p.CSO.Ring
+			stage = p.CSO.Components["stage"]
+		}
+		prefix := []string{p.Name, ring, stage}
+		suffix := []string{
+			fmt.Sprintf("%t", p.Locked),
+			fmt.Sprintf("%d", p.Version),
+			fmt.Sprintf("%d", p.VersionCount),
+			joinMap(p.Labels),
+			joinMap(p.Annotations),
+		}
+
+		// Packages without listable secrets
+		if len(p.Secrets) == 0 {
+			row := append(append([]string{}, prefix...), "", "", "")
+			res = append(res, append(row, suffix...))
+			continue
+		}
+
+		for _, s := range p.Secrets {
+			row := append(append([]string{}, prefix...), s.Key, s.Type, fmt.Sprintf("%d", s.Size))
+			res = append(res, append(row, suffix...))
+		}
+	}
+
+	return res
+}
+
+func joinMap(m map[string]string) string {
+	items := []string{}
+	for _, k := range sortedKeys(m) {
+		items = append(items, fmt.Sprintf("%s=%s", k, m[k]))
+	}
+	return strings.Join(items, ";")
+}
+
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
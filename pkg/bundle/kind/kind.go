@@ -0,0 +1,117 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package kind provides the secret kinds registry used to type bundle secret
+// values.
+package kind
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind describes a secret value kind.
+type Kind struct {
+	// Name is the kind identifier stored as secret type.
+	Name string
+	// Detect returns true if the given secret looks like this kind. A detected
+	// value is only assigned to the kind when it also passes the validation.
+	Detect func(key string, value interface{}) bool
+	// Validate checks the given value against the kind constraints.
+	Validate func(value interface{}) error
+}
+
+var (
+	registry = map[string]*Kind{}
+	ordered  = []*Kind{}
+)
+
+// Register a secret kind. Kinds are detected in registration order.
+func Register(k *Kind) {
+	// Check arguments
+	if k == nil || k.Name == "" {
+		panic(fmt.Errorf("unable to register an anonymous secret kind"))
+	}
+	if k.Detect == nil || k.Validate == nil {
+		panic(fmt.Errorf("secret kind %q must provide detection and validation functions", k.Name))
+	}
+
+	// Check if not already registered
+	if _, ok := registry[k.Name]; ok {
+		panic(fmt.Errorf("secret kind already registered for %q", k.Name))
+	}
+
+	// Register the kind
+	registry[k.Name] = k
+	ordered = append(ordered, k)
+}
+
+// Get returns the kind registered with the given name.
+func Get(name string) (*Kind, bool) {
+	k, ok := registry[name]
+	return k, ok
+}
+
+// Names returns the sorted list of registered kind names.
+func Names() []string {
+	res := make([]string, 0, len(registry))
+	for name := range registry {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Detect returns the name of the first registered kind matching the given
+// secret, or an empty string if none matches. A value is matched only if it
+// is valid for the kind, so that a detected type always passes Validate.
+func Detect(key string, value interface{}) string {
+	for _, k := range ordered {
+		if k.Detect(key, value) && k.Validate(value) == nil {
+			return k.Name
+		}
+	}
+
+	return ""
+}
+
+// TypeOf returns the secret type to assign to the given secret. It falls back
+// to the Go type of the value when no kind matches.
+func TypeOf(key string, value interface{}) string {
+	if name := Detect(key, value); name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// Validate the value against the given secret type. Types not matching a
+// registered kind are not validated.
+func Validate(typ string, value interface{}) error {
+	k, ok := registry[typ]
+	if !ok {
+		return nil
+	}
+
+	if err := k.Validate(value); err != nil {
+		return fmt.Errorf("invalid %s: %w", k.Name, err)
+	}
+
+	// No error
+	return nil
+}
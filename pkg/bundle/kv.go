@@ -24,6 +24,7 @@ import (
 	"github.com/gobwas/glob"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 )
 
@@ -97,7 +98,7 @@ func FromSecretMap(secretKv KV) ([]*bundlev1.KV, error) {
 		// Add to secret package
 		secrets = append(secrets, &bundlev1.KV{
 			Key:   k,
-			Type:  fmt.Sprintf("%T", v),
+			Type:  kind.TypeOf(k, v),
 			Value: packed,
 		})
 	}
@@ -0,0 +1,555 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+)
+
+// MergeStrategy describes how a merge conflict must be resolved.
+type MergeStrategy string
+
+const (
+	// MergeStrategyOurs keeps the value of the bundle merged first.
+	MergeStrategyOurs MergeStrategy = "ours"
+	// MergeStrategyTheirs keeps the value of the bundle merged last.
+	MergeStrategyTheirs MergeStrategy = "theirs"
+	// MergeStrategyFail aborts the merge on the first conflict.
+	MergeStrategyFail MergeStrategy = "fail"
+	// MergeStrategyNewest keeps the value of the package with the highest
+	// secret chain version, ties are resolved as ours.
+	MergeStrategyNewest MergeStrategy = "newest"
+)
+
+// ErrMergeConflict is raised when a conflict is detected with the fail
+// strategy.
+var ErrMergeConflict = errors.New("merge conflict")
+
+// ParseMergeStrategy returns the merge strategy matching the given name.
+func ParseMergeStrategy(value string) (MergeStrategy, error) {
+	switch s := MergeStrategy(strings.ToLower(strings.TrimSpace(value))); s {
+	case MergeStrategyOurs, MergeStrategyTheirs, MergeStrategyFail, MergeStrategyNewest:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unsupported merge strategy %q", value)
+	}
+}
+
+type mergeRule struct {
+	pattern  string
+	key      string
+	strategy MergeStrategy
+	g        glob.Glob
+}
+
+type mergeOptions struct {
+	strategy     MergeStrategy
+	packageRules []*mergeRule
+	keyRules     []*mergeRule
+}
+
+// MergeOption defines merge function option.
+type MergeOption func(*mergeOptions)
+
+// WithMergeStrategy sets the default conflict resolution strategy.
+func WithMergeStrategy(s MergeStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.strategy = s
+	}
+}
+
+// WithPackageMergeStrategy sets the conflict resolution strategy of packages
+// matching the given glob pattern.
+func WithPackageMergeStrategy(pattern string, s MergeStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.packageRules = append(o.packageRules, &mergeRule{
+			pattern:  pattern,
+			strategy: s,
+		})
+	}
+}
+
+// WithKeyMergeStrategy sets the conflict resolution strategy of the given
+// secret key of packages matching the given glob pattern.
+func WithKeyMergeStrategy(pattern, key string, s MergeStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.keyRules = append(o.keyRules, &mergeRule{
+			pattern:  pattern,
+			key:      key,
+			strategy: s,
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// Merge combines the given bundles in order and returns the merged bundle.
+//
+// Packages, secrets, labels and annotations are merged. When the same item
+// exists with different values, the conflict is resolved using the matching
+// strategy (key, then package, then default) and recorded in the returned
+// conflict oplog. Locked packages can't be merged by key and are resolved as a
+// whole package.
+//
+// Package version histories are merged, diverging archived versions with the
+// same number are resolved with the package strategy. A secret chain replaced
+// by the merge is archived and the merged chain is linked to it.
+//
+//nolint:gocognit,gocyclo // To refactor
+func Merge(bundles []*bundlev1.Bundle, opts ...MergeOption) (*bundlev1.Bundle, compare.OpLog, error) {
+	// Check arguments
+	if len(bundles) == 0 {
+		return nil, nil, errors.New("unable to merge an empty bundle list")
+	}
+
+	// Default options
+	dopts := &mergeOptions{
+		strategy: MergeStrategyFail,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+	if err := dopts.compile(); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		res       *bundlev1.Bundle
+		conflicts = compare.OpLog{}
+	)
+	for i, b := range bundles {
+		if b == nil {
+			return nil, nil, fmt.Errorf("unable to merge nil bundle at index %d", i)
+		}
+
+		// Initialize result with the first bundle
+		if res == nil {
+			cloned, ok := proto.Clone(b).(*bundlev1.Bundle)
+			if !ok {
+				return nil, nil, fmt.Errorf("the cloned bundle does not have a correct type: %T", cloned)
+			}
+			res = cloned
+			continue
+		}
+
+		// Merge bundle metadata (not versioned, newest is resolved as ours)
+		var err error
+		if res.Labels, err = mergeMap(res.Labels, b.Labels, "label", "", dopts.strategy, 0, 0, &conflicts); err != nil {
+			return nil, nil, err
+		}
+		if res.Annotations, err = mergeMap(res.Annotations, b.Annotations, "annotation", "", dopts.strategy, 0, 0, &conflicts); err != nil {
+			return nil, nil, err
+		}
+
+		// Index current packages
+		index := map[string]int{}
+		for idx, p := range res.Packages {
+			if p == nil {
+				continue
+			}
+			index[p.Name] = idx
+		}
+
+		for _, theirs := range b.Packages {
+			if theirs == nil {
+				continue
+			}
+
+			idx, ok := index[theirs.Name]
+			if !ok {
+				// New package
+				cloned, ok := proto.Clone(theirs).(*bundlev1.Package)
+				if !ok {
+					return nil, nil, fmt.Errorf("the cloned package does not have a correct type: %T", cloned)
+				}
+				res.Packages = append(res.Packages, cloned)
+				index[theirs.Name] = len(res.Packages) - 1
+				continue
+			}
+
+			// Merge packages
+			merged, err := mergePackage(res.Packages[idx], theirs, dopts, &conflicts)
+			if err != nil {
+				return nil, nil, err
+			}
+			res.Packages[idx] = merged
+		}
+	}
+
+	// Sort conflicts
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+
+	// No error
+	return res, conflicts, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func (o *mergeOptions) compile() error {
+	if _, err := ParseMergeStrategy(string(o.strategy)); err != nil {
+		return fmt.Errorf("invalid default strategy: %w", err)
+	}
+
+	for _, rules := range [][]*mergeRule{o.packageRules, o.keyRules} {
+		for _, r := range rules {
+			if _, err := ParseMergeStrategy(string(r.strategy)); err != nil {
+				return fmt.Errorf("invalid strategy for %q: %w", r.pattern, err)
+			}
+			g, err := glob.Compile(r.pattern)
+			if err != nil {
+				return fmt.Errorf("unable to compile package pattern %q: %w", r.pattern, err)
+			}
+			r.g = g
+		}
+	}
+
+	// No error
+	return nil
+}
+
+func (o *mergeOptions) packageStrategy(packageName string) MergeStrategy {
+	for _, r := range o.packageRules {
+		if r.g.Match(packageName) {
+			return r.strategy
+		}
+	}
+	return o.strategy
+}
+
+func (o *mergeOptions) keyStrategy(packageName, key string) MergeStrategy {
+	for _, r := range o.keyRules {
+		if r.key == key && r.g.Match(packageName) {
+			return r.strategy
+		}
+	}
+	return o.packageStrategy(packageName)
+}
+
+func resolveConflict(s MergeStrategy, path string, oursVersion, theirsVersion uint32) (useTheirs bool, err error) {
+	switch s {
+	case MergeStrategyOurs:
+		return false, nil
+	case MergeStrategyTheirs:
+		return true, nil
+	case MergeStrategyNewest:
+		return theirsVersion > oursVersion, nil
+	default:
+		return false, fmt.Errorf("unable to merge %q: %w", path, ErrMergeConflict)
+	}
+}
+
+func chainVersion(p *bundlev1.Package) uint32 {
+	if p == nil || p.Secrets == nil {
+		return 0
+	}
+	return p.Secrets.Version
+}
+
+//nolint:gocognit,gocyclo // To refactor
+func mergePackage(ours, theirs *bundlev1.Package, opts *mergeOptions, conflicts *compare.OpLog) (*bundlev1.Package, error) {
+	var (
+		strategy      = opts.packageStrategy(ours.Name)
+		oursVersion   = chainVersion(ours)
+		theirsVersion = chainVersion(theirs)
+		err           error
+	)
+
+	// Locked packages are resolved as a whole
+	if ours.GetSecrets().GetLocked() != nil || theirs.GetSecrets().GetLocked() != nil {
+		if proto.Equal(ours.Secrets, theirs.Secrets) {
+			return ours, nil
+		}
+
+		*conflicts = append(*conflicts, compare.DiffItem{
+			Operation: compare.Conflict,
+			Type:      "package",
+			Path:      ours.Name,
+		})
+
+		useTheirs, err := resolveConflict(strategy, ours.Name, oursVersion, theirsVersion)
+		if err != nil {
+			return nil, err
+		}
+		if !useTheirs {
+			if err := mergeVersions(ours, theirs, strategy, oursVersion, theirsVersion, conflicts); err != nil {
+				return nil, err
+			}
+			return ours, nil
+		}
+
+		cloned, ok := proto.Clone(theirs).(*bundlev1.Package)
+		if !ok {
+			return nil, fmt.Errorf("the cloned package does not have a correct type: %T", cloned)
+		}
+
+		// Keep our replaced chain and history
+		if ours.Secrets != nil {
+			if err := archiveVersion(cloned, ours.Secrets); err != nil {
+				return nil, err
+			}
+		}
+		for _, v := range History(ours) {
+			if err := archiveVersion(cloned, v); err != nil {
+				return nil, err
+			}
+		}
+
+		return cloned, nil
+	}
+
+	// Merge package metadata
+	if ours.Labels, err = mergeMap(ours.Labels, theirs.Labels, "label", ours.Name, strategy, oursVersion, theirsVersion, conflicts); err != nil {
+		return nil, err
+	}
+	if ours.Annotations, err = mergeMap(ours.Annotations, theirs.Annotations, "annotation", ours.Name, strategy, oursVersion, theirsVersion, conflicts); err != nil {
+		return nil, err
+	}
+
+	if theirs.Secrets == nil {
+		if err := mergeVersions(ours, theirs, strategy, oursVersion, theirsVersion, conflicts); err != nil {
+			return nil, err
+		}
+		return ours, nil
+	}
+	if ours.Secrets == nil {
+		ours.Secrets = &bundlev1.SecretChain{}
+	}
+
+	// Keep a copy of our secret chain for the version history
+	original, ok := proto.Clone(ours.Secrets).(*bundlev1.SecretChain)
+	if !ok {
+		return nil, fmt.Errorf("the cloned secret chain does not have a correct type: %T", original)
+	}
+
+	// Index current secrets
+	index := map[string]int{}
+	for idx, kv := range ours.Secrets.Data {
+		if kv == nil {
+			continue
+		}
+		index[kv.Key] = idx
+	}
+
+	for _, kv := range theirs.Secrets.Data {
+		if kv == nil {
+			continue
+		}
+
+		cloned, ok := proto.Clone(kv).(*bundlev1.KV)
+		if !ok {
+			return nil, fmt.Errorf("the cloned secret does not have a correct type: %T", cloned)
+		}
+
+		idx, ok := index[kv.Key]
+		if !ok {
+			// New secret
+			ours.Secrets.Data = append(ours.Secrets.Data, cloned)
+			index[kv.Key] = len(ours.Secrets.Data) - 1
+			continue
+		}
+
+		// Same value
+		if security.SecureCompare(ours.Secrets.Data[idx].Value, kv.Value) {
+			continue
+		}
+
+		// Conflict detected
+		path := fmt.Sprintf("%s#%s", ours.Name, kv.Key)
+		*conflicts = append(*conflicts, compare.DiffItem{
+			Operation: compare.Conflict,
+			Type:      "secret",
+			Path:      path,
+		})
+
+		useTheirs, err := resolveConflict(opts.keyStrategy(ours.Name, kv.Key), path, oursVersion, theirsVersion)
+		if err != nil {
+			return nil, err
+		}
+		if useTheirs {
+			ours.Secrets.Data[idx] = cloned
+		}
+	}
+
+	// Archive our replaced secret chain
+	changed := !SameSecrets(original, ours.Secrets)
+	if changed && len(original.Data) > 0 {
+		if err := archiveVersion(ours, original); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge archived versions
+	if err := mergeVersions(ours, theirs, strategy, oursVersion, theirsVersion, conflicts); err != nil {
+		return nil, err
+	}
+	if !changed {
+		return ours, nil
+	}
+
+	// Their secret chain has been adopted as a whole
+	if _, archived := ours.Versions[theirsVersion]; SameSecrets(ours.Secrets, theirs.Secrets) && theirsVersion > oursVersion && !archived {
+		ours.Secrets.Version = theirsVersion
+		ours.Secrets.PreviousVersion = nil
+		if previous := theirs.Secrets.GetPreviousVersion(); previous != nil {
+			ours.Secrets.PreviousVersion = &wrappers.UInt32Value{Value: previous.Value}
+		}
+		ours.Secrets.NextVersion = nil
+	} else {
+		// Merged secret chain is a new version
+		nextVersion := theirsVersion
+		if oursVersion > nextVersion {
+			nextVersion = oursVersion
+		}
+		for v := range ours.Versions {
+			if v > nextVersion {
+				nextVersion = v
+			}
+		}
+		nextVersion++
+
+		ours.Secrets.Version = nextVersion
+		ours.Secrets.PreviousVersion = &wrappers.UInt32Value{Value: oursVersion}
+		ours.Secrets.NextVersion = nil
+	}
+
+	// Link our replaced secret chain
+	if archived, ok := ours.Versions[oursVersion]; ok && len(original.Data) > 0 && SameSecrets(archived, original) {
+		archived.NextVersion = &wrappers.UInt32Value{Value: ours.Secrets.Version}
+	}
+
+	// No error
+	return ours, nil
+}
+
+// mergeVersions copies their archived secret chains to our package version
+// history. Diverging archived versions with the same number are resolved as
+// conflicts.
+func mergeVersions(ours, theirs *bundlev1.Package, strategy MergeStrategy, oursVersion, theirsVersion uint32, conflicts *compare.OpLog) error {
+	for _, v := range History(theirs) {
+		current, ok := ours.Versions[v.Version]
+		if ok {
+			if SameSecrets(current, v) {
+				continue
+			}
+
+			// Conflict detected
+			path := fmt.Sprintf("%s@%d", ours.Name, v.Version)
+			*conflicts = append(*conflicts, compare.DiffItem{
+				Operation: compare.Conflict,
+				Type:      "version",
+				Path:      path,
+			})
+
+			useTheirs, err := resolveConflict(strategy, path, oursVersion, theirsVersion)
+			if err != nil {
+				return err
+			}
+			if !useTheirs {
+				continue
+			}
+			delete(ours.Versions, v.Version)
+		}
+
+		if err := archiveVersion(ours, v); err != nil {
+			return err
+		}
+	}
+
+	// No error
+	return nil
+}
+
+// archiveVersion adds a copy of the given secret chain to the package version
+// history, unless the version number is already used.
+func archiveVersion(p *bundlev1.Package, chain *bundlev1.SecretChain) error {
+	if _, ok := p.Versions[chain.Version]; ok {
+		return nil
+	}
+
+	archived, ok := proto.Clone(chain).(*bundlev1.SecretChain)
+	if !ok {
+		return fmt.Errorf("the cloned secret chain does not have a correct type: %T", archived)
+	}
+
+	if p.Versions == nil {
+		p.Versions = map[uint32]*bundlev1.SecretChain{}
+	}
+	p.Versions[chain.Version] = archived
+
+	// No error
+	return nil
+}
+
+func mergeMap(ours, theirs map[string]string, itemType, prefix string, strategy MergeStrategy, oursVersion, theirsVersion uint32, conflicts *compare.OpLog) (map[string]string, error) {
+	if len(theirs) == 0 {
+		return ours, nil
+	}
+	if ours == nil {
+		ours = map[string]string{}
+	}
+
+	// Sort keys to get a stable conflict detection
+	keys := make([]string, 0, len(theirs))
+	for k := range theirs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := theirs[k]
+		current, ok := ours[k]
+		switch {
+		case !ok:
+			ours[k] = v
+		case current != v:
+			path := k
+			if prefix != "" {
+				path = fmt.Sprintf("%s#%s", prefix, k)
+			}
+			*conflicts = append(*conflicts, compare.DiffItem{
+				Operation: compare.Conflict,
+				Type:      itemType,
+				Path:      path,
+			})
+
+			useTheirs, err := resolveConflict(strategy, path, oursVersion, theirsVersion)
+			if err != nil {
+				return nil, err
+			}
+			if useTheirs {
+				ours[k] = v
+			}
+		}
+	}
+
+	// No error
+	return ours, nil
+}
@@ -0,0 +1,415 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+)
+
+const (
+	// ConflictBothAdded describes an item added by both sides with different
+	// values.
+	ConflictBothAdded = "both-added"
+	// ConflictBothModified describes an item modified by both sides with
+	// different values.
+	ConflictBothModified = "both-modified"
+	// ConflictDeletedByLocal describes an item deleted by the local side and
+	// modified by the remote side.
+	ConflictDeletedByLocal = "deleted-by-local"
+	// ConflictDeletedByRemote describes an item modified by the local side and
+	// deleted by the remote side.
+	ConflictDeletedByRemote = "deleted-by-remote"
+)
+
+// MergeConflict describes a three-way merge conflict.
+type MergeConflict struct {
+	Type       string        `json:"type"`
+	Path       string        `json:"path"`
+	Reason     string        `json:"reason"`
+	Local      string        `json:"local"`
+	Remote     string        `json:"remote"`
+	Resolution MergeStrategy `json:"resolution,omitempty"`
+}
+
+// MergeReport represents the three-way merge conflict list.
+type MergeReport []MergeConflict
+
+// -----------------------------------------------------------------------------
+
+// ThreeWayMerge reconciles local and remote bundles using base as their common
+// ancestor.
+//
+// Changes made by only one side are applied. When both sides changed the same
+// item differently, or when one side deleted an item the other changed, a
+// conflict is recorded in the returned report and resolved using the matching
+// strategy, where `ours` selects the local side and `theirs` the remote one.
+// If a conflict can't be resolved, the report is returned along with an
+// ErrMergeConflict error.
+//
+//nolint:gocognit,gocyclo // To refactor
+func ThreeWayMerge(base, local, remote *bundlev1.Bundle, opts ...MergeOption) (*bundlev1.Bundle, MergeReport, error) {
+	// Check arguments
+	if base == nil {
+		return nil, nil, errors.New("unable to merge with a nil base")
+	}
+	if local == nil {
+		return nil, nil, errors.New("unable to merge with a nil local bundle")
+	}
+	if remote == nil {
+		return nil, nil, errors.New("unable to merge with a nil remote bundle")
+	}
+
+	// Default options
+	dopts := &mergeOptions{
+		strategy: MergeStrategyFail,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+	if err := dopts.compile(); err != nil {
+		return nil, nil, err
+	}
+
+	// Initialize result from local bundle
+	res, ok := proto.Clone(local).(*bundlev1.Bundle)
+	if !ok {
+		return nil, nil, fmt.Errorf("the cloned bundle does not have a correct type: %T", res)
+	}
+	res.Packages = []*bundlev1.Package{}
+
+	m := &threeWayMerger{
+		opts:   dopts,
+		report: MergeReport{},
+	}
+
+	// Merge bundle metadata (not versioned, newest is resolved as ours)
+	res.Labels = m.mergeMap(base.Labels, local.Labels, remote.Labels, "label", "", dopts.strategy, 0, 0)
+	res.Annotations = m.mergeMap(base.Annotations, local.Annotations, remote.Annotations, "annotation", "", dopts.strategy, 0, 0)
+
+	// Index packages
+	baseIndex := packageIndex(base)
+	localIndex := packageIndex(local)
+	remoteIndex := packageIndex(remote)
+
+	// Process packages in local order, then remote added ones
+	names := []string{}
+	seen := map[string]struct{}{}
+	for _, list := range [][]*bundlev1.Package{local.Packages, remote.Packages, base.Packages} {
+		for _, p := range list {
+			if p == nil {
+				continue
+			}
+			if _, ok := seen[p.Name]; ok {
+				continue
+			}
+			seen[p.Name] = struct{}{}
+			names = append(names, p.Name)
+		}
+	}
+
+	for _, name := range names {
+		merged, err := m.mergePackage(name, baseIndex[name], localIndex[name], remoteIndex[name])
+		if err != nil {
+			return nil, nil, err
+		}
+		if merged != nil {
+			res.Packages = append(res.Packages, merged)
+		}
+	}
+
+	// Sort conflicts
+	sort.SliceStable(m.report, func(i, j int) bool {
+		return m.report[i].Path < m.report[j].Path
+	})
+
+	// Check unresolved conflicts
+	for _, c := range m.report {
+		if c.Resolution == "" {
+			return nil, m.report, fmt.Errorf("unable to merge %q (%s): %w", c.Path, c.Reason, ErrMergeConflict)
+		}
+	}
+
+	// No error
+	return res, m.report, nil
+}
+
+// -----------------------------------------------------------------------------
+
+type threeWayMerger struct {
+	opts   *mergeOptions
+	report MergeReport
+}
+
+func packageIndex(b *bundlev1.Bundle) map[string]*bundlev1.Package {
+	index := map[string]*bundlev1.Package{}
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+		index[p.Name] = p
+	}
+	return index
+}
+
+// merge3 returns the side to select and a conflict reason when both sides
+// diverged from base.
+func merge3(baseExists, localExists, remoteExists, localEqualsBase, remoteEqualsBase, localEqualsRemote bool) (useRemote bool, reason string) {
+	switch {
+	case !localExists && !remoteExists:
+		return false, ""
+	case localExists && remoteExists && localEqualsRemote:
+		return false, ""
+	case baseExists && localExists && localEqualsBase:
+		return true, ""
+	case baseExists && remoteExists && remoteEqualsBase:
+		return false, ""
+	case !baseExists && localExists && !remoteExists:
+		return false, ""
+	case !baseExists && !localExists && remoteExists:
+		return true, ""
+	}
+
+	// Conflict detected
+	switch {
+	case !baseExists:
+		return false, ConflictBothAdded
+	case !localExists:
+		return false, ConflictDeletedByLocal
+	case !remoteExists:
+		return false, ConflictDeletedByRemote
+	default:
+		return false, ConflictBothModified
+	}
+}
+
+func changeOperation(baseExists, exists bool) string {
+	switch {
+	case !baseExists && exists:
+		return compare.Add
+	case baseExists && !exists:
+		return compare.Remove
+	default:
+		return compare.Replace
+	}
+}
+
+func (m *threeWayMerger) conflict(itemType, path, reason string, baseExists, localExists, remoteExists bool, strategy MergeStrategy, localVersion, remoteVersion uint32) (useRemote bool) {
+	c := MergeConflict{
+		Type:   itemType,
+		Path:   path,
+		Reason: reason,
+		Local:  changeOperation(baseExists, localExists),
+		Remote: changeOperation(baseExists, remoteExists),
+	}
+
+	// Try to resolve the conflict
+	if useTheirs, err := resolveConflict(strategy, path, localVersion, remoteVersion); err == nil {
+		useRemote = useTheirs
+		c.Resolution = MergeStrategyOurs
+		if useTheirs {
+			c.Resolution = MergeStrategyTheirs
+		}
+	}
+
+	m.report = append(m.report, c)
+
+	return useRemote
+}
+
+//nolint:gocognit,gocyclo // To refactor
+func (m *threeWayMerger) mergePackage(name string, base, local, remote *bundlev1.Package) (*bundlev1.Package, error) {
+	var (
+		strategy      = m.opts.packageStrategy(name)
+		localVersion  = chainVersion(local)
+		remoteVersion = chainVersion(remote)
+	)
+
+	useRemote, reason := merge3(
+		base != nil, local != nil, remote != nil,
+		proto.Equal(local, base), proto.Equal(remote, base), proto.Equal(local, remote),
+	)
+
+	// Secret level merge is only possible when both sides hold an unlocked package
+	if reason == ConflictBothAdded || reason == ConflictBothModified {
+		if local.GetSecrets().GetLocked() == nil && remote.GetSecrets().GetLocked() == nil &&
+			base.GetSecrets().GetLocked() == nil {
+			return m.mergeSecrets(name, base, local, remote, strategy, localVersion, remoteVersion)
+		}
+	}
+
+	if reason != "" {
+		useRemote = m.conflict("package", name, reason, base != nil, local != nil, remote != nil, strategy, localVersion, remoteVersion)
+	}
+
+	selected := local
+	if useRemote {
+		selected = remote
+	}
+	if selected == nil {
+		return nil, nil
+	}
+
+	cloned, ok := proto.Clone(selected).(*bundlev1.Package)
+	if !ok {
+		return nil, fmt.Errorf("the cloned package does not have a correct type: %T", cloned)
+	}
+
+	// No error
+	return cloned, nil
+}
+
+//nolint:gocognit // To refactor
+func (m *threeWayMerger) mergeSecrets(name string, base, local, remote *bundlev1.Package, strategy MergeStrategy, localVersion, remoteVersion uint32) (*bundlev1.Package, error) {
+	res, ok := proto.Clone(local).(*bundlev1.Package)
+	if !ok {
+		return nil, fmt.Errorf("the cloned package does not have a correct type: %T", res)
+	}
+	if res.Secrets == nil {
+		res.Secrets = &bundlev1.SecretChain{}
+	}
+	res.Secrets.Data = []*bundlev1.KV{}
+
+	// Merge package metadata
+	res.Labels = m.mergeMap(base.GetLabels(), local.GetLabels(), remote.GetLabels(), "label", name, strategy, localVersion, remoteVersion)
+	res.Annotations = m.mergeMap(base.GetAnnotations(), local.GetAnnotations(), remote.GetAnnotations(), "annotation", name, strategy, localVersion, remoteVersion)
+
+	// Index secrets
+	baseIndex := kvIndex(base)
+	localIndex := kvIndex(local)
+	remoteIndex := kvIndex(remote)
+
+	keys := []string{}
+	seen := map[string]struct{}{}
+	for _, p := range []*bundlev1.Package{local, remote, base} {
+		for _, kv := range p.GetSecrets().GetData() {
+			if kv == nil {
+				continue
+			}
+			if _, ok := seen[kv.Key]; ok {
+				continue
+			}
+			seen[kv.Key] = struct{}{}
+			keys = append(keys, kv.Key)
+		}
+	}
+
+	for _, k := range keys {
+		b, l, r := baseIndex[k], localIndex[k], remoteIndex[k]
+
+		useRemote, reason := merge3(
+			b != nil, l != nil, r != nil,
+			kvEqual(l, b), kvEqual(r, b), kvEqual(l, r),
+		)
+		if reason != "" {
+			path := fmt.Sprintf("%s#%s", name, k)
+			useRemote = m.conflict("secret", path, reason, b != nil, l != nil, r != nil, m.opts.keyStrategy(name, k), localVersion, remoteVersion)
+		}
+
+		selected := l
+		if useRemote {
+			selected = r
+		}
+		if selected == nil {
+			continue
+		}
+
+		cloned, ok := proto.Clone(selected).(*bundlev1.KV)
+		if !ok {
+			return nil, fmt.Errorf("the cloned secret does not have a correct type: %T", cloned)
+		}
+		res.Secrets.Data = append(res.Secrets.Data, cloned)
+	}
+
+	// Keep the highest version number
+	if remoteVersion > localVersion {
+		res.Secrets.Version = remoteVersion
+	}
+
+	// No error
+	return res, nil
+}
+
+func (m *threeWayMerger) mergeMap(base, local, remote map[string]string, itemType, prefix string, strategy MergeStrategy, localVersion, remoteVersion uint32) map[string]string {
+	keys := []string{}
+	seen := map[string]struct{}{}
+	for _, source := range []map[string]string{local, remote, base} {
+		for k := range source {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	res := map[string]string{}
+	for _, k := range keys {
+		b, bOk := base[k]
+		l, lOk := local[k]
+		r, rOk := remote[k]
+
+		useRemote, reason := merge3(bOk, lOk, rOk, l == b, r == b, l == r)
+		if reason != "" {
+			path := k
+			if prefix != "" {
+				path = fmt.Sprintf("%s#%s", prefix, k)
+			}
+			useRemote = m.conflict(itemType, path, reason, bOk, lOk, rOk, strategy, localVersion, remoteVersion)
+		}
+
+		switch {
+		case useRemote && rOk:
+			res[k] = r
+		case !useRemote && lOk:
+			res[k] = l
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
+}
+
+func kvIndex(p *bundlev1.Package) map[string]*bundlev1.KV {
+	index := map[string]*bundlev1.KV{}
+	for _, kv := range p.GetSecrets().GetData() {
+		if kv == nil {
+			continue
+		}
+		index[kv.Key] = kv
+	}
+	return index
+}
+
+func kvEqual(a, b *bundlev1.KV) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Type == b.Type && security.SecureCompare(a.Value, b.Value)
+}
@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func threeWayFixture(kv map[string]string, extra ...*bundlev1.Package) *bundlev1.Bundle {
+	b := mergeFixture(1, "red", kv)
+	b.Packages = append(b.Packages, extra...)
+	return b
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	base := threeWayFixture(map[string]string{"host": "db1", "password": "foo", "user": "admin"})
+
+	t.Run("nil", func(t *testing.T) {
+		_, _, err := ThreeWayMerge(nil, base, base)
+		assert.Error(t, err)
+		_, _, err = ThreeWayMerge(base, nil, base)
+		assert.Error(t, err)
+		_, _, err = ThreeWayMerge(base, base, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("non conflicting changes", func(t *testing.T) {
+		local := threeWayFixture(map[string]string{"host": "db2", "password": "foo", "user": "admin"})
+		remote := threeWayFixture(map[string]string{"host": "db1", "password": "bar"}, &bundlev1.Package{
+			Name: "app/production/cache",
+			Secrets: &bundlev1.SecretChain{
+				Data: []*bundlev1.KV{
+					{Key: "token", Type: "string", Value: secret.MustPack("secret")},
+				},
+			},
+		})
+
+		res, report, err := ThreeWayMerge(base, local, remote)
+		require.NoError(t, err)
+		assert.Empty(t, report)
+		require.Len(t, res.Packages, 2)
+		assert.Equal(t, "db2", secretValue(t, res, "host"))
+		assert.Equal(t, "bar", secretValue(t, res, "password"))
+		assert.Equal(t, "", secretValue(t, res, "user"))
+		assert.Equal(t, "app/production/cache", res.Packages[1].Name)
+	})
+
+	t.Run("both modified", func(t *testing.T) {
+		local := threeWayFixture(map[string]string{"host": "db1", "password": "local", "user": "admin"})
+		remote := threeWayFixture(map[string]string{"host": "db1", "password": "remote", "user": "admin"})
+
+		_, report, err := ThreeWayMerge(base, local, remote)
+		assert.True(t, errors.Is(err, ErrMergeConflict))
+		assert.Equal(t, MergeReport{
+			{Type: "secret", Path: "app/production/database#password", Reason: ConflictBothModified, Local: "replace", Remote: "replace"},
+		}, report)
+
+		res, report, err := ThreeWayMerge(base, local, remote, WithMergeStrategy(MergeStrategyTheirs))
+		require.NoError(t, err)
+		assert.Equal(t, "remote", secretValue(t, res, "password"))
+		require.Len(t, report, 1)
+		assert.Equal(t, MergeStrategyTheirs, report[0].Resolution)
+	})
+
+	t.Run("deleted by remote", func(t *testing.T) {
+		local := threeWayFixture(map[string]string{"host": "db1", "password": "local", "user": "admin"})
+		remote := threeWayFixture(map[string]string{"host": "db1", "user": "admin"})
+
+		res, report, err := ThreeWayMerge(base, local, remote, WithMergeStrategy(MergeStrategyOurs))
+		require.NoError(t, err)
+		assert.Equal(t, "local", secretValue(t, res, "password"))
+		assert.Equal(t, MergeReport{
+			{Type: "secret", Path: "app/production/database#password", Reason: ConflictDeletedByRemote, Local: "replace", Remote: "remove", Resolution: MergeStrategyOurs},
+		}, report)
+	})
+
+	t.Run("package deleted by local", func(t *testing.T) {
+		local := &bundlev1.Bundle{Labels: map[string]string{"team": "red"}}
+		remote := threeWayFixture(map[string]string{"host": "db1", "password": "remote", "user": "admin"})
+
+		_, report, err := ThreeWayMerge(base, local, remote)
+		assert.True(t, errors.Is(err, ErrMergeConflict))
+		assert.Equal(t, MergeReport{
+			{Type: "package", Path: "app/production/database", Reason: ConflictDeletedByLocal, Local: "remove", Remote: "replace"},
+		}, report)
+	})
+
+	t.Run("both added", func(t *testing.T) {
+		local := threeWayFixture(map[string]string{"host": "db1", "password": "foo", "user": "admin"})
+		remote := threeWayFixture(map[string]string{"host": "db1", "password": "foo", "user": "admin"})
+		local.Labels["env"] = "prod"
+		remote.Labels["env"] = "staging"
+
+		res, report, err := ThreeWayMerge(base, local, remote, WithMergeStrategy(MergeStrategyOurs))
+		require.NoError(t, err)
+		assert.Equal(t, "prod", res.Labels["env"])
+		assert.Equal(t, MergeReport{
+			{Type: "label", Path: "env", Reason: ConflictBothAdded, Local: "add", Remote: "add", Resolution: MergeStrategyOurs},
+		}, report)
+	})
+}
@@ -0,0 +1,216 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func mergeFixture(version uint32, label string, kv map[string]string) *bundlev1.Bundle {
+	p := &bundlev1.Package{
+		Name:   "app/production/database",
+		Labels: map[string]string{"owner": label},
+		Secrets: &bundlev1.SecretChain{
+			Version: version,
+		},
+	}
+	for _, k := range []string{"host", "password", "user"} {
+		if v, ok := kv[k]; ok {
+			p.Secrets.Data = append(p.Secrets.Data, &bundlev1.KV{Key: k, Type: "string", Value: secret.MustPack(v)})
+		}
+	}
+
+	return &bundlev1.Bundle{
+		Labels:   map[string]string{"team": label},
+		Packages: []*bundlev1.Package{p},
+	}
+}
+
+func secretValue(t *testing.T, b *bundlev1.Bundle, key string) string {
+	t.Helper()
+
+	for _, kv := range b.Packages[0].Secrets.Data {
+		if kv.Key == key {
+			var out string
+			require.NoError(t, secret.Unpack(kv.Value, &out))
+			return out
+		}
+	}
+
+	return ""
+}
+
+func TestMerge(t *testing.T) {
+	ours := mergeFixture(2, "red", map[string]string{"host": "db1", "password": "foo"})
+	theirs := mergeFixture(1, "blue", map[string]string{"password": "bar", "user": "admin"})
+
+	t.Run("empty", func(t *testing.T) {
+		_, _, err := Merge(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, _, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy("unknown"))
+		assert.Error(t, err)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		_, _, err := Merge([]*bundlev1.Bundle{ours, theirs})
+		assert.True(t, errors.Is(err, ErrMergeConflict))
+	})
+
+	t.Run("ours", func(t *testing.T) {
+		res, conflicts, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyOurs))
+		require.NoError(t, err)
+		assert.Equal(t, "foo", secretValue(t, res, "password"))
+		assert.Equal(t, "db1", secretValue(t, res, "host"))
+		assert.Equal(t, "admin", secretValue(t, res, "user"))
+		assert.Equal(t, "red", res.Labels["team"])
+		assert.Equal(t, compare.OpLog{
+			{Operation: compare.Conflict, Type: "label", Path: "app/production/database#owner"},
+			{Operation: compare.Conflict, Type: "secret", Path: "app/production/database#password"},
+			{Operation: compare.Conflict, Type: "label", Path: "team"},
+		}, conflicts)
+	})
+
+	t.Run("theirs", func(t *testing.T) {
+		res, _, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyTheirs))
+		require.NoError(t, err)
+		assert.Equal(t, "bar", secretValue(t, res, "password"))
+		assert.Equal(t, "blue", res.Packages[0].Labels["owner"])
+		assert.Equal(t, "blue", res.Labels["team"])
+	})
+
+	t.Run("newest", func(t *testing.T) {
+		res, _, err := Merge([]*bundlev1.Bundle{theirs, ours}, WithMergeStrategy(MergeStrategyNewest))
+		require.NoError(t, err)
+		assert.Equal(t, "foo", secretValue(t, res, "password"))
+		assert.Equal(t, "red", res.Packages[0].Labels["owner"])
+
+		// Merged secrets are a new version of the replaced chain
+		assert.Equal(t, uint32(3), res.Packages[0].Secrets.Version)
+		assert.Equal(t, uint32(1), res.Packages[0].Secrets.PreviousVersion.GetValue())
+		require.Contains(t, res.Packages[0].Versions, uint32(1))
+		assert.Equal(t, uint32(3), res.Packages[0].Versions[1].NextVersion.GetValue())
+	})
+
+	t.Run("key override", func(t *testing.T) {
+		res, _, err := Merge([]*bundlev1.Bundle{ours, theirs},
+			WithMergeStrategy(MergeStrategyOurs),
+			WithKeyMergeStrategy("app/production/*", "password", MergeStrategyTheirs),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "bar", secretValue(t, res, "password"))
+		assert.Equal(t, "red", res.Packages[0].Labels["owner"])
+	})
+
+	t.Run("package override", func(t *testing.T) {
+		_, _, err := Merge([]*bundlev1.Bundle{ours, theirs},
+			WithMergeStrategy(MergeStrategyOurs),
+			WithPackageMergeStrategy("app/production/database", MergeStrategyFail),
+		)
+		assert.True(t, errors.Is(err, ErrMergeConflict))
+	})
+
+	t.Run("inputs are not modified", func(t *testing.T) {
+		assert.Len(t, ours.Packages[0].Secrets.Data, 2)
+		assert.Equal(t, "foo", secretValue(t, ours, "password"))
+	})
+}
+
+func TestMerge_History(t *testing.T) {
+	newBundle := func(values ...string) *bundlev1.Bundle {
+		p := &bundlev1.Package{
+			Name: "app/production/database",
+			Secrets: &bundlev1.SecretChain{
+				Data: []*bundlev1.KV{
+					{Key: "password", Type: "string", Value: secret.MustPack(values[0])},
+				},
+			},
+		}
+		for _, v := range values[1:] {
+			current, ok := proto.Clone(p.Secrets).(*bundlev1.SecretChain)
+			require.True(t, ok)
+			p.Secrets.Data = []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack(v)},
+			}
+			require.NoError(t, PushVersion(p, current))
+		}
+
+		return &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+	}
+
+	t.Run("adopted chain", func(t *testing.T) {
+		ours := newBundle("v0")
+		theirs := newBundle("v0", "v1", "v2")
+
+		res, _, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyNewest))
+		require.NoError(t, err)
+
+		p := res.Packages[0]
+		assert.Equal(t, "v2", secretValue(t, res, "password"))
+		assert.Equal(t, uint32(2), p.Secrets.Version)
+		assert.Equal(t, uint32(1), p.Secrets.PreviousVersion.GetValue())
+		assert.Len(t, p.Versions, 2)
+	})
+
+	t.Run("theirs history", func(t *testing.T) {
+		ours := newBundle("v0", "ours")
+		theirs := newBundle("v0", "v1", "theirs")
+
+		res, conflicts, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyTheirs))
+		require.NoError(t, err)
+
+		p := res.Packages[0]
+		assert.Equal(t, "theirs", secretValue(t, res, "password"))
+		assert.Equal(t, uint32(2), p.Secrets.Version)
+		assert.Equal(t, uint32(1), p.Secrets.PreviousVersion.GetValue())
+
+		// Diverging archived version is resolved with the package strategy
+		require.Len(t, p.Versions, 2)
+		var archived string
+		require.NoError(t, secret.Unpack(p.Versions[1].Data[0].Value, &archived))
+		assert.Equal(t, "v1", archived)
+		assert.Contains(t, conflicts, compare.DiffItem{Operation: compare.Conflict, Type: "version", Path: "app/production/database@1"})
+	})
+
+	t.Run("diverging versions", func(t *testing.T) {
+		ours := newBundle("v0", "ours")
+		theirs := newBundle("v0", "v1", "theirs")
+
+		res, _, err := Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyOurs), WithKeyMergeStrategy("*", "password", MergeStrategyTheirs))
+		require.NoError(t, err)
+
+		// Our replaced chain is kept
+		var archived string
+		require.NoError(t, secret.Unpack(res.Packages[0].Versions[1].Data[0].Value, &archived))
+		assert.Equal(t, "ours", archived)
+
+		_, _, err = Merge([]*bundlev1.Bundle{ours, theirs}, WithMergeStrategy(MergeStrategyFail), WithKeyMergeStrategy("*", "password", MergeStrategyTheirs))
+		assert.True(t, errors.Is(err, ErrMergeConflict))
+	})
+}
@@ -0,0 +1,286 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+)
+
+// ErrOpLogMismatch is raised in strict mode when an operation targets a
+// missing item or an item whose current value differs from the expected one.
+var ErrOpLogMismatch = errors.New("oplog target mismatch")
+
+// ErrOpLogMissingPrevious is raised in strict mode when a secret operation
+// doesn't record the previous value to check its target against.
+var ErrOpLogMissingPrevious = errors.New("oplog operation without previous value")
+
+type applyOptions struct {
+	strict         bool
+	disableHistory bool
+}
+
+// ApplyOption defines oplog application option.
+type ApplyOption func(*applyOptions)
+
+// WithStrictApply enables the strict mode, `remove` and `replace` operations
+// fail if their target is missing, if they don't record the previous value, or
+// if the target current value differs from the recorded previous one.
+func WithStrictApply() ApplyOption {
+	return func(o *applyOptions) {
+		o.strict = true
+	}
+}
+
+// WithoutApplyHistory disables the secret version history recording of
+// modified packages.
+func WithoutApplyHistory() ApplyOption {
+	return func(o *applyOptions) {
+		o.disableHistory = true
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// ApplyOpLog replays the given oplog operations on the given bundle.
+//
+// Operations are applied atomically, the bundle is not modified if an error
+// occurs.
+//
+//nolint:gocognit,gocyclo // To refactor
+func ApplyOpLog(b *bundlev1.Bundle, oplog compare.OpLog, opts ...ApplyOption) error {
+	// Check arguments
+	if b == nil {
+		return errors.New("unable to apply oplog on a nil bundle")
+	}
+
+	// Prepare options
+	dopts := &applyOptions{}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Work on a copy to keep the bundle untouched on error
+	res, ok := proto.Clone(b).(*bundlev1.Bundle)
+	if !ok {
+		return fmt.Errorf("the cloned bundle does not have a correct type: %T", res)
+	}
+
+	// Index packages
+	index := map[string]*bundlev1.Package{}
+	for _, p := range res.Packages {
+		if p == nil {
+			continue
+		}
+		index[p.Name] = p
+	}
+
+	// Keep original chains to record history
+	originals := map[*bundlev1.Package]*bundlev1.SecretChain{}
+	track := func(p *bundlev1.Package) error {
+		if _, ok := originals[p]; ok || p.Secrets == nil {
+			return nil
+		}
+		original, ok := proto.Clone(p.Secrets).(*bundlev1.SecretChain)
+		if !ok {
+			return fmt.Errorf("the cloned secret chain does not have a correct type: %T", original)
+		}
+		originals[p] = original
+		return nil
+	}
+
+	for i, op := range oplog {
+		if op.Redacted {
+			return fmt.Errorf("unable to apply operation %d: redacted values can't be applied", i)
+		}
+
+		switch op.Type {
+		case "package":
+			switch op.Operation {
+			case compare.Add:
+				if _, ok := index[op.Path]; ok {
+					continue
+				}
+				p := &bundlev1.Package{
+					Name: op.Path,
+					Secrets: &bundlev1.SecretChain{
+						Data: []*bundlev1.KV{},
+					},
+				}
+				res.Packages = append(res.Packages, p)
+				index[op.Path] = p
+			case compare.Remove:
+				if _, ok := index[op.Path]; !ok {
+					if dopts.strict {
+						return fmt.Errorf("unable to apply operation %d: package %q not found: %w", i, op.Path, ErrOpLogMismatch)
+					}
+					continue
+				}
+				delete(index, op.Path)
+			default:
+				return fmt.Errorf("unable to apply operation %d: unsupported package operation %q", i, op.Operation)
+			}
+
+		case "secret":
+			pathParts := strings.SplitN(op.Path, "#", 2)
+			if len(pathParts) != 2 || pathParts[0] == "" || pathParts[1] == "" {
+				return fmt.Errorf("unable to apply operation %d: invalid secret path %q", i, op.Path)
+			}
+			packageName, key := pathParts[0], pathParts[1]
+
+			p, ok := index[packageName]
+			if ok && p.Secrets != nil && p.Secrets.Locked != nil {
+				return fmt.Errorf("unable to apply operation %d: package %q is locked", i, packageName)
+			}
+
+			// Lookup secret
+			kvIdx := -1
+			if ok && p.Secrets != nil {
+				for idx, kv := range p.Secrets.Data {
+					if kv != nil && kv.Key == key {
+						kvIdx = idx
+						break
+					}
+				}
+			}
+
+			switch op.Operation {
+			case compare.Add, compare.Replace:
+				if op.Operation == compare.Replace && dopts.strict {
+					if err := checkOpLogTarget(p, kvIdx, op); err != nil {
+						return fmt.Errorf("unable to apply operation %d: %w", i, err)
+					}
+				}
+
+				// Pack secret value
+				payload, err := secret.Pack(op.Value)
+				if err != nil {
+					return fmt.Errorf("unable to pack secret value for %q / %q: %w", packageName, key, err)
+				}
+
+				// Create package if missing
+				if !ok {
+					p = &bundlev1.Package{
+						Name: packageName,
+					}
+					res.Packages = append(res.Packages, p)
+					index[packageName] = p
+				}
+				if err := track(p); err != nil {
+					return err
+				}
+				if p.Secrets == nil {
+					p.Secrets = &bundlev1.SecretChain{}
+				}
+
+				// Assign secret data
+				if kvIdx >= 0 {
+					p.Secrets.Data[kvIdx].Value = payload
+				} else {
+					p.Secrets.Data = append(p.Secrets.Data, &bundlev1.KV{
+						Key:   key,
+						Type:  "string",
+						Value: payload,
+					})
+				}
+			case compare.Remove:
+				if dopts.strict {
+					if err := checkOpLogTarget(p, kvIdx, op); err != nil {
+						return fmt.Errorf("unable to apply operation %d: %w", i, err)
+					}
+				}
+				if kvIdx < 0 {
+					continue
+				}
+				if err := track(p); err != nil {
+					return err
+				}
+				p.Secrets.Data = append(p.Secrets.Data[:kvIdx], p.Secrets.Data[kvIdx+1:]...)
+			default:
+				return fmt.Errorf("unable to apply operation %d: unsupported secret operation %q", i, op.Operation)
+			}
+
+		default:
+			return fmt.Errorf("unable to apply operation %d: unknown oplog type %q", i, op.Type)
+		}
+	}
+
+	// Rebuild package list, keeping original order
+	packages := make([]*bundlev1.Package, 0, len(index))
+	for _, p := range res.Packages {
+		if p == nil {
+			continue
+		}
+		if current, ok := index[p.Name]; !ok || current != p {
+			continue
+		}
+		packages = append(packages, p)
+	}
+
+	// Record version history of modified packages
+	if !dopts.disableHistory {
+		for _, p := range packages {
+			original, ok := originals[p]
+			if !ok || SameSecrets(original, p.Secrets) {
+				continue
+			}
+			if err := PushVersion(p, original); err != nil {
+				return fmt.Errorf("unable to record %q secret version: %w", p.Name, err)
+			}
+		}
+	}
+
+	// Assign result
+	b.Packages = packages
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func checkOpLogTarget(p *bundlev1.Package, kvIdx int, op compare.DiffItem) error {
+	if p == nil || kvIdx < 0 {
+		return fmt.Errorf("secret %q not found: %w", op.Path, ErrOpLogMismatch)
+	}
+
+	// The previous value is required to detect concurrent modifications
+	if op.Previous == nil {
+		return fmt.Errorf("secret %q operation must record its previous value: %w", op.Path, ErrOpLogMissingPrevious)
+	}
+
+	// Unpack current value
+	var current string
+	if err := secret.Unpack(p.Secrets.Data[kvIdx].Value, &current); err != nil {
+		return fmt.Errorf("unable to unpack %q current value: %w", op.Path, err)
+	}
+	if !security.SecureCompare([]byte(current), []byte(*op.Previous)) {
+		return fmt.Errorf("secret %q current value differs from the expected one: %w", op.Path, ErrOpLogMismatch)
+	}
+
+	// No error
+	return nil
+}
@@ -0,0 +1,159 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+)
+
+func TestApplyOpLog(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Error(t, ApplyOpLog(nil, compare.OpLog{}))
+	})
+
+	t.Run("roundtrip", func(t *testing.T) {
+		src := mergeFixture(1, "red", map[string]string{"host": "db1", "password": "foo", "user": "admin"})
+		dst := mergeFixture(1, "red", map[string]string{"host": "db2", "password": "foo"})
+		dst.Packages = append(dst.Packages, &bundlev1.Package{
+			Name:    "app/production/cache",
+			Secrets: mergeFixture(1, "", map[string]string{"password": "bar"}).Packages[0].Secrets,
+		})
+
+		oplog, err := compare.Diff(src, dst, compare.WithPreviousValues())
+		require.NoError(t, err)
+
+		require.NoError(t, ApplyOpLog(src, oplog, WithStrictApply()))
+
+		after, err := compare.Diff(src, dst)
+		require.NoError(t, err)
+		assert.Empty(t, after)
+
+		// History recorded
+		assert.Equal(t, uint32(2), src.Packages[0].Secrets.Version)
+		assert.Contains(t, src.Packages[0].Versions, uint32(1))
+	})
+
+	t.Run("package removal", func(t *testing.T) {
+		b := mergeFixture(1, "red", map[string]string{"host": "db1"})
+		require.NoError(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Remove, Type: "package", Path: "app/production/database"},
+		}))
+		assert.Empty(t, b.Packages)
+	})
+
+	t.Run("without history", func(t *testing.T) {
+		b := mergeFixture(1, "red", map[string]string{"host": "db1"})
+		require.NoError(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#host", Value: "db2"},
+		}, WithoutApplyHistory()))
+		assert.Equal(t, "db2", secretValue(t, b, "host"))
+		assert.Empty(t, b.Packages[0].Versions)
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		b := mergeFixture(1, "red", map[string]string{"host": "db1"})
+		oplog := compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#host", Value: "db2", Previous: stringPtr("db1")},
+			{Operation: compare.Remove, Type: "secret", Path: "app/production/database#password", Previous: stringPtr("foo")},
+		}
+
+		// Lenient mode
+		require.NoError(t, ApplyOpLog(b, oplog))
+		assert.Equal(t, "db2", secretValue(t, b, "host"))
+
+		// Strict mode
+		b = mergeFixture(1, "red", map[string]string{"host": "db1"})
+		err := ApplyOpLog(b, oplog, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMismatch))
+		assert.Equal(t, "db1", secretValue(t, b, "host"), "bundle must not be modified on error")
+	})
+
+	t.Run("missing previous value", func(t *testing.T) {
+		oplog := compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#host", Value: "db2"},
+		}
+
+		// Lenient mode
+		b := mergeFixture(1, "red", map[string]string{"host": "db1", "password": "foo"})
+		require.NoError(t, ApplyOpLog(b, oplog))
+		assert.Equal(t, "db2", secretValue(t, b, "host"))
+
+		// Strict mode
+		b = mergeFixture(1, "red", map[string]string{"host": "db1", "password": "foo"})
+		err := ApplyOpLog(b, oplog, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMissingPrevious))
+		assert.Equal(t, "db1", secretValue(t, b, "host"), "bundle must not be modified on error")
+
+		err = ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Remove, Type: "secret", Path: "app/production/database#password"},
+		}, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMissingPrevious))
+		assert.Equal(t, "foo", secretValue(t, b, "password"))
+	})
+
+	t.Run("previous value mismatch", func(t *testing.T) {
+		b := mergeFixture(1, "red", map[string]string{"host": "db1"})
+		err := ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#host", Value: "db3", Previous: stringPtr("db2")},
+		}, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMismatch))
+	})
+
+	t.Run("empty previous value", func(t *testing.T) {
+		// An empty previous value is asserted
+		b := mergeFixture(1, "red", map[string]string{"host": "db1", "password": ""})
+		err := ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#host", Value: "db2", Previous: stringPtr("")},
+		}, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMismatch))
+		require.NoError(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Replace, Type: "secret", Path: "app/production/database#password", Value: "foo", Previous: stringPtr("")},
+		}, WithStrictApply()))
+		assert.Equal(t, "foo", secretValue(t, b, "password"))
+
+		// Removals are checked against the previous value
+		err = ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Remove, Type: "secret", Path: "app/production/database#host", Previous: stringPtr("")},
+		}, WithStrictApply())
+		assert.True(t, errors.Is(err, ErrOpLogMismatch))
+	})
+
+	t.Run("unsupported operation", func(t *testing.T) {
+		b := mergeFixture(1, "red", map[string]string{"host": "db1"})
+		assert.Error(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Conflict, Type: "secret", Path: "app/production/database#host"},
+		}))
+		assert.Error(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Add, Type: "secret", Path: "app/production/database"},
+		}))
+		assert.Error(t, ApplyOpLog(b, compare.OpLog{
+			{Operation: compare.Add, Type: "label", Path: "app/production/database"},
+		}))
+	})
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/jmespath/go-jmespath"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/bundle/selector"
 	"github.com/zntrio/harp/v2/pkg/template/engine"
@@ -504,7 +505,7 @@ func addSecret(input []*bundlev1.KV, newSecrets map[string]string) ([]*bundlev1.
 
 		out = append(out, &bundlev1.KV{
 			Key:   k,
-			Type:  fmt.Sprintf("%T", newSecrets[k]),
+			Type:  kind.TypeOf(k, newSecrets[k]),
 			Value: payload,
 		})
 	}
@@ -539,10 +540,16 @@ func updateSecret(input []*bundlev1.KV, newSecrets map[string]string) ([]*bundle
 			return nil, fmt.Errorf("unable to pack secret: %w", err)
 		}
 
+		// Keep the declared secret type
+		typ := s.Type
+		if typ == "" {
+			typ = kind.TypeOf(s.Key, v)
+		}
+
 		// Append to result
 		out = append(out, &bundlev1.KV{
 			Key:   s.Key,
-			Type:  fmt.Sprintf("%T", v),
+			Type:  typ,
 			Value: payload,
 		})
 	}
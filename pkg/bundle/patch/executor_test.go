@@ -22,8 +22,12 @@ import (
 	"testing"
 
 	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 )
 
 func Test_executeRule_Fuzz(t *testing.T) {
@@ -228,3 +232,59 @@ func Test_applySecretKVPatch_Fuzz(t *testing.T) {
 		applySecretKVPatch(file.Packages[0].Secrets.Data, spec, values)
 	}
 }
+
+func Test_applySecretKVPatch_Types(t *testing.T) {
+	newKV := func() []*bundlev1.KV {
+		return []*bundlev1.KV{
+			{Key: "password", Type: kind.Password, Value: secret.MustPack("old-password")},
+			{Key: "certificate", Type: kind.Certificate, Value: secret.MustPack("old-certificate")},
+		}
+	}
+	typesOf := func(kv []*bundlev1.KV) map[string]string {
+		types := map[string]string{}
+		for _, s := range kv {
+			types[s.Key] = s.Type
+		}
+		return types
+	}
+
+	t.Run("add", func(t *testing.T) {
+		out, err := applySecretKVPatch(newKV(), &bundlev1.PatchOperation{
+			Add: map[string]string{
+				"admin_password": "new-password",
+				"user":           "admin",
+			},
+		}, map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"password":       kind.Password,
+			"certificate":    kind.Certificate,
+			"admin_password": kind.Password,
+			"user":           "string",
+		}, typesOf(out))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		out, err := applySecretKVPatch(newKV(), &bundlev1.PatchOperation{
+			Update: map[string]string{
+				"password":    "new-password",
+				"certificate": "-----BEGIN CERTIFICATE-----",
+			},
+		}, map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"password":    kind.Password,
+			"certificate": kind.Certificate,
+		}, typesOf(out))
+
+		// Updated values are still validated against their declared type
+		for _, s := range out {
+			if s.Key != "certificate" {
+				continue
+			}
+			var value interface{}
+			require.NoError(t, secret.Unpack(s.Value, &value))
+			assert.Error(t, kind.Validate(s.Type, value))
+		}
+	})
+}
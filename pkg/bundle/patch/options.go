@@ -22,6 +22,7 @@ type options struct {
 	stopAtRuleIndex   int
 	ignoreRuleIDs     []string
 	ignoreRuleIndexes []int
+	disableHistory    bool
 }
 
 type OptionFunc func(o *options)
@@ -55,3 +56,11 @@ func WithIgnoreRuleIndexes(values ...int) OptionFunc {
 		o.ignoreRuleIndexes = values
 	}
 }
+
+// WithoutVersionHistory disables the secret version history recording of
+// modified packages.
+func WithoutVersionHistory() OptionFunc {
+	return func(o *options) {
+		o.disableHistory = true
+	}
+}
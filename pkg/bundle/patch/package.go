@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 
@@ -112,6 +113,7 @@ func Apply(ctx context.Context, spec *bundlev1.Patch, b *bundlev1.Bundle, values
 		stopAtRuleIndex:   -1,
 		ignoreRuleIDs:     []string{},
 		ignoreRuleIndexes: []int{},
+		disableHistory:    false,
 	}
 
 	// Apply functions
@@ -119,6 +121,9 @@ func Apply(ctx context.Context, spec *bundlev1.Patch, b *bundlev1.Bundle, values
 		opt(dopts)
 	}
 
+	// Keep track of created packages
+	created := map[*bundlev1.Package]struct{}{}
+
 	// Process all creation rule first
 	for i, r := range spec.Spec.Rules {
 		// Ignore nil rule
@@ -149,6 +154,20 @@ func Apply(ctx context.Context, spec *bundlev1.Patch, b *bundlev1.Bundle, values
 
 		// Add created package
 		bCopy.Packages = append(bCopy.Packages, p)
+		created[p] = struct{}{}
+	}
+
+	// Keep track of original secret chains
+	originals := map[*bundlev1.Package]*bundlev1.SecretChain{}
+	for _, p := range bCopy.Packages {
+		if p == nil || p.Secrets == nil {
+			continue
+		}
+		chain, ok := proto.Clone(p.Secrets).(*bundlev1.SecretChain)
+		if !ok {
+			return nil, fmt.Errorf("the cloned secret chain does not have the expected type: %T", chain)
+		}
+		originals[p] = chain
 	}
 
 	for ri, r := range spec.Spec.Rules {
@@ -187,6 +206,25 @@ func Apply(ctx context.Context, spec *bundlev1.Patch, b *bundlev1.Bundle, values
 		}
 	}
 
+	// Record secret version history and rotation date
+	now := time.Now().UTC()
+	for _, p := range bCopy.Packages {
+		original, ok := originals[p]
+		changed := ok && !bundle.SameSecrets(original, p.Secrets)
+		if _, isCreated := created[p]; !changed && !isCreated {
+			continue
+		}
+		if err := bundle.MarkRotated(p, now); err != nil {
+			return nil, fmt.Errorf("unable to record %q rotation date: %w", p.Name, err)
+		}
+		if !changed || dopts.disableHistory {
+			continue
+		}
+		if err := bundle.PushVersion(p, original); err != nil {
+			return nil, fmt.Errorf("unable to record %q secret version: %w", p.Name, err)
+		}
+	}
+
 	// Sort packages
 	sort.SliceStable(bCopy.Packages, func(i, j int) bool {
 		return bCopy.Packages[i].Name < bCopy.Packages[j].Name
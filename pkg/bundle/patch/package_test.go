@@ -22,12 +22,15 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
 )
 
 var (
@@ -48,6 +51,11 @@ var (
 		cmpopts.IgnoreUnexported(bundlev1.Package{}),
 		cmpopts.IgnoreUnexported(bundlev1.SecretChain{}),
 		cmpopts.IgnoreUnexported(bundlev1.KV{}),
+		cmpopts.IgnoreUnexported(wrappers.UInt32Value{}),
+		// Rotation date depends on the execution time
+		cmpopts.IgnoreMapEntries(func(k, _ string) bool {
+			return k == bundle.LastRotatedAnnotation
+		}),
 		opt,
 	}
 )
@@ -428,7 +436,19 @@ func TestApply(t *testing.T) {
 							"patched":        "true",
 						},
 						Secrets: &bundlev1.SecretChain{
-							Data: []*bundlev1.KV{},
+							Version:         1,
+							Data:            []*bundlev1.KV{},
+							PreviousVersion: &wrappers.UInt32Value{Value: 0},
+						},
+						Versions: map[uint32]*bundlev1.SecretChain{
+							0: {
+								Data: []*bundlev1.KV{
+									{
+										Key: "USER",
+									},
+								},
+								NextVersion: &wrappers.UInt32Value{Value: 1},
+							},
 						},
 					},
 					{
@@ -693,3 +713,49 @@ func TestApply(t *testing.T) {
 		})
 	}
 }
+
+func TestApply_Rotation(t *testing.T) {
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "application/to-be-removed",
+				Annotations: map[string]string{
+					bundle.RotationIntervalAnnotation: "30d",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{
+							Key: "USER",
+						},
+					},
+				},
+			},
+			{
+				Name: "secrets/application/component-2.yaml",
+			},
+		},
+	}
+
+	got, err := Apply(context.Background(), mustLoadPatch("../../../test/fixtures/patch/valid/remove-secrets.yaml"), b, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Patch.Apply() error = %v", err)
+	}
+
+	// Modified package
+	lastRotated, err := time.Parse(time.RFC3339, got.Packages[0].Annotations[bundle.LastRotatedAnnotation])
+	if err != nil {
+		t.Fatalf("invalid last rotation date: %v", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, got.Packages[0].Annotations[bundle.ExpiresAtAnnotation])
+	if err != nil {
+		t.Fatalf("invalid expiration date: %v", err)
+	}
+	if !expiresAt.Equal(lastRotated.Add(30 * 24 * time.Hour)) {
+		t.Errorf("invalid expiration date, got %v", expiresAt)
+	}
+
+	// Unchanged package
+	if _, ok := got.Packages[1].Annotations[bundle.LastRotatedAnnotation]; ok {
+		t.Error("unchanged package must not be marked as rotated")
+	}
+}
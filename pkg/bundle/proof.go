@@ -0,0 +1,293 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.com/NebulousLabs/merkletree"
+	"golang.org/x/crypto/blake2b"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+// InclusionProof describes a merkle inclusion proof of a secret value in a
+// bundle. It can be verified against a published bundle merkle tree root
+// without any other bundle content.
+type InclusionProof struct {
+	Package   string   `json:"package"`
+	Version   uint32   `json:"version"`
+	Key       string   `json:"key"`
+	ValueHash string   `json:"value_hash"`
+	Root      string   `json:"root"`
+	Index     uint64   `json:"index"`
+	LeafCount uint64   `json:"leaf_count"`
+	Proof     []string `json:"proof"`
+}
+
+// Prove builds the inclusion proof of the active secret value identified by
+// the given package name and key.
+//
+// The proof value hash is an unsalted BLAKE2b-512 hash of the packed secret
+// value. It doesn't hide low entropy values (passwords, PIN codes, enumerable
+// identifiers), which can be recovered by an offline brute force attack, so
+// proofs must be shared with the same care as the proven secret.
+func Prove(b *bundlev1.Bundle, packageName, key string) (*InclusionProof, error) {
+	// Check arguments
+	if b == nil {
+		return nil, errors.New("unable to process nil bundle")
+	}
+	if packageName == "" {
+		return nil, errors.New("unable to process with blank package name")
+	}
+	if key == "" {
+		return nil, errors.New("unable to process with blank secret key")
+	}
+
+	// Lookup package
+	var found *bundlev1.Package
+	for _, p := range b.Packages {
+		if p != nil && strings.EqualFold(p.Name, packageName) {
+			found = p
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unable to lookup package %q", packageName)
+	}
+	if found.Secrets == nil {
+		return nil, fmt.Errorf("package %q has no active secret version", found.Name)
+	}
+	if found.Secrets.Locked != nil {
+		return nil, fmt.Errorf("package %q is locked, unable to prove secret inclusion", found.Name)
+	}
+
+	// Lookup leaf index
+	leaves, _ := treeLeaves(b)
+	index := -1
+	for i, l := range leaves {
+		if l.packageName == found.Name && l.version == found.Secrets.Version && l.key == key {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("unable to lookup secret %q in package %q", key, found.Name)
+	}
+
+	// Build the tree
+	tree, err := newTree(leaves, uint64(index))
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate proof
+	root, proofSet, proofIndex, leafCount := tree.Prove()
+	if len(proofSet) == 0 {
+		return nil, fmt.Errorf("unable to generate inclusion proof of %q - %q", found.Name, key)
+	}
+
+	// Prepare result
+	res := &InclusionProof{
+		Package:   found.Name,
+		Version:   found.Secrets.Version,
+		Key:       key,
+		ValueHash: hex.EncodeToString(leaves[index].valueHash),
+		Root:      hex.EncodeToString(root),
+		Index:     proofIndex,
+		LeafCount: leafCount,
+		Proof:     make([]string, 0, len(proofSet)-1),
+	}
+
+	// First proof item is the leaf itself
+	for _, h := range proofSet[1:] {
+		res.Proof = append(res.Proof, hex.EncodeToString(h))
+	}
+
+	// No error
+	return res, nil
+}
+
+// Verify checks that the proof is valid for the given bundle merkle tree root.
+func (p *InclusionProof) Verify(root []byte) error {
+	// Check arguments
+	if p == nil {
+		return errors.New("unable to verify nil proof")
+	}
+	if len(root) == 0 {
+		return errors.New("unable to verify proof with blank root")
+	}
+
+	// Decode value hash
+	valueHash, err := hex.DecodeString(p.ValueHash)
+	if err != nil {
+		return fmt.Errorf("unable to decode proof value hash: %w", err)
+	}
+
+	// Rebuild proof set
+	leaf := &treeLeaf{
+		packageName: p.Package,
+		version:     p.Version,
+		key:         p.Key,
+		valueHash:   valueHash,
+	}
+	proofSet := [][]byte{[]byte(leaf.String())}
+	for i, item := range p.Proof {
+		h, errDecode := hex.DecodeString(item)
+		if errDecode != nil {
+			return fmt.Errorf("unable to decode proof item %d: %w", i, errDecode)
+		}
+		proofSet = append(proofSet, h)
+	}
+
+	// Initialize hash function
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return fmt.Errorf("unable to initialize hash function for merkle tree")
+	}
+
+	// Verify proof
+	if !merkletree.VerifyProof(h, root, proofSet, p.Index, p.LeafCount) {
+		return fmt.Errorf("invalid inclusion proof of %q - %q for the given root", p.Package, p.Key)
+	}
+
+	// No error
+	return nil
+}
+
+// VerifyValue checks that the given secret value matches the proven value
+// hash. The value is packed before hashing, so it must be provided with the
+// same type as the one stored in the bundle.
+func (p *InclusionProof) VerifyValue(value interface{}) error {
+	// Check arguments
+	if p == nil {
+		return errors.New("unable to verify nil proof")
+	}
+
+	// Pack value
+	packed, err := secret.Pack(value)
+	if err != nil {
+		return fmt.Errorf("unable to pack secret value: %w", err)
+	}
+
+	// Decode value hash
+	expected, err := hex.DecodeString(p.ValueHash)
+	if err != nil {
+		return fmt.Errorf("unable to decode proof value hash: %w", err)
+	}
+
+	// Compare hashes
+	h := blake2b.Sum512(packed)
+	if subtle.ConstantTimeCompare(expected, h[:]) != 1 {
+		return fmt.Errorf("secret value of %q - %q does not match the proof", p.Package, p.Key)
+	}
+
+	// No error
+	return nil
+}
+
+// DecodeRoot decodes a merkle tree root given as an hexadecimal or base64
+// encoded string.
+func DecodeRoot(root string) ([]byte, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return nil, errors.New("unable to decode blank root")
+	}
+
+	// Try hexadecimal encoding first
+	if raw, err := hex.DecodeString(root); err == nil {
+		return raw, nil
+	}
+
+	// Fallback to base64 encoding (protojson)
+	raw, err := base64.StdEncoding.DecodeString(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode root, must be hex or base64 encoded")
+	}
+
+	return raw, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// CorruptionError is raised when the bundle content doesn't match its merkle
+// tree root.
+type CorruptionError struct {
+	root   []byte
+	leaves []*treeLeaf
+}
+
+// Error returns the error message.
+func (e *CorruptionError) Error() string {
+	return "invalid merkle tree root, bundle is corrupted (use inclusion proofs to locate diverging secrets)"
+}
+
+// Locate returns the secret paths diverging from the given inclusion proofs.
+//
+// The bundle doesn't store any leaf index, so divergence is localized by
+// recomputing the leaves from the loaded content and comparing them to the
+// proven leaves. Only proofs valid for the bundle merkle tree root are
+// considered, a proof of a removed secret is reported as diverging.
+func (e *CorruptionError) Locate(proofs ...*InclusionProof) []string {
+	// Index computed leaves
+	computed := map[string][]byte{}
+	for _, l := range e.leaves {
+		computed[leafPath(l.packageName, l.version, l.key)] = l.valueHash
+	}
+
+	paths := map[string]struct{}{}
+	for _, p := range proofs {
+		// Ignore proofs not issued for the expected root
+		if p == nil || p.Verify(e.root) != nil {
+			continue
+		}
+
+		// Decode proven value hash
+		expected, err := hex.DecodeString(p.ValueHash)
+		if err != nil {
+			continue
+		}
+
+		// Compare with loaded content
+		current, ok := computed[leafPath(p.Package, p.Version, p.Key)]
+		if !ok || subtle.ConstantTimeCompare(expected, current) != 1 {
+			paths[fmt.Sprintf("%s#%s", p.Package, p.Key)] = struct{}{}
+		}
+	}
+
+	// Sort them
+	res := make([]string, 0, len(paths))
+	for p := range paths {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// leafPath returns the leaf lookup key.
+func leafPath(packageName string, version uint32, key string) string {
+	return fmt.Sprintf("%s:%d:%s", packageName, version, key)
+}
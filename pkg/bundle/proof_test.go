@@ -0,0 +1,173 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func proofFixture() *bundlev1.Bundle {
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "user", Type: "string", Value: secret.MustPack("admin")},
+						{Key: "password", Type: "string", Value: secret.MustPack("secret")},
+					},
+				},
+			},
+			{
+				Name: "app/production/cache",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("other")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestProve(t *testing.T) {
+	b := proofFixture()
+
+	tree, _, err := Tree(b)
+	require.NoError(t, err)
+	root := tree.Root()
+
+	proof, err := Prove(b, "app/production/database", "password")
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(root), proof.Root)
+	assert.Equal(t, uint64(3), proof.LeafCount)
+
+	// Valid proof
+	assert.NoError(t, proof.Verify(root))
+	assert.NoError(t, proof.VerifyValue("secret"))
+
+	// Invalid value
+	assert.Error(t, proof.VerifyValue("guess"))
+
+	// Invalid root
+	other := proofFixture()
+	other.Packages[1].Secrets.Data[0].Value = secret.MustPack("changed")
+	otherTree, _, err := Tree(other)
+	require.NoError(t, err)
+	assert.Error(t, proof.Verify(otherTree.Root()))
+
+	// Forged value hash
+	forged := *proof
+	forged.ValueHash = proof.ValueHash[2:] + "00"
+	assert.Error(t, forged.Verify(root))
+
+	// Invalid arguments
+	_, err = Prove(nil, "app/production/database", "password")
+	assert.Error(t, err)
+	_, err = Prove(b, "app/production/unknown", "password")
+	assert.Error(t, err)
+	_, err = Prove(b, "app/production/database", "unknown")
+	assert.Error(t, err)
+}
+
+func TestDecodeRoot(t *testing.T) {
+	raw, err := DecodeRoot("0102")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, raw)
+
+	raw, err = DecodeRoot("AQI=")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, raw)
+
+	_, err = DecodeRoot("")
+	assert.Error(t, err)
+	_, err = DecodeRoot("!!")
+	assert.Error(t, err)
+}
+
+func TestLoad_Corruption(t *testing.T) {
+	var buf bytes.Buffer
+	original := proofFixture()
+	require.NoError(t, Dump(&buf, original))
+
+	// Dump doesn't persist nor attach any merkle index
+	assert.NotContains(t, original.Annotations, bundleMerkleIndexKey)
+	loaded, err := Load(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.NotContains(t, loaded.Annotations, bundleMerkleIndexKey)
+
+	// Publish proofs of every secret
+	proofs := []*InclusionProof{}
+	for _, p := range loaded.Packages {
+		for _, s := range p.Secrets.Data {
+			proof, errProof := Prove(loaded, p.Name, s.Key)
+			require.NoError(t, errProof)
+			proofs = append(proofs, proof)
+		}
+	}
+
+	// Tamper a secret value
+	tampered := &bundlev1.Bundle{}
+	require.NoError(t, proto.Unmarshal(buf.Bytes(), tampered))
+	for _, p := range tampered.Packages {
+		if p.Name == "app/production/database" {
+			p.Secrets.Data[0].Value = secret.MustPack("tampered")
+		}
+	}
+	payload, err := proto.Marshal(tampered)
+	require.NoError(t, err)
+
+	_, err = Load(bytes.NewReader(payload))
+	require.Error(t, err)
+
+	var corruption *CorruptionError
+	require.True(t, errors.As(err, &corruption))
+	assert.Empty(t, corruption.Locate())
+	assert.Equal(t, []string{"app/production/database#" + tampered.Packages[1].Secrets.Data[0].Key}, corruption.Locate(proofs...))
+
+	// Proofs issued for another root are ignored
+	foreign := *proofs[0]
+	foreign.Root = "00"
+	foreign.Proof = []string{"00"}
+	assert.Empty(t, corruption.Locate(&foreign, nil))
+
+	// Remove a package
+	tampered = &bundlev1.Bundle{}
+	require.NoError(t, proto.Unmarshal(buf.Bytes(), tampered))
+	tampered.Packages = tampered.Packages[1:]
+	payload, err = proto.Marshal(tampered)
+	require.NoError(t, err)
+
+	_, err = Load(bytes.NewReader(payload))
+	require.True(t, errors.As(err, &corruption))
+	expected := []string{}
+	for _, s := range loaded.Packages[0].Secrets.Data {
+		expected = append(expected, "app/production/cache#"+s.Key)
+	}
+	assert.ElementsMatch(t, expected, corruption.Locate(proofs...))
+}
@@ -0,0 +1,229 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+)
+
+// Rekey decrypts all encrypted packages with one of the given transformers
+// and encrypts them again with the new transformer, without exposing
+// plaintext values outside of the process memory. Clear-text packages are left
+// untouched.
+//
+// If the new transformer and the only old transformer support key rewrapping
+// with the same key identifier, the encrypted values are rewrapped in place.
+//
+// The names of the rotated packages are returned.
+func Rekey(ctx context.Context, b *bundlev1.Bundle, oldTransformers []value.Transformer, newTransformer value.Transformer) ([]string, error) {
+	// Check arguments
+	if b == nil {
+		return nil, fmt.Errorf("unable to process nil bundle")
+	}
+	if len(oldTransformers) == 0 {
+		return nil, fmt.Errorf("unable to process empty old transformer list")
+	}
+	if types.IsNil(newTransformer) {
+		return nil, fmt.Errorf("unable to process nil new transformer")
+	}
+
+	rotated := []string{}
+	for _, p := range b.Packages {
+		if !isEncrypted(p) {
+			continue
+		}
+
+		if err := rekeyPackage(ctx, p, oldTransformers, newTransformer); err != nil {
+			return nil, err
+		}
+		rotated = append(rotated, p.Name)
+	}
+
+	// No error
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// PartialRekey rotates the encryption key of the packages annotated with a key
+// alias, using the alias to resolve the old and the new transformers.
+//
+// The names of the rotated packages are returned.
+func PartialRekey(ctx context.Context, b *bundlev1.Bundle, oldTransformerMap, newTransformerMap map[string]value.Transformer, skipUnresolved bool) ([]string, error) {
+	// Check arguments
+	if b == nil {
+		return nil, fmt.Errorf("unable to process nil bundle")
+	}
+	if oldTransformerMap == nil || newTransformerMap == nil {
+		return nil, fmt.Errorf("unable to process nil transformer map")
+	}
+
+	rotated := []string{}
+	for _, p := range b.Packages {
+		// Check annotation usage
+		keyAlias, hasKeyAlias := p.Annotations[packageEncryptionAnnotation]
+		if !hasKeyAlias || !isEncrypted(p) {
+			// Skip package processing
+			continue
+		}
+
+		// Resolve key aliases
+		oldTransformer, hasOld := oldTransformerMap[keyAlias]
+		newTransformer, hasNew := newTransformerMap[keyAlias]
+		if !hasOld || !hasNew {
+			if skipUnresolved {
+				// Skip unresolved transformer alias.
+				continue
+			}
+			return nil, fmt.Errorf("package encryption annotation found, but no old and new key for %q alias provided", keyAlias)
+		}
+		if types.IsNil(oldTransformer) || types.IsNil(newTransformer) {
+			return nil, fmt.Errorf("key alias %q refers to a nil transformer", keyAlias)
+		}
+
+		if err := rekeyPackage(ctx, p, []value.Transformer{oldTransformer}, newTransformer); err != nil {
+			return nil, err
+		}
+		rotated = append(rotated, p.Name)
+	}
+
+	// No error
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func rekeyPackage(ctx context.Context, p *bundlev1.Package, oldTransformers []value.Transformer, newTransformer value.Transformer) error {
+	chains := append([]*bundlev1.SecretChain{p.Secrets}, History(p)...)
+
+	// Rewrap the encryption key when old and new keys are the same
+	if rw, ok := sameKeyRewrapper(oldTransformers, newTransformer); ok {
+		for _, chain := range chains {
+			if err := rewrapChain(ctx, chain, rw); err != nil {
+				return fmt.Errorf("unable to rewrap %q: %w", p.Name, err)
+			}
+		}
+		return nil
+	}
+
+	// Decrypt in memory
+	for _, chain := range chains {
+		if err := unlock(ctx, p.Name, chain, oldTransformers, false); err != nil {
+			return fmt.Errorf("unable to decrypt %q: %w", p.Name, err)
+		}
+	}
+
+	// Encrypt with the new key
+	if err := lockPackage(ctx, p, newTransformer, false); err != nil {
+		return fmt.Errorf("unable to encrypt %q: %w", p.Name, err)
+	}
+
+	// No error
+	return nil
+}
+
+// sameKeyRewrapper returns the new transformer rewrapping contract when the
+// only old transformer uses the same key encryption key.
+func sameKeyRewrapper(oldTransformers []value.Transformer, newTransformer value.Transformer) (value.Rewrapper, bool) {
+	if len(oldTransformers) != 1 {
+		return nil, false
+	}
+
+	newRewrapper, ok := newTransformer.(value.Rewrapper)
+	if !ok {
+		return nil, false
+	}
+	oldRewrapper, ok := oldTransformers[0].(value.Rewrapper)
+	if !ok {
+		return nil, false
+	}
+
+	keyID := newRewrapper.KeyID()
+	if keyID == "" || keyID != oldRewrapper.KeyID() {
+		return nil, false
+	}
+
+	return newRewrapper, true
+}
+
+func rewrapChain(ctx context.Context, chain *bundlev1.SecretChain, rw value.Rewrapper) error {
+	if chain == nil {
+		return nil
+	}
+
+	// Rewrap locked chain
+	if chain.Locked != nil {
+		out, err := rw.Rewrap(ctx, chain.Locked.Value)
+		if err != nil {
+			return fmt.Errorf("unable to rewrap locked secrets: %w", err)
+		}
+		chain.Locked.Value = out
+		return nil
+	}
+
+	// Rewrap values encrypted in place
+	for _, kv := range chain.Data {
+		if kv == nil {
+			continue
+		}
+
+		ciphertext, ok := encryptedValue(kv)
+		if !ok {
+			continue
+		}
+
+		out, err := rw.Rewrap(ctx, ciphertext)
+		if err != nil {
+			return fmt.Errorf("unable to rewrap %q: %w", kv.Key, err)
+		}
+
+		packed, err := packEncryptedValue(out)
+		if err != nil {
+			return fmt.Errorf("unable to pack encrypted value of %q: %w", kv.Key, err)
+		}
+		kv.Value = packed
+	}
+
+	// No error
+	return nil
+}
+
+// isEncrypted returns true if one of the package secret chains is locked or
+// contains encrypted values.
+func isEncrypted(p *bundlev1.Package) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, chain := range append([]*bundlev1.SecretChain{p.Secrets}, History(p)...) {
+		if chain == nil {
+			continue
+		}
+		if chain.Locked != nil || hasEncryptedValues(chain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,186 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+)
+
+type rewrappingTransformer struct {
+	value.Transformer
+	keyID     string
+	rewrapped int
+}
+
+func (t *rewrappingTransformer) KeyID() string {
+	return t.keyID
+}
+
+func (t *rewrappingTransformer) Rewrap(_ context.Context, input []byte) ([]byte, error) {
+	t.rewrapped++
+	return input, nil
+}
+
+func rekeyBundle() *bundlev1.Bundle {
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Annotations: map[string]string{
+					packageEncryptionAnnotation: "production",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("production")},
+					},
+				},
+			},
+			{
+				Name: "app/staging/database",
+				Annotations: map[string]string{
+					packageEncryptionAnnotation: "staging",
+					packageEncryptionMode:       EncryptionModeKey,
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("staging")},
+					},
+				},
+			},
+			{
+				Name: "app/production/clear",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "user", Type: "string", Value: secret.MustPack("admin")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRekey(t *testing.T) {
+	ctx := context.Background()
+	oldKey := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	newKey := encryption.Must(encryption.FromKey("aes-gcm:yMjsQzwRVVVPw3rlmj-Bxg=="))
+
+	b := rekeyBundle()
+	require.NoError(t, PartialLock(ctx, b, map[string]value.Transformer{"production": oldKey, "staging": oldKey}, false))
+
+	rotated, err := Rekey(ctx, b, []value.Transformer{oldKey}, newKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/production/database", "app/staging/database"}, rotated)
+
+	// Old key can't decrypt anymore
+	var buf bytes.Buffer
+	require.NoError(t, Dump(&buf, b))
+	clone, err := Load(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Error(t, UnLock(ctx, clone, []value.Transformer{oldKey}, false))
+
+	// New key restores all values
+	require.NoError(t, UnLock(ctx, b, []value.Transformer{newKey}, false))
+	secrets, err := Read(b, "app/staging/database")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", secrets["password"])
+
+	// Wrong old key
+	b = rekeyBundle()
+	require.NoError(t, Lock(ctx, b, oldKey))
+	_, err = Rekey(ctx, b, []value.Transformer{newKey}, newKey)
+	assert.Error(t, err)
+
+	// Invalid arguments
+	_, err = Rekey(ctx, nil, []value.Transformer{oldKey}, newKey)
+	assert.Error(t, err)
+	_, err = Rekey(ctx, b, nil, newKey)
+	assert.Error(t, err)
+	_, err = Rekey(ctx, b, []value.Transformer{oldKey}, nil)
+	assert.Error(t, err)
+}
+
+func TestRekey_Rewrap(t *testing.T) {
+	ctx := context.Background()
+	newTransformer := func(keyID string) *rewrappingTransformer {
+		return &rewrappingTransformer{
+			Transformer: encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg==")),
+			keyID:       keyID,
+		}
+	}
+
+	// Distinct instances with the same key are rewrapped
+	oldTransformer, transformer := newTransformer("vault:transit/harp"), newTransformer("vault:transit/harp")
+	b := rekeyBundle()
+	require.NoError(t, Lock(ctx, b, oldTransformer))
+
+	rotated, err := Rekey(ctx, b, []value.Transformer{oldTransformer}, transformer)
+	require.NoError(t, err)
+	assert.Len(t, rotated, 3)
+	assert.Equal(t, 3, transformer.rewrapped)
+
+	require.NoError(t, UnLock(ctx, b, []value.Transformer{transformer}, false))
+
+	// Different keys are decrypted and encrypted again
+	for _, keyID := range []string{"vault:transit/other", ""} {
+		oldTransformer, transformer = newTransformer("vault:transit/harp"), newTransformer(keyID)
+		b = rekeyBundle()
+		require.NoError(t, Lock(ctx, b, oldTransformer))
+
+		_, err = Rekey(ctx, b, []value.Transformer{oldTransformer}, transformer)
+		require.NoError(t, err)
+		assert.Equal(t, 0, transformer.rewrapped)
+	}
+}
+
+func TestPartialRekey(t *testing.T) {
+	ctx := context.Background()
+	oldKey := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	newKey := encryption.Must(encryption.FromKey("aes-gcm:yMjsQzwRVVVPw3rlmj-Bxg=="))
+	stagingKey := encryption.Must(encryption.FromKey("aes-gcm:Lc1rGvXvqCfYWXRJbF1G3A=="))
+
+	b := rekeyBundle()
+	require.NoError(t, PartialLock(ctx, b, map[string]value.Transformer{"production": oldKey, "staging": stagingKey}, false))
+
+	// Unresolved alias
+	_, err := PartialRekey(ctx, proto.Clone(b).(*bundlev1.Bundle), map[string]value.Transformer{"production": oldKey}, map[string]value.Transformer{"production": newKey}, false)
+	assert.Error(t, err)
+
+	rotated, err := PartialRekey(ctx, b, map[string]value.Transformer{"production": oldKey}, map[string]value.Transformer{"production": newKey}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/production/database"}, rotated)
+
+	// Each package is decryptable with its own key
+	require.NoError(t, UnLock(ctx, b, []value.Transformer{newKey, stagingKey}, false))
+
+	// Invalid arguments
+	_, err = PartialRekey(ctx, nil, map[string]value.Transformer{}, map[string]value.Transformer{}, false)
+	assert.Error(t, err)
+	_, err = PartialRekey(ctx, b, nil, map[string]value.Transformer{}, false)
+	assert.Error(t, err)
+}
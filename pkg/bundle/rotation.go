@@ -0,0 +1,302 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+const (
+	// RotationIntervalAnnotation defines the package secret rotation interval
+	// (Go duration with additional 'd' and 'w' units).
+	RotationIntervalAnnotation = "harp.elastic.co/v1/package#rotationInterval"
+	// ExpiresAtAnnotation defines the package secret expiration date (RFC3339).
+	ExpiresAtAnnotation = "harp.elastic.co/v1/package#expiresAt"
+	// OwnerAnnotation defines the package secret owner.
+	OwnerAnnotation = "harp.elastic.co/v1/package#owner"
+	// LastRotatedAnnotation defines the package secret last rotation date
+	// (RFC3339).
+	LastRotatedAnnotation = "harp.elastic.co/v1/package#lastRotated"
+	// ExpiresAtSourceAnnotation records how the package secret expiration
+	// date has been set. It is set to ExpirySourcePolicy when the date has
+	// been derived from the rotation interval.
+	ExpiresAtSourceAnnotation = "harp.elastic.co/v1/package#expiresAtSource"
+)
+
+// ExpiryStatus describes a secret expiration status.
+type ExpiryStatus string
+
+const (
+	// ExpiryStatusExpired is used for secrets with a past expiration date.
+	ExpiryStatusExpired ExpiryStatus = "expired"
+	// ExpiryStatusExpiring is used for secrets expiring in the given window.
+	ExpiryStatusExpiring ExpiryStatus = "expiring"
+)
+
+const (
+	// ExpirySourceAnnotation is used when the expiration date is read from the
+	// expiresAt annotation.
+	ExpirySourceAnnotation = "annotation"
+	// ExpirySourcePolicy is used when the expiration date is computed from the
+	// last rotation date and the rotation interval.
+	ExpirySourcePolicy = "policy"
+	// ExpirySourceCertificate is used when the expiration date is read from a
+	// PEM encoded certificate secret value.
+	ExpirySourceCertificate = "certificate"
+)
+
+// ExpiryEntry describes an expired or expiring secret.
+type ExpiryEntry struct {
+	Package   string       `json:"package"`
+	Key       string       `json:"key,omitempty"`
+	Owner     string       `json:"owner,omitempty"`
+	Source    string       `json:"source"`
+	Status    ExpiryStatus `json:"status"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// ParseDuration parses a duration string. In addition to the units supported
+// by time.ParseDuration, it supports 'd' (day) and 'w' (week) units as a
+// single integer value (e.g. 30d).
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, errors.New("unable to parse blank duration")
+	}
+
+	// Handle day and week units
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit > 0 {
+		n, err := strconv.ParseInt(strings.TrimSpace(value[:len(value)-1]), 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse duration %q: %w", value, err)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	// Delegate to standard parser
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse duration %q: %w", value, err)
+	}
+
+	return d, nil
+}
+
+// MarkRotated records the given date as the package secret last rotation date.
+// When the package has a rotation interval, the expiration date derived from
+// it is updated accordingly, else a previously derived expiration date is
+// removed. An expiration date set explicitly is never modified.
+func MarkRotated(p *bundlev1.Package, at time.Time) error {
+	// Check arguments
+	if p == nil {
+		return errors.New("unable to process nil package")
+	}
+
+	// Compute expiration date
+	var expiresAt time.Time
+	if raw, ok := p.Annotations[RotationIntervalAnnotation]; ok {
+		interval, err := ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid rotation interval for package %q: %w", p.Name, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("invalid rotation interval for package %q, it must be positive", p.Name)
+		}
+		expiresAt = at.Add(interval)
+	}
+
+	// Update annotations
+	if p.Annotations == nil {
+		p.Annotations = map[string]string{}
+	}
+	p.Annotations[LastRotatedAnnotation] = at.UTC().Format(time.RFC3339)
+
+	// Explicit expiration date must be preserved
+	_, hasExpiresAt := p.Annotations[ExpiresAtAnnotation]
+	derived := p.Annotations[ExpiresAtSourceAnnotation] == ExpirySourcePolicy
+	if hasExpiresAt && !derived {
+		return nil
+	}
+
+	// Replace the derived expiration date
+	if expiresAt.IsZero() {
+		delete(p.Annotations, ExpiresAtAnnotation)
+		delete(p.Annotations, ExpiresAtSourceAnnotation)
+	} else {
+		p.Annotations[ExpiresAtAnnotation] = expiresAt.UTC().Format(time.RFC3339)
+		p.Annotations[ExpiresAtSourceAnnotation] = ExpirySourcePolicy
+	}
+
+	// No error
+	return nil
+}
+
+// Expiry returns the secrets of the bundle which are expired or will expire
+// before now + within, ordered by expiration date.
+//
+// The package expiration date is read from the expiresAt annotation, or
+// computed from the lastRotated and rotationInterval annotations. PEM encoded
+// certificate values are reported using their NotAfter date.
+func Expiry(b *bundlev1.Bundle, now time.Time, within time.Duration) ([]*ExpiryEntry, error) {
+	// Check arguments
+	if b == nil {
+		return nil, errors.New("unable to process nil bundle")
+	}
+
+	limit := now.Add(within)
+	res := []*ExpiryEntry{}
+
+	// Check if the given date must be reported
+	report := func(packageName, key, owner, source string, expiresAt time.Time) {
+		if expiresAt.After(limit) {
+			return
+		}
+		status := ExpiryStatusExpiring
+		if !expiresAt.After(now) {
+			status = ExpiryStatusExpired
+		}
+		res = append(res, &ExpiryEntry{
+			Package:   packageName,
+			Key:       key,
+			Owner:     owner,
+			Source:    source,
+			Status:    status,
+			ExpiresAt: expiresAt.UTC(),
+		})
+	}
+
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+		owner := p.Annotations[OwnerAnnotation]
+
+		// Package policy
+		expiresAt, source, err := packageExpiry(p)
+		if err != nil {
+			return nil, err
+		}
+		if !expiresAt.IsZero() {
+			report(p.Name, "", owner, source, expiresAt)
+		}
+
+		// Certificate values
+		if p.Secrets == nil {
+			continue
+		}
+		for _, kv := range p.Secrets.Data {
+			if kv == nil {
+				continue
+			}
+			if notAfter, ok := certificateExpiry(kv); ok {
+				report(p.Name, kv.Key, owner, ExpirySourceCertificate, notAfter)
+			}
+		}
+	}
+
+	// Sort by expiration date
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].ExpiresAt.Equal(res[j].ExpiresAt) {
+			return res[i].Package+res[i].Key < res[j].Package+res[j].Key
+		}
+		return res[i].ExpiresAt.Before(res[j].ExpiresAt)
+	})
+
+	// No error
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func packageExpiry(p *bundlev1.Package) (time.Time, string, error) {
+	// Explicit expiration date
+	if raw, ok := p.Annotations[ExpiresAtAnnotation]; ok {
+		expiresAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid expiration date for package %q: %w", p.Name, err)
+		}
+		return expiresAt, ExpirySourceAnnotation, nil
+	}
+
+	// Rotation policy
+	rawInterval, hasInterval := p.Annotations[RotationIntervalAnnotation]
+	rawLastRotated, hasLastRotated := p.Annotations[LastRotatedAnnotation]
+	if !hasInterval || !hasLastRotated {
+		return time.Time{}, "", nil
+	}
+
+	interval, err := ParseDuration(rawInterval)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid rotation interval for package %q: %w", p.Name, err)
+	}
+	lastRotated, err := time.Parse(time.RFC3339, rawLastRotated)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid last rotation date for package %q: %w", p.Name, err)
+	}
+
+	return lastRotated.Add(interval), ExpirySourcePolicy, nil
+}
+
+func certificateExpiry(kv *bundlev1.KV) (time.Time, bool) {
+	// Unpack value
+	var value interface{}
+	if err := secret.Unpack(kv.Value, &value); err != nil {
+		return time.Time{}, false
+	}
+	raw, ok := value.(string)
+	if !ok || !strings.Contains(raw, "-----BEGIN CERTIFICATE-----") {
+		return time.Time{}, false
+	}
+
+	// Use the first certificate of the chain
+	rest := []byte(raw)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return time.Time{}, false
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return cert.NotAfter, true
+	}
+}
@@ -0,0 +1,180 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+func expiryCertificate(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "db.example.com"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", wantErr: true},
+		{value: "foo", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "-1d", want: -24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarkRotated(t *testing.T) {
+	at := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Without policy
+	p := &bundlev1.Package{Name: "app/production/database"}
+	require.NoError(t, MarkRotated(p, at))
+	assert.Equal(t, "2023-01-01T00:00:00Z", p.Annotations[LastRotatedAnnotation])
+	assert.NotContains(t, p.Annotations, ExpiresAtAnnotation)
+
+	// With policy
+	p.Annotations[RotationIntervalAnnotation] = "30d"
+	require.NoError(t, MarkRotated(p, at.Add(time.Hour)))
+	assert.Equal(t, "2023-01-01T01:00:00Z", p.Annotations[LastRotatedAnnotation])
+	assert.Equal(t, "2023-01-31T01:00:00Z", p.Annotations[ExpiresAtAnnotation])
+	assert.Equal(t, ExpirySourcePolicy, p.Annotations[ExpiresAtSourceAnnotation])
+
+	// Policy removed, previous expiration date must not be kept
+	delete(p.Annotations, RotationIntervalAnnotation)
+	require.NoError(t, MarkRotated(p, at.Add(2*time.Hour)))
+	assert.Equal(t, "2023-01-01T02:00:00Z", p.Annotations[LastRotatedAnnotation])
+	assert.NotContains(t, p.Annotations, ExpiresAtAnnotation)
+	assert.NotContains(t, p.Annotations, ExpiresAtSourceAnnotation)
+
+	// Explicit expiration date must be kept
+	p.Annotations[ExpiresAtAnnotation] = "2024-06-01T00:00:00Z"
+	require.NoError(t, MarkRotated(p, at.Add(3*time.Hour)))
+	assert.Equal(t, "2023-01-01T03:00:00Z", p.Annotations[LastRotatedAnnotation])
+	assert.Equal(t, "2024-06-01T00:00:00Z", p.Annotations[ExpiresAtAnnotation])
+	p.Annotations[RotationIntervalAnnotation] = "30d"
+	require.NoError(t, MarkRotated(p, at.Add(4*time.Hour)))
+	assert.Equal(t, "2024-06-01T00:00:00Z", p.Annotations[ExpiresAtAnnotation])
+	assert.NotContains(t, p.Annotations, ExpiresAtSourceAnnotation)
+
+	// Invalid policy
+	p.Annotations[RotationIntervalAnnotation] = "-1d"
+	assert.Error(t, MarkRotated(p, at))
+	assert.Error(t, MarkRotated(nil, at))
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Annotations: map[string]string{
+					OwnerAnnotation:            "team-db",
+					RotationIntervalAnnotation: "30d",
+					LastRotatedAnnotation:      "2023-04-01T00:00:00Z",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Value: secret.MustPack("secret")},
+						{Key: "tls.crt", Value: secret.MustPack(expiryCertificate(t, now.Add(10*24*time.Hour)))},
+					},
+				},
+			},
+			{
+				Name: "app/production/cache",
+				Annotations: map[string]string{
+					ExpiresAtAnnotation: "2023-12-01T00:00:00Z",
+				},
+			},
+			{
+				Name: "app/production/queue",
+			},
+		},
+	}
+
+	// Default window
+	got, err := Expiry(b, now, 30*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, &ExpiryEntry{
+		Package:   "app/production/database",
+		Owner:     "team-db",
+		Source:    ExpirySourcePolicy,
+		Status:    ExpiryStatusExpired,
+		ExpiresAt: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+	}, got[0])
+	assert.Equal(t, "tls.crt", got[1].Key)
+	assert.Equal(t, ExpirySourceCertificate, got[1].Source)
+	assert.Equal(t, ExpiryStatusExpiring, got[1].Status)
+
+	// Larger window
+	got, err = Expiry(b, now, 365*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, "app/production/cache", got[2].Package)
+	assert.Equal(t, ExpirySourceAnnotation, got[2].Source)
+
+	// Invalid annotation
+	b.Packages[1].Annotations[ExpiresAtAnnotation] = "tomorrow"
+	_, err = Expiry(b, now, 0)
+	assert.Error(t, err)
+	_, err = Expiry(nil, now, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/awnumar/memguard"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+)
+
+// Loader describes bundle loader contract.
+type Loader func(ctx context.Context) (*bundlev1.Bundle, error)
+
+// ContainerLoader returns a loader which unseals the container located at the
+// given path before extracting the bundle.
+//
+// The container is considered as unsealed when the container key is nil.
+// Indexed bundle containers are decrypted with the given index transformers.
+func ContainerLoader(path string, containerKey, preSharedKey *memguard.LockedBuffer, indexTransformers ...value.Transformer) Loader {
+	return func(ctx context.Context) (*bundlev1.Bundle, error) {
+		// Open container file
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open container %q: %w", path, err)
+		}
+		defer log.SafeClose(f, "unable to close container file", zap.String("path", path))
+
+		// Load container
+		c, err := container.Load(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load container %q: %w", path, err)
+		}
+
+		// Unseal if required
+		if container.IsSealed(c) {
+			if containerKey == nil {
+				return nil, errors.New("the container is sealed but no container key has been provided")
+			}
+
+			opts := []container.Option{}
+			if preSharedKey != nil {
+				opts = append(opts, container.WithPreSharedKey(preSharedKey))
+			}
+
+			c, err = container.Unseal(c, containerKey, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unseal container %q: %w", path, err)
+			}
+		}
+
+		// Extract bundle
+		return bundle.OpenContainer(ctx, c, indexTransformers)
+	}
+}
+
+// Watch calls the given function each time the file located at path is
+// modified until the context is cancelled.
+//
+// The parent directory is watched so that atomic file replacements (rename)
+// are also detected.
+func Watch(ctx context.Context, path string, onChange func()) error {
+	// Resolve absolute path
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("unable to resolve absolute path of %q: %w", path, err)
+	}
+
+	// Initialize watcher
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to initialize file watcher: %w", err)
+	}
+	defer log.SafeClose(w, "unable to close file watcher")
+
+	if err := w.Add(filepath.Dir(absPath)); err != nil {
+		return fmt.Errorf("unable to watch %q: %w", path, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(evt.Name) != absPath {
+				continue
+			}
+			if evt.Has(fsnotify.Write) || evt.Has(fsnotify.Create) || evt.Has(fsnotify.Rename) {
+				onChange()
+			}
+		case errWatch, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.For(ctx).Error("file watcher error", zap.Error(errWatch))
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+)
+
+// DefaultNamespace is the namespace name used when none is specified.
+const DefaultNamespace = "default"
+
+// Server implements the BundleService gRPC contract backed by an in-memory
+// bundle.
+type Server struct {
+	bundlev1.UnimplementedBundleServiceServer
+
+	namespace string
+	current   atomic.Pointer[bundlev1.Bundle]
+}
+
+var _ bundlev1.BundleServiceServer = (*Server)(nil)
+
+// New returns a bundle service instance serving the given bundle as namespace.
+func New(namespace string, b *bundlev1.Bundle) (*Server, error) {
+	// Check arguments
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	s := &Server{
+		namespace: namespace,
+	}
+
+	// Assign initial bundle
+	if err := s.Reload(b); err != nil {
+		return nil, err
+	}
+
+	// No error
+	return s, nil
+}
+
+// Reload atomically replaces the served bundle.
+func (s *Server) Reload(b *bundlev1.Bundle) error {
+	// Check arguments
+	if b == nil {
+		return errors.New("unable to serve a nil bundle")
+	}
+
+	// Swap bundle
+	s.current.Store(b)
+
+	// No error
+	return nil
+}
+
+// GetSecret returns the secret value matching the requested path.
+//
+// The path is a package name returning all package secrets as a JSON object,
+// or a package name followed by `#` and a key name to retrieve a single raw
+// secret value.
+func (s *Server) GetSecret(_ context.Context, req *bundlev1.GetSecretRequest) (*bundlev1.GetSecretResponse, error) {
+	// Check arguments
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+	if req.Path == "" {
+		return nil, status.Error(codes.InvalidArgument, "path must not be blank")
+	}
+
+	// Check namespace
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	if namespace != s.namespace {
+		return nil, status.Errorf(codes.NotFound, "namespace %q not found", req.Namespace)
+	}
+
+	// Split package path and secret key
+	packageName, secretKey, hasKey := strings.Cut(req.Path, "#")
+
+	// Read package secrets
+	secrets, err := bundle.Read(s.current.Load(), packageName)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "secret %q not found", packageName)
+	}
+
+	// Encode response content
+	var content []byte
+	if hasKey {
+		value, ok := secrets[secretKey]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "secret key %q not found in %q", secretKey, packageName)
+		}
+		content, err = rawValue(value)
+	} else {
+		content, err = json.Marshal(secrets)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to encode secret value: %v", err)
+	}
+
+	// No error
+	return &bundlev1.GetSecretResponse{
+		Namespace: namespace,
+		Path:      req.Path,
+		Content:   content,
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func rawValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode value as JSON: %w", err)
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,184 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	_ "github.com/zntrio/harp/v2/pkg/sdk/value/encryption/aead"
+)
+
+func testBundle(value string) *bundlev1.Bundle {
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "user", Type: "string", Value: secret.MustPack("admin")},
+						{Key: "password", Type: "string", Value: secret.MustPack(value)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestServer_GetSecret(t *testing.T) {
+	s, err := New("", testBundle("initial"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		req      *bundlev1.GetSecretRequest
+		want     string
+		wantCode codes.Code
+	}{
+		{
+			name:     "nil",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "blank path",
+			req:      &bundlev1.GetSecretRequest{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unknown namespace",
+			req:      &bundlev1.GetSecretRequest{Namespace: "other", Path: "app/production/database"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "unknown package",
+			req:      &bundlev1.GetSecretRequest{Path: "app/production/unknown"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "unknown key",
+			req:      &bundlev1.GetSecretRequest{Path: "app/production/database#unknown"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "package",
+			req:  &bundlev1.GetSecretRequest{Path: "app/production/database"},
+			want: `{"password":"initial","user":"admin"}`,
+		},
+		{
+			name: "key",
+			req:  &bundlev1.GetSecretRequest{Namespace: DefaultNamespace, Path: "app/production/database#password"},
+			want: "initial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetSecret(context.Background(), tt.req)
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got.Content))
+		})
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	s, err := New("secrets", testBundle("initial"))
+	require.NoError(t, err)
+	require.Error(t, s.Reload(nil))
+	require.NoError(t, s.Reload(testBundle("rotated")))
+
+	got, err := s.GetSecret(context.Background(), &bundlev1.GetSecretRequest{Namespace: "secrets", Path: "app/production/database#password"})
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(got.Content))
+}
+
+func TestContainerLoader(t *testing.T) {
+	b, err := ContainerLoader("../../../test/fixtures/bundles/complete.bundle", nil, nil)(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, b.Packages, 2)
+
+	_, err = ContainerLoader("../../../test/fixtures/bundles/complete.v1.sealed", nil, nil)(context.Background())
+	assert.Error(t, err)
+
+	_, err = ContainerLoader("non-existent.bundle", nil, nil)(context.Background())
+	assert.Error(t, err)
+}
+
+func TestContainerLoader_Indexed(t *testing.T) {
+	ctx := context.Background()
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	// Prepare indexed container
+	c, err := bundle.ToIndexedContainer(ctx, testBundle("indexed"), transformer)
+	require.NoError(t, err)
+	f, err := os.Create(filepath.Join(t.TempDir(), "secrets.bundle"))
+	require.NoError(t, err)
+	require.NoError(t, container.Dump(f, c))
+	require.NoError(t, f.Close())
+
+	b, err := ContainerLoader(f.Name(), nil, nil, transformer)(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Packages, 1)
+	assert.Equal(t, "app/production/database", b.Packages[0].Name)
+
+	// Index key is required
+	_, err = ContainerLoader(f.Name(), nil, nil)(ctx)
+	assert.ErrorIs(t, err, bundle.ErrIndexedContainer)
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.bundle")
+	require.NoError(t, os.WriteFile(path, []byte("initial"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, path, func() { changed <- struct{}{} })
+	}()
+
+	// Give some time to the watcher to be registered
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("updated"), 0o600))
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("file change not detected")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
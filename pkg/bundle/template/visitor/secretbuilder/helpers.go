@@ -24,6 +24,7 @@ import (
 	"time"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
 	"github.com/zntrio/harp/v2/pkg/template/engine"
@@ -95,7 +96,7 @@ func buildSecretChain(templateContext engine.Context, secretPath string, item *b
 		// Add secret to package
 		chain.Data = append(chain.Data, &bundlev1.KV{
 			Key:   key,
-			Type:  fmt.Sprintf("%T", value),
+			Type:  kind.TypeOf(key, value),
 			Value: secretBody,
 		})
 	}
@@ -0,0 +1,136 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/merkletree"
+	"golang.org/x/crypto/blake2b"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	csov1 "github.com/zntrio/harp/v2/pkg/cso/v1"
+)
+
+// treeLeaf describes a secret merkle tree leaf.
+type treeLeaf struct {
+	packageName string
+	version     uint32
+	key         string
+	valueHash   []byte
+}
+
+// String returns the leaf content pushed in the merkle tree.
+func (l *treeLeaf) String() string {
+	return fmt.Sprintf("%s:%d:%s:%x", l.packageName, l.version, l.key, l.valueHash)
+}
+
+// -----------------------------------------------------------------------------
+
+// treeLeaves returns the ordered merkle tree leaves of the given bundle.
+func treeLeaves(b *bundlev1.Bundle) ([]*treeLeaf, *Statistic) {
+	// Prepare statistics
+	stats := &Statistic{
+		SecretCount:                  0,
+		PackageCount:                 0,
+		CSOCompliantPackageNameCount: 0,
+	}
+
+	// Ensure packages order
+	sort.SliceStable(b.Packages, func(i, j int) bool {
+		return b.Packages[i].Name < b.Packages[j].Name
+	})
+
+	leaves := []*treeLeaf{}
+
+	// All packages
+	for _, p := range b.Packages {
+		// Increment package count
+		stats.PackageCount++
+
+		// Check compliance with CSO
+		if errValidate := csov1.Validate(p.Name); errValidate == nil {
+			stats.CSOCompliantPackageNameCount++
+		}
+
+		// Follow secret chain
+		if p.Secrets == nil {
+			continue
+		}
+
+		// Prepare package leaves
+		packageLeaves := []*treeLeaf{}
+		for _, s := range p.Secrets.Data {
+			// Increment secret count
+			stats.SecretCount++
+
+			// Build merkle tree leaf
+			packageLeaves = append(packageLeaves, newTreeLeaf(p.Name, p.Secrets.Version, s))
+		}
+
+		// Include archived versions
+		for _, v := range History(p) {
+			for _, s := range v.Data {
+				packageLeaves = append(packageLeaves, newTreeLeaf(p.Name, v.Version, s))
+			}
+		}
+
+		// Sort them
+		sort.SliceStable(packageLeaves, func(i, j int) bool {
+			return packageLeaves[i].String() < packageLeaves[j].String()
+		})
+
+		leaves = append(leaves, packageLeaves...)
+	}
+
+	return leaves, stats
+}
+
+func newTreeLeaf(packageName string, version uint32, kv *bundlev1.KV) *treeLeaf {
+	h := blake2b.Sum512(kv.Value)
+	return &treeLeaf{
+		packageName: packageName,
+		version:     version,
+		key:         kv.Key,
+		valueHash:   h[:],
+	}
+}
+
+// newTree builds a merkle tree from the given leaves, recording the proof of
+// the leaf at the given index.
+func newTree(leaves []*treeLeaf, proofIndex uint64) (*merkletree.Tree, error) {
+	// Calculate merkle tree root
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize hash function for merkle tree")
+	}
+
+	// Initialize merkle tree
+	tree := merkletree.New(h)
+	if err = tree.SetIndex(proofIndex); err != nil {
+		return nil, fmt.Errorf("unable to initialize merkle tree")
+	}
+
+	// Push sorted secret uri as proof
+	for _, l := range leaves {
+		tree.Push([]byte(l.String()))
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+)
+
+// TypeViolation describes a secret value not matching its declared type.
+type TypeViolation struct {
+	Package string `json:"package"`
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+}
+
+// TypeError is raised when bundle secret values don't match their declared
+// types.
+type TypeError struct {
+	Violations []TypeViolation
+}
+
+// Error returns the error message.
+func (e *TypeError) Error() string {
+	items := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		items = append(items, fmt.Sprintf("%s#%s: %s", v.Package, v.Key, v.Reason))
+	}
+	return fmt.Sprintf("%d secret(s) don't match their declared type (%s)", len(e.Violations), strings.Join(items, ", "))
+}
+
+// ValidateTypes checks all unlocked secret values against their declared
+// secret kind. Secrets typed with an unknown kind and encrypted values are
+// ignored.
+func ValidateTypes(b *bundlev1.Bundle) error {
+	// Check arguments
+	if b == nil {
+		return fmt.Errorf("unable to validate nil bundle")
+	}
+
+	violations := []TypeViolation{}
+	for _, p := range b.Packages {
+		// Locked packages can't be checked
+		if p == nil || p.Secrets == nil || p.Secrets.Locked != nil {
+			continue
+		}
+
+		for _, kv := range p.Secrets.Data {
+			if kv == nil {
+				continue
+			}
+			if _, ok := kind.Get(kv.Type); !ok {
+				continue
+			}
+
+			// Skip values encrypted in place
+			if _, ok := encryptedValue(kv); ok {
+				continue
+			}
+
+			// Unpack secret value
+			var value interface{}
+			if err := secret.Unpack(kv.Value, &value); err != nil {
+				return fmt.Errorf("unable to unpack %q secret value: %w", fmt.Sprintf("%s#%s", p.Name, kv.Key), err)
+			}
+
+			if err := kind.Validate(kv.Type, value); err != nil {
+				violations = append(violations, TypeViolation{
+					Package: p.Name,
+					Key:     kv.Key,
+					Type:    kv.Type,
+					Reason:  err.Error(),
+				})
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	// Stable ordering
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Package == violations[j].Package {
+			return violations[i].Key < violations[j].Key
+		}
+		return violations[i].Package < violations[j].Package
+	})
+
+	return &TypeError{Violations: violations}
+}
@@ -31,6 +31,7 @@ import (
 	"go.uber.org/zap"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
 	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/sdk/log"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
@@ -328,7 +329,7 @@ func (op *exporter) packSecret(key string, value interface{}) (*bundlev1.KV, err
 	// Build the secret object
 	return &bundlev1.KV{
 		Key:   key,
-		Type:  fmt.Sprintf("%T", value),
+		Type:  kind.TypeOf(key, value),
 		Value: payload,
 	}, nil
 }
@@ -32,6 +32,9 @@ import (
 	"github.com/zntrio/harp/v2/pkg/container/seal"
 	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
 	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+	v4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+	v5 "github.com/zntrio/harp/v2/pkg/container/seal/v5"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
 )
 
@@ -48,26 +51,9 @@ func Load(r io.Reader) (*containerv1.Container, error) {
 		return nil, fmt.Errorf("unable to process nil reader")
 	}
 
-	// Read magic
-	var magic uint32
-	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
-		return nil, fmt.Errorf("unable to read magic code: %w", err)
-	}
-
-	// Check magic value
-	if magic != containerMagic {
-		return nil, fmt.Errorf("invalid magic signature")
-	}
-
-	// Read container version
-	var version uint16
-	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
-		return nil, fmt.Errorf("unable to read container version: %w", err)
-	}
-
-	// Check magic value
-	if version != containerVersion {
-		return nil, fmt.Errorf("invalid container version %d", version)
+	// Read container preamble
+	if err := readPreamble(r); err != nil {
+		return nil, err
 	}
 
 	// Drain input reader
@@ -115,11 +101,8 @@ func Dump(w io.Writer, c *containerv1.Container) error {
 	}
 
 	// Write packets
-	if err = binary.Write(w, binary.BigEndian, containerMagic); err != nil {
-		return fmt.Errorf("unable to write container magic: %w", err)
-	}
-	if err = binary.Write(w, binary.BigEndian, containerVersion); err != nil {
-		return fmt.Errorf("unable to write container version: %w", err)
+	if err = writePreamble(w); err != nil {
+		return err
 	}
 	if _, err = w.Write(payload); err != nil {
 		return fmt.Errorf("unable to write container content: %w", err)
@@ -155,11 +138,22 @@ func Unseal(container *containerv1.Container, identity *memguard.LockedBuffer, o
 		o(dopts)
 	}
 
+	// Check expected seal version
+	if err := checkSealVersion(container.Headers, dopts.sealVersion); err != nil {
+		return nil, err
+	}
+
 	// Build appropriate unseal strategy processor.
 	var ss seal.Strategy
 	switch container.Headers.SealVersion {
-	case 2:
+	case v2.SealVersion:
 		ss = v2.New()
+	case v3.SealVersion:
+		ss = v3.New()
+	case v4.SealVersion:
+		ss = v4.New()
+	case v5.SealVersion:
+		ss = v5.New()
 	default:
 		ss = v1.New()
 	}
@@ -212,6 +206,7 @@ func Seal(rand io.Reader, container *containerv1.Container, opts ...Option) (*co
 	// Validate peer public keys
 	hasV1 := false
 	hasV2 := false
+	hasV3 := false
 	for i, pub := range dopts.peersPublicKey {
 		switch {
 		case strings.HasPrefix(pub, "v1.sk."):
@@ -238,14 +233,36 @@ func Seal(rand io.Reader, container *containerv1.Container, opts ...Option) (*co
 				return nil, fmt.Errorf("unable to convert v2 identity public key %q: %w", pub, err)
 			}
 
+			// Replace identity key by sealing key
+			dopts.peersPublicKey[i] = identityPublicKey.SealingKey()
+		case strings.HasPrefix(pub, "v3.sk."):
+			hasV3 = true
+		case strings.HasPrefix(pub, "v3.ipk."):
+			hasV3 = true
+
+			// Convert to sealing public key
+			identityPublicKey, err := key.FromString(pub)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert v3 identity public key %q: %w", pub, err)
+			}
+
 			// Replace identity key by sealing key
 			dopts.peersPublicKey[i] = identityPublicKey.SealingKey()
 		default:
 			return nil, fmt.Errorf("invalid key %q", pub)
 		}
 	}
-	if hasV1 && hasV2 {
-		return nil, errors.New("peer public keys are using mixed versions - use v1 or v2 keys")
+	if (hasV1 && hasV2) || (hasV1 && hasV3) || (hasV2 && hasV3) {
+		return nil, errors.New("peer public keys are using mixed versions - use v1, v2 or v3 keys")
+	}
+
+	// Threshold sealing
+	if dopts.threshold > 0 {
+		if !hasV2 || hasV1 || hasV3 {
+			return nil, errors.New("threshold sealing requires v2 keys")
+		}
+
+		return v5.New().SealThreshold(rand, container, dopts.psk, dopts.threshold, dopts.peersPublicKey...)
 	}
 
 	// Create sealing strategy instance
@@ -255,6 +272,8 @@ func Seal(rand io.Reader, container *containerv1.Container, opts ...Option) (*co
 		ss = v1.New()
 	case hasV2:
 		ss = v2.New()
+	case hasV3:
+		ss = v3.New()
 	default:
 		return nil, errors.New("unsupported sealing algorithm")
 	}
@@ -262,3 +281,64 @@ func Seal(rand io.Reader, container *containerv1.Container, opts ...Option) (*co
 	// Delegate to strategy
 	return ss.SealWithPSK(rand, container, dopts.psk, dopts.peersPublicKey...)
 }
+
+// -----------------------------------------------------------------------------
+
+func checkSealVersion(headers *containerv1.Header, expected uint32) error {
+	// No constraint
+	if expected == 0 {
+		return nil
+	}
+
+	// Legacy containers don't declare their seal version
+	version := headers.SealVersion
+	if version == 0 {
+		version = v1.SealVersion
+	}
+
+	if version != expected {
+		return fmt.Errorf("unexpected container seal version %d, expected %d", version, expected)
+	}
+
+	// No error
+	return nil
+}
+
+func readPreamble(r io.Reader) error {
+	// Read magic
+	var magic uint32
+	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
+		return fmt.Errorf("unable to read magic code: %w", err)
+	}
+
+	// Check magic value
+	if magic != containerMagic {
+		return fmt.Errorf("invalid magic signature")
+	}
+
+	// Read container version
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return fmt.Errorf("unable to read container version: %w", err)
+	}
+
+	// Check magic value
+	if version != containerVersion {
+		return fmt.Errorf("invalid container version %d", version)
+	}
+
+	// No error
+	return nil
+}
+
+func writePreamble(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, containerMagic); err != nil {
+		return fmt.Errorf("unable to write container magic: %w", err)
+	}
+	if err := binary.Write(w, binary.BigEndian, containerVersion); err != nil {
+		return fmt.Errorf("unable to write container version: %w", err)
+	}
+
+	// No error
+	return nil
+}
@@ -19,15 +19,20 @@ package container
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/hex"
 	"io"
 	"testing"
 
+	"github.com/awnumar/memguard"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 
 	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
 )
 
 var (
@@ -222,3 +227,47 @@ func Test_Dump_Fuzz(t *testing.T) {
 		Dump(io.Discard, &input)
 	}
 }
+
+func TestSeal_Unseal_V3(t *testing.T) {
+	pubKey, privKey, err := v3.New().GenerateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	v1PubKey, _, err := v1.New().GenerateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte{0x01, 0x02, 0x03},
+	}
+
+	// Mixed key versions
+	if _, err := Seal(rand.Reader, input, WithPeerPublicKeys([]string{pubKey, v1PubKey})); err == nil {
+		t.Error("expected error for mixed key versions")
+	}
+
+	sealed, err := Seal(rand.Reader, input, WithPeerPublicKeys([]string{pubKey}))
+	if err != nil {
+		t.Fatalf("unable to seal container: %v", err)
+	}
+	if sealed.Headers.SealVersion != v3.SealVersion {
+		t.Errorf("invalid seal version, got %d", sealed.Headers.SealVersion)
+	}
+
+	// Seal version constraint
+	if _, err := Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)), WithSealVersion(v2.SealVersion)); err == nil {
+		t.Error("expected error for unexpected seal version")
+	}
+
+	out, err := Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)), WithSealVersion(v3.SealVersion))
+	if err != nil {
+		t.Fatalf("unable to unseal container: %v", err)
+	}
+	if diff := cmp.Diff(input, out, ignoreOpts...); diff != "" {
+		t.Errorf("unsealed container mismatch (-want +got):\n%s", diff)
+	}
+}
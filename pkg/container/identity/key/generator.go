@@ -25,6 +25,7 @@ import (
 	"io"
 
 	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/deterministicecdsa"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
 
 	"golang.org/x/crypto/nacl/box"
 )
@@ -80,3 +81,29 @@ func P384(random io.Reader) (*JSONWebKey, string, error) {
 		D:   base64.RawURLEncoding.EncodeToString(priv.D.Bytes()),
 	}, fmt.Sprintf("v2.ipk.%s", base64.RawURLEncoding.EncodeToString(pub)), err
 }
+
+func Ed25519MLKEM768(random io.Reader) (*JSONWebKey, string, error) {
+	// Generate ed25519 keys as identity signature key
+	pub, priv, err := ed25519.GenerateKey(random)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to generate identity keypair: %w", err)
+	}
+
+	// Generate ML-KEM-768 keys for post-quantum key encapsulation
+	dk, err := mlkem.GenerateKey768(random)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to generate identity keypair: %w", err)
+	}
+
+	// Assemble hybrid keys
+	pubRaw := append(append([]byte{}, pub...), dk.EncapsulationKey().Bytes()...)
+	privRaw := append(append([]byte{}, priv...), dk.Bytes()...)
+
+	// Wrap as JWK
+	return &JSONWebKey{
+		Kty: "OKP",
+		Crv: "Ed25519-MLKEM768",
+		X:   base64.RawURLEncoding.EncodeToString(pubRaw),
+		D:   base64.RawURLEncoding.EncodeToString(privRaw),
+	}, fmt.Sprintf("v3.ipk.%s", base64.RawURLEncoding.EncodeToString(pubRaw)), err
+}
@@ -29,6 +29,7 @@ import (
 	"math/big"
 
 	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/extra25519"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
 )
 
 // JSONWebKey holds internal container key attributes.
@@ -57,6 +58,13 @@ func (k *JSONWebKey) Sign(message []byte) (string, error) {
 
 		// Sign the message
 		sig = ed25519.Sign(ed25519.PrivateKey(d), message)
+	case "Ed25519-MLKEM768":
+		if len(d) != ed25519.PrivateKeySize+mlkem.SeedSize {
+			return "", errors.New("invalid private key size")
+		}
+
+		// Sign the message with the ed25519 component
+		sig = ed25519.Sign(ed25519.PrivateKey(d[:ed25519.PrivateKeySize]), message)
 	case "P-384":
 		if len(d) != 48 {
 			return "", errors.New("invalid private key size")
@@ -97,6 +105,17 @@ func (k *JSONWebKey) RecoveryKey() (string, error) {
 		var sk [32]byte
 		extra25519.PrivateKeyToCurve25519(&sk, privKeyRaw)
 		return fmt.Sprintf("v1.ck.%s", base64.RawURLEncoding.EncodeToString(sk[:])), nil
+	case "Ed25519-MLKEM768":
+		if len(privKeyRaw) != ed25519.PrivateKeySize+mlkem.SeedSize {
+			return "", errors.New("invalid identity, private key is invalid")
+		}
+
+		// Convert Ed25519 private key to x25519 key and append it to the
+		// ML-KEM seed.
+		var sk [32]byte
+		extra25519.PrivateKeyToCurve25519(&sk, privKeyRaw[:ed25519.PrivateKeySize])
+		ck := append(append([]byte{}, privKeyRaw[ed25519.PrivateKeySize:]...), sk[:]...)
+		return fmt.Sprintf("v3.ck.%s", base64.RawURLEncoding.EncodeToString(ck)), nil
 	case "P-384":
 		// FIPS compliant sealing process use ECDSA P-384 key.
 		return fmt.Sprintf("v2.ck.%s", base64.RawURLEncoding.EncodeToString(privKeyRaw)), nil
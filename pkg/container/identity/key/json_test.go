@@ -18,9 +18,18 @@
 package key
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
 	"testing"
 
+	"github.com/awnumar/memguard"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
 )
 
 var (
@@ -80,3 +89,56 @@ func TestJSONWebKey_RecoveryKey(t *testing.T) {
 		assert.Equal(t, "v2.ck.aXN0aWMtcmFuZG9tLXNvdYiXCnZ-xg0Te8QN3AId4n-bdBdDfhXJjz1OngEo78g8", id)
 	})
 }
+
+func TestJSONWebKey_RecoveryKey_V3(t *testing.T) {
+	jwk, pub, err := Ed25519MLKEM768(bytes.NewReader(bytes.Repeat([]byte("deterministic-random-source-for-"), 3)))
+	require.NoError(t, err)
+	assert.Equal(t, "Ed25519-MLKEM768", jwk.Crv)
+	assert.True(t, strings.HasPrefix(pub, V3IdentityPublicKeyPrefix))
+
+	t.Run("valid - v3", func(t *testing.T) {
+		id, err := jwk.RecoveryKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "v3.ck.ZGV0ZXJtaW5pc3RpYy1yYW5kb20tc291cmNlLWZvci1kZXRlcm1pbmlzdGljLXJhbmRvbS1zb3VyY2UtZm9yLejn94OqrfjTwc10jDZeIzyGW62SCMKJ09qZ-xgnOIt6", id)
+	})
+
+	t.Run("invalid private key size", func(t *testing.T) {
+		id, err := (&JSONWebKey{
+			Crv: "Ed25519-MLKEM768",
+			D:   v1PrivateKey.D,
+		}).RecoveryKey()
+		assert.Error(t, err)
+		assert.Empty(t, id)
+	})
+
+	t.Run("seal / unseal", func(t *testing.T) {
+		k, err := FromString(pub)
+		require.NoError(t, err)
+		assert.Equal(t, pub, k.String())
+
+		recoveryKey, err := jwk.RecoveryKey()
+		require.NoError(t, err)
+
+		input := &containerv1.Container{
+			Headers: &containerv1.Header{},
+			Raw:     []byte("test"),
+		}
+		sealed, err := v3.New().Seal(rand.Reader, input, k.SealingKey())
+		require.NoError(t, err)
+		unsealed, err := v3.New().Unseal(sealed, memguard.NewBufferFromBytes([]byte(recoveryKey)))
+		require.NoError(t, err)
+		assert.Equal(t, input.Raw, unsealed.Raw)
+	})
+
+	t.Run("sign / verify", func(t *testing.T) {
+		k, err := FromString(pub)
+		require.NoError(t, err)
+
+		sig, err := jwk.Sign([]byte("test"))
+		require.NoError(t, err)
+		sigRaw, err := base64.RawURLEncoding.DecodeString(sig)
+		require.NoError(t, err)
+		assert.True(t, k.Verify([]byte("test"), sigRaw))
+		assert.False(t, k.Verify([]byte("tset"), sigRaw))
+	})
+}
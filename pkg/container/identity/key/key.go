@@ -29,15 +29,24 @@ import (
 	"strings"
 
 	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/extra25519"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
 )
 
 const (
 	V1IdentityPublicKeyPrefix = "v1.ipk."
 	V2IdentityPublicKeyPrefix = "v2.ipk."
+	V3IdentityPublicKeyPrefix = "v3.ipk."
 )
 
 // -----------------------------------------------------------------------------
 
+// hybridPublicKey holds an Ed25519 signature public key and an ML-KEM-768
+// encapsulation key.
+type hybridPublicKey struct {
+	sig ed25519.PublicKey
+	kem []byte
+}
+
 type Key struct {
 	version  uint32
 	key      interface{}
@@ -92,6 +101,34 @@ func FromString(input string) (*Key, error) {
 			public:   true,
 		}, nil
 
+	// Ed25519 / ML-KEM-768 hybrid public key
+	case strings.HasPrefix(input, V3IdentityPublicKeyPrefix):
+		// Decode public key
+		pkRaw, err := base64.RawURLEncoding.DecodeString(input[7:])
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode public key: %w", err)
+		}
+		if len(pkRaw) != ed25519.PublicKeySize+mlkem.EncapsulationKeySize768 {
+			return nil, errors.New("invalid public key size")
+		}
+
+		// Check the encapsulation key
+		ek := pkRaw[ed25519.PublicKeySize:]
+		if _, err := mlkem.NewEncapsulationKey768(ek); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal the public key: %w", err)
+		}
+
+		// Return wrapped key
+		return &Key{
+			version: 3,
+			key: &hybridPublicKey{
+				sig: ed25519.PublicKey(pkRaw[:ed25519.PublicKeySize]),
+				kem: ek,
+			},
+			identity: true,
+			public:   true,
+		}, nil
+
 	// Unrecognized
 	default:
 	}
@@ -117,6 +154,9 @@ func (k *Key) Verify(message, signature []byte) bool {
 	case ed25519.PublicKey:
 		// Verify the signature
 		return ed25519.Verify(keyRaw, message, signature)
+	case *hybridPublicKey:
+		// Verify the signature
+		return ed25519.Verify(keyRaw.sig, message, signature)
 	default:
 	}
 
@@ -130,6 +170,8 @@ func (k *Key) String() string {
 		payload = elliptic.MarshalCompressed(keyRaw.Curve, keyRaw.X, keyRaw.Y)
 	case ed25519.PublicKey:
 		payload = keyRaw
+	case *hybridPublicKey:
+		payload = append(append([]byte{}, keyRaw.sig...), keyRaw.kem...)
 	default:
 		return ""
 	}
@@ -149,6 +191,13 @@ func (k *Key) SealingKey() string {
 			return ""
 		}
 		payload = pkRaw[:]
+	case *hybridPublicKey:
+		// Convert Ed25519 to X25519 and append it to the encapsulation key
+		var pkRaw [32]byte
+		if !extra25519.PublicKeyToCurve25519(&pkRaw, keyRaw.sig) {
+			return ""
+		}
+		payload = append(append([]byte{}, keyRaw.kem...), pkRaw[:]...)
 	default:
 		return ""
 	}
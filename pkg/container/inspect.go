@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"errors"
+
+	"github.com/awnumar/memguard"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+	v4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+	v5 "github.com/zntrio/harp/v2/pkg/container/seal/v5"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// SealVersion returns the seal strategy version of the given container, or 0
+// if the container is not sealed. Legacy sealed containers don't declare their
+// seal version and are reported as v1 containers.
+func SealVersion(container *containerv1.Container) uint32 {
+	// Check parameters
+	if types.IsNil(container) || types.IsNil(container.Headers) {
+		return 0
+	}
+
+	switch {
+	case IsSealed(container):
+		return container.Headers.SealVersion
+	case container.Headers.ContentType == containerSealedContentType:
+		return v1.SealVersion
+	default:
+	}
+
+	// Not sealed
+	return 0
+}
+
+// IsRecipient returns true if the given identity is able to recover the payload
+// key of the sealed container. Only the container headers are used, the payload
+// is neither decrypted nor verified.
+func IsRecipient(container *containerv1.Container, identity *memguard.LockedBuffer, opts ...Option) (bool, error) {
+	// Check parameters
+	if identity == nil {
+		return false, errors.New("unable to process without container key")
+	}
+
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Build appropriate strategy processor.
+	var ss seal.Strategy
+	switch SealVersion(container) {
+	case 0:
+		return false, errors.New("the container is not sealed")
+	case v2.SealVersion:
+		ss = v2.New()
+	case v3.SealVersion:
+		ss = v3.New()
+	case v4.SealVersion:
+		ss = v4.New()
+	case v5.SealVersion:
+		ss = v5.New()
+	default:
+		ss = v1.New()
+	}
+
+	// Check recipient support
+	rc, ok := ss.(seal.RecipientChecker)
+	if !ok {
+		return false, errors.New("recipient check is not supported by the container seal version")
+	}
+
+	// Delegate to strategy
+	return rc.IsRecipient(container.Headers, identity, dopts.psk), nil
+}
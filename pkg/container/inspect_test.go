@@ -0,0 +1,156 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+	v4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+)
+
+func TestSealVersion(t *testing.T) {
+	assert.Equal(t, uint32(0), SealVersion(nil))
+	assert.Equal(t, uint32(0), SealVersion(&containerv1.Container{}))
+	assert.Equal(t, uint32(0), SealVersion(&containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+	}))
+
+	for fixture, version := range map[string]uint32{
+		"complete.bundle":    0,
+		"complete.v1.sealed": v1.SealVersion,
+		"complete.v2.sealed": v2.SealVersion,
+		"complete.v3.sealed": v3.SealVersion,
+	} {
+		f, err := os.Open("../../test/fixtures/bundles/" + fixture)
+		require.NoError(t, err)
+		c, err := Load(f)
+		require.NoError(t, f.Close())
+		require.NoError(t, err)
+
+		assert.Equal(t, version, SealVersion(c), fixture)
+	}
+}
+
+func TestIsRecipient(t *testing.T) {
+	psk := memguard.NewBufferFromBytes([]byte("pre-shared-key"))
+
+	for name, tc := range map[string]struct {
+		strategy seal.Strategy
+		seal     func(pub string, opts ...Option) (*containerv1.Container, error)
+	}{
+		"v1": {strategy: v1.New()},
+		"v2": {strategy: v2.New()},
+		"v3": {strategy: v3.New()},
+		"v4": {
+			strategy: v4.New(),
+			seal: func(pub string, opts ...Option) (*containerv1.Container, error) {
+				var in, out bytes.Buffer
+				if err := Dump(&in, &containerv1.Container{Headers: &containerv1.Header{}, Raw: []byte("test")}); err != nil {
+					return nil, err
+				}
+				if err := SealStream(rand.Reader, &out, &in, append(opts, WithPeerPublicKeys([]string{pub}))...); err != nil {
+					return nil, err
+				}
+				return Load(&out)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.seal == nil {
+				tc.seal = func(pub string, opts ...Option) (*containerv1.Container, error) {
+					return Seal(rand.Reader, &containerv1.Container{Headers: &containerv1.Header{}, Raw: []byte("test")}, append(opts, WithPeerPublicKeys([]string{pub}))...)
+				}
+			}
+
+			pub, priv, err := tc.strategy.GenerateKey()
+			require.NoError(t, err)
+			_, otherPriv, err := tc.strategy.GenerateKey()
+			require.NoError(t, err)
+			identity := memguard.NewBufferFromBytes([]byte(priv))
+			other := memguard.NewBufferFromBytes([]byte(otherPriv))
+
+			// Without pre-shared key
+			sealed, err := tc.seal(pub)
+			require.NoError(t, err)
+
+			ok, err := IsRecipient(sealed, identity)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = IsRecipient(sealed, identity, WithPreSharedKey(psk))
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = IsRecipient(sealed, other)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// With pre-shared key
+			sealed, err = tc.seal(pub, WithPreSharedKey(psk))
+			require.NoError(t, err)
+
+			ok, err = IsRecipient(sealed, identity)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = IsRecipient(sealed, identity, WithPreSharedKey(psk))
+			require.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = IsRecipient(sealed, other, WithPreSharedKey(psk))
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestIsRecipient_Errors(t *testing.T) {
+	identity := memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg"))
+
+	_, err := IsRecipient(nil, identity)
+	assert.Error(t, err)
+	_, err = IsRecipient(&containerv1.Container{Headers: &containerv1.Header{}}, identity)
+	assert.Error(t, err)
+	_, err = IsRecipient(&containerv1.Container{Headers: &containerv1.Header{ContentType: containerSealedContentType}}, nil)
+	assert.Error(t, err)
+}
+
+func TestIsRecipient_LegacySealedContainer(t *testing.T) {
+	f, err := os.Open("../../test/fixtures/bundles/complete.v1.sealed")
+	require.NoError(t, err)
+	defer f.Close()
+	sealed, err := Load(f)
+	require.NoError(t, err)
+
+	ok, err := IsRecipient(sealed, memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = IsRecipient(sealed, memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
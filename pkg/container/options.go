@@ -26,6 +26,8 @@ type Option func(opts *Options)
 type Options struct {
 	psk            *memguard.LockedBuffer
 	peersPublicKey []string
+	sealVersion    uint32
+	threshold      int
 }
 
 // WithPreSharedKey sets the pre-sharey used for seal/unseal operations.
@@ -41,3 +43,19 @@ func WithPeerPublicKeys(peers []string) Option {
 		opts.peersPublicKey = peers
 	}
 }
+
+// WithSealVersion restricts unseal operations to containers sealed with the
+// given seal strategy version.
+func WithSealVersion(version uint32) Option {
+	return func(opts *Options) {
+		opts.sealVersion = version
+	}
+}
+
+// WithThreshold enables threshold sealing, the given number of recipients is
+// required to unseal the container.
+func WithThreshold(threshold int) Option {
+	return func(opts *Options) {
+		opts.threshold = threshold
+	}
+}
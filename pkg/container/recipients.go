@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/awnumar/memguard"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/identity/key"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// AddRecipients wraps the payload key of a sealed container for the peer public
+// keys set with WithPeerPublicKeys. The payload key is recovered with the given
+// identity, the public keys must match the container seal version.
+func AddRecipients(rand io.Reader, container *containerv1.Container, identity *memguard.LockedBuffer, opts ...Option) (*containerv1.Container, error) {
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Check parameters
+	if len(dopts.peersPublicKey) == 0 {
+		return nil, errors.New("unable to add recipients without public keys")
+	}
+
+	// Select strategy
+	ss, version, err := recipientStrategy(container, identity, dopts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert peer public keys
+	peers := make([]string, 0, len(dopts.peersPublicKey))
+	for _, pub := range dopts.peersPublicKey {
+		switch {
+		case strings.HasPrefix(pub, fmt.Sprintf("v%d.sk.", version)):
+			peers = append(peers, pub)
+		case strings.HasPrefix(pub, fmt.Sprintf("v%d.ipk.", version)):
+			// Convert to sealing public key
+			identityPublicKey, errKey := key.FromString(pub)
+			if errKey != nil {
+				return nil, fmt.Errorf("unable to convert v%d identity public key %q: %w", version, pub, errKey)
+			}
+			peers = append(peers, identityPublicKey.SealingKey())
+		default:
+			return nil, fmt.Errorf("invalid key %q, container sealed with seal version %d requires v%d keys", pub, version, version)
+		}
+	}
+
+	// Delegate to strategy
+	return ss.AddRecipients(rand, container, identity, dopts.psk, peers...)
+}
+
+// RemoveRecipients removes the recipients matching the given identifiers from a
+// sealed container. The payload key is recovered with the given identity.
+//
+// The payload key is not rotated, removing a recipient doesn't revoke its
+// access to the container content.
+func RemoveRecipients(rand io.Reader, container *containerv1.Container, identity *memguard.LockedBuffer, identifiers [][]byte, opts ...Option) (*containerv1.Container, error) {
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Check parameters
+	if len(identifiers) == 0 {
+		return nil, errors.New("unable to remove recipients without identifiers")
+	}
+
+	// Select strategy
+	ss, _, err := recipientStrategy(container, identity, dopts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delegate to strategy
+	return ss.RemoveRecipients(rand, container, identity, dopts.psk, identifiers...)
+}
+
+// -----------------------------------------------------------------------------
+
+func recipientStrategy(container *containerv1.Container, identity *memguard.LockedBuffer, dopts *Options) (seal.RecipientStrategy, uint32, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, 0, errors.New("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return nil, 0, errors.New("unable to process nil container headers")
+	}
+	if identity == nil {
+		return nil, 0, errors.New("unable to process without container key")
+	}
+
+	// Check headers
+	if container.Headers.ContentType != containerSealedContentType {
+		return nil, 0, errors.New("the container is not sealed")
+	}
+
+	// Check expected seal version
+	if err := checkSealVersion(container.Headers, dopts.sealVersion); err != nil {
+		return nil, 0, err
+	}
+
+	// Build appropriate strategy processor.
+	var ss seal.Strategy
+	version := container.Headers.SealVersion
+	switch version {
+	case 0, v1.SealVersion:
+		ss, version = v1.New(), v1.SealVersion
+	case v2.SealVersion:
+		ss = v2.New()
+	case v3.SealVersion:
+		ss = v3.New()
+	default:
+		return nil, 0, fmt.Errorf("recipient management is not supported by seal version %d", version)
+	}
+
+	// Check recipient management support
+	rs, ok := ss.(seal.RecipientStrategy)
+	if !ok {
+		return nil, 0, fmt.Errorf("recipient management is not supported by seal version %d", version)
+	}
+
+	// No error
+	return rs, version, nil
+}
@@ -0,0 +1,121 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/google/go-cmp/cmp"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+)
+
+func TestRecipients_LegacySealedContainer(t *testing.T) {
+	containerKey := memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg"))
+
+	f, err := os.Open("../../test/fixtures/bundles/complete.v1.sealed")
+	if err != nil {
+		t.Fatalf("unable to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	sealed, err := Load(f)
+	if err != nil {
+		t.Fatalf("unable to load container: %v", err)
+	}
+
+	expected, err := Unseal(sealed, containerKey)
+	if err != nil {
+		t.Fatalf("unable to unseal container: %v", err)
+	}
+
+	pubKey, privKey, err := v1.New().GenerateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	v2PubKey, _, err := v2.New().GenerateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	// Key version must match the seal version
+	if _, err := AddRecipients(rand.Reader, sealed, containerKey, WithPeerPublicKeys([]string{v2PubKey})); err == nil {
+		t.Error("expected error for mismatched key version")
+	}
+
+	added, err := AddRecipients(rand.Reader, sealed, containerKey, WithPeerPublicKeys([]string{pubKey}))
+	if err != nil {
+		t.Fatalf("unable to add recipient: %v", err)
+	}
+	if len(added.Headers.Recipients) != len(sealed.Headers.Recipients)+1 {
+		t.Errorf("invalid recipient count, got %d", len(added.Headers.Recipients))
+	}
+
+	// Remove original recipients with the added identity
+	identifiers := [][]byte{}
+	for _, r := range sealed.Headers.Recipients {
+		identifiers = append(identifiers, r.Identifier)
+	}
+	removed, err := RemoveRecipients(rand.Reader, added, memguard.NewBufferFromBytes([]byte(privKey)), identifiers)
+	if err != nil {
+		t.Fatalf("unable to remove recipients: %v", err)
+	}
+	if len(removed.Headers.Recipients) != 1 {
+		t.Errorf("invalid recipient count, got %d", len(removed.Headers.Recipients))
+	}
+
+	if _, err := Unseal(removed, containerKey); err == nil {
+		t.Error("expected error for removed recipient")
+	}
+	out, err := Unseal(removed, memguard.NewBufferFromBytes([]byte(privKey)))
+	if err != nil {
+		t.Fatalf("unable to unseal container: %v", err)
+	}
+	if diff := cmp.Diff(expected, out, ignoreOpts...); diff != "" {
+		t.Errorf("unsealed container mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecipients_Unsupported(t *testing.T) {
+	containerKey := memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D"))
+
+	// Unsealed container
+	unsealed := &containerv1.Container{
+		Headers: &containerv1.Header{},
+		Raw:     []byte{0x01},
+	}
+	if _, err := RemoveRecipients(rand.Reader, unsealed, containerKey, [][]byte{{0x01}}); err == nil {
+		t.Error("expected error for unsealed container")
+	}
+
+	// Streaming seal version
+	streaming := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: containerSealedContentType,
+			SealVersion: 4,
+		},
+	}
+	if _, err := RemoveRecipients(rand.Reader, streaming, containerKey, [][]byte{{0x01}}); err == nil {
+		t.Error("expected error for streaming sealed container")
+	}
+}
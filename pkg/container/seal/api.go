@@ -39,6 +39,78 @@ type Strategy interface {
 	UnsealWithPSK(c *containerv1.Container, id *memguard.LockedBuffer, psk *memguard.LockedBuffer) (*containerv1.Container, error)
 }
 
+// StreamStrategy describes the streaming sealing/unsealing contract. The
+// payload is processed by chunks so that memory usage doesn't depend on the
+// container size.
+type StreamStrategy interface {
+	Strategy
+	// SealedSize returns the sealed payload size for the given payload size.
+	SealedSize(size uint64) uint64
+	// SealStream prepares the sealed container headers and returns the
+	// payload sealing function.
+	SealStream(rand io.Reader, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Header, PayloadFunc, error)
+	// UnsealStream recovers the payload key from the given sealed container
+	// headers and returns the payload unsealing function. The unsealing
+	// function writes each chunk once authenticated, the output written
+	// before an error must be discarded.
+	UnsealStream(headers *containerv1.Header, id, preSharedKey *memguard.LockedBuffer) (PayloadFunc, error)
+}
+
+// RecipientStrategy describes the sealed container recipient management
+// contract. The payload key is recovered with the given identity, the
+// container signature is recomputed for the updated headers.
+type RecipientStrategy interface {
+	Strategy
+	// AddRecipients wraps the payload key for the given peer public keys.
+	AddRecipients(rand io.Reader, c *containerv1.Container, id, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error)
+	// RemoveRecipients removes the recipients matching the given identifiers.
+	// The payload key is not rotated, access is not revoked.
+	RemoveRecipients(rand io.Reader, c *containerv1.Container, id, preSharedKey *memguard.LockedBuffer, identifiers ...[]byte) (*containerv1.Container, error)
+}
+
+// RecipientSealer describes the seal version specific primitives used by the
+// recipient management flow of a sealed container.
+type RecipientSealer interface {
+	// RecoverPayloadKey decrypts the payload key from the given sealed
+	// container headers with the given identity.
+	RecoverPayloadKey(headers *containerv1.Header, id, preSharedKey *memguard.LockedBuffer) (*memguard.LockedBuffer, error)
+	// OpenPayload decrypts the sealed container payload and verifies its
+	// signature.
+	OpenPayload(c *containerv1.Container, payloadKey *memguard.LockedBuffer) ([]byte, error)
+	// PackRecipients wraps the payload key for the given peer public keys.
+	PackRecipients(rand io.Reader, headers *containerv1.Header, payloadKey, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) ([]*containerv1.Recipient, error)
+	// Reseal signs the content for the given headers and encrypts it with the
+	// payload key.
+	Reseal(rand io.Reader, headers *containerv1.Header, payloadKey *memguard.LockedBuffer, content []byte) (*containerv1.Container, error)
+}
+
+// ThresholdStrategy describes the threshold sealing/unsealing contract. The
+// payload key is split between the recipients, a threshold of their shares
+// is required to unseal the container.
+type ThresholdStrategy interface {
+	Strategy
+	// SealThreshold seals the given container, threshold recipient shares
+	// being required to unseal it.
+	SealThreshold(rand io.Reader, c *containerv1.Container, preSharedKey *memguard.LockedBuffer, threshold int, encodedPeersPublicKey ...string) (*containerv1.Container, error)
+	// ExportShare recovers the encoded payload key share of the given
+	// identity from the sealed container headers.
+	ExportShare(headers *containerv1.Header, id, preSharedKey *memguard.LockedBuffer) (*memguard.LockedBuffer, error)
+	// UnsealWithShares unseals the given container by combining the given
+	// encoded payload key shares.
+	UnsealWithShares(c *containerv1.Container, shares ...*memguard.LockedBuffer) (*containerv1.Container, error)
+}
+
+// RecipientChecker describes the sealed container recipient check contract.
+type RecipientChecker interface {
+	// IsRecipient returns true if the payload key can be recovered from the
+	// given sealed container headers with the given identity.
+	IsRecipient(headers *containerv1.Header, id, preSharedKey *memguard.LockedBuffer) bool
+}
+
+// PayloadFunc transforms the payload of the given size read from the reader
+// and writes the result to the writer.
+type PayloadFunc func(w io.Writer, r io.Reader, size uint64) error
+
 // GenerateOptions represents container key generation options.
 type GenerateOptions struct {
 	DCKDMasterKey *memguard.LockedBuffer
@@ -0,0 +1,202 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package seal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+const (
+	containerSealedContentType = "application/vnd.harp.v1.SealedContainer"
+)
+
+// AddRecipients wraps the payload key of a sealed container for the given peer
+// public keys. The payload key is recovered with the given identity, the
+// container signature is recomputed for the updated headers.
+func AddRecipients(s RecipientSealer, rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	// Check parameters
+	if err := checkSealedContainer(container, identity); err != nil {
+		return nil, err
+	}
+	if len(encodedPeersPublicKey) == 0 {
+		return nil, fmt.Errorf("unable to process empty public keys")
+	}
+
+	// Recover payload key
+	payloadKey, err := s.RecoverPayloadKey(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer payloadKey.Destroy()
+
+	// Decrypt and verify payload
+	content, err := s.OpenPayload(container, payloadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap the payload key for the new recipients
+	recipients, err := s.PackRecipients(rand, container.Headers, payloadKey, preSharedKey, encodedPeersPublicKey...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Append to container
+	headers, _ := proto.Clone(container.Headers).(*containerv1.Header)
+	headers.Recipients = append(headers.Recipients, recipients...)
+
+	// Sign and encrypt the payload for the updated headers
+	return s.Reseal(rand, headers, payloadKey, content)
+}
+
+// RemoveRecipients removes the recipients matching the given identifiers from
+// a sealed container. The payload key is recovered with the given identity,
+// the container signature is recomputed for the updated headers.
+//
+// The payload key is not rotated, a removed recipient knowing it is still able
+// to decrypt the container.
+func RemoveRecipients(s RecipientSealer, rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, identifiers ...[]byte) (*containerv1.Container, error) {
+	// Check parameters
+	if err := checkSealedContainer(container, identity); err != nil {
+		return nil, err
+	}
+
+	// Recover payload key
+	payloadKey, err := s.RecoverPayloadKey(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer payloadKey.Destroy()
+
+	// Decrypt and verify payload
+	content, err := s.OpenPayload(container, payloadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove recipients
+	headers, _ := proto.Clone(container.Headers).(*containerv1.Header)
+	headers.Recipients, err = FilterRecipients(headers.Recipients, identifiers...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to remove recipients: %w", err)
+	}
+
+	// Sign and encrypt the payload for the updated headers
+	return s.Reseal(rand, headers, payloadKey, content)
+}
+
+// IsRecipient returns true if the payload key can be recovered from the given
+// sealed container headers with the given identity. The payload is neither
+// decrypted nor verified.
+func IsRecipient(s RecipientSealer, headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) bool {
+	// Check parameters
+	if types.IsNil(headers) || identity == nil {
+		return false
+	}
+	if headers.ContentType != containerSealedContentType {
+		return false
+	}
+
+	// Recover payload key
+	payloadKey, err := s.RecoverPayloadKey(headers, identity, preSharedKey)
+	if err != nil {
+		return false
+	}
+	payloadKey.Destroy()
+
+	// No error
+	return true
+}
+
+// FilterRecipients returns the given recipient list without the recipients
+// matching the given identifiers.
+func FilterRecipients(recipients []*containerv1.Recipient, identifiers ...[]byte) ([]*containerv1.Recipient, error) {
+	// Check arguments
+	if len(identifiers) == 0 {
+		return nil, errors.New("unable to process empty recipient identifiers")
+	}
+
+	// Ensure all identifiers match a recipient
+	for _, id := range identifiers {
+		found := false
+		for _, r := range recipients {
+			if bytes.Equal(r.Identifier, id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("recipient %x not found", id)
+		}
+	}
+
+	// Keep unmatched recipients
+	out := []*containerv1.Recipient{}
+	for _, r := range recipients {
+		removed := false
+		for _, id := range identifiers {
+			if bytes.Equal(r.Identifier, id) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			out = append(out, r)
+		}
+	}
+
+	// Sanity check
+	if len(out) == 0 {
+		return nil, errors.New("unable to remove all container recipients")
+	}
+
+	// No error
+	return out, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func checkSealedContainer(container *containerv1.Container, identity *memguard.LockedBuffer) error {
+	// Check parameters
+	if types.IsNil(container) {
+		return fmt.Errorf("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return fmt.Errorf("unable to process nil container headers")
+	}
+	if identity == nil {
+		return fmt.Errorf("unable to process without container key")
+	}
+
+	// Check headers
+	if container.Headers.ContentType != containerSealedContentType {
+		return fmt.Errorf("unable to process unsealed container")
+	}
+
+	// No error
+	return nil
+}
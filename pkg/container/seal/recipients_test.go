@@ -0,0 +1,187 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package seal_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+)
+
+var recipientStrategies = []struct {
+	name     string
+	strategy seal.Strategy
+}{
+	{name: "v1", strategy: v1.New()},
+	{name: "v2", strategy: v2.New()},
+	{name: "v3", strategy: v3.New()},
+}
+
+func TestRecipients_Add_Remove(t *testing.T) {
+	for _, tt := range recipientStrategies {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, ok := tt.strategy.(seal.RecipientStrategy)
+			require.True(t, ok)
+			psk := memguard.NewBufferRandom(64)
+
+			publicKey1, privateKey1, err := adapter.GenerateKey()
+			require.NoError(t, err)
+			publicKey2, privateKey2, err := adapter.GenerateKey()
+			require.NoError(t, err)
+			publicKey3, privateKey3, err := adapter.GenerateKey()
+			require.NoError(t, err)
+
+			raw := make([]byte, 1024)
+			_, err = rand.Read(raw)
+			require.NoError(t, err)
+
+			input := &containerv1.Container{
+				Headers: &containerv1.Header{
+					ContentEncoding: "gzip",
+					ContentType:     "application/vnd.harp.v1.Bundle",
+				},
+				Raw: raw,
+			}
+
+			sealed, err := adapter.SealWithPSK(rand.Reader, input, psk, publicKey1)
+			require.NoError(t, err)
+
+			// Add a recipient with the initial identity
+			added, err := adapter.AddRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), psk, publicKey2)
+			require.NoError(t, err)
+			assert.Len(t, added.Headers.Recipients, 2)
+			assert.Equal(t, sealed.Headers.EncryptionPublicKey, added.Headers.EncryptionPublicKey)
+
+			// Add a recipient with an added identity
+			added, err = adapter.AddRecipients(rand.Reader, added, memguard.NewBufferFromBytes([]byte(privateKey2)), psk, publicKey3)
+			require.NoError(t, err)
+			assert.Len(t, added.Headers.Recipients, 3)
+
+			for _, privateKey := range []string{privateKey1, privateKey2, privateKey3} {
+				unsealed, errUnseal := adapter.UnsealWithPSK(added, memguard.NewBufferFromBytes([]byte(privateKey)), psk)
+				require.NoError(t, errUnseal)
+				assert.True(t, proto.Equal(input, unsealed))
+			}
+
+			// Remove the initial recipient
+			removed, err := adapter.RemoveRecipients(rand.Reader, added, memguard.NewBufferFromBytes([]byte(privateKey3)), psk, sealed.Headers.Recipients[0].Identifier)
+			require.NoError(t, err)
+			assert.Len(t, removed.Headers.Recipients, 2)
+
+			_, err = adapter.UnsealWithPSK(removed, memguard.NewBufferFromBytes([]byte(privateKey1)), psk)
+			assert.Error(t, err)
+			for _, privateKey := range []string{privateKey2, privateKey3} {
+				unsealed, errUnseal := adapter.UnsealWithPSK(removed, memguard.NewBufferFromBytes([]byte(privateKey)), psk)
+				require.NoError(t, errUnseal)
+				assert.True(t, proto.Equal(input, unsealed))
+			}
+		})
+	}
+}
+
+func TestRecipients_Errors(t *testing.T) {
+	for _, tt := range recipientStrategies {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, ok := tt.strategy.(seal.RecipientStrategy)
+			require.True(t, ok)
+
+			publicKey1, privateKey1, err := adapter.GenerateKey()
+			require.NoError(t, err)
+			publicKey2, privateKey2, err := adapter.GenerateKey()
+			require.NoError(t, err)
+
+			input := &containerv1.Container{
+				Headers: &containerv1.Header{},
+				Raw:     []byte{0x00, 0x00},
+			}
+
+			sealed, err := adapter.Seal(rand.Reader, input, publicKey1)
+			require.NoError(t, err)
+
+			// Not a recipient
+			_, err = adapter.AddRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey2)), nil, publicKey2)
+			assert.Error(t, err)
+
+			// No public key
+			_, err = adapter.AddRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), nil)
+			assert.Error(t, err)
+
+			// Invalid public key
+			_, err = adapter.AddRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), nil, "invalid")
+			assert.Error(t, err)
+
+			// Unsealed container
+			_, err = adapter.AddRecipients(rand.Reader, input, memguard.NewBufferFromBytes([]byte(privateKey1)), nil, publicKey2)
+			assert.Error(t, err)
+
+			// Unknown identifier
+			_, err = adapter.RemoveRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), nil, []byte("unknown"))
+			assert.Error(t, err)
+
+			// All recipients
+			_, err = adapter.RemoveRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), nil, sealed.Headers.Recipients[0].Identifier)
+			assert.Error(t, err)
+
+			// Tampered container
+			sealed.Headers.ContentEncoding = "tampered"
+			_, err = adapter.AddRecipients(rand.Reader, sealed, memguard.NewBufferFromBytes([]byte(privateKey1)), nil, publicKey2)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestIsRecipient(t *testing.T) {
+	for _, tt := range recipientStrategies {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			checker, ok := tt.strategy.(seal.RecipientChecker)
+			require.True(t, ok)
+
+			publicKey1, privateKey1, err := tt.strategy.GenerateKey()
+			require.NoError(t, err)
+			_, privateKey2, err := tt.strategy.GenerateKey()
+			require.NoError(t, err)
+
+			input := &containerv1.Container{
+				Headers: &containerv1.Header{},
+				Raw:     []byte{0x00, 0x00},
+			}
+
+			sealed, err := tt.strategy.Seal(rand.Reader, input, publicKey1)
+			require.NoError(t, err)
+
+			assert.False(t, checker.IsRecipient(nil, memguard.NewBufferFromBytes([]byte(privateKey1)), nil))
+			assert.False(t, checker.IsRecipient(sealed.Headers, nil, nil))
+			assert.False(t, checker.IsRecipient(input.Headers, memguard.NewBufferFromBytes([]byte(privateKey1)), nil))
+			assert.False(t, checker.IsRecipient(sealed.Headers, memguard.NewBufferFromBytes([]byte(privateKey2)), nil))
+			assert.True(t, checker.IsRecipient(sealed.Headers, memguard.NewBufferFromBytes([]byte(privateKey1)), nil))
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"crypto/ed25519"
 
 	"github.com/zntrio/harp/v2/pkg/container/seal"
+
+	"golang.org/x/crypto/nacl/secretbox"
 )
 
 const (
@@ -37,6 +39,8 @@ const (
 	preSharedKeySize           = 64
 	signatureSize              = ed25519.SignatureSize
 	messageLimit               = 64 * 1024 * 1024
+	recipientKeySize           = nonceSize + encryptionKeySize + secretbox.Overhead
+	containerBoxSize           = ed25519.PublicKeySize + secretbox.Overhead
 
 	staticSignatureNonce      = "harp_container_psigk_box"
 	signatureDomainSeparation = "harp encrypted signature"
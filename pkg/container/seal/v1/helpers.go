@@ -203,3 +203,38 @@ func tryRecipientKeys(derivedKey *[encryptionKeySize]byte, recipients []*contain
 	// No recipient found in list.
 	return nil, fmt.Errorf("no recipient found")
 }
+
+func tryEphemeralRecipientKeys(privateKey *[privateKeySize]byte, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) ([]byte, error) {
+	// Find matching recipient
+	for _, r := range recipients {
+		// Recipients added after sealing are prefixed by their own ephemeral
+		// public key.
+		if len(r.Key) != publicKeySize+recipientKeySize {
+			continue
+		}
+
+		// Decode ephemeral public key
+		var publicKey [publicKeySize]byte
+		copy(publicKey[:], r.Key[:publicKeySize])
+
+		// Derive recipient key
+		derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, privateKey, preSharedKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compute recipient shared key: %w", err)
+		}
+
+		// Try the recipient with its own ephemeral public key
+		payloadKey, err := tryRecipientKeys(derivedKey, []*containerv1.Recipient{
+			{Identifier: r.Identifier, Key: r.Key[publicKeySize:]},
+		}, preSharedKey)
+		if err != nil {
+			continue
+		}
+
+		// Encryption key found, return no error.
+		return payloadKey, nil
+	}
+
+	// No recipient found in list.
+	return nil, fmt.Errorf("no recipient found")
+}
@@ -25,8 +25,12 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+
+	"golang.org/x/crypto/nacl/secretbox"
 )
 
 var (
@@ -254,6 +258,62 @@ func Test_Seal_Unseal_WithPSK(t *testing.T) {
 	}
 }
 
+func Test_Reseal_ContainerBox(t *testing.T) {
+	a, _ := New().(*adapter)
+
+	pubKey, privKey, err := a.GenerateKey()
+	require.NoError(t, err)
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: "gzip",
+			ContentType:     "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte{0x00, 0x00},
+	}
+
+	sealed, err := a.Seal(rand.Reader, input, pubKey)
+	require.NoError(t, err)
+
+	// Recover payload key
+	payloadKey, err := a.RecoverPayloadKey(sealed.Headers, memguard.NewBufferFromBytes([]byte(privKey)), nil)
+	require.NoError(t, err)
+	defer payloadKey.Destroy()
+	content, err := a.OpenPayload(sealed, payloadKey)
+	require.NoError(t, err)
+
+	// Reseal with the same payload key
+	headers, _ := proto.Clone(sealed.Headers).(*containerv1.Header)
+	resealed, err := a.Reseal(rand.Reader, headers, payloadKey, content)
+	require.NoError(t, err)
+
+	// Compare the container boxes side by side
+	oldBox := sealed.Headers.ContainerBox
+	newBox := resealed.Headers.ContainerBox
+	require.Len(t, oldBox, containerBoxSize)
+	require.Len(t, newBox, nonceSize+containerBoxSize)
+	assert.NotEqual(t, []byte(staticSignatureNonce), newBox[:nonceSize])
+
+	var oldNonce, newNonce [nonceSize]byte
+	copy(oldNonce[:], staticSignatureNonce)
+	copy(newNonce[:], newBox[:nonceSize])
+	oldSigPub, ok := secretbox.Open(nil, oldBox, &oldNonce, payloadKey.ByteArray32())
+	require.True(t, ok)
+	newSigPub, ok := secretbox.Open(nil, newBox[nonceSize:], &newNonce, payloadKey.ByteArray32())
+	require.True(t, ok)
+	assert.NotEqual(t, oldSigPub, newSigPub)
+
+	// A reused nonce would encrypt both signing keys with the same keystream
+	oldCiphertext := oldBox[secretbox.Overhead:]
+	newCiphertext := newBox[nonceSize+secretbox.Overhead:]
+	for i := range oldSigPub {
+		if oldCiphertext[i]^newCiphertext[i] != oldSigPub[i]^newSigPub[i] {
+			return
+		}
+	}
+	t.Error("resealed container signing key is encrypted with the sealing keystream")
+}
+
 func Test_Seal_Fuzz(t *testing.T) {
 	adapter := New()
 
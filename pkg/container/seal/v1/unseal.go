@@ -42,7 +42,6 @@ func (a *adapter) UnsealWithPSK(container *containerv1.Container, identity, preS
 	return a.unseal(container, identity, preSharedKey)
 }
 
-//nolint:gocyclo,funlen // To refactor
 func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
 	// Check parameters
 	if types.IsNil(container) {
@@ -60,20 +59,45 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 		return nil, fmt.Errorf("unable to unseal container")
 	}
 
+	// Recover payload key
+	encryptionKey, _, err := recoverPayloadKey(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt and verify payload
+	content, err := openPayload(container, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal inner container
+	out := &containerv1.Container{}
+	if err := proto.Unmarshal(content, out); err != nil {
+		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	}
+
+	// No error
+	return out, nil
+}
+
+// recoverPayloadKey decrypts the payload key from the container recipients
+// using the given identity. It also returns the stretched preshared key.
+func recoverPayloadKey(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*[encryptionKeySize]byte, *[preSharedKeySize]byte, error) {
 	// Check ephemeral container public encryption key
-	if len(container.Headers.EncryptionPublicKey) != publicKeySize {
-		return nil, fmt.Errorf("invalid container public size")
+	if len(headers.EncryptionPublicKey) != publicKeySize {
+		return nil, nil, fmt.Errorf("invalid container public size")
 	}
 	var publicKey [publicKeySize]byte
-	copy(publicKey[:], container.Headers.EncryptionPublicKey[:publicKeySize])
+	copy(publicKey[:], headers.EncryptionPublicKey[:publicKeySize])
 
 	// Decode private key
 	privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(identity.String(), PrivateKeyPrefix))
 	if err != nil {
-		return nil, fmt.Errorf("unable to decode private key: %w", err)
+		return nil, nil, fmt.Errorf("unable to decode private key: %w", err)
 	}
 	if len(privRaw) != privateKeySize {
-		return nil, fmt.Errorf("invalid identity private key length")
+		return nil, nil, fmt.Errorf("invalid identity private key length")
 	}
 	var pk [privateKeySize]byte
 	copy(pk[:], privRaw[:privateKeySize])
@@ -83,35 +107,56 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 	if preSharedKey != nil {
 		psk, err = pskStretch(preSharedKey.Bytes(), publicKey[:])
 		if err != nil {
-			return nil, fmt.Errorf("unable to stretch preshared key: %w", err)
+			return nil, nil, fmt.Errorf("unable to stretch preshared key: %w", err)
 		}
 	}
 
 	// Precompute identifier
 	derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, &pk, psk)
 	if err != nil {
-		return nil, fmt.Errorf("unable to compute recipient shared key: %w", err)
+		return nil, nil, fmt.Errorf("unable to compute recipient shared key: %w", err)
 	}
 
 	// Try recipients
-	payloadKey, err := tryRecipientKeys(derivedKey, container.Headers.Recipients, psk)
+	payloadKey, err := tryRecipientKeys(derivedKey, headers.Recipients, psk)
 	if err != nil {
-		return nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+		// Try recipients added after sealing
+		var errEph error
+		payloadKey, errEph = tryEphemeralRecipientKeys(&pk, headers.Recipients, psk)
+		if errEph != nil {
+			return nil, nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+		}
 	}
 
 	// Check private key
 	if len(payloadKey) != encryptionKeySize {
-		return nil, fmt.Errorf("invalid encryption key size")
+		return nil, nil, fmt.Errorf("invalid encryption key size")
 	}
 	var encryptionKey [encryptionKeySize]byte
 	copy(encryptionKey[:], payloadKey[:encryptionKeySize])
 
-	// Prepare sig nonce
+	// No error
+	return &encryptionKey, psk, nil
+}
+
+// openPayload decrypts the container payload and verifies its signature.
+func openPayload(container *containerv1.Container, encryptionKey *[encryptionKeySize]byte) ([]byte, error) {
+	// Prepare sig nonce, the signing public key of a resealed container is
+	// prefixed with a random nonce.
 	var pubSigNonce [nonceSize]byte
-	copy(pubSigNonce[:], "harp_container_psigk_box")
+	containerBox := container.Headers.ContainerBox
+	switch len(containerBox) {
+	case containerBoxSize:
+		copy(pubSigNonce[:], staticSignatureNonce)
+	case nonceSize + containerBoxSize:
+		copy(pubSigNonce[:], containerBox[:nonceSize])
+		containerBox = containerBox[nonceSize:]
+	default:
+		return nil, fmt.Errorf("invalid container key")
+	}
 
 	// Decrypt signing public key
-	containerSignKeyRaw, ok := secretbox.Open(nil, container.Headers.ContainerBox, &pubSigNonce, &encryptionKey)
+	containerSignKeyRaw, ok := secretbox.Open(nil, containerBox, &pubSigNonce, encryptionKey)
 	if !ok {
 		return nil, fmt.Errorf("invalid container key")
 	}
@@ -130,7 +175,7 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 	copy(payloadNonce[:], headerHash[:nonceSize])
 
 	// Decrypt payload
-	payloadRaw, ok := secretbox.Open(nil, container.Raw, &payloadNonce, &encryptionKey)
+	payloadRaw, ok := secretbox.Open(nil, container.Raw, &payloadNonce, encryptionKey)
 	if !ok || len(payloadRaw) < signatureSize {
 		return nil, fmt.Errorf("invalid ciphered content")
 	}
@@ -147,12 +192,6 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 		return nil, fmt.Errorf("invalid container signature")
 	}
 
-	// Unmarshal inner container
-	out := &containerv1.Container{}
-	if err := proto.Unmarshal(content, out); err != nil {
-		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
-	}
-
 	// No error
-	return out, nil
+	return content, nil
 }
@@ -38,6 +38,7 @@ const (
 	macSize                    = 48
 	signatureSize              = 96
 	messageLimit               = 64 * 1024 * 1024
+	recipientKeySize           = seedSize + encryptionKeySize + macSize
 )
 
 var (
@@ -82,6 +82,45 @@ func tryRecipientKeys(derivedKey *[32]byte, recipients []*containerv1.Recipient,
 	return nil, fmt.Errorf("no recipient found")
 }
 
+func tryEphemeralRecipientKeys(privateKey *ecdsa.PrivateKey, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) (*[32]byte, error) {
+	// Find matching recipient
+	for _, r := range recipients {
+		// Recipients added after sealing are prefixed by their own ephemeral
+		// public key.
+		if len(r.Key) != publicKeySize+recipientKeySize {
+			continue
+		}
+
+		// Decode ephemeral public key
+		var publicKey ecdsa.PublicKey
+		publicKey.Curve = encryptionCurve
+		publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(encryptionCurve, r.Key[:publicKeySize])
+		if publicKey.X == nil {
+			continue
+		}
+
+		// Derive recipient key
+		derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, privateKey, preSharedKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+		}
+
+		// Try the recipient with its own ephemeral public key
+		payloadKey, err := tryRecipientKeys(derivedKey, []*containerv1.Recipient{
+			{Identifier: r.Identifier, Key: r.Key[publicKeySize:]},
+		}, preSharedKey)
+		if err != nil {
+			continue
+		}
+
+		// Encryption key found, return no error.
+		return payloadKey, nil
+	}
+
+	// No recipient found in list.
+	return nil, fmt.Errorf("no recipient found")
+}
+
 func prepareSignature(rand io.Reader, encryptionKey *[32]byte) (*ecdsa.PrivateKey, []byte, error) {
 	// Generate ephemeral signing key
 	sigPriv, err := ecdsa.GenerateKey(signatureCurve, cryptorand.Reader)
@@ -112,10 +151,21 @@ func signContainer(sigPriv *ecdsa.PrivateKey, headers *containerv1.Header, conta
 		return nil, nil, fmt.Errorf("unable to encode container content: %w", err)
 	}
 
+	// Sign the serialized container
+	containerSig, err = signContent(sigPriv, headers, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// No error
+	return content, containerSig, nil
+}
+
+func signContent(sigPriv *ecdsa.PrivateKey, headers *containerv1.Header, content []byte) ([]byte, error) {
 	// Compute header hash
 	headerHash, err := computeHeaderHash(headers)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to compute header hash: %w", err)
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
 	}
 
 	// Compute protected content hash
@@ -124,14 +174,17 @@ func signContainer(sigPriv *ecdsa.PrivateKey, headers *containerv1.Header, conta
 	// Sign the protected content
 	r, s, err := ecdsa.Sign(cryptorand.Reader, sigPriv, protectedHash)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to sign protected content: %w", err)
+		return nil, fmt.Errorf("unable to sign protected content: %w", err)
 	}
 
-	// Container signature
-	containerSig = append(r.Bytes(), s.Bytes()...)
+	// Container signature, each component is encoded on a fixed size to keep
+	// the signature length constant.
+	containerSig := make([]byte, signatureSize)
+	r.FillBytes(containerSig[:signatureSize/2])
+	s.FillBytes(containerSig[signatureSize/2:])
 
 	// No error
-	return content, containerSig, nil
+	return containerSig, nil
 }
 
 func generatedEncryptionKey(rand io.Reader) (*[32]byte, error) {
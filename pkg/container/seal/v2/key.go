@@ -86,7 +86,7 @@ func (a *adapter) GenerateKey(fopts ...seal.GenerateOption) (publicKey, privateK
 
 	// Encode keys
 	encodedPub := append([]byte(PublicKeyPrefix), base64.RawURLEncoding.EncodeToString(elliptic.MarshalCompressed(priv.Curve, priv.PublicKey.X, priv.PublicKey.Y))...)
-	encodedPriv := append([]byte(PrivateKeyPrefix), base64.RawURLEncoding.EncodeToString(priv.D.Bytes())...)
+	encodedPriv := append([]byte(PrivateKeyPrefix), base64.RawURLEncoding.EncodeToString(priv.D.FillBytes(make([]byte, privateKeySize)))...)
 
 	// No error
 	return string(encodedPub), string(encodedPriv), nil
@@ -18,6 +18,8 @@ package v2
 
 import (
 	"bytes"
+	"encoding/base64"
+	"strings"
 	"testing"
 
 	"github.com/awnumar/memguard"
@@ -69,6 +71,17 @@ func TestGenerateKey(t *testing.T) {
 		assert.Equal(t, "v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ", pub)
 	})
 
+	t.Run("fixed size private key", func(t *testing.T) {
+		// An empty random source generates the smallest private scalar.
+		pub, pk, err := adapter.GenerateKey(seal.WithRandom(bytes.NewReader(make([]byte, 56))))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, pub)
+
+		privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(pk, PrivateKeyPrefix))
+		assert.NoError(t, err)
+		assert.Len(t, privRaw, privateKeySize)
+	})
+
 	t.Run("default", func(t *testing.T) {
 		pub, pk, err := adapter.GenerateKey()
 		assert.NoError(t, err)
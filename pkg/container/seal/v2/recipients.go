@@ -0,0 +1,145 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+)
+
+// AddRecipients wraps the payload key of a sealed container for the given peer
+// public keys. The payload key is recovered with the given identity.
+func (a *adapter) AddRecipients(rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return seal.AddRecipients(a, rand, container, identity, preSharedKey, encodedPeersPublicKey...)
+}
+
+// RemoveRecipients removes the recipients matching the given identifiers from
+// a sealed container. The payload key is recovered with the given identity.
+func (a *adapter) RemoveRecipients(rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, identifiers ...[]byte) (*containerv1.Container, error) {
+	return seal.RemoveRecipients(a, rand, container, identity, preSharedKey, identifiers...)
+}
+
+// IsRecipient returns true if the payload key can be recovered from the given
+// sealed container headers with the given identity. The payload is neither
+// decrypted nor verified.
+func (a *adapter) IsRecipient(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) bool {
+	return seal.IsRecipient(a, headers, identity, preSharedKey)
+}
+
+// -----------------------------------------------------------------------------
+
+// RecoverPayloadKey decrypts the payload key from the given sealed container
+// headers with the given identity.
+func (a *adapter) RecoverPayloadKey(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*memguard.LockedBuffer, error) {
+	payloadKey, _, err := recoverPayloadKey(headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// No error
+	return memguard.NewBufferFromBytes(payloadKey[:]), nil
+}
+
+// OpenPayload decrypts the sealed container payload and verifies its signature.
+func (a *adapter) OpenPayload(container *containerv1.Container, payloadKey *memguard.LockedBuffer) ([]byte, error) {
+	return openPayload(container, payloadKey.ByteArray32())
+}
+
+// PackRecipients wraps the payload key for the given peer public keys. The
+// container ephemeral private key is not known anymore, the recipient key is
+// prefixed with a new ephemeral public key.
+func (a *adapter) PackRecipients(rand io.Reader, headers *containerv1.Header, payloadKey, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) ([]*containerv1.Recipient, error) {
+	// Convert public keys
+	peersPublicKey, err := a.publicKeys(encodedPeersPublicKey...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert peer public keys: %w", err)
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk = pskStretch(preSharedKey.Bytes(), headers.EncryptionPublicKey)
+	}
+
+	// Generate ephemeral encryption key
+	encPriv, err := ecdsa.GenerateKey(encryptionCurve, rand)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+	encPub := elliptic.MarshalCompressed(encPriv.Curve, encPriv.PublicKey.X, encPriv.PublicKey.Y)
+
+	// Process recipients
+	recipients := []*containerv1.Recipient{}
+	for _, peerPublicKey := range peersPublicKey {
+		// Ignore nil key
+		if peerPublicKey == nil {
+			continue
+		}
+
+		// Pack recipient using its public key
+		r, errPack := packRecipient(rand, payloadKey.ByteArray32(), encPriv, peerPublicKey, psk)
+		if errPack != nil {
+			return nil, fmt.Errorf("unable to pack container recipient: %w", errPack)
+		}
+
+		// Prefix the recipient key with the ephemeral public key
+		r.Key = append(append([]byte{}, encPub...), r.Key...)
+
+		// Append to recipients
+		recipients = append(recipients, r)
+	}
+
+	// No error
+	return recipients, nil
+}
+
+// Reseal signs the content with a new ephemeral signing key bound to the given
+// headers, and encrypts it with the payload key.
+func (a *adapter) Reseal(rand io.Reader, headers *containerv1.Header, payloadKey *memguard.LockedBuffer, content []byte) (*containerv1.Container, error) {
+	// Prepare signature identity
+	sigPriv, encryptedPubSig, err := prepareSignature(rand, payloadKey.ByteArray32())
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare signature materials: %w", err)
+	}
+	headers.ContainerBox = encryptedPubSig
+
+	// Sign the content
+	containerSig, err := signContent(sigPriv, headers, content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign container data: %w", err)
+	}
+
+	// Encrypt payload
+	encryptedPayload, err := encrypt(rand, append(containerSig, content...), payloadKey.ByteArray32())
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt container data: %w", err)
+	}
+
+	// No error
+	return &containerv1.Container{
+		Headers: headers,
+		Raw:     encryptedPayload,
+	}, nil
+}
@@ -17,7 +17,12 @@
 package v2
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/awnumar/memguard"
@@ -25,6 +30,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
 )
@@ -230,6 +236,97 @@ func Test_Seal_Unseal_WithPSK(t *testing.T) {
 	}
 }
 
+func Test_Unseal_Signature(t *testing.T) {
+	adapter := New()
+
+	pubKey, privKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: "gzip",
+			ContentType:     "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte{0x00, 0x00},
+	}
+
+	// Resign the sealed payload content with the given signature function
+	resign := func(t *testing.T, sign func(headerHash, content []byte) []byte) *containerv1.Container {
+		t.Helper()
+
+		sealed, err := adapter.Seal(rand.Reader, input, pubKey)
+		require.NoError(t, err)
+
+		// Recover payload key
+		var publicKey ecdsa.PublicKey
+		publicKey.Curve = encryptionCurve
+		publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(encryptionCurve, sealed.Headers.EncryptionPublicKey)
+		privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(privKey, PrivateKeyPrefix))
+		require.NoError(t, err)
+		var pk ecdsa.PrivateKey
+		pk.PublicKey.Curve = encryptionCurve
+		pk.D = big.NewInt(0).SetBytes(privRaw)
+		derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, &pk, nil)
+		require.NoError(t, err)
+		payloadKey, err := tryRecipientKeys(derivedKey, sealed.Headers.Recipients, nil)
+		require.NoError(t, err)
+
+		// Replace the detached signature
+		payloadRaw, err := decrypt(sealed.Raw, payloadKey)
+		require.NoError(t, err)
+		headerHash, err := computeHeaderHash(sealed.Headers)
+		require.NoError(t, err)
+		content := payloadRaw[signatureSize:]
+		sealed.Raw, err = encrypt(rand.Reader, append(sign(headerHash, content), content...), payloadKey)
+		require.NoError(t, err)
+
+		return sealed
+	}
+
+	// Sign the protected content with the given private key
+	signWith := func(t *testing.T, sigPriv *ecdsa.PrivateKey) func(headerHash, content []byte) []byte {
+		return func(headerHash, content []byte) []byte {
+			r, s, err := ecdsa.Sign(rand.Reader, sigPriv, computeProtectedHash(headerHash, content))
+			require.NoError(t, err)
+
+			sig := make([]byte, signatureSize)
+			r.FillBytes(sig[:signatureSize/2])
+			s.FillBytes(sig[signatureSize/2:])
+			return sig
+		}
+	}
+
+	t.Run("tampered signature", func(t *testing.T) {
+		sealed := resign(t, func(_, _ []byte) []byte {
+			return make([]byte, signatureSize)
+		})
+
+		_, err := adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)))
+		assert.Error(t, err)
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		sigPriv, err := ecdsa.GenerateKey(signatureCurve, rand.Reader)
+		require.NoError(t, err)
+
+		sealed := resign(t, signWith(t, sigPriv))
+
+		_, err = adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)))
+		assert.Error(t, err)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		sealed, err := adapter.Seal(rand.Reader, input, pubKey)
+		require.NoError(t, err)
+
+		unsealed, err := adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)))
+		require.NoError(t, err)
+		if diff := cmp.Diff(unsealed, input, ignoreOpts...); diff != "" {
+			t.Errorf("Seal/Unseal()\n-got/+want\ndiff %s", diff)
+		}
+	})
+}
+
 func Test_Seal_Fuzz(t *testing.T) {
 	adapter := New()
 
@@ -20,7 +20,6 @@ package v2
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
-	"crypto/sha512"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -44,7 +43,6 @@ func (a *adapter) UnsealWithPSK(container *containerv1.Container, identity, preS
 	return a.unseal(container, identity, preSharedKey)
 }
 
-//nolint:funlen,gocyclo // To refactor
 func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
 	// Check parameters
 	if types.IsNil(container) {
@@ -62,26 +60,51 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 		return nil, fmt.Errorf("unable to unseal container")
 	}
 
+	// Recover payload key
+	encryptionKey, _, err := recoverPayloadKey(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt and verify payload
+	content, err := openPayload(container, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal inner container
+	out := &containerv1.Container{}
+	if err := proto.Unmarshal(content, out); err != nil {
+		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	}
+
+	// No error
+	return out, nil
+}
+
+// recoverPayloadKey decrypts the payload key from the container recipients
+// using the given identity. It also returns the stretched preshared key.
+func recoverPayloadKey(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*[encryptionKeySize]byte, *[preSharedKeySize]byte, error) {
 	// Check ephemeral container public encryption key
-	if len(container.Headers.EncryptionPublicKey) != publicKeySize {
-		return nil, fmt.Errorf("invalid container public size")
+	if len(headers.EncryptionPublicKey) != publicKeySize {
+		return nil, nil, fmt.Errorf("invalid container public size")
 	}
 
 	// Decode public key
 	var publicKey ecdsa.PublicKey
 	publicKey.Curve = elliptic.P384()
-	publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(elliptic.P384(), container.Headers.EncryptionPublicKey)
+	publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(elliptic.P384(), headers.EncryptionPublicKey)
 	if publicKey.X == nil {
-		return nil, errors.New("invalid container signing public key")
+		return nil, nil, errors.New("invalid container signing public key")
 	}
 
 	// Decode private key
 	privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(identity.String(), PrivateKeyPrefix))
 	if err != nil {
-		return nil, fmt.Errorf("unable to decode private key: %w", err)
+		return nil, nil, fmt.Errorf("unable to decode private key: %w", err)
 	}
 	if len(privRaw) != privateKeySize {
-		return nil, fmt.Errorf("invalid identity private key length")
+		return nil, nil, fmt.Errorf("invalid identity private key length")
 	}
 	var pk ecdsa.PrivateKey
 	pk.PublicKey.Curve = elliptic.P384()
@@ -90,30 +113,34 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 	// Compute preshared key
 	var psk *[preSharedKeySize]byte
 	if preSharedKey != nil {
-		psk = pskStretch(preSharedKey.Bytes(), container.Headers.EncryptionPublicKey)
+		psk = pskStretch(preSharedKey.Bytes(), headers.EncryptionPublicKey)
 	}
 
 	// Precompute identifier
 	derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, &pk, psk)
 	if err != nil {
-		return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+		return nil, nil, fmt.Errorf("unable to execute key agreement: %w", err)
 	}
 
 	// Try recipients
-	payloadKey, err := tryRecipientKeys(derivedKey, container.Headers.Recipients, psk)
+	payloadKey, err := tryRecipientKeys(derivedKey, headers.Recipients, psk)
 	if err != nil {
-		return nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+		// Try recipients added after sealing
+		var errEph error
+		payloadKey, errEph = tryEphemeralRecipientKeys(&pk, headers.Recipients, psk)
+		if errEph != nil {
+			return nil, nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+		}
 	}
 
-	// Check private key
-	if len(payloadKey) != encryptionKeySize {
-		return nil, fmt.Errorf("invalid encryption key size")
-	}
-	var encryptionKey [encryptionKeySize]byte
-	copy(encryptionKey[:], payloadKey[:encryptionKeySize])
+	// No error
+	return payloadKey, psk, nil
+}
 
+// openPayload decrypts the container payload and verifies its signature.
+func openPayload(container *containerv1.Container, encryptionKey *[encryptionKeySize]byte) ([]byte, error) {
 	// Decrypt signing public key
-	containerSignKeyRaw, err := decrypt(container.Headers.ContainerBox, payloadKey)
+	containerSignKeyRaw, err := decrypt(container.Headers.ContainerBox, encryptionKey)
 	if err != nil {
 		return nil, fmt.Errorf("invalid container key")
 	}
@@ -121,6 +148,14 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 		return nil, fmt.Errorf("invalid signature key size")
 	}
 
+	// Decode signing public key
+	var sigPub ecdsa.PublicKey
+	sigPub.Curve = signatureCurve
+	sigPub.X, sigPub.Y = elliptic.UnmarshalCompressed(signatureCurve, containerSignKeyRaw)
+	if sigPub.X == nil {
+		return nil, errors.New("invalid container signing public key")
+	}
+
 	// Compute headers hash
 	headerHash, err := computeHeaderHash(container.Headers)
 	if err != nil {
@@ -128,36 +163,27 @@ func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKe
 	}
 
 	// Decrypt payload
-	payloadRaw, err := decrypt(container.Raw, &encryptionKey)
+	payloadRaw, err := decrypt(container.Raw, encryptionKey)
 	if err != nil || len(payloadRaw) < signatureSize {
 		return nil, fmt.Errorf("invalid ciphered content")
 	}
 
-	// Prepare protected content
-	protectedHash := computeProtectedHash(headerHash, payloadRaw)
-
 	// Extract signature / content
 	detachedSig := payloadRaw[:signatureSize]
 	content := payloadRaw[signatureSize:]
 
-	// Compute SHA-384 checksum
-	digest := sha512.Sum384(protectedHash)
+	// Prepare protected content
+	protectedHash := computeProtectedHash(headerHash, content)
 
 	var (
 		r = big.NewInt(0).SetBytes(detachedSig[:48])
 		s = big.NewInt(0).SetBytes(detachedSig[48:])
 	)
 	// Validate signature
-	if ecdsa.Verify(&publicKey, digest[:], r, s) {
-		return nil, fmt.Errorf("unable to verify protected content: %w", err)
-	}
-
-	// Unmarshal inner container
-	out := &containerv1.Container{}
-	if err := proto.Unmarshal(content, out); err != nil {
-		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	if !ecdsa.Verify(&sigPub, protectedHash, r, s) {
+		return nil, fmt.Errorf("invalid container signature")
 	}
 
 	// No error
-	return out, nil
+	return content, nil
 }
@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package v3 implements a post-quantum hybrid container sealing strategy.
+//
+// Recipient payload keys are wrapped using a hybrid KEM combining ML-KEM-768
+// and X25519, so that the payload key stays protected as long as one of both
+// primitives remains unbroken.
+package v3
+
+import (
+	"crypto/ed25519"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	SealVersion = 3
+)
+
+const (
+	containerSealedContentType = "application/vnd.harp.v1.SealedContainer"
+	x25519KeySize              = 32
+	publicKeySize              = mlkem.EncapsulationKeySize768 + x25519KeySize
+	privateKeySize             = mlkem.SeedSize + x25519KeySize
+	kemCiphertextSize          = mlkem.CiphertextSize768
+	encryptionKeySize          = 32
+	keyIdentifierSize          = 32
+	nonceSize                  = 24
+	preSharedKeySize           = 64
+	signatureSize              = ed25519.SignatureSize
+	messageLimit               = 64 * 1024 * 1024
+	recipientKeySize           = kemCiphertextSize + nonceSize + encryptionKeySize + secretbox.Overhead
+	containerBoxSize           = ed25519.PublicKeySize + secretbox.Overhead
+
+	staticSignatureNonce      = "harp_container_psigk_box"
+	signatureDomainSeparation = "harp encrypted signature"
+	hybridKEMLabel            = "harp hybrid kem mlkem768x25519 v3"
+)
+
+// -----------------------------------------------------------------------------
+
+func New() seal.Strategy {
+	return &adapter{}
+}
+
+type adapter struct{}
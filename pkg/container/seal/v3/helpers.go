@@ -0,0 +1,274 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/sha3"
+)
+
+func pskStretch(key, salt []byte) (*[preSharedKeySize]byte, error) {
+	pskh, err := blake2b.New512(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare preshared key: %w", err)
+	}
+	pskh.Write(salt)
+	hashPsk := pskh.Sum(nil)
+
+	psk := &[preSharedKeySize]byte{}
+	copy(psk[:], hashPsk[:preSharedKeySize])
+
+	return psk, nil
+}
+
+// combineSharedKeys derives the recipient key from both KEM shared secrets.
+//
+// SHA3-256(label || ss_mlkem || ss_x25519 || ephemeral_x25519 || recipient_x25519)
+func combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey, peerPublicKey []byte, preSharedKey *[preSharedKeySize]byte) (*[encryptionKeySize]byte, error) {
+	// Check arguments
+	if len(kemSharedKey) != mlkem.SharedKeySize {
+		return nil, errors.New("invalid ML-KEM shared key size")
+	}
+	if len(dhSharedKey) != x25519KeySize {
+		return nil, errors.New("invalid X25519 shared key size")
+	}
+	if len(ephPublicKey) != x25519KeySize || len(peerPublicKey) != x25519KeySize {
+		return nil, errors.New("invalid X25519 public key size")
+	}
+
+	// Combine shared secrets
+	h := sha3.New256()
+	h.Write([]byte(hybridKEMLabel))
+	h.Write(kemSharedKey)
+	h.Write(dhSharedKey)
+	h.Write(ephPublicKey)
+	h.Write(peerPublicKey)
+
+	var sharedKey [encryptionKeySize]byte
+	h.Sum(sharedKey[:0])
+
+	// Apply psk, this will act as a second knowledge factor to allow container
+	// unseal
+	if preSharedKey != nil {
+		// Compute HMAC-Blakeb of the shared secret.
+		pskh, err := blake2b.New(encryptionKeySize, preSharedKey[:])
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize PSK derivation: %w", err)
+		}
+		pskh.Write([]byte{0x00, 0x00, 0x00, 0x01})
+		pskh.Write(sharedKey[:])
+		skHash := pskh.Sum(nil)
+		copy(sharedKey[:], skHash[:encryptionKeySize])
+	}
+
+	// No error
+	return &sharedKey, nil
+}
+
+func computeHeaderHash(headers *containerv1.Header) ([]byte, error) {
+	// Check arguments
+	if headers == nil {
+		return nil, errors.New("unable process with nil headers")
+	}
+
+	// Prepare signature
+	header, err := proto.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal container headers")
+	}
+
+	// Hash serialized proto
+	hash := blake2b.Sum512(header)
+
+	// No error
+	return hash[:], nil
+}
+
+func computeProtectedHash(headerHash, content []byte) []byte {
+	// Prepare protected content
+	protected := bytes.Buffer{}
+	protected.Write([]byte(signatureDomainSeparation))
+	protected.WriteByte(0x00)
+	protected.Write(headerHash)
+	contentHash := blake2b.Sum512(content)
+	protected.Write(contentHash[:])
+
+	// No error
+	return protected.Bytes()
+}
+
+func packRecipient(rand io.Reader, payloadKey *[encryptionKeySize]byte, dhSharedKey, ephPublicKey []byte, peerPublicKey *hybridPublicKey, preSharedKey *[preSharedKeySize]byte) (*containerv1.Recipient, error) {
+	// Check arguments
+	if payloadKey == nil {
+		return nil, fmt.Errorf("unable to proceed with nil payload key")
+	}
+	if peerPublicKey == nil {
+		return nil, fmt.Errorf("unable to proceed with nil public key")
+	}
+
+	// Encapsulate a shared key for the recipient
+	kemSharedKey, kemCiphertext, err := peerPublicKey.kem.Encapsulate(rand)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encapsulate recipient shared key: %w", err)
+	}
+
+	// Create recipient key
+	recipientKey, err := combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey, peerPublicKey.x25519[:], preSharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive shared recipient encryption key: %w", err)
+	}
+
+	// Calculate identifier
+	identifier, err := keyIdentifierFromDerivedKey(recipientKey, preSharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive key identifier: %w", err)
+	}
+
+	// Generate recipient nonce
+	var recipientNonce [nonceSize]byte
+	if _, err := io.ReadFull(rand, recipientNonce[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate recipient nonce for encryption")
+	}
+
+	// Pack recipient
+	key := append(kemCiphertext, recipientNonce[:]...)
+	recipient := &containerv1.Recipient{
+		Identifier: identifier,
+		Key:        secretbox.Seal(key, payloadKey[:], &recipientNonce, recipientKey),
+	}
+
+	// Return recipient
+	return recipient, nil
+}
+
+func keyIdentifierFromDerivedKey(derivedKey *[encryptionKeySize]byte, preSharedKey *[preSharedKeySize]byte) ([]byte, error) {
+	// Hash the derived key
+	h, err := blake2b.New512([]byte("harp hybrid kem key identifier"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate recipient identifier hasher")
+	}
+	if _, err := h.Write(derivedKey[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate recipient identifier")
+	}
+
+	// Apply psk if specified
+	if preSharedKey != nil {
+		if _, err := h.Write(preSharedKey[:]); err != nil {
+			return nil, fmt.Errorf("unable to generate recipient identifier")
+		}
+	}
+
+	// Return 32 bytes trucanted hash.
+	return h.Sum(nil)[0:keyIdentifierSize], nil
+}
+
+func tryRecipientKeys(dk *mlkem.DecapsulationKey768, dhSharedKey, ephPublicKey, publicKey []byte, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) ([]byte, error) {
+	// Find matching recipient
+	for _, r := range recipients {
+		// Check recipient key size
+		if len(r.Key) < kemCiphertextSize+nonceSize+secretbox.Overhead {
+			continue
+		}
+
+		// Decapsulate the recipient shared key, an invalid ciphertext produces
+		// a pseudo random key which will not match the recipient identifier.
+		kemSharedKey, err := dk.Decapsulate(r.Key[:kemCiphertextSize])
+		if err != nil {
+			return nil, fmt.Errorf("unable to decapsulate recipient shared key: %w", err)
+		}
+
+		// Derive recipient key
+		derivedKey, err := combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey, publicKey, preSharedKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive shared recipient encryption key: %w", err)
+		}
+
+		// Calculate recipient identifier
+		identifier, err := keyIdentifierFromDerivedKey(derivedKey, preSharedKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate identifier: %w", err)
+		}
+
+		// Check recipient identifiers
+		if !security.SecureCompare(identifier, r.Identifier) {
+			continue
+		}
+
+		var nonce [nonceSize]byte
+		copy(nonce[:], r.Key[kemCiphertextSize:kemCiphertextSize+nonceSize])
+
+		// Try to decrypt the secretbox with the derived key.
+		payloadKey, isValid := secretbox.Open(nil, r.Key[kemCiphertextSize+nonceSize:], &nonce, derivedKey)
+		if !isValid {
+			return nil, fmt.Errorf("invalid recipient encryption key")
+		}
+
+		// Encryption key found, return no error.
+		return payloadKey, nil
+	}
+
+	// No recipient found in list.
+	return nil, fmt.Errorf("no recipient found")
+}
+
+func tryEphemeralRecipientKeys(dk *mlkem.DecapsulationKey768, privateKey, publicKey []byte, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) ([]byte, error) {
+	// Find matching recipient
+	for _, r := range recipients {
+		// Recipients added after sealing are prefixed by their own ephemeral
+		// X25519 public key.
+		if len(r.Key) != x25519KeySize+recipientKeySize {
+			continue
+		}
+		ephPublicKey := r.Key[:x25519KeySize]
+
+		// Compute classical shared secret
+		dhSharedKey, err := curve25519.X25519(privateKey, ephPublicKey)
+		if err != nil {
+			continue
+		}
+
+		// Try the recipient with its own ephemeral public key
+		payloadKey, err := tryRecipientKeys(dk, dhSharedKey, ephPublicKey, publicKey, []*containerv1.Recipient{
+			{Identifier: r.Identifier, Key: r.Key[x25519KeySize:]},
+		}, preSharedKey)
+		memguard.WipeBytes(dhSharedKey)
+		if err != nil {
+			continue
+		}
+
+		// Encryption key found, return no error.
+		return payloadKey, nil
+	}
+
+	// No recipient found in list.
+	return nil, fmt.Errorf("no recipient found")
+}
@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+func Test_combineSharedKeys(t *testing.T) {
+	kemSharedKey := bytes.Repeat([]byte{0x01}, mlkem.SharedKeySize)
+	dhSharedKey := bytes.Repeat([]byte{0x02}, x25519KeySize)
+	ephPublicKey := bytes.Repeat([]byte{0x03}, x25519KeySize)
+	peerPublicKey := bytes.Repeat([]byte{0x04}, x25519KeySize)
+
+	t.Run("without psk", func(t *testing.T) {
+		got, err := combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey, peerPublicKey, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "96d92a91c9a2b6c5de770fdd66949a4847126a657f05e33a29cc24146b9566a7", hex.EncodeToString(got[:]))
+	})
+
+	t.Run("with psk", func(t *testing.T) {
+		var psk [preSharedKeySize]byte
+		copy(psk[:], bytes.Repeat([]byte{0x05}, preSharedKeySize))
+
+		got, err := combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey, peerPublicKey, &psk)
+		require.NoError(t, err)
+		assert.Equal(t, "5fd4164acdb47a242c870c17e0bbd44fb6bf31c33d49d26608f7c423cc10f565", hex.EncodeToString(got[:]))
+	})
+
+	t.Run("invalid kem shared key", func(t *testing.T) {
+		_, err := combineSharedKeys(kemSharedKey[:16], dhSharedKey, ephPublicKey, peerPublicKey, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid dh shared key", func(t *testing.T) {
+		_, err := combineSharedKeys(kemSharedKey, dhSharedKey[:16], ephPublicKey, peerPublicKey, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		_, err := combineSharedKeys(kemSharedKey, dhSharedKey, ephPublicKey[:16], peerPublicKey, nil)
+		assert.Error(t, err)
+	})
+}
+
+func Test_packRecipient_tryRecipientKeys(t *testing.T) {
+	// Recipient key
+	var priv [privateKeySize]byte
+	_, err := rand.Read(priv[:])
+	require.NoError(t, err)
+	pubRaw, err := publicKeyFromPrivate(&priv)
+	require.NoError(t, err)
+	peerPublicKey, err := decodePublicKey(pubRaw[:])
+	require.NoError(t, err)
+
+	// Ephemeral key
+	var ephPriv [x25519KeySize]byte
+	_, err = rand.Read(ephPriv[:])
+	require.NoError(t, err)
+	ephPub, err := curve25519.X25519(ephPriv[:], curve25519.Basepoint)
+	require.NoError(t, err)
+	dhSharedKey, err := curve25519.X25519(ephPriv[:], peerPublicKey.x25519[:])
+	require.NoError(t, err)
+
+	var payloadKey [encryptionKeySize]byte
+	_, err = rand.Read(payloadKey[:])
+	require.NoError(t, err)
+
+	r, err := packRecipient(rand.Reader, &payloadKey, dhSharedKey, ephPub, peerPublicKey, nil)
+	require.NoError(t, err)
+	assert.Len(t, r.Identifier, keyIdentifierSize)
+	assert.Len(t, r.Key, kemCiphertextSize+nonceSize+encryptionKeySize+16)
+
+	// Unpack recipient
+	dk, err := mlkem.NewDecapsulationKey768(priv[:mlkem.SeedSize])
+	require.NoError(t, err)
+	dhRecipientKey, err := curve25519.X25519(priv[mlkem.SeedSize:], ephPub)
+	require.NoError(t, err)
+
+	got, err := tryRecipientKeys(dk, dhRecipientKey, ephPub, peerPublicKey.x25519[:], nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+
+	got, err = tryRecipientKeys(dk, dhRecipientKey, ephPub, peerPublicKey.x25519[:], []*containerv1.Recipient{r}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, payloadKey[:], got)
+}
@@ -0,0 +1,200 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/extra25519"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	PublicKeyPrefix  = "v3.sk."
+	PrivateKeyPrefix = "v3.ck."
+)
+
+// -----------------------------------------------------------------------------
+
+// GenerateKey create an ML-KEM-768/X25519 hybrid key pair used as container
+// identifier.
+func (a *adapter) GenerateKey(fopts ...seal.GenerateOption) (publicKey, privateKey string, err error) {
+	// Prepare defaults
+	opts := &seal.GenerateOptions{
+		DCKDMasterKey: nil,
+		DCKDTarget:    "",
+		RandomSource:  rand.Reader,
+	}
+
+	// Apply optional parameters
+	for _, f := range fopts {
+		f(opts)
+	}
+
+	// Master key derivation
+	if opts.DCKDMasterKey != nil {
+		// Argon2ID(masterKey, Blake2B-512('harp deterministic salt v3', Target), 1, 64Mb, 4, 96)
+		// Don't clean bytes, already done by memguard.
+		masterKey := opts.DCKDMasterKey.Bytes()
+		if len(masterKey) < 32 {
+			return "", "", fmt.Errorf("the master key must be 32 bytes long at least")
+		}
+
+		// Generate deterministic salt
+		h, err := blake2b.New512([]byte("harp deterministic salt v3"))
+		if err != nil {
+			return "", "", fmt.Errorf("unable to initialize salt derivation: %w", err)
+		}
+		h.Write([]byte(opts.DCKDTarget))
+		salt := h.Sum(nil)
+		defer memguard.WipeBytes(salt)
+
+		// Derive deterministic container key using Argon2id
+		dk := argon2.IDKey(masterKey[:32], salt, 1, 64*1024, 4, privateKeySize)
+		defer memguard.WipeBytes(dk)
+
+		// Assign to seed
+		opts.RandomSource = bytes.NewBuffer(dk)
+	}
+
+	// Generate hybrid container key pair
+	var priv [privateKeySize]byte
+	defer memguard.WipeBytes(priv[:])
+	if _, errRead := io.ReadFull(opts.RandomSource, priv[:]); errRead != nil {
+		return "", "", fmt.Errorf("unable to generate container key: %w", errRead)
+	}
+	pub, errPub := publicKeyFromPrivate(&priv)
+	if errPub != nil {
+		return "", "", fmt.Errorf("unable to generate container key: %w", errPub)
+	}
+
+	// Encode keys
+	encodedPub := append([]byte(PublicKeyPrefix), base64.RawURLEncoding.EncodeToString(pub[:])...)
+	encodedPriv := append([]byte(PrivateKeyPrefix), base64.RawURLEncoding.EncodeToString(priv[:])...)
+
+	// No error
+	return string(encodedPub), string(encodedPriv), nil
+}
+
+// hybridPublicKey holds the parsed recipient public key components.
+type hybridPublicKey struct {
+	kem    *mlkem.EncapsulationKey768
+	x25519 [x25519KeySize]byte
+}
+
+// PublicKeys return the appropriate key format used by the sealing strategy.
+func (a *adapter) publicKeys(keys ...string) ([]*hybridPublicKey, error) {
+	// v3.sk.[data]
+	res := []*hybridPublicKey{}
+
+	for _, key := range keys {
+		// Check key prefix
+		if !strings.HasPrefix(key, PublicKeyPrefix) {
+			return nil, fmt.Errorf("unsuppored public key %q for v3 seal algorithm", key)
+		}
+
+		// Remove prefix if exists
+		key = strings.TrimPrefix(key, PublicKeyPrefix)
+
+		// Decode key
+		keyRaw, err := base64.RawURLEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode public key %q: %w", key, err)
+		}
+
+		// Public key sanity checks
+		if len(keyRaw) != publicKeySize {
+			return nil, fmt.Errorf("invalid public key length for key %q", key)
+		}
+
+		// Decode key components
+		pk, err := decodePublicKey(keyRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %w", key, err)
+		}
+
+		// Append it to sealing keys
+		res = append(res, pk)
+	}
+
+	// No error
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func publicKeyFromPrivate(priv *[privateKeySize]byte) (*[publicKeySize]byte, error) {
+	// Expand ML-KEM decapsulation key
+	dk, err := mlkem.NewDecapsulationKey768(priv[:mlkem.SeedSize])
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand ML-KEM key: %w", err)
+	}
+
+	// Compute X25519 public key
+	xPub, err := curve25519.X25519(priv[mlkem.SeedSize:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute X25519 public key: %w", err)
+	}
+
+	// Assemble the hybrid public key
+	var pub [publicKeySize]byte
+	copy(pub[:], dk.EncapsulationKey().Bytes())
+	copy(pub[mlkem.EncapsulationKeySize768:], xPub)
+
+	// No error
+	return &pub, nil
+}
+
+func decodePublicKey(raw []byte) (*hybridPublicKey, error) {
+	// Check arguments
+	if len(raw) != publicKeySize {
+		return nil, fmt.Errorf("invalid public key length")
+	}
+
+	// Decode ML-KEM encapsulation key
+	ek, err := mlkem.NewEncapsulationKey768(raw[:mlkem.EncapsulationKeySize768])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML-KEM encapsulation key: %w", err)
+	}
+
+	// Check X25519 public key
+	xPub := raw[mlkem.EncapsulationKeySize768:]
+	if extra25519.IsEdLowOrder(xPub) {
+		return nil, fmt.Errorf("low order public key usage is forbidden, try to generate a new one to fix the issue")
+	}
+
+	pk := &hybridPublicKey{
+		kem: ek,
+	}
+	copy(pk.x25519[:], xPub)
+
+	// No error
+	return pk, nil
+}
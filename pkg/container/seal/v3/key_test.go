@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+)
+
+func TestGenerateKey(t *testing.T) {
+	adapter := New()
+
+	// Public keys are compared using their SHA256 fingerprint.
+	fingerprint := func(pub string) string {
+		h := sha256.Sum256([]byte(pub))
+		return hex.EncodeToString(h[:])
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey(
+			seal.WithDeterministicKey(memguard.NewBufferFromBytes([]byte("deterministic-seed-for-test-00001")), "Release 64"),
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, pk)
+		assert.Equal(t, "v3.ck.qxZZv_0iKkh5b-gxAY_fINd2BonOhMBKM64Ld6wyXl4U-0APTDTN6g2A9EAo7wbclkXgjPo_tf8TYPZW2dnjFKOSLbT-wp_RwoQjTs66QrIw0m7HEqDkre_rV81x5-Vv", pk)
+		assert.NotNil(t, pub)
+		assert.Equal(t, "9ec69f74486ea4d434c07ad81d7598ad7afbae168c73dc5302939ba5692edf37", fingerprint(pub))
+	})
+
+	t.Run("deterministic - same key with different target", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey(
+			seal.WithDeterministicKey(memguard.NewBufferFromBytes([]byte("deterministic-seed-for-test-00001")), "Release 65"),
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, pk)
+		assert.Equal(t, "v3.ck.GiGDCAdc8hb6S65re_77kz1eS-IDizTqgS-5PK1wvXDhlGYlhY54OkPbFjwI8nklrDpg6hwPKwt06p5UwiWpqvOyAdzVNvJKp9npZUBKrKc5tBocuErCakx8snpEnjbc", pk)
+		assert.NotNil(t, pub)
+		assert.Equal(t, "1bbc6a050ac7fbf39e55fd480352aecdd88d8d48bd34ee609603f0cfeec8815f", fingerprint(pub))
+	})
+
+	t.Run("master key too short", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey(
+			seal.WithDeterministicKey(memguard.NewBufferFromBytes([]byte("determini")), "Release 64"),
+		)
+		assert.Error(t, err)
+		assert.Empty(t, pk)
+		assert.Empty(t, pub)
+	})
+
+	t.Run("default with given random source", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey(seal.WithRandom(bytes.NewReader(bytes.Repeat([]byte("deterministic-seed-for-test-0001"), 3))))
+		assert.NoError(t, err)
+		assert.NotNil(t, pk)
+		assert.Equal(t, "v3.ck.ZGV0ZXJtaW5pc3RpYy1zZWVkLWZvci10ZXN0LTAwMDFkZXRlcm1pbmlzdGljLXNlZWQtZm9yLXRlc3QtMDAwMWRldGVybWluaXN0aWMtc2VlZC1mb3ItdGVzdC0wMDAx", pk)
+		assert.NotNil(t, pub)
+		assert.Equal(t, "2f4bfe0c4ae32549ab3424068a5a12c288a68faa91a56152fecd99f969f299ff", fingerprint(pub))
+	})
+
+	t.Run("random source too short", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey(seal.WithRandom(bytes.NewReader([]byte("deterministic-seed-for-test-00001"))))
+		assert.Error(t, err)
+		assert.Empty(t, pk)
+		assert.Empty(t, pub)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		pub, pk, err := adapter.GenerateKey()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, pk)
+		assert.NotEmpty(t, pub)
+	})
+}
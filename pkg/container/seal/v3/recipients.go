@@ -0,0 +1,171 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// AddRecipients wraps the payload key of a sealed container for the given peer
+// public keys. The payload key is recovered with the given identity.
+func (a *adapter) AddRecipients(rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, encodedPeerPublicKeys ...string) (*containerv1.Container, error) {
+	return seal.AddRecipients(a, rand, container, identity, preSharedKey, encodedPeerPublicKeys...)
+}
+
+// RemoveRecipients removes the recipients matching the given identifiers from
+// a sealed container. The payload key is recovered with the given identity.
+func (a *adapter) RemoveRecipients(rand io.Reader, container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer, identifiers ...[]byte) (*containerv1.Container, error) {
+	return seal.RemoveRecipients(a, rand, container, identity, preSharedKey, identifiers...)
+}
+
+// IsRecipient returns true if the payload key can be recovered from the given
+// sealed container headers with the given identity. The payload is neither
+// decrypted nor verified.
+func (a *adapter) IsRecipient(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) bool {
+	return seal.IsRecipient(a, headers, identity, preSharedKey)
+}
+
+// -----------------------------------------------------------------------------
+
+// RecoverPayloadKey decrypts the payload key from the given sealed container
+// headers with the given identity.
+func (a *adapter) RecoverPayloadKey(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*memguard.LockedBuffer, error) {
+	payloadKey, _, err := recoverPayloadKey(headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// No error
+	return memguard.NewBufferFromBytes(payloadKey[:]), nil
+}
+
+// OpenPayload decrypts the sealed container payload and verifies its signature.
+func (a *adapter) OpenPayload(container *containerv1.Container, payloadKey *memguard.LockedBuffer) ([]byte, error) {
+	return openPayload(container, payloadKey.ByteArray32())
+}
+
+// PackRecipients wraps the payload key for the given peer public keys. The
+// container ephemeral private key is not known anymore, the recipient key is
+// prefixed with a new ephemeral X25519 public key.
+func (a *adapter) PackRecipients(rand io.Reader, headers *containerv1.Header, payloadKey, preSharedKey *memguard.LockedBuffer, encodedPeerPublicKeys ...string) ([]*containerv1.Recipient, error) {
+	// Convert public keys
+	peerPublicKeys, err := a.publicKeys(encodedPeerPublicKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert peer public keys: %w", err)
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk, err = pskStretch(preSharedKey.Bytes(), headers.EncryptionPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stretch preshared key: %w", err)
+		}
+	}
+
+	// Generate ephemeral X25519 encryption key
+	var encPriv [x25519KeySize]byte
+	defer memguard.WipeBytes(encPriv[:])
+	if _, err = io.ReadFull(rand, encPriv[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+	encPub, err := curve25519.X25519(encPriv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+
+	// Process recipients
+	recipients := []*containerv1.Recipient{}
+	for _, peerPublicKey := range peerPublicKeys {
+		if types.IsNil(peerPublicKey) {
+			// Ignore nil key
+			continue
+		}
+
+		// Compute classical shared secret
+		dhSharedKey, errDH := curve25519.X25519(encPriv[:], peerPublicKey.x25519[:])
+		if errDH != nil {
+			return nil, fmt.Errorf("unable to compute recipient shared secret: %w", errDH)
+		}
+
+		// Pack recipient using its public key
+		r, errPack := packRecipient(rand, payloadKey.ByteArray32(), dhSharedKey, encPub, peerPublicKey, psk)
+		memguard.WipeBytes(dhSharedKey)
+		if errPack != nil {
+			return nil, fmt.Errorf("unable to pack container recipient: %w", errPack)
+		}
+
+		// Prefix the recipient key with the ephemeral public key
+		r.Key = append(append([]byte{}, encPub...), r.Key...)
+
+		// Append to recipients
+		recipients = append(recipients, r)
+	}
+
+	// No error
+	return recipients, nil
+}
+
+// Reseal signs the content with a new ephemeral signing key bound to the given
+// headers, and encrypts it with the payload key.
+func (a *adapter) Reseal(rand io.Reader, headers *containerv1.Header, payloadKey *memguard.LockedBuffer, content []byte) (*containerv1.Container, error) {
+	// Generate ephemeral signing key
+	sigPub, sigPriv, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate signing keypair")
+	}
+	defer memguard.WipeBytes(sigPriv)
+
+	// Encrypt public signature key, the payload key is reused so that the
+	// static nonce can't be used again.
+	var pubSigNonce [nonceSize]byte
+	if _, err = io.ReadFull(rand, pubSigNonce[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate signature key nonce: %w", err)
+	}
+	headers.ContainerBox = secretbox.Seal(pubSigNonce[:], sigPub, &pubSigNonce, payloadKey.ByteArray32())
+
+	// Compute header hash
+	headerHash, err := computeHeaderHash(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// Sign the protected content
+	containerSig := ed25519.Sign(sigPriv, computeProtectedHash(headerHash, content))
+
+	// Prepare encryption nonce form header hash
+	var sigNonce [nonceSize]byte
+	copy(sigNonce[:], headerHash[:nonceSize])
+
+	// No error
+	return &containerv1.Container{
+		Headers: headers,
+		Raw:     secretbox.Seal(nil, append(containerSig, content...), &sigNonce, payloadKey.ByteArray32()),
+	}, nil
+}
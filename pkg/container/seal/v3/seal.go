@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Seal a secret container with identities.
+func (a *adapter) Seal(rand io.Reader, container *containerv1.Container, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.seal(rand, container, nil, encodedPeersPublicKey...)
+}
+
+// Seal a secret container with identities and preshared key.
+func (a *adapter) SealWithPSK(rand io.Reader, container *containerv1.Container, psk *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.seal(rand, container, psk, encodedPeersPublicKey...)
+}
+
+//nolint:funlen,gocyclo // To refactor
+func (a *adapter) seal(rand io.Reader, container *containerv1.Container, preSharedKey *memguard.LockedBuffer, encodedPeerPublicKeys ...string) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return nil, fmt.Errorf("unable to process nil container headers")
+	}
+	if len(encodedPeerPublicKeys) == 0 {
+		return nil, fmt.Errorf("unable to process empty public keys")
+	}
+
+	// Convert public keys
+	peerPublicKeys, err := a.publicKeys(encodedPeerPublicKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert peer public keys: %w", err)
+	}
+
+	// Serialize protobuf payload
+	content, err := proto.Marshal(container)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode container content: %w", err)
+	}
+
+	// Check cleartext message size.
+	if len(content) > messageLimit {
+		return nil, errors.New("unable to seal the container, container is too large")
+	}
+
+	// Generate payload encryption key
+	var payloadKey [encryptionKeySize]byte
+	defer memguard.WipeBytes(payloadKey[:])
+	if _, err = io.ReadFull(rand, payloadKey[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate payload key for encryption")
+	}
+
+	// Generate ephemeral signing key
+	sigPub, sigPriv, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate signing keypair")
+	}
+
+	// Encrypt public signature key
+	var pubSigNonce [nonceSize]byte
+	copy(pubSigNonce[:], staticSignatureNonce)
+	encryptedPubSig := secretbox.Seal(nil, sigPub, &pubSigNonce, &payloadKey)
+	memguard.WipeBytes(pubSigNonce[:])
+
+	// Generate ephemeral X25519 encryption key
+	var encPriv [x25519KeySize]byte
+	defer memguard.WipeBytes(encPriv[:])
+	if _, err = io.ReadFull(rand, encPriv[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+	encPub, err := curve25519.X25519(encPriv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+
+	// Prepare sealed container
+	containerHeaders := &containerv1.Header{
+		ContentType:         containerSealedContentType,
+		EncryptionPublicKey: encPub,
+		ContainerBox:        encryptedPubSig,
+		Recipients:          []*containerv1.Recipient{},
+		SealVersion:         SealVersion,
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk, err = pskStretch(preSharedKey.Bytes(), containerHeaders.EncryptionPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stretch preshared key: %w", err)
+		}
+	}
+
+	// Process recipients
+	for _, peerPublicKey := range peerPublicKeys {
+		if types.IsNil(peerPublicKey) {
+			// Ignore nil key
+			continue
+		}
+
+		// Compute classical shared secret
+		dhSharedKey, errDH := curve25519.X25519(encPriv[:], peerPublicKey.x25519[:])
+		if errDH != nil {
+			return nil, fmt.Errorf("unable to compute recipient shared secret: %w", errDH)
+		}
+
+		// Pack recipient using its public key
+		r, errPack := packRecipient(rand, &payloadKey, dhSharedKey, encPub, peerPublicKey, psk)
+		memguard.WipeBytes(dhSharedKey)
+		if errPack != nil {
+			return nil, fmt.Errorf("unable to pack container recipient: %w", errPack)
+		}
+
+		// Append to container
+		containerHeaders.Recipients = append(containerHeaders.Recipients, r)
+	}
+
+	// Sanity check
+	if len(containerHeaders.Recipients) == 0 {
+		return nil, errors.New("unable to seal a container without recipients")
+	}
+
+	// Compute header hash
+	headerHash, err := computeHeaderHash(containerHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// Prepare protected content
+	protectedHash := computeProtectedHash(headerHash, content)
+
+	// Sign th protected content
+	containerSig := ed25519.Sign(sigPriv, protectedHash)
+
+	// Prepare encryption nonce form sigHash
+	var sigNonce [nonceSize]byte
+	copy(sigNonce[:], headerHash[:nonceSize])
+
+	// No error
+	return &containerv1.Container{
+		Headers: containerHeaders,
+		Raw:     secretbox.Seal(nil, append(containerSig, content...), &sigNonce, &payloadKey),
+	}, nil
+}
@@ -0,0 +1,369 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var ignoreOpts = []cmp.Option{
+	cmpopts.IgnoreUnexported(containerv1.Container{}),
+	cmpopts.IgnoreUnexported(containerv1.Header{}),
+}
+
+func mustGenerateKey(t testing.TB, target string) (publicKey, privateKey string) {
+	t.Helper()
+
+	pub, pk, err := New().GenerateKey(
+		seal.WithDeterministicKey(memguard.NewBufferFromBytes([]byte("deterministic-seed-for-test-00001")), target),
+	)
+	if err != nil {
+		t.Fatalf("unable to generate test key: %v", err)
+	}
+
+	return pub, pk
+}
+
+// -----------------------------------------------------------------------------
+
+func TestSeal(t *testing.T) {
+	pub1, _ := mustGenerateKey(t, "Release 64")
+	pub2, _ := mustGenerateKey(t, "Release 65")
+
+	type args struct {
+		container      *containerv1.Container
+		peersPublicKey []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "empty container",
+			args: args{
+				container: &containerv1.Container{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty container headers",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid public key prefix",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				peersPublicKey: []string{
+					"v1.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid public key length",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				peersPublicKey: []string{
+					"v3.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E",
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "empty container with public keys",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				peersPublicKey: []string{pub1, pub2},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid container with public keys",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+					Raw:     memguard.NewBufferRandom(1024).Bytes(),
+				},
+				peersPublicKey: []string{pub1, pub2},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := New()
+			_, err := adapter.Seal(rand.Reader, tt.args.container, tt.args.peersPublicKey...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Seal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_Seal_Unseal(t *testing.T) {
+	pub1, pk1 := mustGenerateKey(t, "Release 64")
+	pub2, pk2 := mustGenerateKey(t, "Release 65")
+	_, pk3 := mustGenerateKey(t, "Release 66")
+
+	adapter := New()
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+		Raw: memguard.NewBufferRandom(1024).Bytes(),
+	}
+
+	sealed, err := adapter.Seal(rand.Reader, input, pub1, pub2)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(SealVersion), sealed.Headers.SealVersion)
+	assert.Len(t, sealed.Headers.Recipients, 2)
+
+	// Each recipient can unseal the container
+	for _, pk := range []string{pk1, pk2} {
+		unsealed, errUnseal := adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(pk)))
+		require.NoError(t, errUnseal)
+		if !cmp.Equal(input, unsealed, ignoreOpts...) {
+			t.Errorf("unsealed container mismatch, diff: %s", cmp.Diff(input, unsealed, ignoreOpts...))
+		}
+	}
+
+	// Unknown recipient
+	_, err = adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(pk3)))
+	assert.Error(t, err)
+
+	// Tampered headers
+	tampered := proto.Clone(sealed).(*containerv1.Container)
+	tampered.Headers.ContentType = "application/vnd.harp.v1.SealedContainer"
+	tampered.Headers.Recipients = tampered.Headers.Recipients[:1]
+	_, err = adapter.Unseal(tampered, memguard.NewBufferFromBytes([]byte(pk1)))
+	assert.Error(t, err)
+
+	// Tampered recipient ciphertext
+	tampered = proto.Clone(sealed).(*containerv1.Container)
+	tampered.Headers.Recipients[0].Key[0] ^= 0x01
+	_, err = adapter.Unseal(tampered, memguard.NewBufferFromBytes([]byte(pk1)))
+	assert.Error(t, err)
+}
+
+func Test_Seal_Unseal_WithPSK(t *testing.T) {
+	pub1, pk1 := mustGenerateKey(t, "Release 64")
+
+	adapter := New()
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+		Raw: memguard.NewBufferRandom(1024).Bytes(),
+	}
+
+	psk := memguard.NewBufferRandom(64)
+	sealed, err := adapter.SealWithPSK(rand.Reader, input, psk, pub1)
+	require.NoError(t, err)
+
+	unsealed, err := adapter.UnsealWithPSK(sealed, memguard.NewBufferFromBytes([]byte(pk1)), psk)
+	require.NoError(t, err)
+	if !cmp.Equal(input, unsealed, ignoreOpts...) {
+		t.Errorf("unsealed container mismatch, diff: %s", cmp.Diff(input, unsealed, ignoreOpts...))
+	}
+
+	// Without PSK
+	_, err = adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(pk1)))
+	assert.Error(t, err)
+
+	// With invalid PSK
+	_, err = adapter.UnsealWithPSK(sealed, memguard.NewBufferFromBytes([]byte(pk1)), memguard.NewBufferRandom(64))
+	assert.Error(t, err)
+}
+
+// Test_Unseal_Vector ensures that the container format stays readable.
+func Test_Unseal_Vector(t *testing.T) {
+	_, pk := mustGenerateKey(t, "Release 64")
+
+	raw, err := os.ReadFile("../../../../test/fixtures/bundles/complete.v3.sealed")
+	require.NoError(t, err)
+	require.Greater(t, len(raw), 6)
+	require.Equal(t, uint32(0x53CB3701), binary.BigEndian.Uint32(raw[:4]))
+
+	sealed := &containerv1.Container{}
+	require.NoError(t, proto.Unmarshal(raw[6:], sealed))
+	assert.Equal(t, uint32(SealVersion), sealed.Headers.SealVersion)
+
+	unsealed, err := New().Unseal(sealed, memguard.NewBufferFromBytes([]byte(pk)))
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.harp.v1.Bundle", unsealed.Headers.ContentType)
+	assert.NotEmpty(t, unsealed.Raw)
+}
+
+func Test_Reseal_ContainerBox(t *testing.T) {
+	a, _ := New().(*adapter)
+
+	pubKey, privKey, err := a.GenerateKey()
+	require.NoError(t, err)
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: "gzip",
+			ContentType:     "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte{0x00, 0x00},
+	}
+
+	sealed, err := a.Seal(rand.Reader, input, pubKey)
+	require.NoError(t, err)
+
+	// Recover payload key
+	payloadKey, err := a.RecoverPayloadKey(sealed.Headers, memguard.NewBufferFromBytes([]byte(privKey)), nil)
+	require.NoError(t, err)
+	defer payloadKey.Destroy()
+	content, err := a.OpenPayload(sealed, payloadKey)
+	require.NoError(t, err)
+
+	// Reseal with the same payload key
+	headers, _ := proto.Clone(sealed.Headers).(*containerv1.Header)
+	resealed, err := a.Reseal(rand.Reader, headers, payloadKey, content)
+	require.NoError(t, err)
+
+	// Compare the container boxes side by side
+	oldBox := sealed.Headers.ContainerBox
+	newBox := resealed.Headers.ContainerBox
+	require.Len(t, oldBox, containerBoxSize)
+	require.Len(t, newBox, nonceSize+containerBoxSize)
+	assert.NotEqual(t, []byte(staticSignatureNonce), newBox[:nonceSize])
+
+	var oldNonce, newNonce [nonceSize]byte
+	copy(oldNonce[:], staticSignatureNonce)
+	copy(newNonce[:], newBox[:nonceSize])
+	oldSigPub, ok := secretbox.Open(nil, oldBox, &oldNonce, payloadKey.ByteArray32())
+	require.True(t, ok)
+	newSigPub, ok := secretbox.Open(nil, newBox[nonceSize:], &newNonce, payloadKey.ByteArray32())
+	require.True(t, ok)
+	assert.NotEqual(t, oldSigPub, newSigPub)
+
+	// A reused nonce would encrypt both signing keys with the same keystream
+	oldCiphertext := oldBox[secretbox.Overhead:]
+	newCiphertext := newBox[nonceSize+secretbox.Overhead:]
+	for i := range oldSigPub {
+		if oldCiphertext[i]^newCiphertext[i] != oldSigPub[i]^newSigPub[i] {
+			return
+		}
+	}
+	t.Error("resealed container signing key is encrypted with the sealing keystream")
+}
+
+func Test_Seal_Fuzz(t *testing.T) {
+	pub, _ := mustGenerateKey(t, "Release 64")
+
+	// Making sure the function never panics
+	for i := 0; i < 50; i++ {
+		f := fuzz.New()
+
+		// Prepare arguments
+		input := containerv1.Container{
+			Headers: &containerv1.Header{},
+			Raw:     []byte{0x00, 0x00},
+		}
+
+		f.Fuzz(&input.Headers)
+		f.Fuzz(&input.Raw)
+
+		// Execute
+		_, _ = New().Seal(rand.Reader, &input, pub)
+	}
+}
+
+func Test_UnSeal_Fuzz(t *testing.T) {
+	_, pk := mustGenerateKey(t, "Release 64")
+	identity := memguard.NewBufferFromBytes([]byte(pk))
+
+	// Making sure the function never panics
+	for i := 0; i < 50; i++ {
+		f := fuzz.New()
+
+		// Prepare arguments
+		input := containerv1.Container{
+			Headers: &containerv1.Header{
+				ContentType: containerSealedContentType,
+			},
+			Raw: []byte{0x00, 0x00},
+		}
+
+		f.Fuzz(&input.Headers.EncryptionPublicKey)
+		f.Fuzz(&input.Headers.Recipients)
+		f.Fuzz(&input.Raw)
+
+		// Execute
+		_, _ = New().Unseal(&input, identity)
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+func Benchmark_Seal(b *testing.B) {
+	pub, _ := mustGenerateKey(b, "Release 64")
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{},
+		Raw:     bytes.Repeat([]byte{0x00}, 1024),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := New().Seal(rand.Reader, input, pub)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v3
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/mlkem"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Unseal a sealed container with the given identity.
+func (a *adapter) Unseal(container *containerv1.Container, identity *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, nil)
+}
+
+// Unseal a sealed container with the given identity and the given preshared key.
+func (a *adapter) UnsealWithPSK(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, preSharedKey)
+}
+
+func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return nil, fmt.Errorf("unable to process nil container headers")
+	}
+	if identity == nil {
+		return nil, fmt.Errorf("unable to process without container key")
+	}
+
+	// Check headers
+	if container.Headers.ContentType != containerSealedContentType {
+		return nil, fmt.Errorf("unable to unseal container")
+	}
+
+	// Recover payload key
+	encryptionKey, _, err := recoverPayloadKey(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer memguard.WipeBytes(encryptionKey[:])
+
+	// Decrypt and verify payload
+	content, err := openPayload(container, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal inner container
+	out := &containerv1.Container{}
+	if err := proto.Unmarshal(content, out); err != nil {
+		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	}
+
+	// No error
+	return out, nil
+}
+
+// recoverPayloadKey decrypts the payload key from the container recipients
+// using the given identity. It also returns the stretched preshared key.
+func recoverPayloadKey(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*[encryptionKeySize]byte, *[preSharedKeySize]byte, error) {
+	// Check ephemeral container public encryption key
+	if len(headers.EncryptionPublicKey) != x25519KeySize {
+		return nil, nil, fmt.Errorf("invalid container public size")
+	}
+	ephPublicKey := headers.EncryptionPublicKey
+
+	// Decode private key
+	privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(identity.String(), PrivateKeyPrefix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode private key: %w", err)
+	}
+	defer memguard.WipeBytes(privRaw)
+	if len(privRaw) != privateKeySize {
+		return nil, nil, fmt.Errorf("invalid identity private key length")
+	}
+
+	// Expand ML-KEM decapsulation key
+	dk, err := mlkem.NewDecapsulationKey768(privRaw[:mlkem.SeedSize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode private key: %w", err)
+	}
+
+	// Compute X25519 public key and classical shared secret
+	xPriv := privRaw[mlkem.SeedSize:]
+	xPub, err := curve25519.X25519(xPriv, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode private key: %w", err)
+	}
+	dhSharedKey, err := curve25519.X25519(xPriv, ephPublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to compute recipient shared secret: %w", err)
+	}
+	defer memguard.WipeBytes(dhSharedKey)
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk, err = pskStretch(preSharedKey.Bytes(), ephPublicKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to stretch preshared key: %w", err)
+		}
+	}
+
+	// Try recipients
+	payloadKey, err := tryRecipientKeys(dk, dhSharedKey, ephPublicKey, xPub, headers.Recipients, psk)
+	if err != nil {
+		// Try recipients added after sealing
+		var errEph error
+		payloadKey, errEph = tryEphemeralRecipientKeys(dk, xPriv, xPub, headers.Recipients, psk)
+		if errEph != nil {
+			return nil, nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+		}
+	}
+
+	// Check private key
+	if len(payloadKey) != encryptionKeySize {
+		return nil, nil, fmt.Errorf("invalid encryption key size")
+	}
+	var encryptionKey [encryptionKeySize]byte
+	copy(encryptionKey[:], payloadKey[:encryptionKeySize])
+	memguard.WipeBytes(payloadKey)
+
+	// No error
+	return &encryptionKey, psk, nil
+}
+
+// openPayload decrypts the container payload and verifies its signature.
+func openPayload(container *containerv1.Container, encryptionKey *[encryptionKeySize]byte) ([]byte, error) {
+	// Prepare sig nonce, the signing public key of a resealed container is
+	// prefixed with a random nonce.
+	var pubSigNonce [nonceSize]byte
+	containerBox := container.Headers.ContainerBox
+	switch len(containerBox) {
+	case containerBoxSize:
+		copy(pubSigNonce[:], staticSignatureNonce)
+	case nonceSize + containerBoxSize:
+		copy(pubSigNonce[:], containerBox[:nonceSize])
+		containerBox = containerBox[nonceSize:]
+	default:
+		return nil, fmt.Errorf("invalid container key")
+	}
+
+	// Decrypt signing public key
+	containerSignKeyRaw, ok := secretbox.Open(nil, containerBox, &pubSigNonce, encryptionKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid container key")
+	}
+	if len(containerSignKeyRaw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid signature key size")
+	}
+
+	// Compute headers hash
+	headerHash, err := computeHeaderHash(container.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// Extract payload nonce
+	var payloadNonce [nonceSize]byte
+	copy(payloadNonce[:], headerHash[:nonceSize])
+
+	// Decrypt payload
+	payloadRaw, ok := secretbox.Open(nil, container.Raw, &payloadNonce, encryptionKey)
+	if !ok || len(payloadRaw) < signatureSize {
+		return nil, fmt.Errorf("invalid ciphered content")
+	}
+
+	// Extract signature / content
+	detachedSig := payloadRaw[:signatureSize]
+	content := payloadRaw[signatureSize:]
+
+	// Prepare protected content
+	protectedHash := computeProtectedHash(headerHash, content)
+
+	// Validate signature
+	if !ed25519.Verify(containerSignKeyRaw, protectedHash, detachedSig) {
+		return nil, fmt.Errorf("invalid container signature")
+	}
+
+	// No error
+	return content, nil
+}
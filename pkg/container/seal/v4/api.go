@@ -0,0 +1,56 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package v4 implements the streaming container sealing strategy.
+//
+// Recipients use the v2 (P-384) container keys, and the payload is encrypted
+// with AES-256-GCM using the STREAM construction: it is split in fixed size
+// chunks authenticated in order, the last chunk being flagged in the nonce so
+// that truncation and reordering are detected.
+package v4
+
+import (
+	"crypto/elliptic"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+)
+
+const (
+	SealVersion = 4
+)
+
+const (
+	containerSealedContentType = "application/vnd.harp.v1.SealedContainer"
+	publicKeySize              = 49
+	privateKeySize             = 48
+	encryptionKeySize          = 32
+	keyIdentifierSize          = 32
+	preSharedKeySize           = 64
+	nonceSize                  = 12
+	tagSize                    = 16
+	chunkSize                  = 64 * 1024
+)
+
+var encryptionCurve = elliptic.P384()
+
+// -----------------------------------------------------------------------------
+
+func New() seal.StreamStrategy {
+	return &adapter{}
+}
+
+type adapter struct{}
@@ -0,0 +1,312 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v4
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+func pskStretch(key, salt []byte) *[preSharedKeySize]byte {
+	pskh := hmac.New(sha512.New, key)
+	pskh.Write(salt)
+	hashPsk := pskh.Sum(nil)
+
+	psk := &[preSharedKeySize]byte{}
+	copy(psk[:], hashPsk[:preSharedKeySize])
+
+	return psk
+}
+
+func deriveSharedKeyFromRecipient(publicKey *ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, preSharedKey *[preSharedKeySize]byte) (*[encryptionKeySize]byte, error) {
+	// Compute Z - ECDH(localPrivate, remotePublic)
+	Z, _ := privateKey.Curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+
+	// HKDF-HMAC-SHA384
+	kdf := hkdf.New(sha512.New384, Z.Bytes(), nil, []byte("harp-stream-recipient-key-v4"))
+
+	var sharedSecret [encryptionKeySize]byte
+	if _, err := io.ReadFull(kdf, sharedSecret[:]); err != nil {
+		return nil, fmt.Errorf("unable to derive shared secret: %w", err)
+	}
+
+	// Apply psk, this will act as a second knowledge factor to allow container
+	// unseal
+	if preSharedKey != nil {
+		pskh := hmac.New(sha512.New, preSharedKey[:])
+		pskh.Write([]byte{0x00, 0x00, 0x00, 0x01})
+		pskh.Write(sharedSecret[:])
+		skHash := pskh.Sum(nil)
+		copy(sharedSecret[:], skHash[:encryptionKeySize])
+	}
+
+	// No error
+	return &sharedSecret, nil
+}
+
+func keyIdentifierFromDerivedKey(derivedKey *[encryptionKeySize]byte, preSharedKey *[preSharedKeySize]byte) []byte {
+	// HMAC-SHA512
+	h := hmac.New(sha512.New, []byte("harp stream recipient key identifier"))
+	h.Write(derivedKey[:])
+
+	// Apply psk if specified
+	if preSharedKey != nil {
+		h.Write(preSharedKey[:])
+	}
+
+	// Return truncated hash.
+	return h.Sum(nil)[:keyIdentifierSize]
+}
+
+func packRecipient(rand io.Reader, payloadKey *[encryptionKeySize]byte, ephPrivKey *ecdsa.PrivateKey, peerPublicKey *ecdsa.PublicKey, preSharedKey *[preSharedKeySize]byte) (*containerv1.Recipient, error) {
+	// Create identifier
+	recipientKey, err := deriveSharedKeyFromRecipient(peerPublicKey, ephPrivKey, preSharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+	}
+	identifier := keyIdentifierFromDerivedKey(recipientKey, preSharedKey)
+
+	// Encrypt the payload key
+	aead, err := newAEAD(recipientKey[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate recipient nonce: %w", err)
+	}
+
+	// No error
+	return &containerv1.Recipient{
+		Identifier: identifier,
+		Key:        aead.Seal(nonce, nonce, payloadKey[:], identifier),
+	}, nil
+}
+
+func tryRecipientKeys(derivedKey *[encryptionKeySize]byte, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) (*[encryptionKeySize]byte, error) {
+	// Calculate recipient identifier
+	identifier := keyIdentifierFromDerivedKey(derivedKey, preSharedKey)
+
+	// Find matching recipient
+	for _, r := range recipients {
+		// Check recipient identifiers
+		if !security.SecureCompare(identifier, r.Identifier) {
+			continue
+		}
+		if len(r.Key) != nonceSize+encryptionKeySize+tagSize {
+			return nil, errors.New("invalid recipient encryption key")
+		}
+
+		// Try to decrypt the payload key with the derived key.
+		aead, err := newAEAD(derivedKey[:])
+		if err != nil {
+			return nil, err
+		}
+		clearText, err := aead.Open(nil, r.Key[:nonceSize], r.Key[nonceSize:], identifier)
+		if err != nil {
+			return nil, errors.New("invalid recipient encryption key")
+		}
+
+		var payloadKey [encryptionKeySize]byte
+		copy(payloadKey[:], clearText)
+
+		// Encryption key found, return no error.
+		return &payloadKey, nil
+	}
+
+	// No recipient found in list.
+	return nil, errors.New("no recipient found")
+}
+
+func computeHeaderHash(headers *containerv1.Header) ([]byte, error) {
+	// Check arguments
+	if headers == nil {
+		return nil, errors.New("unable process with nil headers")
+	}
+
+	// Serialize headers
+	header, err := proto.MarshalOptions{Deterministic: true}.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal container headers")
+	}
+
+	// Hash serialized proto
+	hash := sha512.Sum512(header)
+
+	// No error
+	return hash[:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare block cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare AEAD cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// payloadAEAD derives the payload encryption key bound to the sealed container
+// headers.
+func payloadAEAD(payloadKey *[encryptionKeySize]byte, headers *containerv1.Header) (cipher.AEAD, error) {
+	// Compute headers hash
+	headerHash, err := computeHeaderHash(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// HKDF-HMAC-SHA384
+	kdf := hkdf.New(sha512.New384, payloadKey[:], headerHash, []byte("harp-stream-payload-key-v4"))
+	var key [encryptionKeySize]byte
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return nil, fmt.Errorf("unable to derive payload key: %w", err)
+	}
+
+	return newAEAD(key[:])
+}
+
+// -----------------------------------------------------------------------------
+
+func sealedSize(size uint64) uint64 {
+	chunks := size / chunkSize
+	if size%chunkSize != 0 || size == 0 {
+		chunks++
+	}
+
+	return size + chunks*tagSize
+}
+
+// chunkNonce returns the STREAM nonce: 3 zero bytes, the chunk counter and the
+// last chunk flag.
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, nonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 0x01
+	}
+
+	return nonce
+}
+
+func encryptStream(w io.Writer, r io.Reader, size uint64, aead cipher.AEAD) error {
+	var (
+		in        = make([]byte, chunkSize)
+		out       = make([]byte, 0, chunkSize+tagSize)
+		remaining = size
+	)
+	defer memguard.WipeBytes(in)
+
+	for counter := uint64(0); ; counter++ {
+		n := uint64(chunkSize)
+		if remaining < n {
+			n = remaining
+		}
+
+		// Read plaintext chunk
+		if _, err := io.ReadFull(r, in[:n]); err != nil {
+			return fmt.Errorf("unable to read payload chunk %d: %w", counter, err)
+		}
+		remaining -= n
+		last := remaining == 0
+
+		// Encrypt and write the chunk
+		out = aead.Seal(out[:0], chunkNonce(counter, last), in[:n], nil)
+		if _, err := w.Write(out); err != nil {
+			return fmt.Errorf("unable to write payload chunk %d: %w", counter, err)
+		}
+
+		if last {
+			break
+		}
+	}
+
+	// No error
+	return nil
+}
+
+func decryptStream(w io.Writer, r io.Reader, size uint64, aead cipher.AEAD) error {
+	// Check arguments
+	if size < tagSize {
+		return errors.New("sealed payload is too short")
+	}
+
+	var (
+		in        = make([]byte, chunkSize+tagSize)
+		out       = make([]byte, 0, chunkSize)
+		remaining = size
+	)
+	defer memguard.WipeBytes(out[:cap(out)])
+
+	for counter := uint64(0); ; counter++ {
+		n := uint64(chunkSize + tagSize)
+		if remaining < n {
+			n = remaining
+		}
+		if n < tagSize {
+			return fmt.Errorf("payload chunk %d is too short", counter)
+		}
+		remaining -= n
+		last := remaining == 0
+
+		// Only an empty payload has an empty last chunk
+		if last && n == tagSize && counter > 0 {
+			return fmt.Errorf("payload chunk %d is empty", counter)
+		}
+
+		// Read ciphertext chunk
+		if _, err := io.ReadFull(r, in[:n]); err != nil {
+			return fmt.Errorf("unable to read payload chunk %d: %w", counter, err)
+		}
+
+		// Authenticate and decrypt the chunk
+		var err error
+		out, err = aead.Open(out[:0], chunkNonce(counter, last), in[:n], nil)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt payload chunk %d", counter)
+		}
+		if _, err := w.Write(out); err != nil {
+			return fmt.Errorf("unable to write payload chunk %d: %w", counter, err)
+		}
+
+		if last {
+			break
+		}
+	}
+
+	// No error
+	return nil
+}
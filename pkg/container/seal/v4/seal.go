@@ -0,0 +1,154 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v4
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// Seal a secret container with identities.
+func (a *adapter) Seal(rand io.Reader, container *containerv1.Container, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.seal(rand, container, nil, encodedPeersPublicKey...)
+}
+
+// Seal a secret container with identities and preshared key.
+func (a *adapter) SealWithPSK(rand io.Reader, container *containerv1.Container, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.seal(rand, container, preSharedKey, encodedPeersPublicKey...)
+}
+
+// SealedSize returns the sealed payload size for the given payload size.
+func (a *adapter) SealedSize(size uint64) uint64 {
+	return sealedSize(size)
+}
+
+// SealStream prepares the sealed container headers and returns the payload
+// sealing function.
+func (a *adapter) SealStream(rand io.Reader, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Header, seal.PayloadFunc, error) {
+	// Check parameters
+	if len(encodedPeersPublicKey) == 0 {
+		return nil, nil, fmt.Errorf("unable to process empty public keys")
+	}
+
+	// Convert public keys
+	peersPublicKey, err := a.publicKeys(encodedPeersPublicKey...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to convert peer public keys: %w", err)
+	}
+
+	// Generate payload key
+	var payloadKey [encryptionKeySize]byte
+	if _, err := io.ReadFull(rand, payloadKey[:]); err != nil {
+		return nil, nil, fmt.Errorf("unable to generate payload key for encryption")
+	}
+
+	// Generate ephemeral encryption key
+	encPriv, err := ecdsa.GenerateKey(encryptionCurve, rand)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+
+	// Prepare sealed container
+	containerHeaders := &containerv1.Header{
+		ContentType:         containerSealedContentType,
+		EncryptionPublicKey: elliptic.MarshalCompressed(encPriv.Curve, encPriv.PublicKey.X, encPriv.PublicKey.Y),
+		Recipients:          []*containerv1.Recipient{},
+		SealVersion:         SealVersion,
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk = pskStretch(preSharedKey.Bytes(), containerHeaders.EncryptionPublicKey)
+	}
+
+	// Process recipients
+	for _, peerPublicKey := range peersPublicKey {
+		// Pack recipient using its public key
+		r, errPack := packRecipient(rand, &payloadKey, encPriv, peerPublicKey, psk)
+		if errPack != nil {
+			return nil, nil, fmt.Errorf("unable to pack container recipient: %w", errPack)
+		}
+
+		// Append to container
+		containerHeaders.Recipients = append(containerHeaders.Recipients, r)
+	}
+
+	// Sanity check
+	if len(containerHeaders.Recipients) == 0 {
+		return nil, nil, errors.New("unable to seal a container without recipients")
+	}
+
+	// Derive the payload key bound to the headers
+	aead, err := payloadAEAD(&payloadKey, containerHeaders)
+	memguard.WipeBytes(payloadKey[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// No error
+	return containerHeaders, func(w io.Writer, r io.Reader, size uint64) error {
+		return encryptStream(w, r, size, aead)
+	}, nil
+}
+
+func (a *adapter) seal(rand io.Reader, container *containerv1.Container, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return nil, fmt.Errorf("unable to process nil container headers")
+	}
+
+	// Prepare sealing
+	headers, sealPayload, err := a.SealStream(rand, preSharedKey, encodedPeersPublicKey...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize inner container
+	content, err := proto.Marshal(container)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode container content: %w", err)
+	}
+	defer memguard.WipeBytes(content)
+
+	// Encrypt payload
+	var payload bytes.Buffer
+	if err := sealPayload(&payload, bytes.NewReader(content), uint64(len(content))); err != nil {
+		return nil, fmt.Errorf("unable to encrypt container data: %w", err)
+	}
+
+	// No error
+	return &containerv1.Container{
+		Headers: headers,
+		Raw:     payload.Bytes(),
+	}, nil
+}
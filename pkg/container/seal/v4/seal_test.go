@@ -0,0 +1,240 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v4
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+)
+
+func TestSeal(t *testing.T) {
+	type args struct {
+		container      *containerv1.Container
+		peersPublicKey []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "empty container headers",
+			args: args{
+				container: &containerv1.Container{},
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no public keys",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "v1 public key",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				peersPublicKey: []string{
+					"v1.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E",
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+					Raw:     []byte{0x01, 0x02, 0x03, 0x04},
+				},
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := New()
+			_, err := adapter.Seal(rand.Reader, tt.args.container, tt.args.peersPublicKey...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Seal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+func Test_Seal_Unseal(t *testing.T) {
+	adapter := New()
+
+	pubKey, privKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+	otherPubKey, otherPrivKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+	psk := memguard.NewBufferRandom(64)
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 42} {
+		raw := make([]byte, size)
+		_, err := rand.Read(raw)
+		require.NoError(t, err)
+
+		input := &containerv1.Container{
+			Headers: &containerv1.Header{
+				ContentEncoding: "gzip",
+				ContentType:     "application/vnd.harp.v1.Bundle",
+			},
+			Raw: raw,
+		}
+
+		sealed, err := adapter.SealWithPSK(rand.Reader, input, psk, pubKey, otherPubKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(SealVersion), sealed.Headers.SealVersion)
+
+		content, err := proto.Marshal(input)
+		require.NoError(t, err)
+		assert.Equal(t, adapter.SealedSize(uint64(len(content))), uint64(len(sealed.Raw)))
+
+		for _, key := range []string{privKey, otherPrivKey} {
+			unsealed, err := adapter.UnsealWithPSK(sealed, memguard.NewBufferFromBytes([]byte(key)), psk)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(input, unsealed))
+		}
+
+		// PSK is required
+		_, err = adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(privKey)))
+		assert.Error(t, err)
+	}
+}
+
+func Test_Unseal_Tampering(t *testing.T) {
+	adapter := New()
+
+	pubKey, privKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+	_, otherPrivKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+
+	raw := make([]byte, 3*chunkSize)
+	_, err = rand.Read(raw)
+	require.NoError(t, err)
+
+	sealed, err := adapter.Seal(rand.Reader, &containerv1.Container{
+		Headers: &containerv1.Header{},
+		Raw:     raw,
+	}, pubKey)
+	require.NoError(t, err)
+
+	unseal := func(c *containerv1.Container) error {
+		_, err := adapter.Unseal(c, memguard.NewBufferFromBytes([]byte(privKey)))
+		return err
+	}
+	tamper := func(f func(c *containerv1.Container)) *containerv1.Container {
+		c, ok := proto.Clone(sealed).(*containerv1.Container)
+		require.True(t, ok)
+		f(c)
+		return c
+	}
+
+	require.NoError(t, unseal(sealed))
+
+	// Unknown recipient
+	_, err = adapter.Unseal(sealed, memguard.NewBufferFromBytes([]byte(otherPrivKey)))
+	assert.Error(t, err)
+
+	// Truncated after a full chunk
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		c.Raw = c.Raw[:chunkSize+tagSize]
+	})))
+
+	// Dropped last chunk
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		c.Raw = c.Raw[:3*(chunkSize+tagSize)]
+	})))
+
+	// Reordered chunks
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		first := append([]byte{}, c.Raw[:chunkSize+tagSize]...)
+		copy(c.Raw, c.Raw[chunkSize+tagSize:2*(chunkSize+tagSize)])
+		copy(c.Raw[chunkSize+tagSize:], first)
+	})))
+
+	// Appended chunk
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		c.Raw = append(c.Raw, c.Raw[:chunkSize+tagSize]...)
+	})))
+
+	// Modified headers
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		c.Headers.ContentEncoding = "gzip"
+	})))
+
+	// Flipped bit
+	assert.Error(t, unseal(tamper(func(c *containerv1.Container) {
+		c.Raw[len(c.Raw)/2] ^= 0x01
+	})))
+}
+
+func Test_SealStream_UnsealStream(t *testing.T) {
+	adapter := New()
+
+	pubKey, privKey, err := adapter.GenerateKey()
+	require.NoError(t, err)
+
+	headers, sealPayload, err := adapter.SealStream(rand.Reader, nil, pubKey)
+	require.NoError(t, err)
+
+	plaintext := bytes.Repeat([]byte("harp"), 100000)
+	var sealed bytes.Buffer
+	require.NoError(t, sealPayload(&sealed, bytes.NewReader(plaintext), uint64(len(plaintext))))
+	assert.Equal(t, adapter.SealedSize(uint64(len(plaintext))), uint64(sealed.Len()))
+
+	// Short input
+	assert.Error(t, sealPayload(&bytes.Buffer{}, bytes.NewReader(plaintext[:10]), uint64(len(plaintext))))
+
+	unsealPayload, err := adapter.UnsealStream(headers, memguard.NewBufferFromBytes([]byte(privKey)), nil)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, unsealPayload(&out, bytes.NewReader(sealed.Bytes()), uint64(sealed.Len())))
+	assert.Equal(t, plaintext, out.Bytes())
+}
@@ -0,0 +1,163 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v4
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// Unseal a sealed container with the given identity.
+func (a *adapter) Unseal(container *containerv1.Container, identity *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, nil)
+}
+
+// Unseal a sealed container with the given identity and the given preshared key.
+func (a *adapter) UnsealWithPSK(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, preSharedKey)
+}
+
+// UnsealStream recovers the payload key from the given sealed container
+// headers and returns the payload unsealing function.
+//
+// Payload chunks are written as soon as they are authenticated, a truncated
+// payload is only detected when the last chunk is processed. The plaintext
+// already written before an error is partial and must be discarded by the
+// caller.
+func (a *adapter) UnsealStream(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (seal.PayloadFunc, error) {
+	// Check parameters
+	if types.IsNil(headers) {
+		return nil, fmt.Errorf("unable to process nil container headers")
+	}
+	if identity == nil {
+		return nil, fmt.Errorf("unable to process without container key")
+	}
+
+	// Check headers
+	if headers.ContentType != containerSealedContentType || headers.SealVersion != SealVersion {
+		return nil, fmt.Errorf("unable to unseal container")
+	}
+
+	// Check ephemeral container public encryption key
+	if len(headers.EncryptionPublicKey) != publicKeySize {
+		return nil, fmt.Errorf("invalid container public size")
+	}
+
+	// Decode public key
+	var publicKey ecdsa.PublicKey
+	publicKey.Curve = encryptionCurve
+	publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(encryptionCurve, headers.EncryptionPublicKey)
+	if publicKey.X == nil {
+		return nil, errors.New("invalid container encryption public key")
+	}
+
+	// Decode private key
+	privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(identity.String(), v2.PrivateKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode private key: %w", err)
+	}
+	if len(privRaw) != privateKeySize {
+		return nil, fmt.Errorf("invalid identity private key length")
+	}
+	var pk ecdsa.PrivateKey
+	pk.PublicKey.Curve = encryptionCurve
+	pk.D = big.NewInt(0).SetBytes(privRaw)
+	memguard.WipeBytes(privRaw)
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk = pskStretch(preSharedKey.Bytes(), headers.EncryptionPublicKey)
+	}
+
+	// Precompute identifier
+	derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, &pk, psk)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+	}
+
+	// Try recipients
+	payloadKey, err := tryRecipientKeys(derivedKey, headers.Recipients, psk)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+	}
+
+	// Derive the payload key bound to the headers
+	aead, err := payloadAEAD(payloadKey, headers)
+	memguard.WipeBytes(payloadKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// No error
+	return func(w io.Writer, r io.Reader, size uint64) error {
+		return decryptStream(w, r, size, aead)
+	}, nil
+}
+
+// IsRecipient returns true if the payload key can be recovered from the given
+// sealed container headers with the given identity. The payload is neither
+// decrypted nor verified.
+func (a *adapter) IsRecipient(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) bool {
+	_, err := a.UnsealStream(headers, identity, preSharedKey)
+	return err == nil
+}
+
+func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+
+	// Prepare unsealing
+	unsealPayload, err := a.UnsealStream(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt payload
+	var content bytes.Buffer
+	if err := unsealPayload(&content, bytes.NewReader(container.Raw), uint64(len(container.Raw))); err != nil {
+		return nil, fmt.Errorf("invalid ciphered content: %w", err)
+	}
+	defer memguard.WipeBytes(content.Bytes())
+
+	// Unmarshal inner container
+	out := &containerv1.Container{}
+	if err := proto.Unmarshal(content.Bytes(), out); err != nil {
+		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	}
+
+	// No error
+	return out, nil
+}
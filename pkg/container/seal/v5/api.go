@@ -0,0 +1,61 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package v5 implements the threshold container sealing strategy.
+//
+// Recipients use the v2 (P-384) container keys. The payload key is split with
+// Shamir's secret sharing and each recipient receives one share wrapped with
+// AES-256-GCM, a threshold of shares is required to recover the payload key.
+// The payload is encrypted with AES-256-GCM using a key bound to the sealed
+// container headers.
+package v5
+
+import (
+	"crypto/elliptic"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/shamir"
+)
+
+const (
+	SealVersion = 5
+	SharePrefix = "v5.ks."
+)
+
+const (
+	containerSealedContentType = "application/vnd.harp.v1.SealedContainer"
+	publicKeySize              = 49
+	privateKeySize             = 48
+	encryptionKeySize          = 32
+	keyIdentifierSize          = 32
+	preSharedKeySize           = 64
+	nonceSize                  = 12
+	tagSize                    = 16
+	containerIDSize            = 16
+	shareSize                  = 1 + encryptionKeySize + shamir.ShareOverhead
+	minThreshold               = 2
+)
+
+var encryptionCurve = elliptic.P384()
+
+// -----------------------------------------------------------------------------
+
+func New() seal.ThresholdStrategy {
+	return &adapter{}
+}
+
+type adapter struct{}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v5
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+func pskStretch(key, salt []byte) *[preSharedKeySize]byte {
+	pskh := hmac.New(sha512.New, key)
+	pskh.Write(salt)
+	hashPsk := pskh.Sum(nil)
+
+	psk := &[preSharedKeySize]byte{}
+	copy(psk[:], hashPsk[:preSharedKeySize])
+
+	return psk
+}
+
+func deriveSharedKeyFromRecipient(publicKey *ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, preSharedKey *[preSharedKeySize]byte) (*[encryptionKeySize]byte, error) {
+	// Compute Z - ECDH(localPrivate, remotePublic)
+	Z, _ := privateKey.Curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+
+	// HKDF-HMAC-SHA384
+	kdf := hkdf.New(sha512.New384, Z.Bytes(), nil, []byte("harp-threshold-recipient-key-v5"))
+
+	var sharedSecret [encryptionKeySize]byte
+	if _, err := io.ReadFull(kdf, sharedSecret[:]); err != nil {
+		return nil, fmt.Errorf("unable to derive shared secret: %w", err)
+	}
+
+	// Apply psk, this will act as a second knowledge factor to allow container
+	// unseal
+	if preSharedKey != nil {
+		pskh := hmac.New(sha512.New, preSharedKey[:])
+		pskh.Write([]byte{0x00, 0x00, 0x00, 0x01})
+		pskh.Write(sharedSecret[:])
+		skHash := pskh.Sum(nil)
+		copy(sharedSecret[:], skHash[:encryptionKeySize])
+	}
+
+	// No error
+	return &sharedSecret, nil
+}
+
+func keyIdentifierFromDerivedKey(derivedKey *[encryptionKeySize]byte, preSharedKey *[preSharedKeySize]byte) []byte {
+	// HMAC-SHA512
+	h := hmac.New(sha512.New, []byte("harp threshold recipient key identifier"))
+	h.Write(derivedKey[:])
+
+	// Apply psk if specified
+	if preSharedKey != nil {
+		h.Write(preSharedKey[:])
+	}
+
+	// Return truncated hash.
+	return h.Sum(nil)[:keyIdentifierSize]
+}
+
+// packRecipient wraps the given payload key share for the peer public key.
+func packRecipient(rand io.Reader, share []byte, ephPrivKey *ecdsa.PrivateKey, peerPublicKey *ecdsa.PublicKey, preSharedKey *[preSharedKeySize]byte) (*containerv1.Recipient, error) {
+	// Create identifier
+	recipientKey, err := deriveSharedKeyFromRecipient(peerPublicKey, ephPrivKey, preSharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+	}
+	defer memguard.WipeBytes(recipientKey[:])
+	identifier := keyIdentifierFromDerivedKey(recipientKey, preSharedKey)
+
+	// Encrypt the payload key share
+	aead, err := newAEAD(recipientKey[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate recipient nonce: %w", err)
+	}
+
+	// No error
+	return &containerv1.Recipient{
+		Identifier: identifier,
+		Key:        aead.Seal(nonce, nonce, share, identifier),
+	}, nil
+}
+
+// tryRecipientKeys returns the payload key share of the recipient matching the
+// derived key.
+func tryRecipientKeys(derivedKey *[encryptionKeySize]byte, recipients []*containerv1.Recipient, preSharedKey *[preSharedKeySize]byte) ([]byte, error) {
+	// Calculate recipient identifier
+	identifier := keyIdentifierFromDerivedKey(derivedKey, preSharedKey)
+
+	// Find matching recipient
+	for _, r := range recipients {
+		// Check recipient identifiers
+		if !security.SecureCompare(identifier, r.Identifier) {
+			continue
+		}
+		if len(r.Key) != nonceSize+shareSize+tagSize {
+			return nil, errors.New("invalid recipient encryption key")
+		}
+
+		// Try to decrypt the payload key share with the derived key.
+		aead, err := newAEAD(derivedKey[:])
+		if err != nil {
+			return nil, err
+		}
+		share, err := aead.Open(nil, r.Key[:nonceSize], r.Key[nonceSize:], identifier)
+		if err != nil {
+			return nil, errors.New("invalid recipient encryption key")
+		}
+
+		// Share found, return no error.
+		return share, nil
+	}
+
+	// No recipient found in list.
+	return nil, errors.New("no recipient found")
+}
+
+func computeHeaderHash(headers *containerv1.Header) ([]byte, error) {
+	// Check arguments
+	if headers == nil {
+		return nil, errors.New("unable process with nil headers")
+	}
+
+	// Serialize headers
+	header, err := proto.MarshalOptions{Deterministic: true}.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal container headers")
+	}
+
+	// Hash serialized proto
+	hash := sha512.Sum512(header)
+
+	// No error
+	return hash[:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare block cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare AEAD cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// payloadAEAD derives the payload encryption key bound to the sealed container
+// headers.
+func payloadAEAD(payloadKey []byte, headerHash []byte) (cipher.AEAD, error) {
+	// HKDF-HMAC-SHA384
+	kdf := hkdf.New(sha512.New384, payloadKey, headerHash, []byte("harp-threshold-payload-key-v5"))
+	var key [encryptionKeySize]byte
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return nil, fmt.Errorf("unable to derive payload key: %w", err)
+	}
+	defer memguard.WipeBytes(key[:])
+
+	return newAEAD(key[:])
+}
+
+// -----------------------------------------------------------------------------
+
+// encodeShare binds the payload key share to the container identifier, which
+// is the truncated container headers hash.
+func encodeShare(headerHash, share []byte) *memguard.LockedBuffer {
+	raw := make([]byte, 0, containerIDSize+shareSize)
+	raw = append(raw, headerHash[:containerIDSize]...)
+	raw = append(raw, share...)
+	defer memguard.WipeBytes(raw)
+
+	return memguard.NewBufferFromBytes([]byte(SharePrefix + base64.RawURLEncoding.EncodeToString(raw)))
+}
+
+// decodeShare returns the payload key share if it belongs to the container
+// matching the given headers hash.
+func decodeShare(headerHash []byte, encoded *memguard.LockedBuffer) ([]byte, error) {
+	// Check prefix
+	if !strings.HasPrefix(encoded.String(), SharePrefix) {
+		return nil, errors.New("invalid share prefix")
+	}
+
+	// Decode share
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(encoded.String(), SharePrefix)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode share: %w", err)
+	}
+	if len(raw) != containerIDSize+shareSize {
+		memguard.WipeBytes(raw)
+		return nil, errors.New("invalid share length")
+	}
+
+	// Check container binding
+	if !security.SecureCompare(raw[:containerIDSize], headerHash[:containerIDSize]) {
+		memguard.WipeBytes(raw)
+		return nil, errors.New("the share belongs to another container")
+	}
+
+	// No error
+	return raw[containerIDSize:], nil
+}
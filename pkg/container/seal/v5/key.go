@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v5
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+)
+
+// GenerateKey create an ECDSA P-384 key pair used as container identifier.
+// The threshold strategy shares the v2 container key format.
+func (a *adapter) GenerateKey(fopts ...seal.GenerateOption) (publicKey, privateKey string, err error) {
+	return v2.New().GenerateKey(fopts...)
+}
+
+// PublicKeys return the appropriate key format used by the sealing strategy.
+func (a *adapter) publicKeys(keys ...string) ([]*ecdsa.PublicKey, error) {
+	res := []*ecdsa.PublicKey{}
+	seen := map[string]struct{}{}
+
+	for _, key := range keys {
+		// Check key prefix
+		if !strings.HasPrefix(key, v2.PublicKeyPrefix) {
+			return nil, fmt.Errorf("unsupported public key %q for v5 seal algorithm", key)
+		}
+
+		// Decode key
+		keyRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(key, v2.PublicKeyPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode public key %q: %w", key, err)
+		}
+
+		// Public key sanity checks
+		if len(keyRaw) != publicKeySize {
+			return nil, fmt.Errorf("invalid public key length for key %q", key)
+		}
+
+		// A recipient must not receive several shares
+		if _, ok := seen[string(keyRaw)]; ok {
+			return nil, fmt.Errorf("duplicated public key %q", key)
+		}
+		seen[string(keyRaw)] = struct{}{}
+
+		// Decode the compressed point
+		x, y := elliptic.UnmarshalCompressed(encryptionCurve, keyRaw)
+		if x == nil {
+			return nil, fmt.Errorf("invalid public key %q", key)
+		}
+
+		// Append it to sealing keys
+		res = append(res, &ecdsa.PublicKey{
+			Curve: encryptionCurve,
+			X:     x,
+			Y:     y,
+		})
+	}
+
+	// No error
+	return res, nil
+}
+
+// privateKey decodes the given identity private key.
+func privateKey(identity *memguard.LockedBuffer) (*ecdsa.PrivateKey, error) {
+	// Decode private key
+	privRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(identity.String(), v2.PrivateKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode private key: %w", err)
+	}
+	defer memguard.WipeBytes(privRaw)
+	if len(privRaw) != privateKeySize {
+		return nil, fmt.Errorf("invalid identity private key length")
+	}
+
+	var pk ecdsa.PrivateKey
+	pk.PublicKey.Curve = encryptionCurve
+	pk.D = big.NewInt(0).SetBytes(privRaw)
+
+	// No error
+	return &pk, nil
+}
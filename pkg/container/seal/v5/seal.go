@@ -0,0 +1,154 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v5
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/shamir"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// Seal a secret container with identities, all recipients are required to
+// unseal the container.
+func (a *adapter) Seal(rand io.Reader, container *containerv1.Container, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.SealThreshold(rand, container, nil, len(encodedPeersPublicKey), encodedPeersPublicKey...)
+}
+
+// Seal a secret container with identities and preshared key, all recipients
+// are required to unseal the container.
+func (a *adapter) SealWithPSK(rand io.Reader, container *containerv1.Container, preSharedKey *memguard.LockedBuffer, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	return a.SealThreshold(rand, container, preSharedKey, len(encodedPeersPublicKey), encodedPeersPublicKey...)
+}
+
+// SealThreshold seals a secret container with identities, threshold recipient
+// shares being required to unseal the container.
+func (a *adapter) SealThreshold(rand io.Reader, container *containerv1.Container, preSharedKey *memguard.LockedBuffer, threshold int, encodedPeersPublicKey ...string) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if types.IsNil(container.Headers) {
+		return nil, fmt.Errorf("unable to process nil container headers")
+	}
+	if len(encodedPeersPublicKey) == 0 {
+		return nil, fmt.Errorf("unable to process empty public keys")
+	}
+	if threshold < minThreshold {
+		return nil, fmt.Errorf("threshold must be at least %d", minThreshold)
+	}
+	if threshold > len(encodedPeersPublicKey) {
+		return nil, fmt.Errorf("threshold %d exceeds the recipient count %d", threshold, len(encodedPeersPublicKey))
+	}
+
+	// Convert public keys
+	peersPublicKey, err := a.publicKeys(encodedPeersPublicKey...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert peer public keys: %w", err)
+	}
+
+	// Generate payload key
+	var payloadKey [encryptionKeySize]byte
+	if _, err := io.ReadFull(rand, payloadKey[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate payload key for encryption")
+	}
+	defer memguard.WipeBytes(payloadKey[:])
+
+	// Split the payload key, one share per recipient
+	shares, err := shamir.Split(rand, payloadKey[:], len(peersPublicKey), threshold)
+	if err != nil {
+		return nil, fmt.Errorf("unable to split payload key: %w", err)
+	}
+	defer func() {
+		for _, share := range shares {
+			memguard.WipeBytes(share)
+		}
+	}()
+
+	// Generate ephemeral encryption key
+	encPriv, err := ecdsa.GenerateKey(encryptionCurve, rand)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral encryption keypair")
+	}
+
+	// Prepare sealed container
+	containerHeaders := &containerv1.Header{
+		ContentType:         containerSealedContentType,
+		EncryptionPublicKey: elliptic.MarshalCompressed(encPriv.Curve, encPriv.PublicKey.X, encPriv.PublicKey.Y),
+		Recipients:          []*containerv1.Recipient{},
+		SealVersion:         SealVersion,
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk = pskStretch(preSharedKey.Bytes(), containerHeaders.EncryptionPublicKey)
+	}
+
+	// Process recipients
+	for i, peerPublicKey := range peersPublicKey {
+		// Share record: threshold || share
+		record := append([]byte{byte(threshold)}, shares[i]...)
+
+		// Pack recipient using its public key
+		r, errPack := packRecipient(rand, record, encPriv, peerPublicKey, psk)
+		memguard.WipeBytes(record)
+		if errPack != nil {
+			return nil, fmt.Errorf("unable to pack container recipient: %w", errPack)
+		}
+
+		// Append to container
+		containerHeaders.Recipients = append(containerHeaders.Recipients, r)
+	}
+
+	// Derive the payload key bound to the headers
+	headerHash, err := computeHeaderHash(containerHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+	aead, err := payloadAEAD(payloadKey[:], headerHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize inner container
+	content, err := proto.Marshal(container)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode container content: %w", err)
+	}
+	defer memguard.WipeBytes(content)
+
+	// Encrypt payload
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate payload nonce: %w", err)
+	}
+
+	// No error
+	return &containerv1.Container{
+		Headers: containerHeaders,
+		Raw:     aead.Seal(nonce, nonce, content, nil),
+	}, nil
+}
@@ -0,0 +1,295 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v5
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+)
+
+func TestSealThreshold(t *testing.T) {
+	type args struct {
+		container      *containerv1.Container
+		threshold      int
+		peersPublicKey []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "empty container headers",
+			args: args{
+				container: &containerv1.Container{},
+				threshold: 2,
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no public keys",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				threshold: 2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "threshold too low",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				threshold: 1,
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "threshold too high",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				threshold: 3,
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated public key",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				threshold: 2,
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "v1 public key",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+				},
+				threshold: 2,
+				peersPublicKey: []string{
+					"v1.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			args: args{
+				container: &containerv1.Container{
+					Headers: &containerv1.Header{},
+					Raw:     []byte{0x01, 0x02, 0x03, 0x04},
+				},
+				threshold: 2,
+				peersPublicKey: []string{
+					"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+					"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := New()
+			_, err := adapter.SealThreshold(rand.Reader, tt.args.container, nil, tt.args.threshold, tt.args.peersPublicKey...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SealThreshold() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+func generateKeys(t *testing.T, n int) (publicKeys []string, privateKeys []*memguard.LockedBuffer) {
+	t.Helper()
+
+	adapter := New()
+	for i := 0; i < n; i++ {
+		pub, priv, err := adapter.GenerateKey()
+		require.NoError(t, err)
+		publicKeys = append(publicKeys, pub)
+		privateKeys = append(privateKeys, memguard.NewBufferFromBytes([]byte(priv)))
+	}
+
+	return publicKeys, privateKeys
+}
+
+func exportShares(t *testing.T, headers *containerv1.Header, psk *memguard.LockedBuffer, identities ...*memguard.LockedBuffer) []*memguard.LockedBuffer {
+	t.Helper()
+
+	adapter := New()
+	shares := []*memguard.LockedBuffer{}
+	for _, id := range identities {
+		share, err := adapter.ExportShare(headers, id, psk)
+		require.NoError(t, err)
+		shares = append(shares, share)
+	}
+
+	return shares
+}
+
+func Test_Seal_Unseal(t *testing.T) {
+	adapter := New()
+
+	pubKeys, privKeys := generateKeys(t, 4)
+	psk := memguard.NewBufferFromBytes([]byte("pre-shared-key"))
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: "gzip",
+			ContentType:     "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte("threshold sealed content"),
+	}
+
+	for _, preSharedKey := range []*memguard.LockedBuffer{nil, psk} {
+		sealed, err := adapter.SealThreshold(rand.Reader, input, preSharedKey, 3, pubKeys...)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(SealVersion), sealed.Headers.SealVersion)
+		assert.Len(t, sealed.Headers.Recipients, 4)
+
+		// Single identity
+		_, err = adapter.UnsealWithPSK(sealed, privKeys[0], preSharedKey)
+		assert.Error(t, err)
+		assert.True(t, adapter.(seal.RecipientChecker).IsRecipient(sealed.Headers, privKeys[0], preSharedKey))
+
+		// Any threshold sized subset
+		for _, subset := range [][]int{{0, 1, 2}, {1, 2, 3}, {3, 0, 2}, {0, 1, 2, 3}} {
+			identities := []*memguard.LockedBuffer{}
+			for _, i := range subset {
+				identities = append(identities, privKeys[i])
+			}
+
+			out, err := adapter.UnsealWithShares(sealed, exportShares(t, sealed.Headers, preSharedKey, identities...)...)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(input, out))
+		}
+
+		// The same share provided several times
+		shares := exportShares(t, sealed.Headers, preSharedKey, privKeys[0], privKeys[1], privKeys[1], privKeys[2])
+		out, err := adapter.UnsealWithShares(sealed, shares...)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(input, out))
+
+		// Not enough shares
+		_, err = adapter.UnsealWithShares(sealed, exportShares(t, sealed.Headers, preSharedKey, privKeys[0], privKeys[1], privKeys[1])...)
+		assert.Error(t, err)
+	}
+}
+
+func Test_Unseal_Errors(t *testing.T) {
+	adapter := New()
+
+	pubKeys, privKeys := generateKeys(t, 3)
+	_, otherPrivKeys := generateKeys(t, 1)
+	psk := memguard.NewBufferFromBytes([]byte("pre-shared-key"))
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{},
+		Raw:     []byte("threshold sealed content"),
+	}
+	sealed, err := adapter.SealThreshold(rand.Reader, input, nil, 2, pubKeys...)
+	require.NoError(t, err)
+	other, err := adapter.SealThreshold(rand.Reader, input, nil, 2, pubKeys...)
+	require.NoError(t, err)
+	shares := exportShares(t, sealed.Headers, nil, privKeys...)
+
+	// Not a recipient
+	_, err = adapter.ExportShare(sealed.Headers, otherPrivKeys[0], nil)
+	assert.Error(t, err)
+	assert.False(t, adapter.(seal.RecipientChecker).IsRecipient(sealed.Headers, otherPrivKeys[0], nil))
+
+	// Unexpected pre-shared key
+	_, err = adapter.ExportShare(sealed.Headers, privKeys[0], psk)
+	assert.Error(t, err)
+
+	// Invalid parameters
+	_, err = adapter.ExportShare(nil, privKeys[0], nil)
+	assert.Error(t, err)
+	_, err = adapter.ExportShare(sealed.Headers, nil, nil)
+	assert.Error(t, err)
+	_, err = adapter.UnsealWithShares(nil, shares...)
+	assert.Error(t, err)
+	_, err = adapter.UnsealWithShares(sealed)
+	assert.Error(t, err)
+	_, err = adapter.UnsealWithShares(sealed, shares[0], nil)
+	assert.Error(t, err)
+
+	// Shares of another container
+	_, err = adapter.UnsealWithShares(other, shares...)
+	assert.Error(t, err)
+
+	// Invalid share encoding
+	_, err = adapter.UnsealWithShares(sealed, shares[0], memguard.NewBufferFromBytes([]byte("v2.ks.AAAA")))
+	assert.Error(t, err)
+	_, err = adapter.UnsealWithShares(sealed, shares[0], memguard.NewBufferFromBytes([]byte(SharePrefix+"AAAA")))
+	assert.Error(t, err)
+
+	// Tampered headers
+	tampered, ok := proto.Clone(sealed).(*containerv1.Container)
+	require.True(t, ok)
+	tampered.Headers.ContentEncoding = "gzip"
+	_, err = adapter.UnsealWithShares(tampered, exportShares(t, tampered.Headers, nil, privKeys[0], privKeys[1])...)
+	assert.Error(t, err)
+
+	// Tampered payload
+	tampered, ok = proto.Clone(sealed).(*containerv1.Container)
+	require.True(t, ok)
+	tampered.Raw[len(tampered.Raw)-1] ^= 0x01
+	_, err = adapter.UnsealWithShares(tampered, shares...)
+	assert.Error(t, err)
+}
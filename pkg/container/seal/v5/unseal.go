@@ -0,0 +1,271 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v5
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/shamir"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// Unseal a sealed container with the given identity. A single identity only
+// recovers a payload key share, use UnsealWithShares to combine them.
+func (a *adapter) Unseal(container *containerv1.Container, identity *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, nil)
+}
+
+// Unseal a sealed container with the given identity and the given preshared key.
+func (a *adapter) UnsealWithPSK(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	return a.unseal(container, identity, preSharedKey)
+}
+
+// ExportShare recovers the payload key share of the given identity from the
+// sealed container headers. The share is bound to the container.
+func (a *adapter) ExportShare(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) (*memguard.LockedBuffer, error) {
+	// Recover payload key share
+	record, err := recoverShare(headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer memguard.WipeBytes(record)
+
+	// Compute container identifier
+	headerHash, err := computeHeaderHash(headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// No error
+	return encodeShare(headerHash, record), nil
+}
+
+// UnsealWithShares unseals a sealed container by combining the given payload
+// key shares.
+func (a *adapter) UnsealWithShares(container *containerv1.Container, shares ...*memguard.LockedBuffer) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+	if err := checkHeaders(container.Headers); err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return nil, errors.New("unable to process without shares")
+	}
+
+	// Compute container identifier
+	headerHash, err := computeHeaderHash(container.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute header hash: %w", err)
+	}
+
+	// Decode shares
+	var (
+		threshold byte
+		parts     = [][]byte{}
+		seen      = map[byte][]byte{}
+	)
+	defer func() {
+		for _, part := range parts {
+			memguard.WipeBytes(part)
+		}
+	}()
+	for i, encoded := range shares {
+		if encoded == nil {
+			return nil, fmt.Errorf("unable to process nil share #%d", i+1)
+		}
+
+		record, errDecode := decodeShare(headerHash, encoded)
+		if errDecode != nil {
+			return nil, fmt.Errorf("unable to decode share #%d: %w", i+1, errDecode)
+		}
+
+		// Check share consistency
+		if threshold == 0 {
+			threshold = record[0]
+		}
+		if record[0] != threshold {
+			memguard.WipeBytes(record)
+			return nil, fmt.Errorf("share #%d threshold doesn't match", i+1)
+		}
+
+		// Ignore the same share provided several times
+		part := record[1:]
+		x := part[len(part)-1]
+		if previous, ok := seen[x]; ok {
+			same := security.SecureCompare(previous, part)
+			memguard.WipeBytes(record)
+			if !same {
+				return nil, fmt.Errorf("share #%d conflicts with another share", i+1)
+			}
+			continue
+		}
+		seen[x] = part
+		parts = append(parts, part)
+	}
+
+	// Check threshold
+	if len(parts) < int(threshold) {
+		return nil, fmt.Errorf("%d distinct shares provided, %d are required to unseal the container", len(parts), threshold)
+	}
+
+	// Recover payload key
+	payloadKey, err := shamir.Combine(parts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to combine shares: %w", err)
+	}
+	defer memguard.WipeBytes(payloadKey)
+
+	// Derive the payload key bound to the headers
+	aead, err := payloadAEAD(payloadKey, headerHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt payload
+	if len(container.Raw) < nonceSize+tagSize {
+		return nil, errors.New("invalid container payload size")
+	}
+	content, err := aead.Open(nil, container.Raw[:nonceSize], container.Raw[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt container payload, invalid shares or corrupted container")
+	}
+	defer memguard.WipeBytes(content)
+
+	// Unmarshal inner container
+	out := &containerv1.Container{}
+	if err := proto.Unmarshal(content, out); err != nil {
+		return nil, fmt.Errorf("unable to unpack inner content: %w", err)
+	}
+
+	// No error
+	return out, nil
+}
+
+// IsRecipient returns true if a payload key share can be recovered from the
+// given sealed container headers with the given identity. The payload is
+// neither decrypted nor verified.
+func (a *adapter) IsRecipient(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) bool {
+	record, err := recoverShare(headers, identity, preSharedKey)
+	if err != nil {
+		return false
+	}
+	memguard.WipeBytes(record)
+
+	// No error
+	return true
+}
+
+// -----------------------------------------------------------------------------
+
+func (a *adapter) unseal(container *containerv1.Container, identity, preSharedKey *memguard.LockedBuffer) (*containerv1.Container, error) {
+	// Check parameters
+	if types.IsNil(container) {
+		return nil, fmt.Errorf("unable to process nil container")
+	}
+
+	// Recover payload key share
+	record, err := recoverShare(container.Headers, identity, preSharedKey)
+	if err != nil {
+		return nil, err
+	}
+	threshold := record[0]
+	memguard.WipeBytes(record)
+
+	return nil, fmt.Errorf("unable to unseal container with a single identity, %d recipient shares are required", threshold)
+}
+
+func checkHeaders(headers *containerv1.Header) error {
+	// Check parameters
+	if types.IsNil(headers) {
+		return fmt.Errorf("unable to process nil container headers")
+	}
+
+	// Check headers
+	if headers.ContentType != containerSealedContentType || headers.SealVersion != SealVersion {
+		return fmt.Errorf("unable to unseal container")
+	}
+
+	// Check ephemeral container public encryption key
+	if len(headers.EncryptionPublicKey) != publicKeySize {
+		return fmt.Errorf("invalid container public size")
+	}
+
+	// No error
+	return nil
+}
+
+// recoverShare decrypts the payload key share record of the given identity.
+func recoverShare(headers *containerv1.Header, identity, preSharedKey *memguard.LockedBuffer) ([]byte, error) {
+	// Check parameters
+	if err := checkHeaders(headers); err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, fmt.Errorf("unable to process without container key")
+	}
+
+	// Decode public key
+	var publicKey ecdsa.PublicKey
+	publicKey.Curve = encryptionCurve
+	publicKey.X, publicKey.Y = elliptic.UnmarshalCompressed(encryptionCurve, headers.EncryptionPublicKey)
+	if publicKey.X == nil {
+		return nil, errors.New("invalid container encryption public key")
+	}
+
+	// Decode private key
+	pk, err := privateKey(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute preshared key
+	var psk *[preSharedKeySize]byte
+	if preSharedKey != nil {
+		psk = pskStretch(preSharedKey.Bytes(), headers.EncryptionPublicKey)
+	}
+
+	// Precompute identifier
+	derivedKey, err := deriveSharedKeyFromRecipient(&publicKey, pk, psk)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute key agreement: %w", err)
+	}
+	defer memguard.WipeBytes(derivedKey[:])
+
+	// Try recipients
+	record, err := tryRecipientKeys(derivedKey, headers.Recipients, psk)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred during recipient key tests: %w", err)
+	}
+	if record[0] < minThreshold {
+		memguard.WipeBytes(record)
+		return nil, errors.New("invalid share threshold")
+	}
+
+	// No error
+	return record, nil
+}
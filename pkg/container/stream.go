@@ -0,0 +1,314 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/awnumar/memguard"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/container/identity/key"
+	v4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+const (
+	containerHeadersField = protowire.Number(1)
+	containerRawField     = protowire.Number(2)
+	maxHeadersSize        = 16 * 1024 * 1024 // 16MB
+)
+
+// SealStream seals the container read from the reader with the streaming seal
+// strategy and writes the sealed container to the writer. The container
+// payload is never loaded in memory.
+//
+// The streaming seal strategy only accepts v2 container public keys.
+func SealStream(rand io.Reader, w io.Writer, r io.Reader, opts ...Option) error {
+	// Check parameters
+	if types.IsNil(w) {
+		return fmt.Errorf("unable to process nil writer")
+	}
+	if types.IsNil(r) {
+		return fmt.Errorf("unable to process nil reader")
+	}
+
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Validate peer public keys
+	peers := make([]string, 0, len(dopts.peersPublicKey))
+	for _, pub := range dopts.peersPublicKey {
+		switch {
+		case strings.HasPrefix(pub, "v2.sk."):
+			peers = append(peers, pub)
+		case strings.HasPrefix(pub, "v2.ipk."):
+			// Convert to sealing public key
+			identityPublicKey, err := key.FromString(pub)
+			if err != nil {
+				return fmt.Errorf("unable to convert v2 identity public key %q: %w", pub, err)
+			}
+			peers = append(peers, identityPublicKey.SealingKey())
+		default:
+			return fmt.Errorf("invalid key %q, streaming seal requires v2 keys", pub)
+		}
+	}
+
+	// Read input container
+	br := bufio.NewReader(r)
+	if err := readPreamble(br); err != nil {
+		return err
+	}
+	headers, rawSize, err := readStreamHeaders(br)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+	if IsSealed(&containerv1.Container{Headers: headers}) {
+		return errors.New("the container is already sealed")
+	}
+
+	// Rebuild the serialized inner container prefix
+	headersRaw, err := proto.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("unable to encode container headers: %w", err)
+	}
+	prefix := protowire.AppendTag(nil, containerHeadersField, protowire.BytesType)
+	prefix = protowire.AppendBytes(prefix, headersRaw)
+	prefix = protowire.AppendTag(prefix, containerRawField, protowire.BytesType)
+	prefix = protowire.AppendVarint(prefix, rawSize)
+	size := uint64(len(prefix)) + rawSize
+
+	// Prepare sealing
+	ss := v4.New()
+	sealedHeaders, sealPayload, err := ss.SealStream(rand, dopts.psk, peers...)
+	if err != nil {
+		return fmt.Errorf("unable to prepare container sealing: %w", err)
+	}
+
+	// Write sealed container
+	bw := bufio.NewWriter(w)
+	if err := writeStreamHeaders(bw, sealedHeaders, ss.SealedSize(size)); err != nil {
+		return err
+	}
+	if err := sealPayload(bw, io.MultiReader(bytes.NewReader(prefix), io.LimitReader(br, int64(rawSize))), size); err != nil {
+		return fmt.Errorf("unable to seal container payload: %w", err)
+	}
+
+	// Check trailing content
+	if err := expectEOF(br); err != nil {
+		return err
+	}
+
+	// Flush the writer
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write sealed container: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// UnsealStream unseals the sealed container read from the reader with the
+// given identity and writes the unsealed container to the writer.
+//
+// Containers sealed with the streaming seal strategy are processed by chunks,
+// other containers are unsealed in memory. Streamed chunks are written as soon
+// as they are authenticated, a truncated or tampered sealed container is only
+// detected once its chunk is read, so the output written before an error holds
+// a partial plaintext and must be discarded.
+func UnsealStream(w io.Writer, r io.Reader, identity *memguard.LockedBuffer, opts ...Option) error {
+	// Check parameters
+	if types.IsNil(w) {
+		return fmt.Errorf("unable to process nil writer")
+	}
+	if types.IsNil(r) {
+		return fmt.Errorf("unable to process nil reader")
+	}
+	if identity == nil {
+		return fmt.Errorf("unable to process without container key")
+	}
+
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Read input container
+	br := bufio.NewReader(r)
+	if err := readPreamble(br); err != nil {
+		return err
+	}
+	headers, rawSize, err := readStreamHeaders(br)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+	if headers.ContentType != containerSealedContentType {
+		return fmt.Errorf("unable to unseal container")
+	}
+	if err := checkSealVersion(headers, dopts.sealVersion); err != nil {
+		return err
+	}
+
+	// Fallback to in-memory unsealing
+	if headers.SealVersion != v4.SealVersion {
+		raw := make([]byte, rawSize)
+		if _, err := io.ReadFull(br, raw); err != nil {
+			return fmt.Errorf("unable to read container content: %w", err)
+		}
+		if err := expectEOF(br); err != nil {
+			return err
+		}
+
+		out, err := Unseal(&containerv1.Container{Headers: headers, Raw: raw}, identity, opts...)
+		if err != nil {
+			return err
+		}
+
+		return Dump(w, out)
+	}
+
+	// Prepare unsealing
+	unsealPayload, err := v4.New().UnsealStream(headers, identity, dopts.psk)
+	if err != nil {
+		return err
+	}
+
+	// The unsealed payload is the serialized inner container
+	bw := bufio.NewWriter(w)
+	if err := writePreamble(bw); err != nil {
+		return err
+	}
+	if err := unsealPayload(bw, io.LimitReader(br, int64(rawSize)), rawSize); err != nil {
+		return fmt.Errorf("unable to unseal container payload: %w", err)
+	}
+
+	// Check trailing content
+	if err := expectEOF(br); err != nil {
+		return err
+	}
+
+	// Flush the writer
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write unsealed container: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+// readStreamHeaders decodes the container fields preceding the raw content and
+// returns the headers and the raw content size. The reader is positioned at the
+// beginning of the raw content.
+func readStreamHeaders(br *bufio.Reader) (*containerv1.Header, uint64, error) {
+	headers := &containerv1.Header{}
+
+	for {
+		// Read field tag
+		tag, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			// No raw content
+			return headers, 0, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read field tag: %w", err)
+		}
+		num, typ := protowire.DecodeTag(tag)
+		if typ != protowire.BytesType {
+			return nil, 0, fmt.Errorf("unsupported container field %d", num)
+		}
+
+		// Read field length
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read field length: %w", err)
+		}
+
+		switch num {
+		case containerHeadersField:
+			if length > maxHeadersSize {
+				return nil, 0, errors.New("container headers are too large")
+			}
+			raw := make([]byte, length)
+			if _, err := io.ReadFull(br, raw); err != nil {
+				return nil, 0, fmt.Errorf("unable to read container headers: %w", err)
+			}
+			if err := proto.Unmarshal(raw, headers); err != nil {
+				return nil, 0, fmt.Errorf("unable to decode container headers: %w", err)
+			}
+		case containerRawField:
+			return headers, length, nil
+		default:
+			// Skip unknown fields
+			if _, err := io.CopyN(io.Discard, br, int64(length)); err != nil {
+				return nil, 0, fmt.Errorf("unable to skip container field %d: %w", num, err)
+			}
+		}
+	}
+}
+
+func writeStreamHeaders(w io.Writer, headers *containerv1.Header, rawSize uint64) error {
+	// Serialize headers
+	headersRaw, err := proto.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("unable to encode container headers: %w", err)
+	}
+
+	// Container preamble
+	if err := writePreamble(w); err != nil {
+		return err
+	}
+
+	// Headers and raw content prefix
+	out := protowire.AppendTag(nil, containerHeadersField, protowire.BytesType)
+	out = protowire.AppendBytes(out, headersRaw)
+	out = protowire.AppendTag(out, containerRawField, protowire.BytesType)
+	out = protowire.AppendVarint(out, rawSize)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("unable to write container headers: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+func expectEOF(br *bufio.Reader) error {
+	if _, err := br.ReadByte(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected content after container raw content")
+	}
+
+	// No error
+	return nil
+}
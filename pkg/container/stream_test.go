@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+)
+
+func TestSealStream_UnsealStream(t *testing.T) {
+	pubKey, privKey, err := v4.New().GenerateKey()
+	require.NoError(t, err)
+	identity := memguard.NewBufferFromBytes([]byte(privKey))
+
+	raw := make([]byte, 200*1024+17)
+	_, err = rand.Read(raw)
+	require.NoError(t, err)
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentEncoding: "gzip",
+			ContentType:     "application/vnd.harp.v1.Bundle",
+		},
+		Raw: raw,
+	}
+	var in bytes.Buffer
+	require.NoError(t, Dump(&in, input))
+
+	// Seal
+	var sealed bytes.Buffer
+	require.NoError(t, SealStream(rand.Reader, &sealed, bytes.NewReader(in.Bytes()), WithPeerPublicKeys([]string{pubKey})))
+
+	sealedContainer, err := Load(bytes.NewReader(sealed.Bytes()))
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealedContainer))
+	assert.Equal(t, uint32(v4.SealVersion), sealedContainer.Headers.SealVersion)
+
+	// Streaming unseal
+	var unsealed bytes.Buffer
+	require.NoError(t, UnsealStream(&unsealed, bytes.NewReader(sealed.Bytes()), identity))
+	out, err := Load(bytes.NewReader(unsealed.Bytes()))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(input, out))
+
+	// In-memory unseal
+	out, err = Unseal(sealedContainer, identity)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(input, out))
+
+	// Errors
+	assert.Error(t, SealStream(rand.Reader, &bytes.Buffer{}, bytes.NewReader(in.Bytes())))
+	assert.Error(t, SealStream(rand.Reader, &bytes.Buffer{}, bytes.NewReader(in.Bytes()), WithPeerPublicKeys([]string{"v1.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E"})))
+	assert.Error(t, SealStream(rand.Reader, &bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), WithPeerPublicKeys([]string{pubKey})))
+	assert.Error(t, SealStream(rand.Reader, &bytes.Buffer{}, bytes.NewReader(in.Bytes()[:in.Len()-1]), WithPeerPublicKeys([]string{pubKey})))
+	assert.Error(t, SealStream(rand.Reader, &bytes.Buffer{}, bytes.NewReader(append(in.Bytes(), 0x00)), WithPeerPublicKeys([]string{pubKey})))
+	assert.Error(t, UnsealStream(&bytes.Buffer{}, bytes.NewReader(in.Bytes()), identity))
+	assert.Error(t, UnsealStream(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()[:sealed.Len()-1]), identity))
+	assert.Error(t, UnsealStream(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), nil))
+}
+
+func TestUnsealStream_InMemoryFallback(t *testing.T) {
+	pubKey, privKey, err := v1.New().GenerateKey()
+	require.NoError(t, err)
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte{0x01, 0x02, 0x03},
+	}
+	sealedContainer, err := Seal(rand.Reader, input, WithPeerPublicKeys([]string{pubKey}))
+	require.NoError(t, err)
+	var sealed bytes.Buffer
+	require.NoError(t, Dump(&sealed, sealedContainer))
+
+	var unsealed bytes.Buffer
+	require.NoError(t, UnsealStream(&unsealed, bytes.NewReader(sealed.Bytes()), memguard.NewBufferFromBytes([]byte(privKey))))
+	out, err := Load(bytes.NewReader(unsealed.Bytes()))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(input, out))
+}
+
+func TestUnsealStream_LegacySealedContainer(t *testing.T) {
+	// Legacy sealed containers don't declare their seal version
+	sealed, err := os.ReadFile("../../test/fixtures/bundles/complete.v1.sealed")
+	require.NoError(t, err)
+
+	var unsealed bytes.Buffer
+	require.NoError(t, UnsealStream(&unsealed, bytes.NewReader(sealed), memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg"))))
+	out, err := Load(bytes.NewReader(unsealed.Bytes()))
+	require.NoError(t, err)
+	assert.False(t, IsSealed(out))
+}
@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/awnumar/memguard"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v5 "github.com/zntrio/harp/v2/pkg/container/seal/v5"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+)
+
+// ExportShare recovers the payload key share of the given identity from a
+// threshold sealed container. The encoded share is bound to the container and
+// can be combined with other recipient shares using UnsealWithShares.
+func ExportShare(container *containerv1.Container, identity *memguard.LockedBuffer, opts ...Option) (*memguard.LockedBuffer, error) {
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Check parameters
+	if err := checkThresholdContainer(container); err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, errors.New("unable to process without container key")
+	}
+	if err := checkSealVersion(container.Headers, dopts.sealVersion); err != nil {
+		return nil, err
+	}
+
+	// Delegate to strategy
+	return v5.New().ExportShare(container.Headers, identity, dopts.psk)
+}
+
+// UnsealWithShares unseals a threshold sealed container by combining the
+// payload key shares recovered with the given identities and the given
+// exported shares.
+func UnsealWithShares(container *containerv1.Container, identities, shares []*memguard.LockedBuffer, opts ...Option) (*containerv1.Container, error) {
+	// Compute default option values
+	dopts := &Options{
+		psk: nil,
+	}
+	for _, o := range opts {
+		o(dopts)
+	}
+
+	// Check parameters
+	if err := checkThresholdContainer(container); err != nil {
+		return nil, err
+	}
+	if err := checkSealVersion(container.Headers, dopts.sealVersion); err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 && len(shares) == 0 {
+		return nil, errors.New("unable to unseal without container keys or shares")
+	}
+
+	// Recover identity shares
+	recovered := make([]*memguard.LockedBuffer, 0, len(identities))
+	defer func() {
+		for _, share := range recovered {
+			share.Destroy()
+		}
+	}()
+	for i, identity := range identities {
+		share, err := ExportShare(container, identity, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to recover share with container key #%d: %w", i+1, err)
+		}
+		recovered = append(recovered, share)
+	}
+
+	// Delegate to strategy
+	return v5.New().UnsealWithShares(container, append(recovered, shares...)...)
+}
+
+// -----------------------------------------------------------------------------
+
+func checkThresholdContainer(container *containerv1.Container) error {
+	// Check parameters
+	if types.IsNil(container) {
+		return errors.New("unable to process nil container")
+	}
+	if !IsSealed(container) {
+		return errors.New("the container is not sealed")
+	}
+	if container.Headers.SealVersion != v5.SealVersion {
+		return fmt.Errorf("the container is not threshold sealed (seal version %d)", container.Headers.SealVersion)
+	}
+
+	// No error
+	return nil
+}
@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	v1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	v2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	v5 "github.com/zntrio/harp/v2/pkg/container/seal/v5"
+)
+
+func TestThreshold_Seal_Unseal(t *testing.T) {
+	pubKeys := []string{}
+	privKeys := []*memguard.LockedBuffer{}
+	for i := 0; i < 3; i++ {
+		pub, priv, err := v2.New().GenerateKey()
+		require.NoError(t, err)
+		pubKeys = append(pubKeys, pub)
+		privKeys = append(privKeys, memguard.NewBufferFromBytes([]byte(priv)))
+	}
+	psk := memguard.NewBufferFromBytes([]byte("pre-shared-key"))
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{
+			ContentType: "application/vnd.harp.v1.Bundle",
+		},
+		Raw: []byte("threshold sealed content"),
+	}
+
+	sealed, err := Seal(rand.Reader, input, WithPeerPublicKeys(pubKeys), WithPreSharedKey(psk), WithThreshold(2))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(v5.SealVersion), SealVersion(sealed))
+
+	// A single identity is not enough
+	_, err = Unseal(sealed, privKeys[0], WithPreSharedKey(psk))
+	assert.Error(t, err)
+	_, err = UnsealWithShares(sealed, privKeys[:1], nil, WithPreSharedKey(psk))
+	assert.Error(t, err)
+	ok, err := IsRecipient(sealed, privKeys[0], WithPreSharedKey(psk))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Several identities
+	out, err := UnsealWithShares(sealed, privKeys[1:], nil, WithPreSharedKey(psk))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(input, out))
+
+	// Identity and exported share
+	share, err := ExportShare(sealed, privKeys[2], WithPreSharedKey(psk))
+	require.NoError(t, err)
+	out, err = UnsealWithShares(sealed, privKeys[:1], []*memguard.LockedBuffer{share}, WithPreSharedKey(psk))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(input, out))
+
+	// Missing pre-shared key
+	_, err = UnsealWithShares(sealed, privKeys[1:], nil)
+	assert.Error(t, err)
+}
+
+func TestThreshold_Errors(t *testing.T) {
+	v1PubKey, _, err := v1.New().GenerateKey()
+	require.NoError(t, err)
+	v2PubKey, _, err := v2.New().GenerateKey()
+	require.NoError(t, err)
+	identity := memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg"))
+
+	input := &containerv1.Container{
+		Headers: &containerv1.Header{},
+		Raw:     []byte("threshold sealed content"),
+	}
+
+	// Threshold requires v2 keys
+	_, err = Seal(rand.Reader, input, WithPeerPublicKeys([]string{v1PubKey, v1PubKey}), WithThreshold(2))
+	assert.Error(t, err)
+	// Threshold can't exceed recipients
+	_, err = Seal(rand.Reader, input, WithPeerPublicKeys([]string{v2PubKey}), WithThreshold(2))
+	assert.Error(t, err)
+
+	// Not a threshold sealed container
+	f, err := os.Open("../../test/fixtures/bundles/complete.v1.sealed")
+	require.NoError(t, err)
+	defer f.Close()
+	sealed, err := Load(f)
+	require.NoError(t, err)
+
+	_, err = ExportShare(sealed, identity)
+	assert.Error(t, err)
+	_, err = UnsealWithShares(sealed, []*memguard.LockedBuffer{identity}, nil)
+	assert.Error(t, err)
+	_, err = ExportShare(input, identity)
+	assert.Error(t, err)
+	_, err = ExportShare(nil, identity)
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	csov1 "github.com/zntrio/harp/v2/api/gen/go/cso/v1"
+)
+
+const (
+	// ValidatePath is the HTTP gateway route of the Validate method.
+	ValidatePath = "/v1/cso/validate"
+
+	maxRequestSize = 64 * 1024 // 64KB
+)
+
+// Gateway returns a JSON/HTTP handler exposing the given validator service.
+//
+// The Validate method is exposed using `GET /v1/cso/validate?path=...` or
+// `POST /v1/cso/validate` with a JSON encoded ValidateRequest body.
+func Gateway(svc csov1.ValidatorServiceServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, func(w http.ResponseWriter, r *http.Request) {
+		req := &csov1.ValidateRequest{}
+
+		switch r.Method {
+		case http.MethodGet:
+			req.Path = r.URL.Query().Get("path")
+		case http.MethodPost:
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+			if err != nil {
+				writeError(w, status.Error(codes.InvalidArgument, "unable to read request body"))
+				return
+			}
+			if err := protojson.Unmarshal(body, req); err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "unable to decode request: %v", err))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, status.Error(codes.Unimplemented, "method not allowed"))
+			return
+		}
+
+		// Delegate to service
+		res, err := svc.Validate(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeMessage(w, http.StatusOK, res)
+	})
+
+	return mux
+}
+
+// Mux returns an HTTP handler dispatching gRPC requests to the gRPC server
+// and all others to the given HTTP handler.
+func Mux(grpcServer *grpc.Server, httpHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+}
+
+// -----------------------------------------------------------------------------
+
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeMessage(w, httpStatusFromCode(st.Code()), st.Proto())
+}
+
+func writeMessage(w http.ResponseWriter, statusCode int, msg proto.Message) {
+	out, err := protojson.Marshal(msg)
+	if err != nil {
+		http.Error(w, "unable to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	//nolint:errcheck // Nothing to do on client disconnection
+	w.Write(out)
+}
+
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.Unimplemented:
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,63 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csov1 "github.com/zntrio/harp/v2/api/gen/go/cso/v1"
+	cso "github.com/zntrio/harp/v2/pkg/cso/v1"
+)
+
+// Server implements the ValidatorService gRPC contract.
+type Server struct {
+	csov1.UnimplementedValidatorServiceServer
+}
+
+var _ csov1.ValidatorServiceServer = (*Server)(nil)
+
+// New returns a validator service instance.
+func New() *Server {
+	return &Server{}
+}
+
+// Validate the given path according to CSO specification and returns the
+// decomposed secret path.
+func (s *Server) Validate(_ context.Context, req *csov1.ValidateRequest) (*csov1.ValidateResponse, error) {
+	// Check arguments
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+	if req.Path == "" {
+		return nil, status.Error(codes.InvalidArgument, "path must not be blank")
+	}
+
+	// Validate and pack secret path
+	secret, err := cso.Pack(req.Path)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "path %q is not CSO compliant: %v", req.Path, err)
+	}
+
+	// No error
+	return &csov1.ValidateResponse{
+		Secret: secret,
+	}, nil
+}
@@ -0,0 +1,135 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	csov1 "github.com/zntrio/harp/v2/api/gen/go/cso/v1"
+)
+
+const validPath = "app/production/customer1/ece/v1.0.0/adminconsole/database/usage_credentials"
+
+func TestServer_Validate(t *testing.T) {
+	s := New()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := s.Validate(context.Background(), nil)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("blank", func(t *testing.T) {
+		_, err := s.Validate(context.Background(), &csov1.ValidateRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := s.Validate(context.Background(), &csov1.ValidateRequest{Path: "foo/bar"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		res, err := s.Validate(context.Background(), &csov1.ValidateRequest{Path: validPath})
+		require.NoError(t, err)
+		require.NotNil(t, res.Secret)
+		assert.Equal(t, csov1.RingLevel_RING_LEVEL_APPLICATION, res.Secret.RingLevel)
+	})
+}
+
+func TestGateway(t *testing.T) {
+	srv := httptest.NewServer(Gateway(New()))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "get valid",
+			method:     http.MethodGet,
+			url:        ValidatePath + "?path=" + validPath,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get invalid",
+			method:     http.MethodGet,
+			url:        ValidatePath + "?path=foo",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "post valid",
+			method:     http.MethodPost,
+			url:        ValidatePath,
+			body:       `{"path":"` + validPath + `"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "post malformed",
+			method:     http.MethodPost,
+			url:        ValidatePath,
+			body:       `{"path":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodDelete,
+			url:        ValidatePath,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			url:        "/v1/cso/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, srv.URL+tt.url, strings.NewReader(tt.body))
+			require.NoError(t, err)
+
+			res, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus == http.StatusOK {
+				out := &csov1.ValidateResponse{}
+				raw, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				require.NoError(t, protojson.Unmarshal(raw, out))
+				assert.Equal(t, csov1.RingLevel_RING_LEVEL_APPLICATION, out.Secret.GetRingLevel())
+				assert.Equal(t, "database/usage_credentials", out.Secret.GetApplication().GetKey())
+			}
+		})
+	}
+}
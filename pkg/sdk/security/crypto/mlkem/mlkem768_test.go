@@ -0,0 +1,173 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mlkem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestRoundTrip(t *testing.T) {
+	dk, err := GenerateKey768(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, Ke, err := encapsulate(t, dk.EncapsulationKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Kd, err := dk.Decapsulate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Ke, Kd) {
+		t.Fail()
+	}
+
+	dk1, err := NewDecapsulationKey768(dk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dk.EncapsulationKey().Bytes(), dk1.EncapsulationKey().Bytes()) {
+		t.Fail()
+	}
+
+	dk2, err := GenerateKey768(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(dk.EncapsulationKey().Bytes(), dk2.EncapsulationKey().Bytes()) {
+		t.Fail()
+	}
+	Kd2, err := dk2.Decapsulate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(Ke, Kd2) {
+		t.Fail()
+	}
+}
+
+func TestBadLengths(t *testing.T) {
+	dk, err := GenerateKey768(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek := dk.EncapsulationKey().Bytes()
+	_, c, err := dk.EncapsulationKey().Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(ek)-1; i++ {
+		if _, err := NewEncapsulationKey768(ek[:i]); err == nil {
+			t.Errorf("expected error for ek length %d", i)
+		}
+	}
+	if _, err := NewEncapsulationKey768(append(ek, 0)); err == nil {
+		t.Errorf("expected error for ek length %d", len(ek)+1)
+	}
+
+	for i := 0; i < len(c)-1; i++ {
+		if _, err := dk.Decapsulate(c[:i]); err == nil {
+			t.Errorf("expected error for c length %d", i)
+		}
+	}
+	if _, err := dk.Decapsulate(append(c, 0)); err == nil {
+		t.Errorf("expected error for c length %d", len(c)+1)
+	}
+
+	if _, err := NewDecapsulationKey768(dk.Bytes()[:SeedSize-1]); err == nil {
+		t.Errorf("expected error for seed length %d", SeedSize-1)
+	}
+}
+
+// TestAccumulated accumulates 10k (or 100) random vectors and checks the
+// hash of the result, to avoid checking in 150MB of test vectors.
+//
+// Vectors from https://github.com/C2SP/CCTV/tree/main/ML-KEM
+func TestAccumulated(t *testing.T) {
+	n := 10000
+	expected := "8a518cc63da366322a8e7a818c7a0d63483cb3528d34a4cf42f35d5ad73f22fc"
+	if testing.Short() {
+		n = 100
+		expected = "1114b1b6699ed191734fa339376afa7e285c9e6acf6ff0177d346696ce564415"
+	}
+
+	s := sha3.NewShake128()
+	o := sha3.NewShake128()
+	seed := make([]byte, SeedSize)
+	var msg [messageSize]byte
+	ct1 := make([]byte, CiphertextSize768)
+
+	for i := 0; i < n; i++ {
+		_, _ = s.Read(seed)
+		dk, err := NewDecapsulationKey768(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ek, err := NewEncapsulationKey768(dk.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = o.Write(ek.Bytes())
+
+		_, _ = s.Read(msg[:])
+		k, ct := kemEncaps(ek, &msg)
+		_, _ = o.Write(ct)
+		_, _ = o.Write(k)
+
+		kk, err := dk.Decapsulate(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(kk, k) {
+			t.Errorf("k: got %x, expected %x", kk, k)
+		}
+
+		_, _ = s.Read(ct1)
+		k1, err := dk.Decapsulate(ct1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = o.Write(k1)
+	}
+
+	out := make([]byte, 32)
+	_, _ = o.Read(out)
+	if got := hex.EncodeToString(out); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+func encapsulate(t *testing.T, encapsulationKey []byte) (c, K []byte, err error) {
+	t.Helper()
+
+	ek, err := NewEncapsulationKey768(encapsulationKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	K, c, err = ek.Encapsulate(rand.Reader)
+	return c, K, err
+}
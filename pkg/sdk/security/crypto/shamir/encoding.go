@@ -0,0 +1,303 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/bech32"
+)
+
+// Encoded shares are split in Bech32 strings. Each string holds the share
+// metadata followed by a share segment:
+//
+//	version(1) || set(4) || threshold(1) || parts(1) || index(1) ||
+//	segment(1) || segments(1) || data(<=38)
+//
+// Bech32 strings are protected by a BCH checksum, and a truncated SHA256
+// checksum of the secret is split with it to detect invalid combinations.
+const (
+	// ShareHRP is the human readable part of encoded share strings.
+	ShareHRP = "harpks"
+	// MaxEncodedSecretSize is the maximum secret size supported by the share
+	// encoding.
+	MaxEncodedSecretSize = maxSegments*segmentDataSize - checksumSize
+
+	encodingVersion  = 0x01
+	setIDSize        = 4
+	metadataSize     = 1 + setIDSize + 5
+	segmentDataSize  = 38
+	maxSegments      = 255
+	checksumSize     = 4
+	minEncodedLength = metadataSize + 1
+)
+
+// ShareInfo describes an encoded share string.
+type ShareInfo struct {
+	SetID     string `json:"set_id"`
+	Threshold int    `json:"threshold"`
+	Parts     int    `json:"parts"`
+	Index     int    `json:"index"`
+	Segment   int    `json:"segment"`
+	Segments  int    `json:"segments"`
+}
+
+type segment struct {
+	ShareInfo
+	data []byte
+}
+
+// SplitEncoded splits the given secret in parts shares, threshold of them being
+// required to reconstruct the secret. Each share is encoded as a list of
+// Bech32 strings.
+func SplitEncoded(rand io.Reader, secret []byte, parts, threshold int) ([][]string, error) {
+	// Check parameters
+	if rand == nil {
+		return nil, errors.New("unable to split secret with a nil random source")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("unable to split an empty secret")
+	}
+	if len(secret) > MaxEncodedSecretSize {
+		return nil, fmt.Errorf("secret size can't exceed %d bytes", MaxEncodedSecretSize)
+	}
+
+	// Append the secret checksum
+	checksum := sha256.Sum256(secret)
+	payload := make([]byte, 0, len(secret)+checksumSize)
+	payload = append(payload, secret...)
+	payload = append(payload, checksum[:checksumSize]...)
+	defer memguard.WipeBytes(payload)
+
+	// Split the secret
+	shares, err := Split(rand, payload, parts, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, share := range shares {
+			memguard.WipeBytes(share)
+		}
+	}()
+
+	// Generate a share set identifier
+	setID := make([]byte, setIDSize)
+	if _, err := io.ReadFull(rand, setID); err != nil {
+		return nil, fmt.Errorf("unable to generate share set identifier: %w", err)
+	}
+
+	// Encode shares
+	segments := (len(payload) + segmentDataSize - 1) / segmentDataSize
+	res := make([][]string, len(shares))
+	for i, share := range shares {
+		y, x := share[:len(payload)], share[len(payload)]
+
+		for s := 0; s < segments; s++ {
+			end := (s + 1) * segmentDataSize
+			if end > len(y) {
+				end = len(y)
+			}
+
+			raw := make([]byte, 0, metadataSize+segmentDataSize)
+			raw = append(raw, encodingVersion)
+			raw = append(raw, setID...)
+			raw = append(raw, byte(threshold), byte(parts), x, byte(s+1), byte(segments))
+			raw = append(raw, y[s*segmentDataSize:end]...)
+
+			encoded, err := bech32.Encode(ShareHRP, raw)
+			memguard.WipeBytes(raw)
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode share %d: %w", x, err)
+			}
+			res[i] = append(res[i], encoded)
+		}
+	}
+
+	// No error
+	return res, nil
+}
+
+// CombineEncoded reconstructs the secret from the given share strings. Strings
+// can be given in any order, at least threshold complete shares are required.
+func CombineEncoded(encoded []string) ([]byte, error) {
+	// Check parameters
+	if len(encoded) == 0 {
+		return nil, errors.New("unable to combine without shares")
+	}
+
+	// Decode all share segments
+	var ref *ShareInfo
+	segmentsByIndex := map[int]map[int][]byte{}
+	defer func() {
+		for _, segs := range segmentsByIndex {
+			for _, data := range segs {
+				memguard.WipeBytes(data)
+			}
+		}
+	}()
+	for _, s := range encoded {
+		seg, err := decodeSegment(s)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check share set consistency
+		if ref == nil {
+			ref = &seg.ShareInfo
+		}
+		if seg.SetID != ref.SetID {
+			return nil, fmt.Errorf("share set mismatch, found %q and %q", ref.SetID, seg.SetID)
+		}
+		if seg.Threshold != ref.Threshold || seg.Parts != ref.Parts || seg.Segments != ref.Segments {
+			return nil, errors.New("inconsistent share metadata in the share set")
+		}
+
+		// Deduplicate segments
+		segs, ok := segmentsByIndex[seg.Index]
+		if !ok {
+			segs = map[int][]byte{}
+			segmentsByIndex[seg.Index] = segs
+		}
+		if prev, ok := segs[seg.Segment]; ok {
+			if !security.SecureCompare(prev, seg.data) {
+				return nil, fmt.Errorf("conflicting segment %d for share %d", seg.Segment, seg.Index)
+			}
+			memguard.WipeBytes(seg.data)
+			continue
+		}
+		segs[seg.Segment] = seg.data
+	}
+
+	// Assemble complete shares
+	indexes := make([]int, 0, len(segmentsByIndex))
+	for index := range segmentsByIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	shares := [][]byte{}
+	defer func() {
+		for _, share := range shares {
+			memguard.WipeBytes(share)
+		}
+	}()
+	for _, index := range indexes {
+		segs := segmentsByIndex[index]
+		if len(segs) != ref.Segments {
+			return nil, fmt.Errorf("share %d is incomplete, %d of %d segments found", index, len(segs), ref.Segments)
+		}
+
+		var share bytes.Buffer
+		for s := 1; s <= ref.Segments; s++ {
+			share.Write(segs[s])
+		}
+		share.WriteByte(byte(index))
+		shares = append(shares, share.Bytes())
+	}
+	if len(shares) < ref.Threshold {
+		return nil, fmt.Errorf("not enough shares to reconstruct the secret, %d of %d found", len(shares), ref.Threshold)
+	}
+
+	// Reconstruct the secret
+	payload, err := Combine(shares)
+	if err != nil {
+		return nil, fmt.Errorf("unable to combine shares: %w", err)
+	}
+	if len(payload) < checksumSize {
+		memguard.WipeBytes(payload)
+		return nil, errors.New("reconstructed secret is too short")
+	}
+
+	// Check the secret checksum
+	secret, expected := payload[:len(payload)-checksumSize], payload[len(payload)-checksumSize:]
+	checksum := sha256.Sum256(secret)
+	if !security.SecureCompare(checksum[:checksumSize], expected) {
+		memguard.WipeBytes(payload)
+		return nil, errors.New("invalid secret checksum, shares are corrupted or belong to different secrets")
+	}
+
+	// No error
+	return secret, nil
+}
+
+// DecodeShareInfo returns the metadata of the given share string.
+func DecodeShareInfo(encoded string) (*ShareInfo, error) {
+	seg, err := decodeSegment(encoded)
+	if err != nil {
+		return nil, err
+	}
+	memguard.WipeBytes(seg.data)
+
+	// No error
+	return &seg.ShareInfo, nil
+}
+
+// -----------------------------------------------------------------------------
+
+func decodeSegment(encoded string) (*segment, error) {
+	// Decode Bech32 string
+	hrp, raw, err := bech32.Decode(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode share: %w", err)
+	}
+	if !strings.EqualFold(hrp, ShareHRP) {
+		return nil, fmt.Errorf("invalid share prefix %q", hrp)
+	}
+	if len(raw) < minEncodedLength {
+		return nil, errors.New("share is too short")
+	}
+	if raw[0] != encodingVersion {
+		return nil, fmt.Errorf("unsupported share version %d", raw[0])
+	}
+
+	// Decode metadata
+	meta := raw[1+setIDSize:]
+	seg := &segment{
+		ShareInfo: ShareInfo{
+			SetID:     fmt.Sprintf("%x", raw[1:1+setIDSize]),
+			Threshold: int(meta[0]),
+			Parts:     int(meta[1]),
+			Index:     int(meta[2]),
+			Segment:   int(meta[3]),
+			Segments:  int(meta[4]),
+		},
+		data: raw[metadataSize:],
+	}
+
+	// Check metadata
+	switch {
+	case seg.Threshold < 2 || seg.Parts < seg.Threshold:
+		return nil, errors.New("invalid share threshold")
+	case seg.Index < 1 || seg.Index > seg.Parts:
+		return nil, errors.New("invalid share index")
+	case seg.Segment < 1 || seg.Segment > seg.Segments:
+		return nil, errors.New("invalid share segment")
+	}
+
+	// No error
+	return seg, nil
+}
@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/bech32"
+)
+
+func TestSplitEncoded_CombineEncoded(t *testing.T) {
+	for name, secret := range map[string][]byte{
+		"master key":    []byte("cnTAvNK6nyb6FWYNEx3U-5HrSmiGkvnXqwDdAStkr6U"),
+		"single byte":   {0x42},
+		"multi segment": bytes.Repeat([]byte("harp"), 100),
+		"max size":      bytes.Repeat([]byte{0xff}, MaxEncodedSecretSize),
+	} {
+		t.Run(name, func(t *testing.T) {
+			shares, err := SplitEncoded(rand.Reader, secret, 5, 3)
+			require.NoError(t, err)
+			require.Len(t, shares, 5)
+
+			// Check metadata
+			for i, share := range shares {
+				for j, s := range share {
+					assert.True(t, strings.HasPrefix(s, ShareHRP+"1"))
+
+					info, err := DecodeShareInfo(s)
+					require.NoError(t, err)
+					assert.Equal(t, 3, info.Threshold)
+					assert.Equal(t, 5, info.Parts)
+					assert.Equal(t, i+1, info.Index)
+					assert.Equal(t, j+1, info.Segment)
+					assert.Equal(t, len(share), info.Segments)
+				}
+			}
+
+			// Threshold shares, in any order
+			in := append(append(append([]string{}, shares[4]...), shares[0]...), shares[2]...)
+			in[0], in[len(in)-1] = in[len(in)-1], in[0]
+			out, err := CombineEncoded(in)
+			require.NoError(t, err)
+			assert.Equal(t, secret, out)
+
+			// Duplicated strings are ignored
+			out, err = CombineEncoded(append(in, shares[0]...))
+			require.NoError(t, err)
+			assert.Equal(t, secret, out)
+
+			// Uppercase strings
+			upper := []string{}
+			for _, s := range append(append(append([]string{}, shares[1]...), shares[3]...), shares[4]...) {
+				upper = append(upper, strings.ToUpper(s))
+			}
+			out, err = CombineEncoded(upper)
+			require.NoError(t, err)
+			assert.Equal(t, secret, out)
+
+			// Less than threshold shares
+			_, err = CombineEncoded(append(append([]string{}, shares[1]...), shares[3]...))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSplitEncoded_Errors(t *testing.T) {
+	_, err := SplitEncoded(nil, []byte("secret"), 3, 2)
+	assert.Error(t, err)
+	_, err = SplitEncoded(rand.Reader, nil, 3, 2)
+	assert.Error(t, err)
+	_, err = SplitEncoded(rand.Reader, make([]byte, MaxEncodedSecretSize+1), 3, 2)
+	assert.Error(t, err)
+	_, err = SplitEncoded(rand.Reader, []byte("secret"), 3, 1)
+	assert.Error(t, err)
+}
+
+func TestCombineEncoded_Errors(t *testing.T) {
+	secret := bytes.Repeat([]byte("secret"), 10)
+	shares, err := SplitEncoded(rand.Reader, secret, 3, 2)
+	require.NoError(t, err)
+	require.Len(t, shares[0], 2)
+	others, err := SplitEncoded(rand.Reader, secret, 3, 2)
+	require.NoError(t, err)
+
+	// Flip a character of a share string
+	corrupted := []byte(shares[1][0])
+	if corrupted[10] == 'q' {
+		corrupted[10] = 'p'
+	} else {
+		corrupted[10] = 'q'
+	}
+
+	// Build a share string with a valid encoding and an unsupported version
+	_, raw, err := bech32.Decode(shares[1][0])
+	require.NoError(t, err)
+	raw[0] = 0x02
+	unsupported, err := bech32.Encode(ShareHRP, raw)
+	require.NoError(t, err)
+
+	// Build a share string with a valid encoding and a tampered value
+	_, raw2, err := bech32.Decode(shares[1][0])
+	require.NoError(t, err)
+	raw2[len(raw2)-1] ^= 0x01
+	tampered, err := bech32.Encode(ShareHRP, raw2)
+	require.NoError(t, err)
+
+	// Build a share string with a different prefix
+	otherPrefix, err := bech32.Encode("other", raw)
+	require.NoError(t, err)
+
+	for name, in := range map[string][]string{
+		"nil":                 nil,
+		"invalid string":      {"foo"},
+		"corrupted string":    append([]string{string(corrupted), shares[1][1]}, shares[0]...),
+		"unsupported version": append([]string{unsupported, shares[1][1]}, shares[0]...),
+		"tampered value":      append([]string{tampered, shares[1][1]}, shares[0]...),
+		"other prefix":        append([]string{otherPrefix, shares[1][1]}, shares[0]...),
+		"incomplete share":    append([]string{shares[1][0]}, shares[0]...),
+		"mixed share sets":    append(append([]string{}, shares[0]...), others[1]...),
+		"single share":        shares[0],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := CombineEncoded(in)
+			assert.Error(t, err)
+		})
+	}
+}
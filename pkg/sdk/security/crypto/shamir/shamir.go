@@ -0,0 +1,184 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+//
+// Each byte of the secret is the constant term of a random polynomial of
+// degree threshold-1. A share holds the polynomial evaluations for all secret
+// bytes, followed by the evaluation point: y(len(secret)) || x(1).
+package shamir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+)
+
+const (
+	// MaxParts is the maximum number of shares.
+	MaxParts = 255
+	// ShareOverhead is the share size overhead compared to the secret size.
+	ShareOverhead = 1
+)
+
+// Split the given secret in parts shares, threshold of them being required to
+// reconstruct the secret.
+func Split(rand io.Reader, secret []byte, parts, threshold int) ([][]byte, error) {
+	// Check parameters
+	if rand == nil {
+		return nil, errors.New("unable to split secret with a nil random source")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("unable to split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	if parts < threshold {
+		return nil, errors.New("parts can't be less than threshold")
+	}
+	if parts > MaxParts {
+		return nil, fmt.Errorf("parts can't exceed %d", MaxParts)
+	}
+
+	// Prepare shares, evaluation points are 1..parts
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+ShareOverhead)
+		shares[i][len(secret)] = uint8(i + 1)
+	}
+
+	// Random polynomial coefficients, the constant term is the secret byte
+	coefficients := make([]byte, threshold)
+	defer memguard.WipeBytes(coefficients)
+
+	for idx, b := range secret {
+		coefficients[0] = b
+		if _, err := io.ReadFull(rand, coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("unable to generate polynomial coefficients: %w", err)
+		}
+
+		// Evaluate the polynomial for each share
+		for i := range shares {
+			shares[i][idx] = evaluate(coefficients, shares[i][len(secret)])
+		}
+	}
+
+	// No error
+	return shares, nil
+}
+
+// Combine the given shares to reconstruct the secret. At least threshold
+// shares must be given, otherwise the result is an unrelated value.
+func Combine(shares [][]byte) ([]byte, error) {
+	// Check parameters
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+	if len(shares) > MaxParts {
+		return nil, fmt.Errorf("shares count can't exceed %d", MaxParts)
+	}
+
+	// Check shares
+	shareSize := len(shares[0])
+	if shareSize <= ShareOverhead {
+		return nil, errors.New("shares are too short")
+	}
+	xs := make([]byte, len(shares))
+	seen := map[byte]struct{}{}
+	for i, share := range shares {
+		if len(share) != shareSize {
+			return nil, errors.New("all shares must have the same length")
+		}
+
+		x := share[shareSize-1]
+		if x == 0 {
+			return nil, errors.New("invalid share evaluation point")
+		}
+		if _, ok := seen[x]; ok {
+			return nil, errors.New("duplicated share")
+		}
+		seen[x] = struct{}{}
+		xs[i] = x
+	}
+
+	// Interpolate each secret byte
+	secret := make([]byte, shareSize-ShareOverhead)
+	ys := make([]byte, len(shares))
+	defer memguard.WipeBytes(ys)
+	for idx := range secret {
+		for i, share := range shares {
+			ys[i] = share[idx]
+		}
+		secret[idx] = interpolate(xs, ys)
+	}
+
+	// No error
+	return secret, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// evaluate the polynomial at x using Horner's method.
+func evaluate(coefficients []byte, x uint8) uint8 {
+	var result uint8
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// interpolate the polynomial value at 0 with Lagrange's method.
+func interpolate(xs, ys []byte) uint8 {
+	var result uint8
+	for i := range xs {
+		basis := uint8(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, mul(xs[j], inverse(xs[i]^xs[j])))
+		}
+		result ^= mul(ys[i], basis)
+	}
+	return result
+}
+
+// mul multiplies a and b in GF(2^8) reduced by x^8+x^4+x^3+x+1, without
+// secret dependent branches.
+func mul(a, b uint8) uint8 {
+	var result uint8
+	for i := 0; i < 8; i++ {
+		result ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+		b >>= 1
+	}
+	return result
+}
+
+// inverse returns the multiplicative inverse of a in GF(2^8) computed as
+// a^254, the inverse of 0 is 0.
+func inverse(a uint8) uint8 {
+	result := a
+	for i := 0; i < 6; i++ {
+		result = mul(result, result)
+		result = mul(result, a)
+	}
+	return mul(result, result)
+}
@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit_Combine(t *testing.T) {
+	secret := []byte("harp shamir secret sharing test")
+
+	shares, err := Split(rand.Reader, secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+	for _, share := range shares {
+		assert.Len(t, share, len(secret)+ShareOverhead)
+	}
+
+	// Any threshold sized subset reconstructs the secret
+	for i := 0; i < len(shares); i++ {
+		for j := i + 1; j < len(shares); j++ {
+			for k := j + 1; k < len(shares); k++ {
+				out, err := Combine([][]byte{shares[k], shares[i], shares[j]})
+				require.NoError(t, err)
+				assert.Equal(t, secret, out)
+			}
+		}
+	}
+
+	// All shares
+	out, err := Combine(shares)
+	require.NoError(t, err)
+	assert.Equal(t, secret, out)
+
+	// Less than threshold shares
+	out, err = Combine(shares[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, out)
+}
+
+func TestSplit_Errors(t *testing.T) {
+	secret := []byte("secret")
+
+	_, err := Split(nil, secret, 3, 2)
+	assert.Error(t, err)
+	_, err = Split(rand.Reader, nil, 3, 2)
+	assert.Error(t, err)
+	_, err = Split(rand.Reader, secret, 3, 1)
+	assert.Error(t, err)
+	_, err = Split(rand.Reader, secret, 2, 3)
+	assert.Error(t, err)
+	_, err = Split(rand.Reader, secret, MaxParts+1, 2)
+	assert.Error(t, err)
+	_, err = Split(bytes.NewReader(nil), secret, 3, 2)
+	assert.Error(t, err)
+}
+
+func TestCombine_Errors(t *testing.T) {
+	shares, err := Split(rand.Reader, []byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	for name, in := range map[string][][]byte{
+		"nil":             nil,
+		"single share":    shares[:1],
+		"too short":       {{0x01}, {0x02}},
+		"length mismatch": {shares[0], shares[1][1:]},
+		"duplicated":      {shares[0], shares[0]},
+		"zero point":      {shares[0], append(append([]byte{}, shares[1][:6]...), 0x00)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Combine(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	// Known AES field values
+	assert.Equal(t, uint8(0xc1), mul(0x57, 0x83))
+	assert.Equal(t, uint8(0xfe), mul(0x57, 0x13))
+	assert.Equal(t, uint8(0), inverse(0))
+
+	for a := 1; a < 256; a++ {
+		assert.Equal(t, uint8(1), mul(uint8(a), inverse(uint8(a))), "inverse of %d", a)
+	}
+}
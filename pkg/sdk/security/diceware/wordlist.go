@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diceware
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/sethvargo/go-diceware/diceware"
+)
+
+var (
+	wordIndex     map[string]struct{}
+	wordIndexOnce sync.Once
+)
+
+// WordEntropy defines the entropy in bits of a word randomly picked in the
+// word list used for passphrase generation.
+var WordEntropy = math.Log2(math.Pow(6, float64(diceware.WordListEffLarge().Digits())))
+
+// IsWord returns true if the given word is part of the word list used for
+// passphrase generation.
+func IsWord(word string) bool {
+	wordIndexOnce.Do(func() {
+		wordIndex = indexWords(diceware.WordListEffLarge())
+	})
+
+	_, ok := wordIndex[strings.ToLower(word)]
+	return ok
+}
+
+// -----------------------------------------------------------------------------
+
+func indexWords(list diceware.WordList) map[string]struct{} {
+	res := map[string]struct{}{}
+
+	// Enumerate all dice rolls
+	var roll func(digits, prefix int)
+	roll = func(digits, prefix int) {
+		if digits == 0 {
+			if w := list.WordAt(prefix); w != "" {
+				res[w] = struct{}{}
+			}
+			return
+		}
+		for face := 1; face <= 6; face++ {
+			roll(digits-1, prefix*10+face)
+		}
+	}
+	roll(list.Digits(), 0)
+
+	return res
+}
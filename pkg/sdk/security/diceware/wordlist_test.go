@@ -0,0 +1,43 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diceware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsWord(t *testing.T) {
+	if !IsWord("abacus") || !IsWord("Zoom") {
+		t.Error("expected word list entries")
+	}
+	if IsWord("") || IsWord("xkqzv") {
+		t.Error("unexpected word list entries")
+	}
+
+	// Generated passphrases must be detected
+	passphrase, err := Basic()
+	if err != nil {
+		t.Fatalf("unable to generate passphrase: %v", err)
+	}
+	for _, w := range strings.Split(passphrase, "-") {
+		if !IsWord(w) {
+			t.Errorf("word %q of %q must be detected", w, passphrase)
+		}
+	}
+}
@@ -31,3 +31,19 @@ type Transformer interface {
 	To(ctx context.Context, input []byte) ([]byte, error)
 	From(ctx context.Context, input []byte) ([]byte, error)
 }
+
+// AdditionalDataBinder declares the contract of transformers authenticating
+// the additional data given with the encryption context.
+type AdditionalDataBinder interface {
+	BindsAdditionalData() bool
+}
+
+// Rewrapper declares the contract of transformers able to rotate their
+// encryption key without exposing the plaintext value.
+//
+// KeyID identifies the key encryption key, values encrypted by a transformer
+// can be rewrapped by another one only when both return the same identifier.
+type Rewrapper interface {
+	Rewrap(ctx context.Context, input []byte) ([]byte, error)
+	KeyID() string
+}
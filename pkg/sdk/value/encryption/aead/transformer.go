@@ -28,6 +28,10 @@ type aeadTransformer struct {
 	aead cipher.AEAD
 }
 
+func (t *aeadTransformer) BindsAdditionalData() bool {
+	return true
+}
+
 func (t *aeadTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
 	// Encrypt
 	out, err := encrypt(ctx, input, t.aead)
@@ -17,7 +17,11 @@
 
 package encryption
 
-import "context"
+import (
+	"context"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+)
 
 type contextKey string
 
@@ -46,6 +50,17 @@ func WithAdditionalData(ctx context.Context, value []byte) context.Context {
 
 // AdditionalData gets the aad value from the context.
 func AdditionalData(ctx context.Context) ([]byte, bool) {
+	if ctx == nil {
+		return nil, false
+	}
 	aad, ok := ctx.Value(contextKeyAAD).([]byte)
 	return aad, ok
 }
+
+// BindsAdditionalData returns true if the given transformer authenticates the
+// additional data given with the context. Other transformers silently ignore
+// it.
+func BindsAdditionalData(t value.Transformer) bool {
+	b, ok := t.(value.AdditionalDataBinder)
+	return ok && b.BindsAdditionalData()
+}
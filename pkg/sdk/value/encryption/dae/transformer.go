@@ -33,6 +33,10 @@ type daeTransformer struct {
 	nonceDeriverFunc NonceDeriverFunc
 }
 
+func (t *daeTransformer) BindsAdditionalData() bool {
+	return true
+}
+
 func (t *daeTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
 	// Check input size
 	if len(input) > 64*1024*1024 {
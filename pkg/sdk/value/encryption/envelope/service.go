@@ -26,3 +26,11 @@ type Service interface {
 	Decrypt(ctx context.Context, encrypted []byte) ([]byte, error)
 	Encrypt(ctx context.Context, cleartext []byte) ([]byte, error)
 }
+
+// Rewrapper declares envelope key rewrapping contract, used to re-encrypt a
+// data encryption key with the latest version of the key encryption key.
+// KeyID returns the key encryption key identifier.
+type Rewrapper interface {
+	Rewrap(ctx context.Context, encrypted []byte) ([]byte, error)
+	KeyID() string
+}
@@ -31,10 +31,20 @@ import (
 
 // Transformer returns an envelope encryption value transformer.
 func Transformer(envelopeService Service, transformerFactory encryption.TransformerFactoryFunc) (value.Transformer, error) {
-	return &envelopeTransformer{
+	t := &envelopeTransformer{
 		envelopeService:        envelopeService,
 		transformerFactoryFunc: transformerFactory,
-	}, nil
+	}
+
+	// Expose key rewrapping if supported by the envelope service
+	if rw, ok := envelopeService.(Rewrapper); ok {
+		return &rewrappingTransformer{
+			envelopeTransformer: t,
+			rewrapper:           rw,
+		}, nil
+	}
+
+	return t, nil
 }
 
 // -----------------------------------------------------------------------------
@@ -44,6 +54,16 @@ type envelopeTransformer struct {
 	transformerFactoryFunc encryption.TransformerFactoryFunc
 }
 
+func (t *envelopeTransformer) BindsAdditionalData() bool {
+	// Additional data is delegated to the payload transformer
+	transformer, err := t.transformerFactoryFunc(base64.URLEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		return false
+	}
+
+	return encryption.BindsAdditionalData(transformer)
+}
+
 func (t *envelopeTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
 	// Generate a random 32 byte length key
 	newKey := make([]byte, 32)
@@ -107,3 +127,42 @@ func (t *envelopeTransformer) From(ctx context.Context, input []byte) ([]byte, e
 	// Delegate to transformer
 	return transformer.From(ctx, payload)
 }
+
+// -----------------------------------------------------------------------------
+
+type rewrappingTransformer struct {
+	*envelopeTransformer
+	rewrapper Rewrapper
+}
+
+var _ value.Rewrapper = (*rewrappingTransformer)(nil)
+
+func (t *rewrappingTransformer) KeyID() string {
+	return t.rewrapper.KeyID()
+}
+
+func (t *rewrappingTransformer) Rewrap(ctx context.Context, input []byte) ([]byte, error) {
+	// Extract encrypted Data Encryption Key from input
+	var encKey cryptobyte.String
+
+	s := cryptobyte.String(input)
+	if ok := s.ReadUint16LengthPrefixed(&encKey); !ok {
+		return nil, fmt.Errorf("envelope: unable to read prefix")
+	}
+
+	// Rewrap DEK with envelope service
+	newKey, err := t.rewrapper.Rewrap(ctx, encKey)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: unable to rewrap dek: %w", err)
+	}
+
+	// Keep the encrypted payload as is
+	b := cryptobyte.NewBuilder(nil)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(newKey)
+	})
+	b.AddBytes(s)
+
+	// No error
+	return b.Bytes()
+}
@@ -18,6 +18,7 @@
 package envelope
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -275,3 +276,64 @@ func Test_Envelope_Transformer_Error(t *testing.T) {
 		})
 	}
 }
+
+type testRewrappingEnvelopeService struct {
+	testEnvelopeService
+}
+
+func (s *testRewrappingEnvelopeService) Rewrap(_ context.Context, data []byte) ([]byte, error) {
+	return append([]byte("rewrapped:"), data...), nil
+}
+
+func (s *testRewrappingEnvelopeService) KeyID() string {
+	return "test"
+}
+
+func (s *testRewrappingEnvelopeService) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return s.testEnvelopeService.Decrypt(ctx, bytes.TrimPrefix(data, []byte("rewrapped:")))
+}
+
+func Test_Envelope_Rewrap(t *testing.T) {
+	ctx := context.Background()
+
+	// Rewrapping is not exposed without service support
+	underTest, err := Transformer(&testEnvelopeService{}, secretbox.Transformer)
+	if err != nil {
+		t.Fatalf("error during transformer initialization, error = %v", err)
+	}
+	if _, ok := underTest.(value.Rewrapper); ok {
+		t.Fatal("transformer should not support rewrapping")
+	}
+
+	underTest, err = Transformer(&testRewrappingEnvelopeService{}, secretbox.Transformer)
+	if err != nil {
+		t.Fatalf("error during transformer initialization, error = %v", err)
+	}
+	rw, ok := underTest.(value.Rewrapper)
+	if !ok {
+		t.Fatal("transformer should support rewrapping")
+	}
+	if rw.KeyID() != "test" {
+		t.Errorf("unexpected key identifier %q", rw.KeyID())
+	}
+
+	encrypted, err := underTest.To(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("unable to encrypt, error = %v", err)
+	}
+	rewrapped, err := rw.Rewrap(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("unable to rewrap, error = %v", err)
+	}
+	if !bytes.Contains(rewrapped, []byte("rewrapped:")) {
+		t.Error("the data encryption key should be rewrapped")
+	}
+
+	got, err := underTest.From(ctx, rewrapped)
+	if err != nil {
+		t.Fatalf("unable to decrypt, error = %v", err)
+	}
+	if diff := cmp.Diff(got, []byte("foo")); diff != "" {
+		t.Errorf("Envelope.Rewrap():\n-got/+want\ndiff %s", diff)
+	}
+}
@@ -19,14 +19,22 @@ package jwe
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
 
 	"gopkg.in/square/go-jose.v2"
 
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
 	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 )
 
+// additionalDataHeader is the protected header holding the additional data
+// digest.
+const additionalDataHeader jose.HeaderKey = "harp-aad"
+
 // PBKDF2SaltSize is the default size of the salt for PBKDF2, 128-bit salt.
 const PBKDF2SaltSize = 16
 
@@ -56,7 +64,11 @@ type jweTransformer struct {
 	contentEncryption jose.ContentEncryption
 }
 
-func (d *jweTransformer) To(_ context.Context, input []byte) ([]byte, error) {
+func (d *jweTransformer) BindsAdditionalData() bool {
+	return true
+}
+
+func (d *jweTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
 	// Prepare JOSE recipient
 	recipient := jose.Recipient{
 		Algorithm:  d.keyAlgorithm,
@@ -67,6 +79,12 @@ func (d *jweTransformer) To(_ context.Context, input []byte) ([]byte, error) {
 	// JWE Header
 	opts := new(jose.EncrypterOptions)
 
+	// Compact serialization doesn't support additional data, bind its digest
+	// as a protected header
+	if digest := additionalDataDigest(ctx); digest != "" {
+		opts.WithHeader(additionalDataHeader, digest)
+	}
+
 	// Prepare encryption
 	encrypter, err := jose.NewEncrypter(d.contentEncryption, recipient, opts)
 	if err != nil {
@@ -89,13 +107,19 @@ func (d *jweTransformer) To(_ context.Context, input []byte) ([]byte, error) {
 	return []byte(out), nil
 }
 
-func (d *jweTransformer) From(_ context.Context, input []byte) ([]byte, error) {
+func (d *jweTransformer) From(ctx context.Context, input []byte) ([]byte, error) {
 	// Parse JWE Token
 	jwe, errParse := jose.ParseEncrypted(string(input))
 	if errParse != nil {
 		return nil, fmt.Errorf("jwe: unable to parse JWE token")
 	}
 
+	// Check additional data binding
+	bound, _ := jwe.Header.ExtraHeaders[additionalDataHeader].(string)
+	if subtle.ConstantTimeCompare([]byte(bound), []byte(additionalDataDigest(ctx))) != 1 {
+		return nil, fmt.Errorf("jwe: additional data mismatch")
+	}
+
 	// Try to decrypt with given passphrase
 	payload, errDecrypt := jwe.Decrypt(d.key)
 	if errDecrypt != nil {
@@ -105,3 +129,15 @@ func (d *jweTransformer) From(_ context.Context, input []byte) ([]byte, error) {
 	// No error
 	return payload, nil
 }
+
+// additionalDataDigest returns the encoded digest of the context additional
+// data, or an empty string if none is set.
+func additionalDataDigest(ctx context.Context) string {
+	aad, ok := encryption.AdditionalData(ctx)
+	if !ok || len(aad) == 0 {
+		return ""
+	}
+
+	h := sha256.Sum256(aad)
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -24,6 +24,8 @@ import (
 	"testing"
 
 	"gopkg.in/square/go-jose.v2"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 )
 
 func mustDecodeBase64(in string) []byte {
@@ -208,3 +210,36 @@ func Test_jweTransformer_From(t *testing.T) {
 		})
 	}
 }
+
+func Test_jweTransformer_AdditionalData(t *testing.T) {
+	d := &jweTransformer{
+		key:               []byte("deterministic-key-for-test-0001"),
+		keyAlgorithm:      jose.PBES2_HS256_A128KW,
+		contentEncryption: jose.A128GCM,
+	}
+
+	ctx := encryption.WithAdditionalData(context.Background(), []byte("app/production/database#password"))
+	encrypted, err := d.To(ctx, []byte("cleartext message"))
+	if err != nil {
+		t.Fatalf("unable to encrypt: %v", err)
+	}
+
+	// Matching additional data
+	out, err := d.From(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("unable to decrypt: %v", err)
+	}
+	if string(out) != "cleartext message" {
+		t.Errorf("unexpected decrypted value %q", out)
+	}
+
+	// Mismatching additional data
+	if _, err := d.From(encryption.WithAdditionalData(context.Background(), []byte("app/production/other#password")), encrypted); err == nil {
+		t.Error("decryption with a different additional data should fail")
+	}
+
+	// Missing additional data
+	if _, err := d.From(context.Background(), encrypted); err == nil {
+		t.Error("decryption without additional data should fail")
+	}
+}
@@ -64,11 +64,22 @@ type pasetoTransformer struct {
 	key [pasetov4.KeyLength]byte
 }
 
-func (d *pasetoTransformer) From(_ context.Context, input []byte) ([]byte, error) {
-	return pasetov4.Decrypt(d.key[:], input, "", "")
+func (d *pasetoTransformer) BindsAdditionalData() bool {
+	return true
 }
 
-func (d *pasetoTransformer) To(_ context.Context, input []byte) ([]byte, error) {
-	// Encrypt with paseto v4.local
-	return pasetov4.Encrypt(rand.Reader, d.key[:], input, "", "")
+func (d *pasetoTransformer) From(ctx context.Context, input []byte) ([]byte, error) {
+	// Retrieve additional data from context
+	aad, _ := encryption.AdditionalData(ctx)
+
+	return pasetov4.Decrypt(d.key[:], input, "", string(aad))
+}
+
+func (d *pasetoTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
+	// Retrieve additional data from context
+	aad, _ := encryption.AdditionalData(ctx)
+
+	// Encrypt with paseto v4.local, additional data is bound as implicit
+	// assertion
+	return pasetov4.Encrypt(rand.Reader, d.key[:], input, "", string(aad))
 }
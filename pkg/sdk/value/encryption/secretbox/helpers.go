@@ -18,7 +18,10 @@
 package secretbox
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +32,7 @@ import (
 const (
 	keyLength   = 32
 	nonceLength = 24
+	tagLength   = sha256.Size
 )
 
 func generateNonce() ([nonceLength]byte, error) {
@@ -54,3 +58,35 @@ func decrypt(ciphertext []byte, key [keyLength]byte) ([]byte, error) {
 	}
 	return decrypted, nil
 }
+
+// mac computes the tag binding the additional data to the given ciphertext.
+func mac(ciphertext, aad []byte, key [keyLength]byte) []byte {
+	// Derive a dedicated authentication key
+	kdf := hmac.New(sha256.New, key[:])
+	kdf.Write([]byte("harp secretbox additional data"))
+
+	// Length-prefix the additional data to prevent ambiguities
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(aad)))
+
+	h := hmac.New(sha256.New, kdf.Sum(nil))
+	h.Write(length[:])
+	h.Write(aad)
+	h.Write(ciphertext)
+
+	return h.Sum(nil)
+}
+
+// open checks and removes the additional data tag of the given ciphertext.
+func open(input, aad []byte, key [keyLength]byte) ([]byte, error) {
+	if len(input) < nonceLength+tagLength {
+		return nil, errors.New("ciphered text too short")
+	}
+
+	ciphertext, tag := input[:len(input)-tagLength], input[len(input)-tagLength:]
+	if !hmac.Equal(tag, mac(ciphertext, aad, key)) {
+		return nil, errors.New("additional data authentication failed")
+	}
+
+	return ciphertext, nil
+}
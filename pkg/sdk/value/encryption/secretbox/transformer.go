@@ -64,7 +64,19 @@ type secretboxTransformer struct {
 	key *[keyLength]byte
 }
 
-func (d *secretboxTransformer) From(_ context.Context, input []byte) ([]byte, error) {
+func (d *secretboxTransformer) BindsAdditionalData() bool {
+	return true
+}
+
+func (d *secretboxTransformer) From(ctx context.Context, input []byte) ([]byte, error) {
+	// Check additional data authentication
+	if aad, ok := encryption.AdditionalData(ctx); ok && len(aad) > 0 {
+		var err error
+		if input, err = open(input, aad, *d.key); err != nil {
+			return nil, fmt.Errorf("secretbox: unable to transform value: %w", err)
+		}
+	}
+
 	// Check output
 	if l := len(input); l < nonceLength {
 		return nil, fmt.Errorf("secretbox: invalid secret length (%d), check encryption status", l)
@@ -80,13 +92,18 @@ func (d *secretboxTransformer) From(_ context.Context, input []byte) ([]byte, er
 	return out, nil
 }
 
-func (d *secretboxTransformer) To(_ context.Context, input []byte) ([]byte, error) {
+func (d *secretboxTransformer) To(ctx context.Context, input []byte) ([]byte, error) {
 	// Encrypt value
 	out, err := encrypt(input, *d.key)
 	if err != nil {
 		return nil, fmt.Errorf("secretbox: unable to transform value: %w", err)
 	}
 
+	// Secretbox doesn't support additional data, bind it with a MAC
+	if aad, ok := encryption.AdditionalData(ctx); ok && len(aad) > 0 {
+		out = append(out, mac(out, aad, *d.key)...)
+	}
+
 	// No error
 	return out, nil
 }
@@ -26,6 +26,8 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 )
 
 func Test_Transformer_SecretBox_InvalidKey(t *testing.T) {
@@ -184,3 +186,30 @@ func Test_Transformer_SecretBox_To(t *testing.T) {
 		})
 	}
 }
+
+func Test_Transformer_AdditionalData(t *testing.T) {
+	underTest, err := Transformer(base64.URLEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	if err != nil {
+		t.Fatalf("unable to initialize transformer: %v", err)
+	}
+
+	ctx := encryption.WithAdditionalData(context.Background(), []byte("app/production/database#password"))
+	encrypted, err := underTest.To(ctx, []byte("cool-protected-data"))
+	if err != nil {
+		t.Fatalf("unable to encrypt: %v", err)
+	}
+
+	// Matching additional data
+	out, err := underTest.From(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("unable to decrypt: %v", err)
+	}
+	if string(out) != "cool-protected-data" {
+		t.Errorf("unexpected decrypted value %q", out)
+	}
+
+	// Mismatching additional data
+	if _, err := underTest.From(encryption.WithAdditionalData(context.Background(), []byte("app/production/other#password")), encrypted); err == nil {
+		t.Error("decryption with a different additional data should fail")
+	}
+}
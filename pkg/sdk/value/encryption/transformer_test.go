@@ -273,3 +273,44 @@ func TestMust(t *testing.T) {
 		encryption.Must(nil, nil)
 	})
 }
+
+func TestBindsAdditionalData(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyValue string
+		want     bool
+	}{
+		{
+			name:     "fernet",
+			keyValue: "fernet:ZER8WwNyw5Dsd65bctxillSrRMX4ObaZsQjaNW1nBBI=",
+			want:     false,
+		},
+		{
+			name:     "age-recipients",
+			keyValue: "age-recipients:age1ce20pmz8z0ue97v7rz838v6pcpvzqan30lr40tjlzy40ez8eldrqf2zuxe",
+			want:     false,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name:     "aes-gcm",
+			keyValue: "aes-gcm:zQyPnNa-jlQsLW3Ypd87cX88ROMkdgnqv0a3y8LiISg=",
+			want:     true,
+		},
+		{
+			name:     "dae-chacha",
+			keyValue: "dae-chacha:gCUODuqhcktiM1USKOfkwVlKhoUyHxXZm6d64nztCp0=",
+			want:     true,
+		},
+		{
+			name:     "secretbox",
+			keyValue: "secretbox:gCUODuqhcktiM1USKOfkwVlKhoUyHxXZm6d64nztCp0=",
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer := encryption.Must(encryption.FromKey(tt.keyValue))
+			assert.Equal(t, tt.want, encryption.BindsAdditionalData(transformer))
+		})
+	}
+}
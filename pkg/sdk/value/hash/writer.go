@@ -19,6 +19,8 @@ package hash
 
 import (
 	"crypto"
+	"crypto/hmac"
+	"errors"
 	"fmt"
 	"hash"
 	"sort"
@@ -51,16 +53,10 @@ func init() {
 
 // NewHasher returns a hasher instance.
 func NewHasher(algorithm string) (hash.Hash, error) {
-	// Normalize input
-	algorithm = strings.TrimSpace(strings.ToLower(algorithm))
-
 	// Resolve algorithm
-	hf, ok := name2Hash[algorithm]
-	if !ok {
-		return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
-	}
-	if !hf.Available() {
-		return nil, fmt.Errorf("hash algorithm %q is not available", algorithm)
+	hf, err := resolve(algorithm)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build hash instance.
@@ -70,6 +66,24 @@ func NewHasher(algorithm string) (hash.Hash, error) {
 	return h, nil
 }
 
+// NewKeyedHasher returns a HMAC hasher instance based on the given hash
+// algorithm.
+func NewKeyedHasher(algorithm string, key []byte) (hash.Hash, error) {
+	// Check arguments
+	if len(key) == 0 {
+		return nil, errors.New("unable to initialize a keyed hasher with an empty key")
+	}
+
+	// Resolve algorithm
+	hf, err := resolve(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// No error
+	return hmac.New(hf.New, key), nil
+}
+
 // SupportedAlgorithms returns the available hash algorithms.
 func SupportedAlgorithms() []string {
 	res := []string{}
@@ -84,3 +98,22 @@ func SupportedAlgorithms() []string {
 
 	return res
 }
+
+// -----------------------------------------------------------------------------
+
+func resolve(algorithm string) (crypto.Hash, error) {
+	// Normalize input
+	algorithm = strings.TrimSpace(strings.ToLower(algorithm))
+
+	// Resolve algorithm
+	hf, ok := name2Hash[algorithm]
+	if !ok {
+		return 0, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+	if !hf.Available() {
+		return 0, fmt.Errorf("hash algorithm %q is not available", algorithm)
+	}
+
+	// No error
+	return hf, nil
+}
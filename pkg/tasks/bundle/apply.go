@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/compare"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// ApplyTask implements oplog application task.
+type ApplyTask struct {
+	ContainerReader tasks.ReaderProvider
+	OpLogReader     tasks.ReaderProvider
+	JSONPatchReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	Strict          bool
+	WithoutHistory  bool
+}
+
+// Run the task.
+func (t *ApplyTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OpLogReader) == types.IsNil(t.JSONPatchReader) {
+		return errors.New("unable to run task without exactly one of opLogReader or jsonPatchReader providers")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Load bundle
+	b, err := loadBundle(ctx, t.ContainerReader, "input")
+	if err != nil {
+		return err
+	}
+
+	// Load operations
+	oplog, err := t.readOpLog(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Prepare options
+	opts := []bundle.ApplyOption{}
+	if t.Strict {
+		opts = append(opts, bundle.WithStrictApply())
+	}
+	if t.WithoutHistory {
+		opts = append(opts, bundle.WithoutApplyHistory())
+	}
+
+	// Apply operations
+	if err = bundle.ApplyOpLog(b, oplog, opts...); err != nil {
+		return fmt.Errorf("unable to apply oplog: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output bundle: %w", err)
+	}
+
+	// Dump bundle
+	if err = bundle.ToContainerWriter(writer, b); err != nil {
+		return fmt.Errorf("unable to produce patched bundle: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func (t *ApplyTask) readOpLog(ctx context.Context) (compare.OpLog, error) {
+	// Convert JSON Patch
+	if !types.IsNil(t.JSONPatchReader) {
+		reader, err := t.JSONPatchReader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open JSON Patch: %w", err)
+		}
+
+		var patch compare.JSONPatch
+		if err = json.NewDecoder(reader).Decode(&patch); err != nil {
+			return nil, fmt.Errorf("unable to decode input JSON Patch: %w", err)
+		}
+
+		oplog, err := compare.FromJSONPatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert JSON Patch as an oplog: %w", err)
+		}
+
+		return oplog, nil
+	}
+
+	// Create oplog reader
+	reader, err := t.OpLogReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open oplog: %w", err)
+	}
+
+	// Decode oplog
+	var oplog compare.OpLog
+	if err = json.NewDecoder(reader).Decode(&oplog); err != nil {
+		return nil, fmt.Errorf("unable to decode input JSON oplog: %w", err)
+	}
+
+	// No error
+	return oplog, nil
+}
@@ -0,0 +1,179 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func stringReader(content string) tasks.ReaderProvider {
+	return func(_ context.Context) (io.Reader, error) {
+		return strings.NewReader(content), nil
+	}
+}
+
+func TestApplyTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OpLogReader     tasks.ReaderProvider
+		JSONPatchReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		Strict          bool
+		WithoutHistory  bool
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil opLogReader",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`[]`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "both opLogReader and jsonPatchReader",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`[]`),
+				JSONPatchReader: stringReader(`[]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid JSON Patch",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				JSONPatchReader: stringReader(`[{"op":"move","from":"/a","path":"/b"}]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OpLogReader:     stringReader(`[]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid oplog",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`{`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "strict mismatch",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`[{"op":"replace","type":"secret","path":"app/production/database#password","value":"new","previous":"initial"}]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Strict:          true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`[]`),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OpLogReader:     stringReader(`[{"op":"replace","type":"secret","path":"app/production/database#password","value":"new","previous":"rotated"}]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Strict:          true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid JSON Patch",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				JSONPatchReader: stringReader(`[{"op":"test","path":"/app~1production~1database/password","value":"rotated"},{"op":"replace","path":"/app~1production~1database/password","value":"new"}]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Strict:          true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid without history",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OpLogReader:     stringReader(`[{"op":"add","type":"secret","path":"app/production/database#user","value":"admin"}]`),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				WithoutHistory:  true,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &ApplyTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OpLogReader:     tt.fields.OpLogReader,
+				JSONPatchReader: tt.fields.JSONPatchReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				Strict:          tt.fields.Strict,
+				WithoutHistory:  tt.fields.WithoutHistory,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("ApplyTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
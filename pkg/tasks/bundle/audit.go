@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/audit"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+const (
+	// AuditFormatJSON exports audit findings as JSON.
+	AuditFormatJSON = "json"
+	// AuditFormatSARIF exports audit findings as a SARIF log.
+	AuditFormatSARIF = "sarif"
+)
+
+// AuditTask implements secret hygiene audit task.
+type AuditTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	Format          string
+	FingerprintKey  []byte
+	MinEntropy      float64
+}
+
+// Run the task.
+func (t *AuditTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Check format
+	format := t.Format
+	if format == "" {
+		format = AuditFormatJSON
+	}
+	switch format {
+	case AuditFormatJSON, AuditFormatSARIF:
+	default:
+		return fmt.Errorf("unsupported audit format %q", format)
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to load bundle content: %w", err)
+	}
+
+	// Prepare options
+	opts := []audit.Option{}
+	if t.FingerprintKey != nil {
+		opts = append(opts, audit.WithFingerprintKey(t.FingerprintKey))
+	}
+	if t.MinEntropy > 0 {
+		opts = append(opts, audit.WithMinEntropy(t.MinEntropy))
+	}
+
+	// Audit bundle secrets
+	report, err := audit.Run(b, opts...)
+	if err != nil {
+		return fmt.Errorf("unable to audit bundle: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Encode report
+	switch format {
+	case AuditFormatSARIF:
+		if err := audit.WriteSARIF(writer, report); err != nil {
+			return fmt.Errorf("unable to write SARIF report: %w", err)
+		}
+	default:
+		if err := json.NewEncoder(writer).Encode(report); err != nil {
+			return fmt.Errorf("unable to marshal JSON report: %w", err)
+		}
+	}
+
+	// No error
+	return nil
+}
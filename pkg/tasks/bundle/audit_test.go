@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestAuditTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		Format          string
+		FingerprintKey  []byte
+		MinEntropy      float64
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid format",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Format:          "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty fingerprint key",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				FingerprintKey:  []byte{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - sarif",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Format:          AuditFormatSARIF,
+				FingerprintKey:  []byte("audit-key"),
+				MinEntropy:      64,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &AuditTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				Format:          tt.fields.Format,
+				FingerprintKey:  tt.fields.FingerprintKey,
+				MinEntropy:      tt.fields.MinEntropy,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("AuditTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -36,6 +36,8 @@ type DecryptTask struct {
 	OutputWriter       tasks.WriterProvider
 	Transformers       []value.Transformer
 	SkipNotDecryptable bool
+	RequireContext     bool
+	WorkerCount        int64
 }
 
 // Run the task.
@@ -65,13 +67,17 @@ func (t *DecryptTask) Run(ctx context.Context) error {
 	}
 
 	// Read input bundle
-	b, err = bundle.FromContainerReader(reader)
+	b, err = bundle.OpenContainerReader(ctx, reader, t.Transformers)
 	if err != nil {
 		return fmt.Errorf("unable to read input as bundle: %w", err)
 	}
 
 	// Apply transformer to bundle
-	if err = bundle.UnLock(ctx, b, t.Transformers, t.SkipNotDecryptable); err != nil {
+	opts := []bundle.LockOption{
+		bundle.WithMaxWorkerCount(t.WorkerCount),
+		bundle.WithRequiredEncryptionContext(t.RequireContext),
+	}
+	if err = bundle.UnLock(ctx, b, t.Transformers, t.SkipNotDecryptable, opts...); err != nil {
 		return fmt.Errorf("unable to apply bundle transformation: %w", err)
 	}
 
@@ -39,6 +39,7 @@ func TestDecryptTask_Run(t *testing.T) {
 		ContainerReader tasks.ReaderProvider
 		OutputWriter    tasks.WriterProvider
 		Transformers    []value.Transformer
+		RequireContext  bool
 	}
 	tests := []struct {
 		name    string
@@ -136,6 +137,18 @@ func TestDecryptTask_Run(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unbound bundle with required context",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.aes-gcm.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Transformers: []value.Transformer{
+					encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg==")),
+				},
+				RequireContext: true,
+			},
+			wantErr: true,
+		},
 		// ---------------------------------------------------------------------
 		{
 			name: "valid",
@@ -155,6 +168,7 @@ func TestDecryptTask_Run(t *testing.T) {
 				ContainerReader: tt.fields.ContainerReader,
 				OutputWriter:    tt.fields.OutputWriter,
 				Transformers:    tt.fields.Transformers,
+				RequireContext:  tt.fields.RequireContext,
 			}
 			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
 				t.Errorf("DecryptTask.Run() error = %v, wantErr %v", err, tt.wantErr)
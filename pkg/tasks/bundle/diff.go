@@ -27,15 +27,30 @@ import (
 	"github.com/zntrio/harp/v2/pkg/bundle/compare"
 	"github.com/zntrio/harp/v2/pkg/sdk/convert"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
+const (
+	// DiffFormatOpLog exports differences as a JSON OpLog.
+	DiffFormatOpLog = "oplog"
+	// DiffFormatPatch exports differences as a BundlePatch.
+	DiffFormatPatch = "patch"
+	// DiffFormatJSONPatch exports differences as a RFC6902 JSON Patch.
+	DiffFormatJSONPatch = "jsonpatch"
+)
+
 // DiffTask implements secret container difference task.
 type DiffTask struct {
 	SourceReader      tasks.ReaderProvider
 	DestinationReader tasks.ReaderProvider
 	OutputWriter      tasks.WriterProvider
 	GeneratePatch     bool
+	WithPrevious      bool
+	Format            string
+	RedactionKey      []byte
+	Semantic          bool
+	Transformers      []value.Transformer
 }
 
 // Run the task.
@@ -51,6 +66,23 @@ func (t *DiffTask) Run(ctx context.Context) error {
 		return errors.New("unable to run task with a nil outputWriter provider")
 	}
 
+	// Resolve output format
+	format := t.Format
+	switch {
+	case t.GeneratePatch:
+		format = DiffFormatPatch
+	case format == "":
+		format = DiffFormatOpLog
+	}
+	switch format {
+	case DiffFormatOpLog, DiffFormatPatch, DiffFormatJSONPatch:
+	default:
+		return fmt.Errorf("unsupported diff output format %q", format)
+	}
+	if t.RedactionKey != nil && format != DiffFormatOpLog {
+		return fmt.Errorf("redacted diff can only be exported as %q", DiffFormatOpLog)
+	}
+
 	// Create input reader
 	readerSrc, err := t.SourceReader(ctx)
 	if err != nil {
@@ -58,7 +90,7 @@ func (t *DiffTask) Run(ctx context.Context) error {
 	}
 
 	// Load source bundle
-	bSrc, err := bundle.FromContainerReader(readerSrc)
+	bSrc, err := bundle.OpenContainerReader(ctx, readerSrc, t.Transformers)
 	if err != nil {
 		return fmt.Errorf("unable to load source bundle content: %w", err)
 	}
@@ -70,13 +102,25 @@ func (t *DiffTask) Run(ctx context.Context) error {
 	}
 
 	// Load destination bundle
-	bDst, err := bundle.FromContainerReader(readerDst)
+	bDst, err := bundle.OpenContainerReader(ctx, readerDst, t.Transformers)
 	if err != nil {
 		return fmt.Errorf("unable to load destination bundle content: %w", err)
 	}
 
+	// Prepare diff options
+	opts := []compare.DiffOption{}
+	if t.WithPrevious {
+		opts = append(opts, compare.WithPreviousValues())
+	}
+	if t.RedactionKey != nil {
+		opts = append(opts, compare.WithRedactedValues(t.RedactionKey))
+	}
+	if t.Semantic {
+		opts = append(opts, compare.WithSemanticDiff())
+	}
+
 	// Calculate diff
-	report, err := compare.Diff(bSrc, bDst)
+	report, err := compare.Diff(bSrc, bDst, opts...)
 	if err != nil {
 		return fmt.Errorf("unable to calculate bundle difference: %w", err)
 	}
@@ -87,12 +131,8 @@ func (t *DiffTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to open output writer: %w", err)
 	}
 
-	if !t.GeneratePatch {
-		// Encode as JSON
-		if err := json.NewEncoder(writer).Encode(report); err != nil {
-			return fmt.Errorf("unable to marshal JSON OpLog: %w", err)
-		}
-	} else {
+	switch format {
+	case DiffFormatPatch:
 		// Convert optlog as a patch
 		patch, err := compare.ToPatch(report)
 		if err != nil {
@@ -107,6 +147,22 @@ func (t *DiffTask) Run(ctx context.Context) error {
 
 		// Write output
 		fmt.Fprintln(writer, string(out))
+	case DiffFormatJSONPatch:
+		// Convert oplog as a JSON Patch
+		patch, err := compare.ToJSONPatch(report)
+		if err != nil {
+			return fmt.Errorf("unable to convert oplog as a JSON Patch: %w", err)
+		}
+
+		// Encode as JSON
+		if err := json.NewEncoder(writer).Encode(patch); err != nil {
+			return fmt.Errorf("unable to marshal JSON Patch: %w", err)
+		}
+	default:
+		// Encode as JSON
+		if err := json.NewEncoder(writer).Encode(report); err != nil {
+			return fmt.Errorf("unable to marshal JSON OpLog: %w", err)
+		}
 	}
 
 	// No error
@@ -33,6 +33,10 @@ func TestDiffTask_Run(t *testing.T) {
 		DestinationReader tasks.ReaderProvider
 		OutputWriter      tasks.WriterProvider
 		GeneratePatch     bool
+		WithPrevious      bool
+		Format            string
+		RedactionKey      []byte
+		Semantic          bool
 	}
 	type args struct {
 		ctx context.Context
@@ -167,6 +171,68 @@ func TestDiffTask_Run(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "bundle diff - invalid format",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/empty.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				Format:            "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "bundle diff - with jsonpatch",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/empty.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				WithPrevious:      true,
+				Format:            DiffFormatJSONPatch,
+			},
+			wantErr: false,
+		},
+		{
+			name: "bundle diff - redacted patch",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/empty.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				GeneratePatch:     true,
+				RedactionKey:      []byte("diff-key"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "bundle diff - empty redaction key",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/empty.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				RedactionKey:      []byte{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bundle diff - semantic",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				Semantic:          true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "bundle diff - redacted",
+			fields: fields{
+				SourceReader:      cmdutil.FileReader("../../../test/fixtures/bundles/empty.bundle"),
+				DestinationReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				RedactionKey:      []byte("diff-key"),
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -175,6 +241,10 @@ func TestDiffTask_Run(t *testing.T) {
 				DestinationReader: tt.fields.DestinationReader,
 				OutputWriter:      tt.fields.OutputWriter,
 				GeneratePatch:     tt.fields.GeneratePatch,
+				WithPrevious:      tt.fields.WithPrevious,
+				Format:            tt.fields.Format,
+				RedactionKey:      tt.fields.RedactionKey,
+				Semantic:          tt.fields.Semantic,
 			}
 			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
 				t.Errorf("DiffTask.Run() error = %v, wantErr %v", err, tt.wantErr)
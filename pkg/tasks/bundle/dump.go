@@ -28,10 +28,21 @@ import (
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
 	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/inventory"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
+const (
+	// InventoryFormatCSV exports the inventory as CSV.
+	InventoryFormatCSV = "csv"
+	// InventoryFormatMarkdown exports the inventory as a Markdown table.
+	InventoryFormatMarkdown = "markdown"
+	// InventoryFormatCycloneDX exports the inventory as CycloneDX-style JSON.
+	InventoryFormatCycloneDX = "cyclonedx"
+)
+
 // DumpTask implements secret-container dumping task.
 type DumpTask struct {
 	ContainerReader tasks.ReaderProvider
@@ -41,6 +52,9 @@ type DumpTask struct {
 	MetadataOnly    bool
 	JMESPathFilter  string
 	IgnoreTemplate  bool
+	Inventory       bool
+	InventoryFormat string
+	Transformers    []value.Transformer
 }
 
 // Run the task.
@@ -65,7 +79,7 @@ func (t *DumpTask) Run(ctx context.Context) error {
 	}
 
 	// Load bundle
-	b, err := bundle.FromContainerReader(reader)
+	b, err := bundle.OpenContainerReader(ctx, reader, t.Transformers)
 	if err != nil {
 		return fmt.Errorf("unable to load bundle content: %w", err)
 	}
@@ -82,6 +96,8 @@ func (t *DumpTask) Run(ctx context.Context) error {
 	}
 
 	switch {
+	case t.Inventory:
+		return t.dumpInventory(writer, b)
 	case t.DataOnly:
 		return t.dumpData(writer, b)
 	case t.MetadataOnly:
@@ -173,6 +189,42 @@ func (t *DumpTask) dumpPath(writer io.Writer, b *bundlev1.Bundle) error {
 	return nil
 }
 
+func (t *DumpTask) dumpInventory(writer io.Writer, b *bundlev1.Bundle) error {
+	// Check arguments
+	if types.IsNil(writer) {
+		return fmt.Errorf("unable to process nil writer")
+	}
+	if b == nil {
+		return fmt.Errorf("unable to process nil bundle")
+	}
+
+	// Select renderer
+	var render func(io.Writer, *inventory.Inventory) error
+	switch t.InventoryFormat {
+	case InventoryFormatCSV:
+		render = inventory.WriteCSV
+	case InventoryFormatMarkdown, "":
+		render = inventory.WriteMarkdown
+	case InventoryFormatCycloneDX:
+		render = inventory.WriteCycloneDX
+	default:
+		return fmt.Errorf("unsupported inventory format %q", t.InventoryFormat)
+	}
+
+	// Build inventory
+	inv, err := inventory.Build(b)
+	if err != nil {
+		return fmt.Errorf("unable to build bundle inventory: %w", err)
+	}
+
+	// Render inventory
+	if err := render(writer, inv); err != nil {
+		return fmt.Errorf("unable to render bundle inventory: %w", err)
+	}
+
+	return nil
+}
+
 func (t *DumpTask) dumpFilter(writer io.Writer, b *bundlev1.Bundle) error {
 	// Check arguments
 	if types.IsNil(writer) {
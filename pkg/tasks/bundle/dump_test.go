@@ -41,6 +41,8 @@ func TestDumpTask_Run(t *testing.T) {
 		MetadataOnly    bool
 		JMESPathFilter  string
 		IgnoreTemplate  bool
+		Inventory       bool
+		InventoryFormat string
 	}
 	type args struct {
 		ctx context.Context
@@ -136,6 +138,45 @@ func TestDumpTask_Run(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid inventory format",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Inventory:       true,
+				InventoryFormat: "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid - inventory",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Inventory:       true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - inventory csv",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Inventory:       true,
+				InventoryFormat: InventoryFormatCSV,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - inventory cyclonedx",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Inventory:       true,
+				InventoryFormat: InventoryFormatCycloneDX,
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid - with JMES Filter",
 			fields: fields{
@@ -164,6 +205,8 @@ func TestDumpTask_Run(t *testing.T) {
 				MetadataOnly:    tt.fields.MetadataOnly,
 				JMESPathFilter:  tt.fields.JMESPathFilter,
 				IgnoreTemplate:  tt.fields.IgnoreTemplate,
+				Inventory:       tt.fields.Inventory,
+				InventoryFormat: tt.fields.InventoryFormat,
 			}
 			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
 				t.Errorf("DumpTask.Run() error = %v, wantErr %v", err, tt.wantErr)
@@ -37,6 +37,8 @@ type EncryptTask struct {
 	BundleTransformer value.Transformer
 	TransformerMap    map[string]value.Transformer
 	SkipUnresolved    bool
+	WorkerCount       int64
+	RecordHistory     bool
 }
 
 // Run the task.
@@ -68,16 +70,22 @@ func (t *EncryptTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to read input as bundle: %w", err)
 	}
 
+	// Encryption options
+	opts := []bundle.LockOption{
+		bundle.WithMaxWorkerCount(t.WorkerCount),
+		bundle.WithVersionHistory(t.RecordHistory),
+	}
+
 	// Select appropriate encryption strategy.
 	switch {
 	case !types.IsNil(t.BundleTransformer):
 		// Apply transformer to bundle
-		if err = bundle.Lock(ctx, b, t.BundleTransformer); err != nil {
+		if err = bundle.Lock(ctx, b, t.BundleTransformer, opts...); err != nil {
 			return fmt.Errorf("unable to apply bundle transformation: %w", err)
 		}
 	case len(t.TransformerMap) > 0:
 		// Apply annotation based encryption
-		if err = bundle.PartialLock(ctx, b, t.TransformerMap, t.SkipUnresolved); err != nil {
+		if err = bundle.PartialLock(ctx, b, t.TransformerMap, t.SkipUnresolved, opts...); err != nil {
 			return fmt.Errorf("unable to apply annotation based transformation: %w", err)
 		}
 	default:
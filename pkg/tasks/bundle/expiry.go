@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// ExpiryTask implements secret expiration report task.
+type ExpiryTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	// Within defines the reporting window of soon to expire secrets.
+	Within time.Duration
+	// Threshold raises an error when a secret expires before now + threshold.
+	// A negative value disables the check.
+	Threshold time.Duration
+}
+
+// Run the task.
+func (t *ExpiryTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if t.Within < 0 {
+		return errors.New("unable to run task with a negative reporting window")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to load bundle content: %w", err)
+	}
+
+	// Build expiry report
+	now := time.Now().UTC()
+	report, err := bundle.Expiry(b, now, t.Within)
+	if err != nil {
+		return fmt.Errorf("unable to compute secret expiry report: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Encode as JSON
+	if err := json.NewEncoder(writer).Encode(report); err != nil {
+		return fmt.Errorf("unable to marshal JSON expiry report: %w", err)
+	}
+
+	// Check threshold
+	if t.Threshold < 0 {
+		return nil
+	}
+	overdue, err := bundle.Expiry(b, now, t.Threshold)
+	if err != nil {
+		return fmt.Errorf("unable to compute secret expiry report: %w", err)
+	}
+	if len(overdue) > 0 {
+		return fmt.Errorf("%d secret(s) expired or expiring before %s", len(overdue), now.Add(t.Threshold).Format(time.RFC3339))
+	}
+
+	// No error
+	return nil
+}
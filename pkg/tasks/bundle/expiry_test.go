@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func expiringBundleReader(t *testing.T, expiresAt time.Time) tasks.ReaderProvider {
+	t.Helper()
+
+	p := &bundlev1.Package{
+		Name: "app/production/database",
+		Annotations: map[string]string{
+			bundle.OwnerAnnotation:     "team-db",
+			bundle.ExpiresAtAnnotation: expiresAt.UTC().Format(time.RFC3339),
+		},
+		Secrets: &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("secret")},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func TestExpiryTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		Within          time.Duration
+		Threshold       time.Duration
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative window",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          -time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "overdue",
+			fields: fields{
+				ContainerReader: expiringBundleReader(t, time.Now().Add(-time.Hour)),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          30 * 24 * time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "expiring before threshold",
+			fields: fields{
+				ContainerReader: expiringBundleReader(t, time.Now().Add(10*24*time.Hour)),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          30 * 24 * time.Hour,
+				Threshold:       15 * 24 * time.Hour,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          30 * 24 * time.Hour,
+			},
+			wantErr: false,
+		},
+		{
+			name: "expiring after threshold",
+			fields: fields{
+				ContainerReader: expiringBundleReader(t, time.Now().Add(10*24*time.Hour)),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          30 * 24 * time.Hour,
+				Threshold:       24 * time.Hour,
+			},
+			wantErr: false,
+		},
+		{
+			name: "overdue - threshold disabled",
+			fields: fields{
+				ContainerReader: expiringBundleReader(t, time.Now().Add(-time.Hour)),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Within:          30 * 24 * time.Hour,
+				Threshold:       -1,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &ExpiryTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				Within:          tt.fields.Within,
+				Threshold:       tt.fields.Threshold,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("ExpiryTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// HistoryTask implements secret version history listing task.
+type HistoryTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	PackageName     string
+}
+
+type packageHistory struct {
+	Name     string           `json:"name"`
+	Current  versionSummary   `json:"current"`
+	Versions []versionSummary `json:"versions,omitempty"`
+}
+
+type versionSummary struct {
+	Version         uint32            `json:"version"`
+	PreviousVersion *uint32           `json:"previous_version,omitempty"`
+	NextVersion     *uint32           `json:"next_version,omitempty"`
+	Locked          bool              `json:"locked"`
+	Keys            []string          `json:"keys,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// Run the task.
+func (t *HistoryTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to load bundle content: %w", err)
+	}
+
+	// Build history report
+	report := []packageHistory{}
+	for _, p := range b.Packages {
+		if p == nil || p.Secrets == nil {
+			continue
+		}
+		if t.PackageName != "" && p.Name != t.PackageName {
+			continue
+		}
+
+		item := packageHistory{
+			Name:     p.Name,
+			Current:  summarizeVersion(p.Secrets),
+			Versions: []versionSummary{},
+		}
+		for _, v := range bundle.History(p) {
+			item.Versions = append(item.Versions, summarizeVersion(v))
+		}
+
+		report = append(report, item)
+	}
+	if t.PackageName != "" && len(report) == 0 {
+		return fmt.Errorf("unable to lookup package %q", t.PackageName)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Encode as JSON
+	if err := json.NewEncoder(writer).Encode(report); err != nil {
+		return fmt.Errorf("unable to marshal JSON history: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func summarizeVersion(chain *bundlev1.SecretChain) versionSummary {
+	res := versionSummary{
+		Version:     chain.Version,
+		Locked:      chain.Locked != nil,
+		Annotations: chain.Annotations,
+		Keys:        []string{},
+	}
+	if chain.PreviousVersion != nil {
+		res.PreviousVersion = &chain.PreviousVersion.Value
+	}
+	if chain.NextVersion != nil {
+		res.NextVersion = &chain.NextVersion.Value
+	}
+	for _, kv := range chain.Data {
+		if kv == nil {
+			continue
+		}
+		res.Keys = append(res.Keys, kv.Key)
+	}
+	sort.Strings(res.Keys)
+
+	return res
+}
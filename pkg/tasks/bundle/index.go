@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// IndexTask implements indexed bundle container conversion task.
+type IndexTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	Transformer     value.Transformer
+}
+
+// Run the task.
+func (t *IndexTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if types.IsNil(t.Transformer) {
+		return errors.New("unable to run task with a nil transformer")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Read input bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input as bundle: %w", err)
+	}
+
+	// Build the indexed container
+	c, err := bundle.ToIndexedContainer(ctx, b, t.Transformer)
+	if err != nil {
+		return fmt.Errorf("unable to build indexed bundle container: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output bundle: %w", err)
+	}
+
+	// Dump container
+	if err := container.Dump(writer, c); err != nil {
+		return fmt.Errorf("unable to produce indexed bundle container: %w", err)
+	}
+
+	// No error
+	return nil
+}
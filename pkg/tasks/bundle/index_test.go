@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func indexedBundleReader(t *testing.T, transformer value.Transformer) tasks.ReaderProvider {
+	t.Helper()
+
+	var buf bytes.Buffer
+	it := &IndexTask{
+		ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+		OutputWriter: func(_ context.Context) (io.Writer, error) {
+			return &buf, nil
+		},
+		Transformer: transformer,
+	}
+	if err := it.Run(context.Background()); err != nil {
+		t.Fatalf("unable to prepare indexed bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func TestIndexTask_Run(t *testing.T) {
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		Transformer     value.Transformer
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil transformer",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Transformer:     transformer,
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader - not a bundle",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.json"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Transformer:     transformer,
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader - already indexed",
+			fields: fields{
+				ContainerReader: indexedBundleReader(t, transformer),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Transformer:     transformer,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				Transformer: transformer,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				Transformer: transformer,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Transformer:     transformer,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &IndexTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				Transformer:     tt.fields.Transformer,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("IndexTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -68,6 +68,11 @@ func (t *LintTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to load bundle content: %w", err)
 	}
 
+	// Check secret values against their declared type
+	if err := bundle.ValidateTypes(b); err != nil {
+		return fmt.Errorf("unable to validate secret types: %w", err)
+	}
+
 	if err := ruleset.Evaluate(ctx, b, spec); err != nil {
 		return fmt.Errorf("unable to validate given bundle: %w", err)
 	}
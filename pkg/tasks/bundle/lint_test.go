@@ -18,13 +18,45 @@
 package bundle
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"testing"
 
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
 	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
+func mistypedBundleReader(t *testing.T) tasks.ReaderProvider {
+	t.Helper()
+
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: kind.Password, Value: secret.MustPack(`{"user":"admin","password":"s3cr3t"}`)},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, b); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
 func TestLintTask_Run(t *testing.T) {
 	type fields struct {
 		ContainerReader tasks.ReaderProvider
@@ -100,6 +132,14 @@ func TestLintTask_Run(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "type violation",
+			fields: fields{
+				ContainerReader: mistypedBundleReader(t),
+				RuleSetReader:   cmdutil.FileReader("../../../test/fixtures/ruleset/valid/cso.yaml"),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
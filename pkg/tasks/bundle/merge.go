@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// MergeTask implements secret container merge task.
+type MergeTask struct {
+	ContainerReaders []tasks.ReaderProvider
+	OutputWriter     tasks.WriterProvider
+	ConflictWriter   tasks.WriterProvider
+	Strategy         string
+	Overrides        []string
+}
+
+// Run the task.
+func (t *MergeTask) Run(ctx context.Context) error {
+	// Check arguments
+	if len(t.ContainerReaders) < 2 {
+		return errors.New("unable to run task with less than 2 containerReader providers")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Prepare merge options
+	opts, err := mergeOptions(t.Strategy, t.Overrides)
+	if err != nil {
+		return err
+	}
+
+	// Load all bundles
+	bundles := make([]*bundlev1.Bundle, 0, len(t.ContainerReaders))
+	for i, readerProvider := range t.ContainerReaders {
+		if types.IsNil(readerProvider) {
+			return fmt.Errorf("unable to run task with a nil containerReader provider at index %d", i)
+		}
+
+		// Create input reader
+		reader, errReader := readerProvider(ctx)
+		if errReader != nil {
+			return fmt.Errorf("unable to open input bundle %d: %w", i, errReader)
+		}
+
+		// Load bundle
+		b, errLoad := bundle.FromContainerReader(reader)
+		if errLoad != nil {
+			return fmt.Errorf("unable to load bundle content %d: %w", i, errLoad)
+		}
+
+		bundles = append(bundles, b)
+	}
+
+	// Merge bundles
+	merged, conflicts, err := bundle.Merge(bundles, opts...)
+	if err != nil {
+		return fmt.Errorf("unable to merge bundles: %w", err)
+	}
+
+	// Write conflict report
+	if !types.IsNil(t.ConflictWriter) {
+		conflictWriter, errWriter := t.ConflictWriter(ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to open conflict report writer: %w", errWriter)
+		}
+
+		if errEncode := json.NewEncoder(conflictWriter).Encode(conflicts); errEncode != nil {
+			return fmt.Errorf("unable to marshal JSON conflict OpLog: %w", errEncode)
+		}
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output bundle: %w", err)
+	}
+
+	// Dump bundle
+	if err = bundle.ToContainerWriter(writer, merged); err != nil {
+		return fmt.Errorf("unable to produce merged bundle: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func mergeOptions(strategy string, overrides []string) ([]bundle.MergeOption, error) {
+	opts := []bundle.MergeOption{}
+
+	// Default strategy
+	if strategy != "" {
+		s, err := bundle.ParseMergeStrategy(strategy)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse default strategy: %w", err)
+		}
+		opts = append(opts, bundle.WithMergeStrategy(s))
+	}
+
+	// Overrides are expressed as '<package-glob>[#<key>]=<strategy>'
+	for _, o := range overrides {
+		idx := strings.LastIndex(o, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid strategy override %q, expected '<package>[#<key>]=<strategy>'", o)
+		}
+
+		s, err := bundle.ParseMergeStrategy(o[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse strategy override %q: %w", o, err)
+		}
+
+		target := o[:idx]
+		if pkgPattern, key, ok := strings.Cut(target, "#"); ok {
+			opts = append(opts, bundle.WithKeyMergeStrategy(pkgPattern, key, s))
+		} else {
+			opts = append(opts, bundle.WithPackageMergeStrategy(target, s))
+		}
+	}
+
+	// No error
+	return opts, nil
+}
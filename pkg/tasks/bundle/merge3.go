@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// ThreeWayMergeTask implements secret container three-way merge task.
+type ThreeWayMergeTask struct {
+	BaseReader   tasks.ReaderProvider
+	LocalReader  tasks.ReaderProvider
+	RemoteReader tasks.ReaderProvider
+	OutputWriter tasks.WriterProvider
+	ReportWriter tasks.WriterProvider
+	Strategy     string
+	Overrides    []string
+}
+
+// Run the task.
+//
+//nolint:gocyclo // To refactor
+func (t *ThreeWayMergeTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.BaseReader) {
+		return errors.New("unable to run task with a nil baseReader provider")
+	}
+	if types.IsNil(t.LocalReader) {
+		return errors.New("unable to run task with a nil localReader provider")
+	}
+	if types.IsNil(t.RemoteReader) {
+		return errors.New("unable to run task with a nil remoteReader provider")
+	}
+	if types.IsNil(t.OutputWriter) && types.IsNil(t.ReportWriter) {
+		return errors.New("unable to run task without outputWriter or reportWriter providers")
+	}
+
+	// Prepare merge options
+	opts, err := mergeOptions(t.Strategy, t.Overrides)
+	if err != nil {
+		return err
+	}
+
+	// Load bundles
+	base, err := loadBundle(ctx, t.BaseReader, "base")
+	if err != nil {
+		return err
+	}
+	local, err := loadBundle(ctx, t.LocalReader, "local")
+	if err != nil {
+		return err
+	}
+	remote, err := loadBundle(ctx, t.RemoteReader, "remote")
+	if err != nil {
+		return err
+	}
+
+	// Merge bundles
+	merged, report, errMerge := bundle.ThreeWayMerge(base, local, remote, opts...)
+	if errMerge != nil && !errors.Is(errMerge, bundle.ErrMergeConflict) {
+		return fmt.Errorf("unable to merge bundles: %w", errMerge)
+	}
+
+	// Write conflict report
+	if !types.IsNil(t.ReportWriter) {
+		reportWriter, errWriter := t.ReportWriter(ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to open conflict report writer: %w", errWriter)
+		}
+
+		if errEncode := json.NewEncoder(reportWriter).Encode(report); errEncode != nil {
+			return fmt.Errorf("unable to marshal JSON conflict report: %w", errEncode)
+		}
+	}
+
+	// Merged bundle not requested
+	if types.IsNil(t.OutputWriter) {
+		return nil
+	}
+
+	// Unresolved conflicts
+	if errMerge != nil {
+		return fmt.Errorf("unable to merge bundles: %w", errMerge)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output bundle: %w", err)
+	}
+
+	// Dump bundle
+	if err = bundle.ToContainerWriter(writer, merged); err != nil {
+		return fmt.Errorf("unable to produce merged bundle: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func loadBundle(ctx context.Context, readerProvider tasks.ReaderProvider, name string) (*bundlev1.Bundle, error) {
+	// Create input reader
+	reader, err := readerProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s bundle: %w", name, err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load %s bundle content: %w", name, err)
+	}
+
+	// No error
+	return b, nil
+}
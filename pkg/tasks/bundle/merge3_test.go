@@ -0,0 +1,144 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestThreeWayMergeTask_Run(t *testing.T) {
+	type fields struct {
+		BaseReader   tasks.ReaderProvider
+		LocalReader  tasks.ReaderProvider
+		RemoteReader tasks.ReaderProvider
+		OutputWriter tasks.WriterProvider
+		ReportWriter tasks.WriterProvider
+		Strategy     string
+		Overrides    []string
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil writers",
+			fields: fields{
+				BaseReader:   rotatedBundleReader(t),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: rotatedBundleReader(t),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid strategy",
+			fields: fields{
+				BaseReader:   rotatedBundleReader(t),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: rotatedBundleReader(t),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Strategy:     "unknown",
+			},
+			wantErr: true,
+		},
+		{
+			name: "baseReader error",
+			fields: fields{
+				BaseReader:   cmdutil.FileReader("non-existent.bundle"),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: rotatedBundleReader(t),
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unresolved conflict",
+			fields: fields{
+				BaseReader:   cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: conflictingBundleReader(t),
+				OutputWriter: cmdutil.DiscardWriter(),
+				ReportWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				BaseReader:   rotatedBundleReader(t),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: conflictingBundleReader(t),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "report only with unresolved conflict",
+			fields: fields{
+				BaseReader:   cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: conflictingBundleReader(t),
+				ReportWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid",
+			fields: fields{
+				BaseReader:   cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				LocalReader:  rotatedBundleReader(t),
+				RemoteReader: conflictingBundleReader(t),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Strategy:     "theirs",
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &ThreeWayMergeTask{
+				BaseReader:   tt.fields.BaseReader,
+				LocalReader:  tt.fields.LocalReader,
+				RemoteReader: tt.fields.RemoteReader,
+				OutputWriter: tt.fields.OutputWriter,
+				ReportWriter: tt.fields.ReportWriter,
+				Strategy:     tt.fields.Strategy,
+				Overrides:    tt.fields.Overrides,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("ThreeWayMergeTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func conflictingBundleReader(t *testing.T) tasks.ReaderProvider {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("conflicting")},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func TestMergeTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReaders []tasks.ReaderProvider
+		OutputWriter     tasks.WriterProvider
+		ConflictWriter   tasks.WriterProvider
+		Strategy         string
+		Overrides        []string
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "single container",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					rotatedBundleReader(t),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid strategy",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					rotatedBundleReader(t),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+				Strategy:     "unknown",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid override",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					rotatedBundleReader(t),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+				Overrides:    []string{"app/*"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					cmdutil.FileReader("non-existent.bundle"),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflict with fail strategy",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					rotatedBundleReader(t),
+					conflictingBundleReader(t),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+				Strategy:     "fail",
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflictWriter error",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					rotatedBundleReader(t),
+					conflictingBundleReader(t),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+				ConflictWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				Strategy: "ours",
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					rotatedBundleReader(t),
+				},
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+					rotatedBundleReader(t),
+				},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with overrides",
+			fields: fields{
+				ContainerReaders: []tasks.ReaderProvider{
+					rotatedBundleReader(t),
+					conflictingBundleReader(t),
+				},
+				OutputWriter:   cmdutil.DiscardWriter(),
+				ConflictWriter: cmdutil.DiscardWriter(),
+				Strategy:       "fail",
+				Overrides:      []string{"app/production/*#password=theirs"},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &MergeTask{
+				ContainerReaders: tt.fields.ContainerReaders,
+				OutputWriter:     tt.fields.OutputWriter,
+				ConflictWriter:   tt.fields.ConflictWriter,
+				Strategy:         tt.fields.Strategy,
+				Overrides:        tt.fields.Overrides,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("MergeTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
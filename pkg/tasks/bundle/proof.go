@@ -0,0 +1,205 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/security"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// ProofTask implements secret inclusion proof generation task.
+type ProofTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	PackageName     string
+	Key             string
+}
+
+// Run the task.
+func (t *ProofTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if t.PackageName == "" {
+		return errors.New("unable to run task with a blank package name")
+	}
+	if t.Key == "" {
+		return errors.New("unable to run task with a blank secret key")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to load bundle content: %w", err)
+	}
+
+	// Generate inclusion proof
+	proof, err := bundle.Prove(b, t.PackageName, t.Key)
+	if err != nil {
+		return fmt.Errorf("unable to generate inclusion proof: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Encode as JSON
+	if err := json.NewEncoder(writer).Encode(proof); err != nil {
+		return fmt.Errorf("unable to marshal JSON proof: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+// VerifyProofTask implements secret inclusion proof verification task.
+type VerifyProofTask struct {
+	ProofReader     tasks.ReaderProvider
+	ValueReader     tasks.ReaderProvider
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	Root            string
+}
+
+// Run the task.
+func (t *VerifyProofTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ProofReader) {
+		return errors.New("unable to run task with a nil proofReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Decode expected root
+	root, err := bundle.DecodeRoot(t.Root)
+	if err != nil {
+		return fmt.Errorf("unable to decode merkle tree root: %w", err)
+	}
+
+	// Create proof reader
+	reader, err := t.ProofReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open proof: %w", err)
+	}
+
+	// Decode proof
+	var proof bundle.InclusionProof
+	if err = json.NewDecoder(reader).Decode(&proof); err != nil {
+		return fmt.Errorf("unable to decode proof: %w", err)
+	}
+
+	// Verify inclusion
+	if err = proof.Verify(root); err != nil {
+		return fmt.Errorf("unable to verify inclusion proof: %w", err)
+	}
+
+	// Verify value if provided
+	if !types.IsNil(t.ValueReader) {
+		valueReader, errValue := t.ValueReader(ctx)
+		if errValue != nil {
+			return fmt.Errorf("unable to open secret value: %w", errValue)
+		}
+
+		value, errValue := io.ReadAll(valueReader)
+		if errValue != nil {
+			return fmt.Errorf("unable to read secret value: %w", errValue)
+		}
+
+		if errValue = proof.VerifyValue(string(value)); errValue != nil {
+			return fmt.Errorf("unable to verify secret value: %w", errValue)
+		}
+	}
+
+	// Verify bundle content if provided
+	if !types.IsNil(t.ContainerReader) {
+		if err = t.verifyBundle(ctx, &proof, root); err != nil {
+			return err
+		}
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Display result
+	if _, err = fmt.Fprintf(writer, "%s#%s (version %d) is included in bundle %x\n", proof.Package, proof.Key, proof.Version, root); err != nil {
+		return fmt.Errorf("unable to write verification result: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// verifyBundle checks that the bundle holds the proven secret value and
+// locates the proven secret divergence when the bundle is corrupted.
+func (t *VerifyProofTask) verifyBundle(ctx context.Context, proof *bundle.InclusionProof, root []byte) error {
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Load bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		var corruption *bundle.CorruptionError
+		if !errors.As(err, &corruption) {
+			return fmt.Errorf("unable to load bundle content: %w", err)
+		}
+
+		// Locate the proven secret divergence
+		if paths := corruption.Locate(proof); len(paths) > 0 {
+			return fmt.Errorf("bundle is corrupted, %s doesn't match the proven value: %w", strings.Join(paths, ", "), err)
+		}
+		return fmt.Errorf("bundle is corrupted, %s#%s matches the proven value: %w", proof.Package, proof.Key, err)
+	}
+
+	// Check bundle identity
+	if !security.SecureCompare(b.MerkleTreeRoot, root) {
+		return errors.New("bundle merkle tree root doesn't match the published root")
+	}
+
+	// No error
+	return nil
+}
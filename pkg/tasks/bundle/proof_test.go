@@ -0,0 +1,408 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestProofTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		PackageName     string
+		Key             string
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "blank packageName",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Key:             "password",
+			},
+			wantErr: true,
+		},
+		{
+			name: "blank key",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+				Key:             "password",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown key",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+				Key:             "unknown",
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				PackageName: "app/production/database",
+				Key:         "password",
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				PackageName: "app/production/database",
+				Key:         "password",
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+				Key:             "password",
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &ProofTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				PackageName:     tt.fields.PackageName,
+				Key:             tt.fields.Key,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("ProofTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyProofTask_Run(t *testing.T) {
+	// Prepare proof
+	var proof bytes.Buffer
+	if err := (&ProofTask{
+		ContainerReader: rotatedBundleReader(t),
+		OutputWriter: func(_ context.Context) (io.Writer, error) {
+			return &proof, nil
+		},
+		PackageName: "app/production/database",
+		Key:         "password",
+	}).Run(context.Background()); err != nil {
+		t.Fatalf("unable to prepare proof: %v", err)
+	}
+
+	// Compute published root
+	reader, _ := rotatedBundleReader(t)(context.Background())
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		t.Fatalf("unable to load bundle: %v", err)
+	}
+	root := hex.EncodeToString(b.MerkleTreeRoot)
+
+	type fields struct {
+		ProofReader     tasks.ReaderProvider
+		ValueReader     tasks.ReaderProvider
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		Root            string
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				OutputWriter: nil,
+				Root:         root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "blank root",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid proof",
+			fields: fields{
+				ProofReader:  stringReader("{"),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Root:         root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "root mismatch",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Root:         strings.Repeat("00", 64),
+			},
+			wantErr: true,
+		},
+		{
+			name: "value mismatch",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				ValueReader:  stringReader("initial"),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Root:         root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ProofReader:     stringReader(proof.String()),
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Root:            root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "bundle root mismatch",
+			fields: fields{
+				ProofReader:     stringReader(proof.String()),
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Root:            root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "corrupted bundle",
+			fields: fields{
+				ProofReader:     stringReader(proof.String()),
+				ContainerReader: tamperedBundleReader(t, func(p *bundlev1.Package) { p.Secrets.Data[0].Value = secret.MustPack("tampered") }),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Root:            root,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ProofReader: stringReader(proof.String()),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				Root: root,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Root:         root,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with bundle",
+			fields: fields{
+				ProofReader:     stringReader(proof.String()),
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				Root:            root,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with value",
+			fields: fields{
+				ProofReader:  stringReader(proof.String()),
+				ValueReader:  stringReader("rotated"),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Root:         root,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &VerifyProofTask{
+				ProofReader:     tt.fields.ProofReader,
+				ValueReader:     tt.fields.ValueReader,
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				Root:            tt.fields.Root,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyProofTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func tamperedBundleReader(t *testing.T, tamper func(p *bundlev1.Package)) tasks.ReaderProvider {
+	t.Helper()
+
+	// Extract bundle content
+	reader, _ := rotatedBundleReader(t)(context.Background())
+	c, err := container.Load(reader)
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(c.Raw))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	b := &bundlev1.Bundle{}
+	require.NoError(t, proto.Unmarshal(raw, b))
+
+	// Tamper content without updating the merkle tree root
+	tamper(b.Packages[0])
+	raw, err = proto.Marshal(b)
+	require.NoError(t, err)
+
+	var payload bytes.Buffer
+	zw := gzip.NewWriter(&payload)
+	_, err = zw.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	c.Raw = payload.Bytes()
+
+	var out bytes.Buffer
+	require.NoError(t, container.Dump(&out, c))
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(out.Bytes()), nil
+	}
+}
+
+func TestVerifyProofTask_Run_Corruption(t *testing.T) {
+	// Prepare proof
+	var proof bytes.Buffer
+	require.NoError(t, (&ProofTask{
+		ContainerReader: rotatedBundleReader(t),
+		OutputWriter: func(_ context.Context) (io.Writer, error) {
+			return &proof, nil
+		},
+		PackageName: "app/production/database",
+		Key:         "password",
+	}).Run(context.Background()))
+
+	var published bundle.InclusionProof
+	require.NoError(t, json.Unmarshal(proof.Bytes(), &published))
+
+	verify := func(tamper func(p *bundlev1.Package)) error {
+		return (&VerifyProofTask{
+			ProofReader:     stringReader(proof.String()),
+			ContainerReader: tamperedBundleReader(t, tamper),
+			OutputWriter:    cmdutil.DiscardWriter(),
+			Root:            published.Root,
+		}).Run(context.Background())
+	}
+
+	// Proven secret has been modified
+	err := verify(func(p *bundlev1.Package) {
+		p.Secrets.Data[0].Value = secret.MustPack("tampered")
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app/production/database#password doesn't match the proven value")
+
+	// Another secret version has been modified
+	err = verify(func(p *bundlev1.Package) {
+		for _, v := range p.Versions {
+			v.Data[0].Value = secret.MustPack("tampered")
+		}
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app/production/database#password matches the proven value")
+}
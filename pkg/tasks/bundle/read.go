@@ -23,8 +23,11 @@ import (
 	"errors"
 	"fmt"
 
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
 	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/container"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
@@ -34,6 +37,7 @@ type ReadTask struct {
 	OutputWriter    tasks.WriterProvider
 	PackageName     string
 	SecretKey       string
+	Transformers    []value.Transformer
 }
 
 // Run the task.
@@ -55,12 +59,19 @@ func (t *ReadTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to open input bundle: %w", err)
 	}
 
-	// Load bundle
-	b, err := bundle.FromContainerReader(reader)
+	// Load container
+	c, err := container.Load(reader)
 	if err != nil {
 		return fmt.Errorf("unable to load bundle content: %w", err)
 	}
 
+	// Decrypt the requested package only
+	p, err := bundle.OpenPackage(ctx, c, t.Transformers, t.PackageName)
+	if err != nil {
+		return fmt.Errorf("unable to read bundle content: %w", err)
+	}
+	b := &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}
+
 	// Read a secret from bundle
 	s, err := bundle.Read(b, t.PackageName)
 	if err != nil {
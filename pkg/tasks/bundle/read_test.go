@@ -24,15 +24,21 @@ import (
 	"testing"
 
 	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
 func TestReadTask_Run(t *testing.T) {
+	transformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	otherTransformer := encryption.Must(encryption.FromKey("aes-gcm:Ad2PSymVvDlBHGf0dMTvbw=="))
+
 	type fields struct {
 		ContainerReader tasks.ReaderProvider
 		OutputWriter    tasks.WriterProvider
 		PackageName     string
 		SecretKey       string
+		Transformers    []value.Transformer
 	}
 	type args struct {
 		ctx context.Context
@@ -104,6 +110,35 @@ func TestReadTask_Run(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "indexed - without transformers",
+			fields: fields{
+				ContainerReader: indexedBundleReader(t, transformer),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/customer1/ece/v1.0.0/adminconsole/database/usage_credentials",
+			},
+			wantErr: true,
+		},
+		{
+			name: "indexed - invalid transformer",
+			fields: fields{
+				ContainerReader: indexedBundleReader(t, transformer),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/customer1/ece/v1.0.0/adminconsole/database/usage_credentials",
+				Transformers:    []value.Transformer{otherTransformer},
+			},
+			wantErr: true,
+		},
+		{
+			name: "indexed - package not found",
+			fields: fields{
+				ContainerReader: indexedBundleReader(t, transformer),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "not-found",
+				Transformers:    []value.Transformer{transformer},
+			},
+			wantErr: true,
+		},
 		// ---------------------------------------------------------------------
 		{
 			name: "valid",
@@ -143,6 +178,17 @@ func TestReadTask_Run(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid indexed with secret key",
+			fields: fields{
+				ContainerReader: indexedBundleReader(t, transformer),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/customer1/ece/v1.0.0/adminconsole/database/usage_credentials",
+				SecretKey:       "host",
+				Transformers:    []value.Transformer{otherTransformer, transformer},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -151,6 +197,7 @@ func TestReadTask_Run(t *testing.T) {
 				OutputWriter:    tt.fields.OutputWriter,
 				PackageName:     tt.fields.PackageName,
 				SecretKey:       tt.fields.SecretKey,
+				Transformers:    tt.fields.Transformers,
 			}
 			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
 				t.Errorf("ReadTask.Run() error = %v, wantErr %v", err, tt.wantErr)
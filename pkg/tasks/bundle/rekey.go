@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// RekeyTask implements secret container encryption key rotation task.
+type RekeyTask struct {
+	ContainerReader   tasks.ReaderProvider
+	OutputWriter      tasks.WriterProvider
+	ReportWriter      tasks.WriterProvider
+	OldTransformers   []value.Transformer
+	NewTransformer    value.Transformer
+	OldTransformerMap map[string]value.Transformer
+	NewTransformerMap map[string]value.Transformer
+	SkipUnresolved    bool
+}
+
+// Run the task.
+func (t *RekeyTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if types.IsNil(t.ReportWriter) {
+		return errors.New("unable to run task with a nil reportWriter provider")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input bundle: %w", err)
+	}
+
+	// Read input bundle
+	b, err := bundle.FromContainerReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input as bundle: %w", err)
+	}
+
+	// Select appropriate rotation strategy.
+	var rotated []string
+	switch {
+	case !types.IsNil(t.NewTransformer):
+		// Rotate all encrypted packages
+		if rotated, err = bundle.Rekey(ctx, b, t.OldTransformers, t.NewTransformer); err != nil {
+			return fmt.Errorf("unable to rotate bundle encryption key: %w", err)
+		}
+	case len(t.NewTransformerMap) > 0:
+		// Rotate annotation based encrypted packages
+		if rotated, err = bundle.PartialRekey(ctx, b, t.OldTransformerMap, t.NewTransformerMap, t.SkipUnresolved); err != nil {
+			return fmt.Errorf("unable to rotate annotation based encryption keys: %w", err)
+		}
+	default:
+		return errors.New("invalid rotation strategy, can't determine if it's a full bundle or a selective annotation based key rotation")
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output bundle: %w", err)
+	}
+
+	// Dump bundle
+	if err = bundle.ToContainerWriter(writer, b); err != nil {
+		return fmt.Errorf("unable to produce rotated bundle: %w", err)
+	}
+
+	// Create report writer
+	reportWriter, err := t.ReportWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open report writer: %w", err)
+	}
+
+	// Display rotated packages
+	if err := json.NewEncoder(reportWriter).Encode(map[string]interface{}{
+		"rotated": rotated,
+	}); err != nil {
+		return fmt.Errorf("unable to display rotated packages: %w", err)
+	}
+
+	// No error
+	return nil
+}
@@ -0,0 +1,250 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func lockedBundleReader(t *testing.T, transformer value.Transformer) tasks.ReaderProvider {
+	t.Helper()
+
+	b := &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Annotations: map[string]string{
+					"harp.elastic.co/v1/package#encryptionKeyAlias": "production",
+				},
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: "string", Value: secret.MustPack("production")},
+					},
+				},
+			},
+		},
+	}
+	if err := bundle.Lock(context.Background(), b, transformer); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, b); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func TestRekeyTask_Run(t *testing.T) {
+	oldKey := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	newKey := encryption.Must(encryption.FromKey("aes-gcm:yMjsQzwRVVVPw3rlmj-Bxg=="))
+
+	type fields struct {
+		ContainerReader   tasks.ReaderProvider
+		OutputWriter      tasks.WriterProvider
+		ReportWriter      tasks.WriterProvider
+		OldTransformers   []value.Transformer
+		NewTransformer    value.Transformer
+		OldTransformerMap map[string]value.Transformer
+		NewTransformerMap map[string]value.Transformer
+		SkipUnresolved    bool
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				ReportWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil reportWriter",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ReportWriter:    cmdutil.DiscardWriter(),
+				OldTransformers: []value.Transformer{oldKey},
+				NewTransformer:  newKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no strategy",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ReportWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid old key",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ReportWriter:    cmdutil.DiscardWriter(),
+				OldTransformers: []value.Transformer{newKey},
+				NewTransformer:  newKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				ReportWriter:    cmdutil.DiscardWriter(),
+				OldTransformers: []value.Transformer{oldKey},
+				NewTransformer:  newKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				ReportWriter:    cmdutil.DiscardWriter(),
+				OldTransformers: []value.Transformer{oldKey},
+				NewTransformer:  newKey,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: lockedBundleReader(t, oldKey),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ReportWriter:    cmdutil.DiscardWriter(),
+				OldTransformers: []value.Transformer{oldKey},
+				NewTransformer:  newKey,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with key aliases",
+			fields: fields{
+				ContainerReader:   lockedBundleReader(t, oldKey),
+				OutputWriter:      cmdutil.DiscardWriter(),
+				ReportWriter:      cmdutil.DiscardWriter(),
+				OldTransformerMap: map[string]value.Transformer{"production": oldKey},
+				NewTransformerMap: map[string]value.Transformer{"production": newKey},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &RekeyTask{
+				ContainerReader:   tt.fields.ContainerReader,
+				OutputWriter:      tt.fields.OutputWriter,
+				ReportWriter:      tt.fields.ReportWriter,
+				OldTransformers:   tt.fields.OldTransformers,
+				NewTransformer:    tt.fields.NewTransformer,
+				OldTransformerMap: tt.fields.OldTransformerMap,
+				NewTransformerMap: tt.fields.NewTransformerMap,
+				SkipUnresolved:    tt.fields.SkipUnresolved,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("RekeyTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRekeyTask_Run_Report(t *testing.T) {
+	oldKey := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
+	newKey := encryption.Must(encryption.FromKey("aes-gcm:yMjsQzwRVVVPw3rlmj-Bxg=="))
+
+	var out, report bytes.Buffer
+	tr := &RekeyTask{
+		ContainerReader: lockedBundleReader(t, oldKey),
+		OutputWriter: func(_ context.Context) (io.Writer, error) {
+			return &out, nil
+		},
+		ReportWriter: func(_ context.Context) (io.Writer, error) {
+			return &report, nil
+		},
+		OldTransformers: []value.Transformer{oldKey},
+		NewTransformer:  newKey,
+	}
+	if err := tr.Run(context.Background()); err != nil {
+		t.Fatalf("RekeyTask.Run() error = %v", err)
+	}
+
+	var got struct {
+		Rotated []string `json:"rotated"`
+	}
+	if err := json.NewDecoder(&report).Decode(&got); err != nil {
+		t.Fatalf("unable to decode report: %v", err)
+	}
+	if len(got.Rotated) != 1 || got.Rotated[0] != "app/production/database" {
+		t.Errorf("unexpected rotated packages %v", got.Rotated)
+	}
+
+	// Decrypt with the new key
+	b, err := bundle.FromContainerReader(&out)
+	if err != nil {
+		t.Fatalf("unable to load rotated bundle: %v", err)
+	}
+	if err := bundle.UnLock(context.Background(), b, []value.Transformer{newKey}, false); err != nil {
+		t.Errorf("unable to decrypt with the new key: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func rotatedBundleReader(t *testing.T) tasks.ReaderProvider {
+	t.Helper()
+
+	p := &bundlev1.Package{
+		Name: "app/production/database",
+		Secrets: &bundlev1.SecretChain{
+			Data: []*bundlev1.KV{
+				{Key: "password", Type: "string", Value: secret.MustPack("rotated")},
+			},
+		},
+	}
+	if err := bundle.PushVersion(p, &bundlev1.SecretChain{
+		Data: []*bundlev1.KV{
+			{Key: "password", Type: "string", Value: secret.MustPack("initial")},
+		},
+	}); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, &bundlev1.Bundle{Packages: []*bundlev1.Package{p}}); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func TestRollbackTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		PackageName     string
+		Version         uint32
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "blank packageName",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+			},
+			wantErr: true,
+		},
+		{
+			name: "no history",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/testing/ece/v1.0.0/adminconsole/authentication/otp/okta_api_key",
+				Version:         0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				PackageName: "app/production/database",
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				PackageName: "app/production/database",
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: rotatedBundleReader(t),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PackageName:     "app/production/database",
+				Version:         0,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &RollbackTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				PackageName:     tt.fields.PackageName,
+				Version:         tt.fields.Version,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("RollbackTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
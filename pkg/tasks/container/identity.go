@@ -39,6 +39,8 @@ const (
 	LegacyIdentity IdentityVersion = 1
 	ModernIdentity IdentityVersion = 2
 	NISTIdentity   IdentityVersion = 3
+	// PostQuantumIdentity uses an Ed25519 / ML-KEM-768 hybrid key pair.
+	PostQuantumIdentity IdentityVersion = 4
 )
 
 // IdentityTask implements secret container identity creation task.
@@ -75,6 +77,8 @@ func (t *IdentityTask) Run(ctx context.Context) error {
 			generator = key.Ed25519
 		case NISTIdentity:
 			generator = key.P384
+		case PostQuantumIdentity:
+			generator = key.Ed25519MLKEM768
 		default:
 			return fmt.Errorf("invalid or unsupported identity version '%d'", t.Version)
 		}
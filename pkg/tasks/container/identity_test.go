@@ -135,6 +135,16 @@ func TestIdentityTask_Run(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid - post-quantum",
+			fields: fields{
+				OutputWriter: cmdutil.DiscardWriter(),
+				Description:  "test",
+				Transformer:  identity.Transformer(),
+				Version:      PostQuantumIdentity,
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid - v3",
 			fields: fields{
@@ -0,0 +1,200 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/container/identity"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// InspectTask implements secret container inspection task.
+type InspectTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	ContainerKeys   []*memguard.LockedBuffer
+	IdentityReaders []tasks.ReaderProvider
+	Transformer     value.Transformer
+	PreSharedKey    *memguard.LockedBuffer
+}
+
+type containerReport struct {
+	Sealed          bool        `json:"sealed"`
+	SealVersion     uint32      `json:"seal_version,omitempty"`
+	ContentType     string      `json:"content_type"`
+	ContentEncoding string      `json:"content_encoding,omitempty"`
+	RecipientCount  int         `json:"recipient_count"`
+	Recipients      []string    `json:"recipients,omitempty"`
+	PreSharedKey    *bool       `json:"pre_shared_key"`
+	Keys            []keyReport `json:"keys,omitempty"`
+}
+
+type keyReport struct {
+	Name      string `json:"name"`
+	Recipient bool   `json:"recipient"`
+}
+
+// Run the task.
+func (t *InspectTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if len(t.IdentityReaders) > 0 && types.IsNil(t.Transformer) {
+		return errors.New("unable to run task with a nil transformer")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input reader: %w", err)
+	}
+
+	// Load input container
+	in, err := container.Load(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+
+	// Prepare report
+	report := &containerReport{
+		SealVersion:     container.SealVersion(in),
+		ContentType:     in.Headers.ContentType,
+		ContentEncoding: in.Headers.ContentEncoding,
+		RecipientCount:  len(in.Headers.Recipients),
+	}
+	report.Sealed = report.SealVersion != 0
+	for _, r := range in.Headers.Recipients {
+		report.Recipients = append(report.Recipients, hex.EncodeToString(r.Identifier))
+	}
+
+	// Collect keys to check
+	names := []string{}
+	keys := []*memguard.LockedBuffer{}
+	for i, k := range t.ContainerKeys {
+		names = append(names, fmt.Sprintf("key #%d", i+1))
+		keys = append(keys, k)
+	}
+	for _, identityReader := range t.IdentityReaders {
+		name, k, errIdentity := recoverIdentityKey(ctx, identityReader, t.Transformer)
+		if errIdentity != nil {
+			return errIdentity
+		}
+		names = append(names, name)
+		keys = append(keys, k)
+	}
+
+	// Check recipients
+	if len(keys) > 0 {
+		if !report.Sealed {
+			return errors.New("unable to check recipients of an unsealed container")
+		}
+
+		pskOpts, errOpts := recipientOptions(t.PreSharedKey)
+		if errOpts != nil {
+			return errOpts
+		}
+
+		for i, k := range keys {
+			// Check without pre-shared key
+			ok, errCheck := container.IsRecipient(in, k)
+			if errCheck != nil {
+				return fmt.Errorf("unable to check %q: %w", names[i], errCheck)
+			}
+			if ok {
+				pskUsed := false
+				report.PreSharedKey = &pskUsed
+			}
+
+			// Check with pre-shared key
+			if !ok && len(pskOpts) > 0 {
+				ok, errCheck = container.IsRecipient(in, k, pskOpts...)
+				if errCheck != nil {
+					return fmt.Errorf("unable to check %q: %w", names[i], errCheck)
+				}
+				if ok && report.PreSharedKey == nil {
+					pskUsed := true
+					report.PreSharedKey = &pskUsed
+				}
+			}
+
+			report.Keys = append(report.Keys, keyReport{
+				Name:      names[i],
+				Recipient: ok,
+			})
+		}
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Display as json
+	if err := json.NewEncoder(writer).Encode(report); err != nil {
+		return fmt.Errorf("unable to display as json: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func recoverIdentityKey(ctx context.Context, identityReader tasks.ReaderProvider, transformer value.Transformer) (string, *memguard.LockedBuffer, error) {
+	// Create input reader
+	reader, err := identityReader(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to open identity reader: %w", err)
+	}
+
+	// Extract from reader
+	input, err := identity.FromReader(reader)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to extract an identity from reader: %w", err)
+	}
+
+	// Try to decrypt the private key
+	privateKey, err := input.Decrypt(ctx, transformer)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decrypt private key of identity %q: %w", input.Public, err)
+	}
+
+	// Retrieve recovery key
+	recoveryPrivateKey, err := privateKey.RecoveryKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to retrieve recovery key from identity %q: %w", input.Public, err)
+	}
+
+	// No error
+	return input.Public, memguard.NewBufferFromBytes([]byte(recoveryPrivateKey)), nil
+}
@@ -0,0 +1,252 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/mock"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestInspectTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		ContainerKeys   []*memguard.LockedBuffer
+		IdentityReaders []tasks.ReaderProvider
+		Transformer     value.Transformer
+		PreSharedKey    *memguard.LockedBuffer
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil transformer",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				IdentityReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/identity/security.v1.json"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader not a container",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.json"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "identityReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				IdentityReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("non-existent.json"),
+				},
+				Transformer: encryption.Must(encryption.FromKey("jwe:pbes2-hs512-a256kw:test")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "transformer error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				IdentityReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/identity/security.v1.json"),
+				},
+				Transformer: mock.Transformer(errors.New("test")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "keys with unsealed container",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid pre-shared key",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+				},
+				PreSharedKey: memguard.NewBufferFromBytes([]byte("!!!")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid - unsealed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - v2",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+					memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - v3",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v3.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v3.ck.qxZZv_0iKkh5b-gxAY_fINd2BonOhMBKM64Ld6wyXl4U-0APTDTN6g2A9EAo7wbclkXgjPo_tf8TYPZW2dnjFKOSLbT-wp_RwoQjTs66QrIw0m7HEqDkre_rV81x5-Vv")),
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &InspectTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				ContainerKeys:   tt.fields.ContainerKeys,
+				IdentityReaders: tt.fields.IdentityReaders,
+				Transformer:     tt.fields.Transformer,
+				PreSharedKey:    tt.fields.PreSharedKey,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("InspectTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInspectTask_Report(t *testing.T) {
+	var out bytes.Buffer
+	tr := &InspectTask{
+		ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+		OutputWriter: func(ctx context.Context) (io.Writer, error) {
+			return &out, nil
+		},
+		ContainerKeys: []*memguard.LockedBuffer{
+			memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+			memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+		},
+		IdentityReaders: []tasks.ReaderProvider{
+			cmdutil.FileReader("../../../test/fixtures/identity/security.v2.json"),
+		},
+		Transformer: encryption.Must(encryption.FromKey("jwe:pbes2-hs512-a256kw:test")),
+	}
+	if err := tr.Run(context.Background()); err != nil {
+		t.Fatalf("unable to inspect container: %v", err)
+	}
+
+	var report containerReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("unable to decode report: %v", err)
+	}
+
+	pskUsed := false
+	expected := containerReport{
+		Sealed:         true,
+		SealVersion:    2,
+		ContentType:    "application/vnd.harp.v1.SealedContainer",
+		RecipientCount: 2,
+		Recipients: []string{
+			"8d63d6b24b4b6cddcf9d349aee5d42fca196e56e21892f25bc6a33d0fe7c92d8",
+			"4534596479bb487a5c516f9b0ebdc9485171f6d4827cda22b511f4201a788d6e",
+		},
+		PreSharedKey: &pskUsed,
+		Keys: []keyReport{
+			{Name: "key #1", Recipient: true},
+			{Name: "key #2", Recipient: false},
+			{Name: "v2.ipk.AkLr_HHMO5Loy2bK42mvCADrJ7s2PSYCRTnqDWJV8PCK2EXmu-GTV8HmNJwmA8IJ8Q", Recipient: true},
+		},
+	}
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("InspectTask.Run() report\n-want/+got\ndiff %s", diff)
+	}
+}
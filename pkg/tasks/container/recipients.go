@@ -0,0 +1,244 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// RecipientsListTask implements sealed container recipient listing task.
+type RecipientsListTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+}
+
+// Run the task.
+func (t *RecipientsListTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input reader: %w", err)
+	}
+
+	// Load input container
+	in, err := container.Load(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+	if len(in.Headers.Recipients) == 0 {
+		return errors.New("the container doesn't have recipients")
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Display recipient identifiers
+	for _, r := range in.Headers.Recipients {
+		if _, err := fmt.Fprintln(writer, hex.EncodeToString(r.Identifier)); err != nil {
+			return fmt.Errorf("unable to write recipient identifier: %w", err)
+		}
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+// RecipientsAddTask implements sealed container recipient addition task.
+type RecipientsAddTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	ContainerKey    *memguard.LockedBuffer
+	PreSharedKey    *memguard.LockedBuffer
+	PeerPublicKeys  []string
+}
+
+// Run the task.
+func (t *RecipientsAddTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if t.ContainerKey == nil {
+		return errors.New("unable to run task with a nil container key")
+	}
+	if len(t.PeerPublicKeys) == 0 {
+		return errors.New("at least one public key must be provided")
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input reader: %w", err)
+	}
+
+	// Load input container
+	in, err := container.Load(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+
+	// Prepare options
+	sopts, err := recipientOptions(t.PreSharedKey)
+	if err != nil {
+		return err
+	}
+	sopts = append(sopts, container.WithPeerPublicKeys(t.PeerPublicKeys))
+
+	// Add recipients
+	out, err := container.AddRecipients(rand.Reader, in, t.ContainerKey, sopts...)
+	if err != nil {
+		return fmt.Errorf("unable to add container recipients: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Dump to writer
+	if err := container.Dump(writer, out); err != nil {
+		return fmt.Errorf("unable to write sealed container: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+// RecipientsRemoveTask implements sealed container recipient removal task.
+type RecipientsRemoveTask struct {
+	ContainerReader tasks.ReaderProvider
+	OutputWriter    tasks.WriterProvider
+	ContainerKey    *memguard.LockedBuffer
+	PreSharedKey    *memguard.LockedBuffer
+	Identifiers     []string
+}
+
+// Run the task.
+func (t *RecipientsRemoveTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.ContainerReader) {
+		return errors.New("unable to run task with a nil containerReader provider")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if t.ContainerKey == nil {
+		return errors.New("unable to run task with a nil container key")
+	}
+	if len(t.Identifiers) == 0 {
+		return errors.New("at least one recipient identifier must be provided")
+	}
+
+	// Decode identifiers
+	identifiers := make([][]byte, 0, len(t.Identifiers))
+	for _, id := range t.Identifiers {
+		raw, err := hex.DecodeString(id)
+		if err != nil {
+			return fmt.Errorf("unable to decode recipient identifier %q: %w", id, err)
+		}
+		identifiers = append(identifiers, raw)
+	}
+
+	// Create input reader
+	reader, err := t.ContainerReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open input reader: %w", err)
+	}
+
+	// Load input container
+	in, err := container.Load(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+
+	// Prepare options
+	sopts, err := recipientOptions(t.PreSharedKey)
+	if err != nil {
+		return err
+	}
+
+	// Remove recipients
+	out, err := container.RemoveRecipients(rand.Reader, in, t.ContainerKey, identifiers, sopts...)
+	if err != nil {
+		return fmt.Errorf("unable to remove container recipients: %w", err)
+	}
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Dump to writer
+	if err := container.Dump(writer, out); err != nil {
+		return fmt.Errorf("unable to write sealed container: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func recipientOptions(preSharedKey *memguard.LockedBuffer) ([]container.Option, error) {
+	sopts := []container.Option{}
+
+	// Process pre-shared key
+	if preSharedKey != nil {
+		// Try to decode preshared key
+		psk, errDecode := base64.RawURLEncoding.DecodeString(preSharedKey.String())
+		if errDecode != nil {
+			return nil, fmt.Errorf("unable to decode pre-shared key: %w", errDecode)
+		}
+		sopts = append(sopts, container.WithPreSharedKey(memguard.NewBufferFromBytes(psk)))
+		preSharedKey.Destroy()
+	}
+
+	// No error
+	return sopts, nil
+}
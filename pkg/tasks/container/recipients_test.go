@@ -0,0 +1,273 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/awnumar/memguard"
+
+	sealv1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestRecipientsListTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsealed container",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &RecipientsListTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("RecipientsListTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecipientsAddTask_Run(t *testing.T) {
+	publicKey, _, err := sealv1.New().GenerateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		ContainerKey    *memguard.LockedBuffer
+		PeerPublicKeys  []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil containerKey",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				PeerPublicKeys:  []string{publicKey},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty public keys",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid container key",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBuffer(32),
+				PeerPublicKeys:  []string{publicKey},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mismatched key version",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				PeerPublicKeys:  []string{publicKey},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				ContainerKey:   memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+				PeerPublicKeys: []string{publicKey},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v1.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v1.ck.MiVGh4KOmdzZbej17BZGChkCPZ9uK9uBWdPNU0GlBNg")),
+				PeerPublicKeys:  []string{publicKey},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &RecipientsAddTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				ContainerKey:    tt.fields.ContainerKey,
+				PeerPublicKeys:  tt.fields.PeerPublicKeys,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("RecipientsAddTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecipientsRemoveTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		ContainerKey    *memguard.LockedBuffer
+		Identifiers     []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "empty identifiers",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid identifier encoding",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				Identifiers:     []string{"not-hex"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown identifier",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				Identifiers:     []string{"0102030405"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "all recipients",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				Identifiers: []string{
+					"8d63d6b24b4b6cddcf9d349aee5d42fca196e56e21892f25bc6a33d0fe7c92d8",
+					"4534596479bb487a5c516f9b0ebdc9485171f6d4827cda22b511f4201a788d6e",
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				Identifiers:     []string{"4534596479bb487a5c516f9b0ebdc9485171f6d4827cda22b511f4201a788d6e"},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &RecipientsRemoveTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				ContainerKey:    tt.fields.ContainerKey,
+				Identifiers:     tt.fields.Identifiers,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("RecipientsRemoveTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -27,11 +27,18 @@ import (
 
 	"github.com/awnumar/memguard"
 
+	containerv1 "github.com/zntrio/harp/v2/api/gen/go/harp/container/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
 	"github.com/zntrio/harp/v2/pkg/container"
 	"github.com/zntrio/harp/v2/pkg/container/seal"
 	sealv1 "github.com/zntrio/harp/v2/pkg/container/seal/v1"
 	sealv2 "github.com/zntrio/harp/v2/pkg/container/seal/v2"
+	sealv3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
+	sealv4 "github.com/zntrio/harp/v2/pkg/container/seal/v4"
+	sealv5 "github.com/zntrio/harp/v2/pkg/container/seal/v5"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
 	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
@@ -47,6 +54,9 @@ type SealTask struct {
 	DisableContainerIdentity bool
 	SealVersion              uint
 	PreSharedKey             *memguard.LockedBuffer
+	SkipTypeValidation       bool
+	IndexTransformers        []value.Transformer
+	Threshold                uint
 }
 
 // Run the task.
@@ -67,16 +77,49 @@ func (t *SealTask) Run(ctx context.Context) error {
 		return errors.New("at least one public key must be provided for recovery")
 	}
 
+	// Threshold sealing uses the v5 strategy with v2 keys
+	if t.SealVersion == sealv5.SealVersion && t.Threshold == 0 {
+		return errors.New("threshold seal version requires a threshold")
+	}
+	if t.Threshold > 0 {
+		if t.SealVersion != sealv2.SealVersion && t.SealVersion != sealv5.SealVersion {
+			return fmt.Errorf("threshold sealing is not supported by seal version %d", t.SealVersion)
+		}
+
+		// A container key would only hold a single share
+		t.DisableContainerIdentity = true
+	}
+
 	// Create input reader
 	reader, err := t.ContainerReader(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to open input reader: %w", err)
 	}
 
+	// Streaming seal doesn't load the input container in memory
+	streaming := t.SealVersion == sealv4.SealVersion
+	if streaming && !t.SkipTypeValidation {
+		log.For(ctx).Warn("bundle secret type validation is skipped by the streaming seal")
+	}
+
 	// Load input container
-	in, err := container.Load(reader)
-	if err != nil {
-		return fmt.Errorf("unable to read input container: %w", err)
+	var in *containerv1.Container
+	if !streaming {
+		in, err = container.Load(reader)
+		if err != nil {
+			return fmt.Errorf("unable to read input container: %w", err)
+		}
+	}
+
+	// Check bundle secret values against their declared type
+	if !t.SkipTypeValidation && !streaming && (bundle.IsBundleContainer(in) || bundle.IsIndexedBundleContainer(in)) {
+		b, errLoad := bundle.OpenContainer(ctx, in, t.IndexTransformers)
+		if errLoad != nil {
+			return fmt.Errorf("unable to load bundle content: %w", errLoad)
+		}
+		if errValidate := bundle.ValidateTypes(b); errValidate != nil {
+			return fmt.Errorf("unable to validate secret types: %w", errValidate)
+		}
 	}
 
 	var containerKey string
@@ -107,6 +150,10 @@ func (t *SealTask) Run(ctx context.Context) error {
 			ss = sealv1.New()
 		case 2:
 			ss = sealv2.New()
+		case sealv3.SealVersion:
+			ss = sealv3.New()
+		case sealv4.SealVersion:
+			ss = sealv4.New()
 		default:
 			ss = sealv1.New()
 		}
@@ -128,6 +175,9 @@ func (t *SealTask) Run(ctx context.Context) error {
 	sopts := []container.Option{
 		container.WithPeerPublicKeys(t.PeerPublicKeys),
 	}
+	if t.Threshold > 0 {
+		sopts = append(sopts, container.WithThreshold(int(t.Threshold)))
+	}
 
 	// Process pre-shared key
 	if t.PreSharedKey != nil {
@@ -140,21 +190,34 @@ func (t *SealTask) Run(ctx context.Context) error {
 		t.PreSharedKey.Destroy()
 	}
 
-	// Seal the container
-	sealedContainer, err := container.Seal(rand.Reader, in, sopts...)
-	if err != nil {
-		return fmt.Errorf("unable to seal container: %w", err)
-	}
+	if streaming {
+		// Open output file
+		writer, errWriter := t.SealedContainerWriter(ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to create output bundle: %w", errWriter)
+		}
 
-	// Open output file
-	writer, err := t.SealedContainerWriter(ctx)
-	if err != nil {
-		return fmt.Errorf("unable to create output bundle: %w", err)
-	}
+		// Seal the container by chunks
+		if err = container.SealStream(rand.Reader, writer, reader, sopts...); err != nil {
+			return fmt.Errorf("unable to seal container: %w", err)
+		}
+	} else {
+		// Seal the container
+		sealedContainer, errSeal := container.Seal(rand.Reader, in, sopts...)
+		if errSeal != nil {
+			return fmt.Errorf("unable to seal container: %w", errSeal)
+		}
 
-	// Dump to writer
-	if err = container.Dump(writer, sealedContainer); err != nil {
-		return fmt.Errorf("unable to write sealed container: %w", err)
+		// Open output file
+		writer, errWriter := t.SealedContainerWriter(ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to create output bundle: %w", errWriter)
+		}
+
+		// Dump to writer
+		if err = container.Dump(writer, sealedContainer); err != nil {
+			return fmt.Errorf("unable to write sealed container: %w", err)
+		}
 	}
 
 	if !t.DisableContainerIdentity {
@@ -18,6 +18,7 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -26,12 +27,69 @@ import (
 	"github.com/awnumar/memguard"
 	fuzz "github.com/google/gofuzz"
 
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/bundle/kind"
+	"github.com/zntrio/harp/v2/pkg/bundle/secret"
+	"github.com/zntrio/harp/v2/pkg/container"
+	"github.com/zntrio/harp/v2/pkg/container/seal"
+	sealv3 "github.com/zntrio/harp/v2/pkg/container/seal/v3"
 	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+	"github.com/zntrio/harp/v2/pkg/sdk/value/encryption"
+	_ "github.com/zntrio/harp/v2/pkg/sdk/value/encryption/aead"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 )
 
+func mistypedBundle() *bundlev1.Bundle {
+	return &bundlev1.Bundle{
+		Packages: []*bundlev1.Package{
+			{
+				Name: "app/production/database",
+				Secrets: &bundlev1.SecretChain{
+					Data: []*bundlev1.KV{
+						{Key: "password", Type: kind.Password, Value: secret.MustPack(`{"user":"admin","password":"s3cr3t"}`)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func mistypedBundleReader(t *testing.T) tasks.ReaderProvider {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := bundle.ToContainerWriter(&buf, mistypedBundle()); err != nil {
+		t.Fatalf("unable to prepare bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
+func mistypedIndexedBundleReader(t *testing.T, transformer value.Transformer) tasks.ReaderProvider {
+	t.Helper()
+
+	c, err := bundle.ToIndexedContainer(context.Background(), mistypedBundle(), transformer)
+	if err != nil {
+		t.Fatalf("unable to prepare indexed bundle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := container.Dump(&buf, c); err != nil {
+		t.Fatalf("unable to dump indexed bundle: %v", err)
+	}
+
+	return func(_ context.Context) (io.Reader, error) {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+}
+
 func TestSealTask_Run_V1(t *testing.T) {
 	pub := "v1.sk.qKXPnUP6-2Bb_4nYnmxOXyCdN4IV3AR5HooB33N3g2E"
+	indexTransformer := encryption.Must(encryption.FromKey("aes-gcm:5OSpiJUr_XS2M1_vvTBeGg=="))
 
 	type fields struct {
 		ContainerReader          tasks.ReaderProvider
@@ -43,6 +101,8 @@ func TestSealTask_Run_V1(t *testing.T) {
 		JSONOutput               bool
 		DisableContainerIdentity bool
 		PreSharedKey             *memguard.LockedBuffer
+		SkipTypeValidation       bool
+		IndexTransformers        []value.Transformer
 	}
 	type args struct {
 		ctx context.Context
@@ -135,6 +195,37 @@ func TestSealTask_Run_V1(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "mistyped secret",
+			fields: fields{
+				ContainerReader:       mistypedBundleReader(t),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pub},
+			},
+			wantErr: true,
+		},
+		{
+			name: "indexed bundle without index key",
+			fields: fields{
+				ContainerReader:       mistypedIndexedBundleReader(t, indexTransformer),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pub},
+			},
+			wantErr: true,
+		},
+		{
+			name: "indexed bundle with mistyped secret",
+			fields: fields{
+				ContainerReader:       mistypedIndexedBundleReader(t, indexTransformer),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pub},
+				IndexTransformers:     []value.Transformer{indexTransformer},
+			},
+			wantErr: true,
+		},
 		// ---------------------------------------------------------------------
 		{
 			name: "valid",
@@ -157,6 +248,17 @@ func TestSealTask_Run_V1(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "mistyped secret without type validation",
+			fields: fields{
+				ContainerReader:       mistypedBundleReader(t),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pub},
+				SkipTypeValidation:    true,
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -171,6 +273,8 @@ func TestSealTask_Run_V1(t *testing.T) {
 				DisableContainerIdentity: tt.fields.DisableContainerIdentity,
 				SealVersion:              1,
 				PreSharedKey:             tt.fields.PreSharedKey,
+				SkipTypeValidation:       tt.fields.SkipTypeValidation,
+				IndexTransformers:        tt.fields.IndexTransformers,
 			}
 			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
 				t.Errorf("SealTask.Run() error = %v, wantErr %v", err, tt.wantErr)
@@ -328,6 +432,419 @@ func TestSealTask_Run_V2(t *testing.T) {
 	}
 }
 
+func TestSealTask_Run_V3(t *testing.T) {
+	pk, _, err := sealv3.New().GenerateKey(seal.WithRandom(bytes.NewReader(bytes.Repeat([]byte("deterministic-seed-for-test-0001"), 3))))
+	if err != nil {
+		t.Fatalf("unable to generate v3 key: %v", err)
+	}
+
+	type fields struct {
+		ContainerReader          tasks.ReaderProvider
+		SealedContainerWriter    tasks.WriterProvider
+		OutputWriter             tasks.WriterProvider
+		PeerPublicKeys           []string
+		DCKDMasterKey            string
+		DCKDTarget               string
+		JSONOutput               bool
+		DisableContainerIdentity bool
+		PreSharedKey             *memguard.LockedBuffer
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil containerReader",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil sealedContainerWriter",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no public keys",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("non-existent.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader not a bundle",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.json"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sealedContainerWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				OutputWriter:   cmdutil.DiscardWriter(),
+				PeerPublicKeys: []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sealedContainerWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				OutputWriter:   cmdutil.DiscardWriter(),
+				PeerPublicKeys: []string{pk},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with psk",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+				PreSharedKey:          memguard.NewBufferFromBytes([]byte("Kw6tb0QWUH3vueG5uCvS6lAnUa00a5-lsM2aqOZk3MFvoDTUUyhjIdb6ZAG7eQt3LJ1QnJQQAZBLVGXQkx33kg")),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &SealTask{
+				ContainerReader:          tt.fields.ContainerReader,
+				SealedContainerWriter:    tt.fields.SealedContainerWriter,
+				OutputWriter:             tt.fields.OutputWriter,
+				PeerPublicKeys:           tt.fields.PeerPublicKeys,
+				DCKDMasterKey:            tt.fields.DCKDMasterKey,
+				DCKDTarget:               tt.fields.DCKDTarget,
+				JSONOutput:               tt.fields.JSONOutput,
+				DisableContainerIdentity: tt.fields.DisableContainerIdentity,
+				SealVersion:              3,
+				PreSharedKey:             tt.fields.PreSharedKey,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("SealTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealTask_Run_V4(t *testing.T) {
+	pk := "v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ"
+
+	type fields struct {
+		ContainerReader          tasks.ReaderProvider
+		SealedContainerWriter    tasks.WriterProvider
+		OutputWriter             tasks.WriterProvider
+		PeerPublicKeys           []string
+		DCKDMasterKey            string
+		DCKDTarget               string
+		JSONOutput               bool
+		DisableContainerIdentity bool
+		PreSharedKey             *memguard.LockedBuffer
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil containerReader",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil sealedContainerWriter",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no public keys",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("non-existent.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader not a bundle",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.json"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sealedContainerWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				OutputWriter:   cmdutil.DiscardWriter(),
+				PeerPublicKeys: []string{pk},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sealedContainerWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				OutputWriter:   cmdutil.DiscardWriter(),
+				PeerPublicKeys: []string{pk},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with psk",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        []string{pk},
+				PreSharedKey:          memguard.NewBufferFromBytes([]byte("Kw6tb0QWUH3vueG5uCvS6lAnUa00a5-lsM2aqOZk3MFvoDTUUyhjIdb6ZAG7eQt3LJ1QnJQQAZBLVGXQkx33kg")),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &SealTask{
+				ContainerReader:          tt.fields.ContainerReader,
+				SealedContainerWriter:    tt.fields.SealedContainerWriter,
+				OutputWriter:             tt.fields.OutputWriter,
+				PeerPublicKeys:           tt.fields.PeerPublicKeys,
+				DCKDMasterKey:            tt.fields.DCKDMasterKey,
+				DCKDTarget:               tt.fields.DCKDTarget,
+				JSONOutput:               tt.fields.JSONOutput,
+				DisableContainerIdentity: tt.fields.DisableContainerIdentity,
+				SealVersion:              4,
+				PreSharedKey:             tt.fields.PreSharedKey,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("SealTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealTask_Run_Threshold(t *testing.T) {
+	pks := []string{
+		"v2.sk.A0V1xCxGNtVAE9EVhaKi-pIADhd1in8xV_FI5Y0oHSHLAkew9gDAqiALSd6VgvBCbQ",
+		"v2.sk.AuSjVpMZben6n9fXiaDj8bMjSvhcZ9n7c82VOt7v9_UBzZJaMLamkQUFAVp_9frpAg",
+	}
+
+	type fields struct {
+		ContainerReader       tasks.ReaderProvider
+		SealedContainerWriter tasks.WriterProvider
+		OutputWriter          tasks.WriterProvider
+		PeerPublicKeys        []string
+		SealVersion           uint
+		Threshold             uint
+		PreSharedKey          *memguard.LockedBuffer
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name: "threshold seal version without threshold",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported seal version",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           1,
+				Threshold:             2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "threshold exceeds recipients",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           2,
+				Threshold:             3,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           2,
+				Threshold:             2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - threshold seal version",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           5,
+				Threshold:             2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with psk",
+			fields: fields{
+				ContainerReader:       cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
+				SealedContainerWriter: cmdutil.DiscardWriter(),
+				OutputWriter:          cmdutil.DiscardWriter(),
+				PeerPublicKeys:        pks,
+				SealVersion:           2,
+				Threshold:             2,
+				PreSharedKey:          memguard.NewBufferFromBytes([]byte("Kw6tb0QWUH3vueG5uCvS6lAnUa00a5-lsM2aqOZk3MFvoDTUUyhjIdb6ZAG7eQt3LJ1QnJQQAZBLVGXQkx33kg")),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &SealTask{
+				ContainerReader:       tt.fields.ContainerReader,
+				SealedContainerWriter: tt.fields.SealedContainerWriter,
+				OutputWriter:          tt.fields.OutputWriter,
+				PeerPublicKeys:        append([]string{}, tt.fields.PeerPublicKeys...),
+				SealVersion:           tt.fields.SealVersion,
+				Threshold:             tt.fields.Threshold,
+				PreSharedKey:          tt.fields.PreSharedKey,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("SealTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSealTask_Fuzz(t *testing.T) {
 	tsk := &SealTask{
 		ContainerReader:          cmdutil.FileReader("../../../test/fixtures/bundles/complete.bundle"),
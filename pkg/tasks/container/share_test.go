@@ -0,0 +1,185 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestExportShareTask_Run(t *testing.T) {
+	type fields struct {
+		ContainerReader tasks.ReaderProvider
+		OutputWriter    tasks.WriterProvider
+		ContainerKey    *memguard.LockedBuffer
+		PreSharedKey    *memguard.LockedBuffer
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil containerKey",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "containerReader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("non-existent.bundle"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a threshold sealed container",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a recipient",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid pre-shared key",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+				PreSharedKey:    memguard.NewBufferFromBytes([]byte("%%%")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				ContainerKey: memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				ContainerKey: memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &ExportShareTask{
+				ContainerReader: tt.fields.ContainerReader,
+				OutputWriter:    tt.fields.OutputWriter,
+				ContainerKey:    tt.fields.ContainerKey,
+				PreSharedKey:    tt.fields.PreSharedKey,
+			}
+			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("ExportShareTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportShareTask_Run_Unseal(t *testing.T) {
+	// Export the share of the first recipient
+	var out bytes.Buffer
+	export := &ExportShareTask{
+		ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+		OutputWriter: func(ctx context.Context) (io.Writer, error) {
+			return &out, nil
+		},
+		ContainerKey: memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+	}
+	if err := export.Run(context.Background()); err != nil {
+		t.Fatalf("unable to export share: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "v5.ks.") {
+		t.Fatalf("unexpected share format: %q", out.String())
+	}
+
+	// Combine with the previously exported share
+	unseal := &UnsealTask{
+		ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+		OutputWriter:    cmdutil.DiscardWriter(),
+		ShareReaders: []tasks.ReaderProvider{
+			func(ctx context.Context) (io.Reader, error) {
+				return bytes.NewReader(out.Bytes()), nil
+			},
+			cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.share"),
+		},
+	}
+	if err := unseal.Run(context.Background()); err != nil {
+		t.Fatalf("unable to unseal container with shares: %v", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/awnumar/memguard"
 
@@ -35,7 +36,10 @@ type UnsealTask struct {
 	ContainerReader tasks.ReaderProvider
 	OutputWriter    tasks.WriterProvider
 	ContainerKey    *memguard.LockedBuffer
+	ContainerKeys   []*memguard.LockedBuffer
+	ShareReaders    []tasks.ReaderProvider
 	PreSharedKey    *memguard.LockedBuffer
+	SealVersion     uint
 }
 
 // Run the task.
@@ -47,7 +51,7 @@ func (t *UnsealTask) Run(ctx context.Context) error {
 	if types.IsNil(t.OutputWriter) {
 		return errors.New("unable to run task with a nil outputWriter provider")
 	}
-	if t.ContainerKey == nil {
+	if t.ContainerKey == nil && len(t.ContainerKeys) == 0 && len(t.ShareReaders) == 0 {
 		return errors.New("unable to run task with a nil container key")
 	}
 
@@ -57,12 +61,6 @@ func (t *UnsealTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to open input bundle reader: %w", err)
 	}
 
-	// Load input container
-	in, err := container.Load(reader)
-	if err != nil {
-		return fmt.Errorf("unable to read input container: %w", err)
-	}
-
 	// Seal options
 	sopts := []container.Option{}
 
@@ -77,10 +75,9 @@ func (t *UnsealTask) Run(ctx context.Context) error {
 		t.PreSharedKey.Destroy()
 	}
 
-	// Unseal the container
-	out, err := container.Unseal(in, t.ContainerKey, sopts...)
-	if err != nil {
-		return fmt.Errorf("unable to unseal bundle content: %w", err)
+	// Restrict the accepted seal strategy
+	if t.SealVersion != 0 {
+		sopts = append(sopts, container.WithSealVersion(uint32(t.SealVersion)))
 	}
 
 	// Create output writer
@@ -89,7 +86,61 @@ func (t *UnsealTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to open output bundle: %w", err)
 	}
 
-	// Dump all content
+	// Threshold sealed containers are unsealed by combining the shares
+	// recovered with each container key and the exported shares
+	keys := t.ContainerKeys
+	if t.ContainerKey != nil {
+		keys = append([]*memguard.LockedBuffer{t.ContainerKey}, keys...)
+	}
+	if len(keys) > 1 || len(t.ShareReaders) > 0 {
+		return t.unsealWithShares(ctx, reader, writer, keys, sopts)
+	}
+
+	// Unseal the container, streaming sealed containers are processed by
+	// chunks
+	if err := container.UnsealStream(writer, reader, keys[0], sopts...); err != nil {
+		return fmt.Errorf("unable to unseal bundle content: %w", err)
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func (t *UnsealTask) unsealWithShares(ctx context.Context, reader io.Reader, writer io.Writer, keys []*memguard.LockedBuffer, sopts []container.Option) error {
+	// Load input container
+	in, err := container.Load(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read input container: %w", err)
+	}
+
+	// Read exported shares
+	shares := make([]*memguard.LockedBuffer, 0, len(t.ShareReaders))
+	defer func() {
+		for _, share := range shares {
+			share.Destroy()
+		}
+	}()
+	for i, shareReader := range t.ShareReaders {
+		r, errReader := shareReader(ctx)
+		if errReader != nil {
+			return fmt.Errorf("unable to open share #%d reader: %w", i+1, errReader)
+		}
+		share, errRead := memguard.NewBufferFromEntireReader(r)
+		if errRead != nil {
+			return fmt.Errorf("unable to read share #%d: %w", i+1, errRead)
+		}
+		shares = append(shares, share)
+	}
+
+	// Unseal the container
+	out, err := container.UnsealWithShares(in, keys, shares, sopts...)
+	if err != nil {
+		return fmt.Errorf("unable to unseal bundle content: %w", err)
+	}
+
+	// Dump to writer
 	if err := container.Dump(writer, out); err != nil {
 		return fmt.Errorf("unable to write unsealed container: %w", err)
 	}
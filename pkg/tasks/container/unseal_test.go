@@ -34,7 +34,10 @@ func TestUnsealTask_Run(t *testing.T) {
 		ContainerReader tasks.ReaderProvider
 		OutputWriter    tasks.WriterProvider
 		ContainerKey    *memguard.LockedBuffer
+		ContainerKeys   []*memguard.LockedBuffer
+		ShareReaders    []tasks.ReaderProvider
 		PreSharedKey    *memguard.LockedBuffer
+		SealVersion     uint
 	}
 	type args struct {
 		ctx context.Context
@@ -131,6 +134,61 @@ func TestUnsealTask_Run(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "v3 with unexpected seal version",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v3.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v3.ck.qxZZv_0iKkh5b-gxAY_fINd2BonOhMBKM64Ld6wyXl4U-0APTDTN6g2A9EAo7wbclkXgjPo_tf8TYPZW2dnjFKOSLbT-wp_RwoQjTs66QrIw0m7HEqDkre_rV81x5-Vv")),
+				SealVersion:     2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "v5 with a single container key",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "v5 with duplicated container keys",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+					memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "v5 share reader error",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+				ShareReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("non-existent.share"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "v5 share from another container",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v2.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.CLMEUoY-EgvMGKCcKeByPdJjQDod6fqTnqvxtD_Z0_SX4PMITu_emttDL91z_61D")),
+				ShareReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.share"),
+				},
+			},
+			wantErr: true,
+		},
 		// ---------------------------------------------------------------------
 		{
 			name: "valid - v1",
@@ -177,6 +235,41 @@ func TestUnsealTask_Run(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid - v3",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v3.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v3.ck.qxZZv_0iKkh5b-gxAY_fINd2BonOhMBKM64Ld6wyXl4U-0APTDTN6g2A9EAo7wbclkXgjPo_tf8TYPZW2dnjFKOSLbT-wp_RwoQjTs66QrIw0m7HEqDkre_rV81x5-Vv")),
+				SealVersion:     3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - v5 - with container keys",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKeys: []*memguard.LockedBuffer{
+					memguard.NewBufferFromBytes([]byte("v2.ck.-juRGtSIHBO4i74HG_qio4dz_31GQCbyZtITsxj_nOOYLw_1qKCE9m46AvpYaV2l")),
+					memguard.NewBufferFromBytes([]byte("v2.ck.HtkyDh7j1k8LMAXZyvWOrDB6d733TzDzY2eZaevGUpjjlgXfvF8fy7DpFxkpmPf7")),
+				},
+				SealVersion: 5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - v5 - with exported share",
+			fields: fields{
+				ContainerReader: cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.sealed"),
+				OutputWriter:    cmdutil.DiscardWriter(),
+				ContainerKey:    memguard.NewBufferFromBytes([]byte("v2.ck.HtkyDh7j1k8LMAXZyvWOrDB6d733TzDzY2eZaevGUpjjlgXfvF8fy7DpFxkpmPf7")),
+				ShareReaders: []tasks.ReaderProvider{
+					cmdutil.FileReader("../../../test/fixtures/bundles/complete.v5.share"),
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -184,6 +277,9 @@ func TestUnsealTask_Run(t *testing.T) {
 				ContainerReader: tt.fields.ContainerReader,
 				OutputWriter:    tt.fields.OutputWriter,
 				ContainerKey:    tt.fields.ContainerKey,
+				ContainerKeys:   tt.fields.ContainerKeys,
+				ShareReaders:    tt.fields.ShareReaders,
+				SealVersion:     tt.fields.SealVersion,
 			}
 			if err := tr.Run(tt.args.ctx); (err != nil) != tt.wantErr {
 				t.Errorf("UnsealTask.Run() error = %v, wantErr %v", err, tt.wantErr)
@@ -21,11 +21,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
 	"github.com/zntrio/harp/v2/pkg/bundle"
 	"github.com/zntrio/harp/v2/pkg/bundle/template"
 	"github.com/zntrio/harp/v2/pkg/bundle/template/visitor/secretbuilder"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
 	"github.com/zntrio/harp/v2/pkg/tasks"
 	"github.com/zntrio/harp/v2/pkg/template/engine"
 )
@@ -34,6 +36,7 @@ import (
 // manifest.
 type BundleTemplateTask struct {
 	TemplateReader  tasks.ReaderProvider
+	PreviousReader  tasks.ReaderProvider
 	OutputWriter    tasks.WriterProvider
 	TemplateContext engine.Context
 }
@@ -72,6 +75,44 @@ func (t *BundleTemplateTask) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to generate output bundle from template: %w", err)
 	}
 
+	// Carry version history from previous bundle
+	if !types.IsNil(t.PreviousReader) {
+		previousReader, errPrevious := t.PreviousReader(ctx)
+		if errPrevious != nil {
+			return fmt.Errorf("unable to open previous bundle: %w", errPrevious)
+		}
+
+		previous, errPrevious := bundle.FromContainerReader(previousReader)
+		if errPrevious != nil {
+			return fmt.Errorf("unable to load previous bundle content: %w", errPrevious)
+		}
+
+		if err = bundle.CarryHistory(previous, b); err != nil {
+			return fmt.Errorf("unable to carry previous bundle history: %w", err)
+		}
+	}
+
+	// Apply rotation metadata
+	now := time.Now().UTC()
+	for _, p := range b.Packages {
+		if p == nil {
+			continue
+		}
+
+		// Default owner from template metadata
+		if owner := spec.GetMeta().GetOwner(); owner != "" {
+			bundle.Annotate(p, bundle.OwnerAnnotation, owner)
+		}
+
+		// Newly generated secrets
+		if _, ok := p.Annotations[bundle.LastRotatedAnnotation]; ok {
+			continue
+		}
+		if err = bundle.MarkRotated(p, now); err != nil {
+			return fmt.Errorf("unable to set rotation metadata: %w", err)
+		}
+	}
+
 	// Create output writer
 	writer, err = t.OutputWriter(ctx)
 	if err != nil {
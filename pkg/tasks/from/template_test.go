@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package from
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zntrio/harp/v2/pkg/bundle"
+	"github.com/zntrio/harp/v2/pkg/template/engine"
+)
+
+const rotationTemplate = `apiVersion: harp.elastic.co/v1
+kind: BundleTemplate
+
+meta:
+  name: "rotation"
+  owner: security@elastic.co
+  description: "Rotation metadata"
+
+spec:
+  selector:
+    quality: "production"
+    platform: "security"
+    product: "harp"
+    version: "1.0.0"
+
+  namespaces:
+    application:
+    - name: "database"
+      description: "Database secrets"
+      secrets:
+      - suffix: "explicit"
+        annotations:
+          harp.elastic.co/v1/package#rotationInterval: "30d"
+          harp.elastic.co/v1/package#expiresAt: "2030-01-01T00:00:00Z"
+        template: |-
+          {"password":"explicit"}
+      - suffix: "explicit-without-policy"
+        annotations:
+          harp.elastic.co/v1/package#expiresAt: "2031-01-01T00:00:00Z"
+        template: |-
+          {"password":"explicit"}
+      - suffix: "policy"
+        annotations:
+          harp.elastic.co/v1/package#rotationInterval: "30d"
+        template: |-
+          {"password":"policy"}
+`
+
+func TestBundleTemplateTask_Run_Rotation(t *testing.T) {
+	var out bytes.Buffer
+	task := &BundleTemplateTask{
+		TemplateReader: func(_ context.Context) (io.Reader, error) {
+			return strings.NewReader(rotationTemplate), nil
+		},
+		OutputWriter: func(_ context.Context) (io.Writer, error) {
+			return &out, nil
+		},
+		TemplateContext: engine.NewContext(),
+	}
+
+	before := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, task.Run(context.Background()))
+
+	b, err := bundle.FromContainerReader(&out)
+	require.NoError(t, err)
+
+	got := map[string]map[string]string{}
+	for _, p := range b.Packages {
+		got[p.Name] = p.Annotations
+	}
+
+	// Explicit expiration dates must be kept
+	for name, expiresAt := range map[string]string{
+		"app/production/security/harp/1.0.0/database/explicit":                "2030-01-01T00:00:00Z",
+		"app/production/security/harp/1.0.0/database/explicit-without-policy": "2031-01-01T00:00:00Z",
+	} {
+		require.Contains(t, got, name)
+		assert.Equal(t, expiresAt, got[name][bundle.ExpiresAtAnnotation], name)
+		assert.NotContains(t, got[name], bundle.ExpiresAtSourceAnnotation, name)
+		assert.Contains(t, got[name], bundle.LastRotatedAnnotation, name)
+	}
+
+	// Expiration date derived from the rotation interval
+	policy := got["app/production/security/harp/1.0.0/database/policy"]
+	require.NotNil(t, policy)
+	assert.Equal(t, bundle.ExpirySourcePolicy, policy[bundle.ExpiresAtSourceAnnotation])
+	expiresAt, err := time.Parse(time.RFC3339, policy[bundle.ExpiresAtAnnotation])
+	require.NoError(t, err)
+	assert.False(t, expiresAt.Before(before.Add(30*24*time.Hour)))
+}
@@ -0,0 +1,92 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keygen
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/shamir"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// CombineTask implements secret reconstruction from shares task.
+type CombineTask struct {
+	ShareReaders []tasks.ReaderProvider
+	OutputWriter tasks.WriterProvider
+}
+
+// Run the task.
+func (t *CombineTask) Run(ctx context.Context) error {
+	// Check arguments
+	if len(t.ShareReaders) == 0 {
+		return errors.New("unable to run task without share readers")
+	}
+	if types.IsNil(t.OutputWriter) {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+
+	// Collect share strings, comments and blank lines are ignored
+	shares := []string{}
+	for i, shareReader := range t.ShareReaders {
+		reader, err := shareReader(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to open share reader #%d: %w", i+1, err)
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			shares = append(shares, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("unable to read shares #%d: %w", i+1, err)
+		}
+	}
+
+	// Reconstruct the secret
+	raw, err := shamir.CombineEncoded(shares)
+	if err != nil {
+		return fmt.Errorf("unable to combine shares: %w", err)
+	}
+	secret := memguard.NewBufferFromBytes(raw)
+	defer secret.Destroy()
+
+	// Create output writer
+	writer, err := t.OutputWriter(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open output writer: %w", err)
+	}
+
+	// Write the secret
+	if _, err := writer.Write(secret.Bytes()); err != nil {
+		return fmt.Errorf("unable to write secret: %w", err)
+	}
+
+	// No error
+	return nil
+}
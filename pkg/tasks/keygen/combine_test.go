@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keygen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+func TestCombineTask_Run(t *testing.T) {
+	type fields struct {
+		ShareReaders []tasks.ReaderProvider
+		OutputWriter tasks.WriterProvider
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{stringReader("")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shareReader error",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{cmdutil.FileReader("non-existent.share")},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no shares",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{stringReader("# comment\n\n")},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid share",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{stringReader("harpks1invalid\n")},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{cmdutil.FileReader("../../../test/fixtures/shares/master-key.shares")},
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{cmdutil.FileReader("../../../test/fixtures/shares/master-key.shares")},
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				ShareReaders: []tasks.ReaderProvider{cmdutil.FileReader("../../../test/fixtures/shares/master-key.shares")},
+				OutputWriter: cmdutil.DiscardWriter(),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &CombineTask{
+				ShareReaders: tt.fields.ShareReaders,
+				OutputWriter: tt.fields.OutputWriter,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("CombineTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
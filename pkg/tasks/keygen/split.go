@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keygen
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/security/crypto/shamir"
+	"github.com/zntrio/harp/v2/pkg/sdk/types"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+// SplitTask implements secret splitting task.
+type SplitTask struct {
+	SecretReader tasks.ReaderProvider
+	OutputWriter tasks.WriterProvider
+	ShareWriters []tasks.WriterProvider
+	Parts        uint
+	Threshold    uint
+}
+
+// Run the task.
+func (t *SplitTask) Run(ctx context.Context) error {
+	// Check arguments
+	if types.IsNil(t.SecretReader) {
+		return errors.New("unable to run task with a nil secretReader provider")
+	}
+	if types.IsNil(t.OutputWriter) && len(t.ShareWriters) == 0 {
+		return errors.New("unable to run task with a nil outputWriter provider")
+	}
+	if len(t.ShareWriters) > 0 && len(t.ShareWriters) != int(t.Parts) {
+		return fmt.Errorf("unable to run task with %d share writers for %d parts", len(t.ShareWriters), t.Parts)
+	}
+
+	// Create input reader
+	reader, err := t.SecretReader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open secret reader: %w", err)
+	}
+
+	// Read the secret
+	secret, err := memguard.NewBufferFromEntireReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read secret: %w", err)
+	}
+	defer secret.Destroy()
+
+	// Split the secret
+	shares, err := shamir.SplitEncoded(rand.Reader, secret.Bytes(), int(t.Parts), int(t.Threshold))
+	if err != nil {
+		return fmt.Errorf("unable to split secret: %w", err)
+	}
+
+	// Write all shares to the same output
+	if len(t.ShareWriters) == 0 {
+		writer, errWriter := t.OutputWriter(ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to open output writer: %w", errWriter)
+		}
+
+		for i, share := range shares {
+			if i > 0 {
+				if _, err := fmt.Fprintln(writer); err != nil {
+					return fmt.Errorf("unable to write shares: %w", err)
+				}
+			}
+			if err := writeShare(writer, share); err != nil {
+				return err
+			}
+		}
+
+		// No error
+		return nil
+	}
+
+	// Write each share to its own output
+	for i, share := range shares {
+		writer, errWriter := t.ShareWriters[i](ctx)
+		if errWriter != nil {
+			return fmt.Errorf("unable to open share %d output writer: %w", i+1, errWriter)
+		}
+		if err := writeShare(writer, share); err != nil {
+			return err
+		}
+	}
+
+	// No error
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+
+func writeShare(w io.Writer, share []string) error {
+	// Describe the share
+	info, err := shamir.DecodeShareInfo(share[0])
+	if err != nil {
+		return fmt.Errorf("unable to decode share metadata: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "# share %d of %d, threshold %d, set %s\n", info.Index, info.Parts, info.Threshold, info.SetID); err != nil {
+		return fmt.Errorf("unable to write share %d: %w", info.Index, err)
+	}
+
+	// Write share strings
+	for _, s := range share {
+		if _, err := fmt.Fprintln(w, s); err != nil {
+			return fmt.Errorf("unable to write share %d: %w", info.Index, err)
+		}
+	}
+
+	// No error
+	return nil
+}
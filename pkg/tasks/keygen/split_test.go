@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keygen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/cmdutil"
+	"github.com/zntrio/harp/v2/pkg/tasks"
+)
+
+const testMasterKey = "cnTAvNK6nyb6FWYNEx3U-5HrSmiGkvnXqwDdAStkr6U"
+
+func bufferWriter(buf *bytes.Buffer) tasks.WriterProvider {
+	return func(ctx context.Context) (io.Writer, error) {
+		return buf, nil
+	}
+}
+
+func stringReader(s string) tasks.ReaderProvider {
+	return func(ctx context.Context) (io.Reader, error) {
+		return strings.NewReader(s), nil
+	}
+}
+
+func TestSplitTask_Run(t *testing.T) {
+	type fields struct {
+		SecretReader tasks.ReaderProvider
+		OutputWriter tasks.WriterProvider
+		ShareWriters []tasks.WriterProvider
+		Parts        uint
+		Threshold    uint
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "nil outputWriter",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "share writers count mismatch",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				ShareWriters: []tasks.WriterProvider{cmdutil.DiscardWriter()},
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "secretReader error",
+			fields: fields{
+				SecretReader: cmdutil.FileReader("non-existent.key"),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty secret",
+			fields: fields{
+				SecretReader: stringReader(""),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid threshold",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Parts:        3,
+				Threshold:    1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter error",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return nil, errors.New("test")
+				},
+				Parts:     3,
+				Threshold: 2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "outputWriter closed",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				OutputWriter: func(ctx context.Context) (io.Writer, error) {
+					return cmdutil.NewClosedWriter(), nil
+				},
+				Parts:     3,
+				Threshold: 2,
+			},
+			wantErr: true,
+		},
+		// ---------------------------------------------------------------------
+		{
+			name: "valid",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				OutputWriter: cmdutil.DiscardWriter(),
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid - share writers",
+			fields: fields{
+				SecretReader: stringReader(testMasterKey),
+				ShareWriters: []tasks.WriterProvider{cmdutil.DiscardWriter(), cmdutil.DiscardWriter(), cmdutil.DiscardWriter()},
+				Parts:        3,
+				Threshold:    2,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &SplitTask{
+				SecretReader: tt.fields.SecretReader,
+				OutputWriter: tt.fields.OutputWriter,
+				ShareWriters: tt.fields.ShareWriters,
+				Parts:        tt.fields.Parts,
+				Threshold:    tt.fields.Threshold,
+			}
+			if err := tr.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("SplitTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitTask_Combine(t *testing.T) {
+	// Split a secret, one output per share
+	outs := []*bytes.Buffer{{}, {}, {}}
+	split := &SplitTask{
+		SecretReader: stringReader(testMasterKey),
+		ShareWriters: []tasks.WriterProvider{bufferWriter(outs[0]), bufferWriter(outs[1]), bufferWriter(outs[2])},
+		Parts:        3,
+		Threshold:    2,
+	}
+	require.NoError(t, split.Run(context.Background()))
+	assert.True(t, strings.HasPrefix(outs[1].String(), "# share 2 of 3, threshold 2, set "))
+
+	// Combine from separate share files
+	var secret bytes.Buffer
+	combine := &CombineTask{
+		ShareReaders: []tasks.ReaderProvider{stringReader(outs[2].String()), stringReader(outs[0].String())},
+		OutputWriter: bufferWriter(&secret),
+	}
+	require.NoError(t, combine.Run(context.Background()))
+	assert.Equal(t, testMasterKey, secret.String())
+
+	// Not enough shares
+	combine = &CombineTask{
+		ShareReaders: []tasks.ReaderProvider{stringReader(outs[1].String())},
+		OutputWriter: cmdutil.DiscardWriter(),
+	}
+	assert.Error(t, combine.Run(context.Background()))
+
+	// Split a secret to a single output
+	var all bytes.Buffer
+	split = &SplitTask{
+		SecretReader: stringReader(testMasterKey),
+		OutputWriter: bufferWriter(&all),
+		Parts:        5,
+		Threshold:    3,
+	}
+	require.NoError(t, split.Run(context.Background()))
+
+	// Combine all shares from the same output
+	secret.Reset()
+	combine = &CombineTask{
+		ShareReaders: []tasks.ReaderProvider{stringReader(all.String())},
+		OutputWriter: bufferWriter(&secret),
+	}
+	require.NoError(t, combine.Run(context.Background()))
+	assert.Equal(t, testMasterKey, secret.String())
+}
@@ -0,0 +1,183 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/awnumar/memguard"
+	"github.com/oklog/run"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	bundlev1 "github.com/zntrio/harp/v2/api/gen/go/harp/bundle/v1"
+	"github.com/zntrio/harp/v2/build/version"
+	"github.com/zntrio/harp/v2/pkg/bundle/server"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/platform"
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+	"github.com/zntrio/harp/v2/pkg/sdk/value"
+)
+
+// BundleTask implements bundle service server task.
+type BundleTask struct {
+	ContainerPath     string
+	ContainerKey      *memguard.LockedBuffer
+	PreSharedKey      *memguard.LockedBuffer
+	IndexTransformers []value.Transformer
+	Namespace         string
+	Network           string
+	Address           string
+	TLS               *tlsconfig.Options
+	Debug             bool
+	Instrumentation   platform.InstrumentationConfig
+}
+
+// Run the task.
+func (t *BundleTask) Run(ctx context.Context) error {
+	// Check arguments
+	if t.ContainerPath == "" {
+		return errors.New("unable to run task with a blank container path")
+	}
+	if t.Address == "" {
+		return errors.New("unable to run task with a blank listen address")
+	}
+
+	// Prepare transport credentials
+	serverOpts := []grpc.ServerOption{}
+	if t.TLS != nil {
+		creds, err := mutualTLSCredentials(t.TLS)
+		if err != nil {
+			return fmt.Errorf("unable to prepare TLS settings: %w", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	// Process pre-shared key
+	var psk *memguard.LockedBuffer
+	if t.PreSharedKey != nil {
+		// Try to decode preshared key
+		raw, errDecode := base64.RawURLEncoding.DecodeString(t.PreSharedKey.String())
+		if errDecode != nil {
+			return fmt.Errorf("unable to decode pre-shared key: %w", errDecode)
+		}
+		psk = memguard.NewBufferFromBytes(raw)
+		t.PreSharedKey.Destroy()
+	}
+
+	// Load the initial bundle
+	loader := server.ContainerLoader(t.ContainerPath, t.ContainerKey, psk, t.IndexTransformers...)
+	b, err := loader(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load initial bundle: %w", err)
+	}
+
+	// Initialize bundle service
+	svc, err := server.New(t.Namespace, b)
+	if err != nil {
+		return fmt.Errorf("unable to initialize bundle service: %w", err)
+	}
+
+	// Delegate to platform server
+	return platform.Serve(ctx, &platform.Server{
+		Debug:           t.Debug,
+		Name:            "harp-server",
+		Version:         version.Version,
+		Revision:        version.Commit,
+		Instrumentation: t.Instrumentation,
+		Network:         t.Network,
+		Address:         t.Address,
+		Builder: func(ln net.Listener, group *run.Group) {
+			// gRPC server
+			{
+				grpcServer := grpc.NewServer(serverOpts...)
+				bundlev1.RegisterBundleServiceServer(grpcServer, svc)
+
+				group.Add(
+					func() error {
+						log.For(ctx).Info("Starting gRPC server", zap.String("address", ln.Addr().String()))
+						return grpcServer.Serve(ln)
+					},
+					func(e error) {
+						log.For(ctx).Info("Shutting gRPC server down")
+						grpcServer.GracefulStop()
+					},
+				)
+			}
+
+			// Container hot-reload
+			{
+				watchCtx, cancel := context.WithCancel(ctx)
+
+				group.Add(
+					func() error {
+						return server.Watch(watchCtx, t.ContainerPath, func() {
+							// Reload the container
+							updated, errLoad := loader(watchCtx)
+							if errLoad != nil {
+								log.For(ctx).Error("unable to reload container, keeping the previous one", zap.Error(errLoad))
+								return
+							}
+
+							if errReload := svc.Reload(updated); errReload != nil {
+								log.For(ctx).Error("unable to swap served bundle", zap.Error(errReload))
+								return
+							}
+
+							log.For(ctx).Info("Container reloaded", zap.String("path", t.ContainerPath))
+						})
+					},
+					func(e error) {
+						cancel()
+					},
+				)
+			}
+		},
+	})
+}
+
+// -----------------------------------------------------------------------------
+
+func mutualTLSCredentials(opts *tlsconfig.Options) (credentials.TransportCredentials, error) {
+	// Check arguments
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("server certificate and private key are required")
+	}
+	if opts.CAFile == "" {
+		return nil, errors.New("client certificate authority is required for mutual TLS")
+	}
+
+	// Enforce client authentication
+	opts.ClientAuth = tls.RequireAndVerifyClientCert
+	opts.ExclusiveRootPools = true
+
+	// Build TLS configuration
+	tlsConfig, err := tlsconfig.Server(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build server TLS configuration: %w", err)
+	}
+
+	// No error
+	return credentials.NewTLS(tlsConfig), nil
+}
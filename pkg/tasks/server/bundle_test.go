@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+)
+
+func TestBundleTask_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    *BundleTask
+		wantErr bool
+	}{
+		{
+			name:    "blank",
+			task:    &BundleTask{},
+			wantErr: true,
+		},
+		{
+			name: "blank address",
+			task: &BundleTask{
+				ContainerPath: "../../../test/fixtures/bundles/complete.bundle",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing client CA",
+			task: &BundleTask{
+				ContainerPath: "../../../test/fixtures/bundles/complete.bundle",
+				Address:       ":0",
+				TLS: &tlsconfig.Options{
+					CertFile: "server.pem",
+					KeyFile:  "server-key.pem",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "container not found",
+			task: &BundleTask{
+				ContainerPath: "non-existent.bundle",
+				Address:       ":0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "sealed container without key",
+			task: &BundleTask{
+				ContainerPath: "../../../test/fixtures/bundles/complete.v1.sealed",
+				Address:       ":0",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.task.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("BundleTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
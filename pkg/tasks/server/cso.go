@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/oklog/run"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+
+	csov1 "github.com/zntrio/harp/v2/api/gen/go/cso/v1"
+	"github.com/zntrio/harp/v2/build/version"
+	"github.com/zntrio/harp/v2/pkg/cso/server"
+	"github.com/zntrio/harp/v2/pkg/sdk/log"
+	"github.com/zntrio/harp/v2/pkg/sdk/platform"
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+)
+
+// CSOTask implements CSO validator service server task.
+type CSOTask struct {
+	Network         string
+	Address         string
+	TLS             *tlsconfig.Options
+	Debug           bool
+	Instrumentation platform.InstrumentationConfig
+}
+
+// Run the task.
+func (t *CSOTask) Run(ctx context.Context) error {
+	// Check arguments
+	if t.Address == "" {
+		return errors.New("unable to run task with a blank listen address")
+	}
+
+	// Initialize validator service
+	svc := server.New()
+
+	// Expose gRPC and JSON/HTTP gateway on the same listener
+	grpcServer := grpc.NewServer()
+	csov1.RegisterValidatorServiceServer(grpcServer, svc)
+	handler := server.Mux(grpcServer, server.Gateway(svc))
+
+	httpServer := &http.Server{
+		Handler: handler,
+		// Set timeouts to avoid Slowloris attacks.
+		ReadHeaderTimeout: time.Second * 20,
+		WriteTimeout:      time.Second * 60,
+		ReadTimeout:       time.Second * 60,
+		IdleTimeout:       time.Second * 120,
+	}
+
+	// Prepare transport security
+	if t.TLS != nil {
+		tlsConfig, err := tlsconfig.Server(t.TLS)
+		if err != nil {
+			return fmt.Errorf("unable to build server TLS configuration: %w", err)
+		}
+		httpServer.TLSConfig = tlsConfig
+	} else {
+		// Allow HTTP/2 without TLS for gRPC clients
+		httpServer.Handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	// Delegate to platform server
+	return platform.Serve(ctx, &platform.Server{
+		Debug:           t.Debug,
+		Name:            "harp-cso-server",
+		Version:         version.Version,
+		Revision:        version.Commit,
+		Instrumentation: t.Instrumentation,
+		Network:         t.Network,
+		Address:         t.Address,
+		Builder: func(ln net.Listener, group *run.Group) {
+			group.Add(
+				func() error {
+					log.For(ctx).Info("Starting CSO validator server", zap.String("address", ln.Addr().String()))
+					if httpServer.TLSConfig != nil {
+						return httpServer.ServeTLS(ln, "", "")
+					}
+					return httpServer.Serve(ln)
+				},
+				func(e error) {
+					log.For(ctx).Info("Shutting CSO validator server down")
+
+					ctxShutdown, cancel := context.WithTimeout(ctx, 60*time.Second)
+					defer cancel()
+
+					log.CheckErrCtx(ctx, "Error raised while shutting down the server", httpServer.Shutdown(ctxShutdown))
+				},
+			)
+		},
+	})
+}
@@ -0,0 +1,57 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zntrio/harp/v2/pkg/sdk/tlsconfig"
+)
+
+func TestCSOTask_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    *CSOTask
+		wantErr bool
+	}{
+		{
+			name:    "blank",
+			task:    &CSOTask{},
+			wantErr: true,
+		},
+		{
+			name: "invalid TLS configuration",
+			task: &CSOTask{
+				Address: ":0",
+				TLS: &tlsconfig.Options{
+					CertFile: "non-existent.pem",
+					KeyFile:  "non-existent-key.pem",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.task.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("CSOTask.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -29,6 +29,13 @@ type Decryptor interface {
 	Decrypt(ctx context.Context, encrypted []byte) ([]byte, error)
 }
 
+// Rewrapper describes rewrapping operations contract. It's an optional
+// contract, callers must check the service support with a type assertion.
+type Rewrapper interface {
+	Rewrap(ctx context.Context, encrypted []byte) ([]byte, error)
+	KeyID() string
+}
+
 // Service represents the Vault Transit backend operation service contract.
 type Service interface {
 	Encryptor
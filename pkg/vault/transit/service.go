@@ -116,3 +116,38 @@ func (s *service) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error
 	// Return error.
 	return nil, errors.New("could not decrypt given data")
 }
+
+func (s *service) KeyID() string {
+	return fmt.Sprintf("vault:%s/%s", s.mountPath, s.keyName)
+}
+
+func (s *service) Rewrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	// Prepare query
+	rewrapPath := vpath.SanitizePath(path.Join(url.PathEscape(s.mountPath), "rewrap", url.PathEscape(s.keyName)))
+	data := map[string]interface{}{
+		"ciphertext": string(ciphertext),
+	}
+
+	// Send to Vault.
+	secret, err := s.logical.Write(rewrapPath, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to rewrap with %q key: %w", s.keyName, err)
+	}
+
+	// Check response wrapping
+	if secret.WrapInfo != nil {
+		// Unwrap with response token
+		secret, err = s.logical.Unwrap(secret.WrapInfo.Token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unwrap the response: %w", err)
+		}
+	}
+
+	// Parse server response.
+	if cipherText, ok := secret.Data["ciphertext"].(string); ok && cipherText != "" {
+		return []byte(cipherText), nil
+	}
+
+	// Return error.
+	return nil, errors.New("could not rewrap given data")
+}